@@ -1,20 +1,39 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rnegic/synchronous/internal/config"
-	gormRepo "github.com/rnegic/synchronous/internal/repository/gorm"
+	"github.com/rnegic/synchronous/internal/health"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/metrics"
 	"github.com/rnegic/synchronous/internal/router"
 	"github.com/rnegic/synchronous/internal/service"
 	"github.com/rnegic/synchronous/internal/transport/http/middleware"
 	v1 "github.com/rnegic/synchronous/internal/transport/http/v1"
 	"github.com/rnegic/synchronous/pkg/jwt"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+	"github.com/rnegic/synchronous/pkg/maxapi/fake"
 )
 
+// shutdownTimeout ограничивает остановку: ожидание текущих запросов, закрытие
+// WebSocket и воркеров. По истечении оставшиеся компоненты не ждем
+const shutdownTimeout = 15 * time.Second
+
+// maxAPIHealthTTL как часто readiness реально обращается к MAX API: пробы
+// приходят каждые несколько секунд, а GetBotInfo расходует лимит запросов
+const maxAPIHealthTTL = 30 * time.Second
+
 type App struct {
 }
 
@@ -30,40 +49,151 @@ func (a *App) Run() error {
 		return fmt.Errorf("error with config: %v", err)
 	}
 
-	// Инициализация подключения к БД
-	dsn := cfg.BuildDSN()
-	db, err := gormRepo.InitDB(dsn)
+	log, err := logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return fmt.Errorf("error with config: %v", err)
+	}
+	// Стандартный log (gorm, сторонние пакеты) тоже пишем через slog
+	slog.SetDefault(log)
+	if cfg.File() == "" {
+		logger.Component(log, "config").Info("config file not found, using environment variables only")
+	}
+
+	// build дописывает в cfg адрес фейка MAX API — сравнивать при перезагрузке будем с прочитанным
+	current := *cfg
+	srv, err := build(cfg, log)
 	if err != nil {
-		return fmt.Errorf("failed to initialize database: %v", err)
+		return err
+	}
+	log = logger.Component(log, "app")
+
+	// Перезагрузка некритичных настроек при изменении файла конфигурации
+	watching := current.Watch(func(next *config.Config) {
+		srv.reloadConfig(&current, next)
+		current = *next
+	}, func(err error) {
+		log.Error("config reload rejected, keeping current settings", logger.Err(err))
+	})
+	if watching {
+		log.Info("watching config file for changes", "file", current.File())
+	}
+
+	httpServer := &http.Server{
+		Addr:              cfg.Server.Address,
+		Handler:           srv.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Зарегистрирован последним — при остановке первым перестает принимать запросы
+	srv.lifecycle.onStop("http server", httpServer.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("server starting", "address", cfg.Server.Address)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Info("shutdown signal received, stopping", "timeout", shutdownTimeout)
+	}
+	stop() // повторный сигнал завершает процесс сразу
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if stopErr := srv.shutdown(shutdownCtx); stopErr != nil && err == nil {
+		err = stopErr
+	}
+	if err == nil {
+		log.Info("stopped gracefully")
 	}
+
+	return err
+}
+
+// server — собранное приложение: роутер и компоненты, которые нужно остановить
+type server struct {
+	router    *gin.Engine
+	fakeMax   *fake.Server // in-process MAX API, если включен MaxAPI.UseFake
+	repos     *repositories
+	lifecycle lifecycle
+
+	// Компоненты с настройками, которые меняются при перезагрузке конфигурации
+	log     *slog.Logger
+	origins *middleware.AllowedOrigins
+	limiter *middleware.RateLimiter
+	cleanup *service.SessionCleanupService
+	maxAPI  interfaces.MaxAPIService
+}
+
+// shutdown останавливает компоненты в порядке, обратном запуску
+func (s *server) shutdown(ctx context.Context) error {
+	return s.lifecycle.stop(ctx)
+}
+
+// build собирает хранилище, сервисы и роутер по конфигу, не запуская HTTP-сервер
+func build(cfg *config.Config, log *slog.Logger) (_ *server, err error) {
+	srv := &server{lifecycle: lifecycle{log: logger.Component(log, "lifecycle")}, log: log}
+	appMetrics := metrics.New()
+	configLog := logger.Component(log, "config")
 	defer func() {
-		if err := gormRepo.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
+		if err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			srv.shutdown(ctx)
 		}
 	}()
 
-	// Автомиграция моделей (опционально, если не используем goose)
-	// err = db.AutoMigrate(
-	// 	&entity.User{},
-	// 	&entity.UserStats{},
-	// 	&entity.Session{},
-	// 	&entity.Participant{},
-	// 	&entity.Task{},
-	// 	&entity.Message{},
-	// )
-	// if err != nil {
-	// 	return fmt.Errorf("failed to run migrations: %v", err)
-	// }
-
-	// Инициализация репозиториев
-	userRepo := gormRepo.NewUserRepository(db)
-	sessionRepo := gormRepo.NewSessionRepository(db)
-	taskRepo := gormRepo.NewTaskRepository(db)
-	messageRepo := gormRepo.NewMessageRepository(db)
-	leaderboardRepo := gormRepo.NewLeaderboardRepository(db)
+	// Инициализация хранилища (postgres, sqlite или memory по Database.Driver)
+	repos, err := newRepositories(cfg, configLog)
+	if err != nil {
+		return nil, err
+	}
+	srv.repos = repos
+	srv.lifecycle.onStop("storage", func(context.Context) error {
+		return repos.close()
+	})
+	appMetrics.RegisterSessions(repos.sessions)
+
+	// Локальная разработка без platform-api.max.ru: поднимаем фейк MAX API в процессе
+	if cfg.MaxAPI.UseFake {
+		fakeMax := fake.NewServer(cfg.MaxAPI.AccessToken)
+		srv.fakeMax = fakeMax
+		srv.lifecycle.onStopFunc("fake max api", fakeMax.Close)
+
+		cfg.MaxAPI.BaseURL = fakeMax.URL
+		cfg.MaxAPI.AccessToken = fakeMax.AccessToken
+		if cfg.MaxAPI.BotToken == "" {
+			cfg.MaxAPI.BotToken = "fake-bot-token"
+		}
+
+		// initData для dev-пользователя подписывается fake.InitDataForUser с BotToken;
+		// в лог ее не пишем, как и любую другую initData
+		fakeMax.AddUser(1001, "Dev", "User")
+		configLog.Warn("using fake MAX API", "url", fakeMax.URL, "dev_user_id", 1001)
+	}
 
 	// Инициализация Max API клиента и сервиса
-	maxAPIService := service.NewMaxAPIService(cfg.MaxAPI.BaseURL, cfg.MaxAPI.AccessToken)
+	retryPolicy := maxapi.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = cfg.MaxAPI.MaxAttempts
+	maxAPIService, err := service.NewMaxAPIService(
+		cfg.MaxAPI.BaseURL,
+		cfg.MaxAPI.AccessToken,
+		maxapi.WithRateLimit(cfg.MaxAPI.RateLimit, int(cfg.MaxAPI.RateLimit)),
+		maxapi.WithRetryPolicy(retryPolicy),
+		maxapi.WithObserver(appMetrics.ObserveMaxAPICall),
+	)
+	if err != nil {
+		return nil, err
+	}
+	srv.lifecycle.onStopFunc("max api client", maxAPIService.Close)
+	srv.maxAPI = maxAPIService
 
 	// Инициализация JWT менеджера
 	tokenManager := jwt.NewTokenManager(
@@ -75,31 +205,99 @@ func (a *App) Run() error {
 	// Инициализация сервисов
 	botToken := cfg.MaxAPI.BotToken
 	if botToken == "" {
-		log.Printf("[Config] ⚠️ WARNING: BOT_TOKEN is not configured! InitData validation will fail.")
-	} else {
-		log.Printf("[Config] ✅ BOT_TOKEN loaded (length: %d)", len(botToken))
+		configLog.Warn("BOT_TOKEN is not configured, initData validation will fail")
 	}
-	authService := service.NewAuthService(userRepo, tokenManager, botToken)
-	userService := service.NewUserService(userRepo)
-	sessionService := service.NewSessionService(sessionRepo, taskRepo, userRepo, maxAPIService)
-	messageService := service.NewMessageService(sessionService, maxAPIService, userRepo, messageRepo)
-	leaderboardService := service.NewLeaderboardService(leaderboardRepo, sessionRepo, userRepo)
+	authService := service.NewAuthService(repos.users, tokenManager, botToken, log)
+	userService := service.NewUserService(repos.users)
+	sessionService := service.NewSessionService(repos.sessions, repos.tasks, repos.users, maxAPIService, repos.transactor, cfg.App.MaxSessionSize, cfg.App.SessionRestoreWindow)
+	messageService := service.NewMessageService(sessionService, maxAPIService, repos.sessions, repos.users, repos.messages)
+	leaderboardService := service.NewLeaderboardService(repos.leaderboard, repos.sessions, repos.users)
+	seriesService := service.NewSeriesService(repos.series, repos.sessions, repos.users, repos.transactor,
+		cfg.App.MaxSessionSize, cfg.Scheduler.SeriesHorizon)
+	templateService := service.NewTemplateService(repos.templates, repos.sessions, repos.tasks, repos.users, repos.transactor,
+		cfg.App.MaxSessionSize)
 
-	// Start session cleanup service (cleanup sessions older than 1 hour every 15 minutes)
-	cleanupService := service.NewSessionCleanupService(sessionRepo, 15*time.Minute, 1*time.Hour)
-	cleanupService.Start()
+	// Доставка отложенных вызовов Max API из outbox (до 10 попыток, затем dead letters)
+	outboxService := service.NewOutboxService(repos.outbox, maxAPIService, cfg.Outbox.Interval, cfg.Outbox.MaxAttempts, log)
+	outboxService.Start()
+	srv.lifecycle.onStopFunc("outbox worker", outboxService.Stop)
+
+	// Проверки готовности: БД критична, MAX API и воркеры только деградируют сервис
+	checker := health.NewChecker()
+	if repos.db != nil {
+		checker.Add("database", true, health.Database(repos.db))
+	} else {
+		checker.Add("database", true, func(context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"driver": DriverMemory}, nil
+		})
+	}
+	checker.Add("max_api", false, health.Cached(maxAPIHealthTTL, func(context.Context) (map[string]interface{}, error) {
+		info, err := maxAPIService.GetBotInfo()
+		if err != nil {
+			return map[string]interface{}{"errorKind": maxapi.ErrorKind(err)}, err
+		}
+		return map[string]interface{}{"botUserId": info.UserID, "username": info.Username}, nil
+	}))
+	checker.Add("outbox", false, outboxService.Heartbeat().Check)
 
 	// Инициализация handlers
-	baseHandler := v1.NewBaseHandler()
+	baseHandler := v1.NewBaseHandler(log)
 
 	authHandler := v1.NewAuthHandler(baseHandler, authService, tokenManager)
 	userHandler := v1.NewUserHandler(baseHandler, userService)
-	wsHandler := v1.NewWebSocketHandler(baseHandler)
-	sessionHandler := v1.NewSessionHandler(baseHandler, sessionService, messageService, leaderboardService, wsHandler)
-	webhookHandler := v1.NewWebhookHandler(baseHandler, sessionService, maxAPIService)
+	srv.origins = middleware.NewAllowedOrigins(cfg.Server.CORSOrigins)
+	srv.limiter = middleware.NewRateLimiter(repos.rateLimits, cfg.RateLimits(), log, appMetrics)
+	wsHandler := v1.NewWebSocketHandler(baseHandler, sessionService, appMetrics, srv.origins)
+	appMetrics.RegisterWebSocket(wsHandler)
+	srv.lifecycle.onStop("websocket", wsHandler.Close)
+
+	// Отмена брошенных сессий (по умолчанию каждые 15 минут: не начатые за час и начатые
+	// без активности и подключений 2 часа) и окончательное удаление сессий, удаленных раньше
+	// окна восстановления. Как и планировщик, останавливается раньше WebSocket
+	cleanupService := service.NewSessionCleanupService(repos.sessions, sessionService, repos.messages, repos.users, repos.transactor,
+		wsHandler, wsHandler, cfg.Cleanup.Interval, cfg.Cleanup.MaxAge, cfg.Cleanup.IdleTimeout, cfg.App.SessionRestoreWindow, log, appMetrics)
+	cleanupService.Start()
+	srv.lifecycle.onStopFunc("session cleanup", cleanupService.Stop)
+	srv.cleanup = cleanupService
+	checker.Add("session_cleanup", false, cleanupService.Heartbeat().Check)
+
+	// Сессии по времени: экземпляры серий, напоминания, автостарт по кворуму, отмена, если
+	// никто не пришел, и завершение по плану циклов. Останавливается раньше WebSocket,
+	// чтобы не слать события в закрытые соединения
+	schedulerService := service.NewSessionSchedulerService(repos.sessions, repos.users, sessionService, seriesService,
+		repos.transactor, wsHandler, cfg.Scheduler.Interval, cfg.ReminderOffsets(), cfg.Scheduler.NoShowTimeout, log)
+	schedulerService.Start()
+	srv.lifecycle.onStopFunc("session scheduler", schedulerService.Stop)
+	checker.Add("session_scheduler", false, schedulerService.Heartbeat().Check)
+
+	sessionHandler := v1.NewSessionHandler(baseHandler, sessionService, templateService, messageService, leaderboardService, wsHandler)
+	seriesHandler := v1.NewSeriesHandler(baseHandler, seriesService)
+	templateHandler := v1.NewTemplateHandler(baseHandler, templateService)
+	updateDispatcher := v1.NewUpdateDispatcher(sessionService, messageService, outboxService, wsHandler, log)
+	webhookHandler := v1.NewWebhookHandler(baseHandler, updateDispatcher)
+	adminHandler := v1.NewAdminHandler(baseHandler, outboxService)
+	healthHandler := v1.NewHealthHandler(baseHandler, checker)
+
+	// Источник обновлений Max API: webhook (по умолчанию) или long polling /updates
+	switch cfg.MaxAPI.UpdatesMode {
+	case "webhook":
+	case "polling":
+		updatePoller := service.NewUpdatePoller(maxAPIService, repos.updateCursors, updateDispatcher, log)
+		updatePoller.Start()
+		checker.Add("update_poller", false, updatePoller.Heartbeat().Check)
+		srv.lifecycle.onStopFunc("update poller", updatePoller.Stop)
+	default:
+		return nil, fmt.Errorf("unknown MAXAPI.UPDATES_MODE %q (expected webhook or polling)", cfg.MaxAPI.UpdatesMode)
+	}
 
 	// Инициализация роутера на gin
-	appRouter := router.New()
+	appRouter := router.New(log, appMetrics, srv.origins, cfg.Server.TrustedProxies)
+
+	// Метрики Prometheus. nginx проксирует только /api и /ws, так что снаружи /metrics недоступен
+	appRouter.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+
+	// Пробы для оркестратора: /health/live и /health/ready (тоже только внутри сети)
+	healthHandler.RegisterRoutes(appRouter.Group(""))
 
 	// Health check endpoint (публичный)
 	appRouter.GET("/api/v1/health", func(c *gin.Context) {
@@ -111,29 +309,44 @@ func (a *App) Run() error {
 
 	// API v1 группа
 	api := appRouter.Group("/api/v1")
+	api.Use(baseHandler.ErrorMiddleware())
 	{
-		// Публичные routes (без аутентификации)
-		authHandler.RegisterRoutes(api)
-		webhookHandler.RegisterRoutes(api) // Webhook должен быть публичным
+		// Публичные routes (без аутентификации), вход ограничен по IP
+		authHandler.RegisterRoutes(api.Group("", srv.limiter.Limit(config.RateLimitGroupAuth)))
+		if cfg.MaxAPI.UpdatesMode == "webhook" {
+			webhookHandler.RegisterRoutes(api) // Webhook должен быть публичным
+		}
 
 		// Защищенные routes (с аутентификацией)
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(authService))
+		protected.Use(
+			middleware.AuthMiddleware(authService),
+			srv.limiter.Limit(config.RateLimitGroupAPI),
+			// Каждое сообщение уходит в MAX API — для него отдельный, более строгий лимит
+			srv.limiter.LimitRoute(config.RateLimitGroupMessages, http.MethodPost, "/api/v1/sessions/:sessionId/messages"),
+			middleware.Idempotency(repos.idempotency, cfg.App.IdempotencyTTL, log),
+			// Ошибки обработчиков рендерятся здесь, чтобы Idempotency сохранил и их ответ
+			baseHandler.ErrorMiddleware(),
+		)
 		{
 			userHandler.RegisterRoutes(protected)
 			sessionHandler.RegisterRoutes(protected)
+			seriesHandler.RegisterRoutes(protected)
+			templateHandler.RegisterRoutes(protected)
 			wsHandler.RegisterRoutes(protected)
 		}
+
+		// Служебные routes (по X-Admin-Token)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AdminMiddleware(cfg.App.AdminToken))
+		{
+			adminHandler.RegisterRoutes(admin)
+		}
 	}
 
 	appRouter.StaticFile("/swagger.yaml", "./swagger.yaml")
 	appRouter.StaticFile("/swagger.json", "./swagger.yaml") // В реальности нужно конвертировать YAML в JSON
 
-	fmt.Printf("Server starting on %s\n", cfg.Server.Address)
-	err = appRouter.Run(cfg.Server.Address)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	srv.router = appRouter
+	return srv, nil
 }
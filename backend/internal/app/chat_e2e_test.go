@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/pkg/maxapi/fake"
+)
+
+// deliver отправляет обновление MAX на webhook и ждет 200
+func (h *harness) deliver(update interface{}) {
+	h.t.Helper()
+
+	resp, err := fake.DeliverWebhook(h.server.URL+"/api/v1/webhook/max", update)
+	if err != nil {
+		h.t.Fatalf("deliver webhook: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		h.t.Fatalf("webhook status %d", resp.StatusCode)
+	}
+}
+
+func TestBotWelcomesDialogStart(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+
+	// У личного диалога тоже есть chat_id — это не сообщение чата сессии
+	update, err := h.fakeMax.DialogMessage(alice.maxID, "/start")
+	if err != nil {
+		t.Fatalf("dialog message: %v", err)
+	}
+	if update.Message.Recipient.ChatID == 0 {
+		t.Fatal("dialog message without chat_id")
+	}
+	h.deliver(update)
+
+	h.waitWelcomed(alice.maxID)
+}
+
+// waitWelcomed ждет, пока outbox доставит приветствие бота пользователю
+func (h *harness) waitWelcomed(maxUserID int64) {
+	h.t.Helper()
+
+	for deadline := time.Now().Add(wsTimeout); !h.welcomed(maxUserID); {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("no welcome message in dialog: %+v", h.fakeMax.MessagesToUser(maxUserID))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// welcomed сообщает, ответил ли бот приветствием в личке пользователя
+func (h *harness) welcomed(maxUserID int64) bool {
+	for _, message := range h.fakeMax.MessagesToUser(maxUserID) {
+		if message.Sender.IsBot && strings.HasPrefix(message.Body.Text, "Привет! Это бот Синхрон") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPollingSurvivesBlockedBot(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.MaxAPI.UpdatesMode = "polling"
+	})
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	// Alice заблокировала бота: приветствие получает 403. Оно уходит в dead letters,
+	// а поллер не застревает на ее обновлении
+	h.fakeMax.FailNext(http.MethodPost, "/messages", http.StatusForbidden, 0)
+	for _, maxID := range []int64{alice.maxID, bob.maxID} {
+		update, err := h.fakeMax.DialogMessage(maxID, "/start")
+		if err != nil {
+			t.Fatalf("dialog message: %v", err)
+		}
+		if err := h.fakeMax.PushUpdate(update); err != nil {
+			t.Fatalf("push update: %v", err)
+		}
+	}
+
+	h.waitWelcomed(bob.maxID)
+	if h.welcomed(alice.maxID) {
+		t.Fatal("welcome delivered despite 403")
+	}
+	var statuses []string
+	for _, message := range h.outboxMessages() {
+		statuses = append(statuses, message.Status)
+	}
+	slices.Sort(statuses)
+	if !slices.Equal(statuses, []string{"dead", "delivered"}) {
+		t.Fatalf("outbox statuses: %v, want one dead and one delivered welcome", statuses)
+	}
+}
+
+func TestChatCreatedSkipsUnknownParticipants(t *testing.T) {
+	// Участника без пользователя держит только memory: в SQL мешает внешний ключ
+	h := newHarness(t, func(cfg *config.Config) { cfg.Database.Driver = DriverMemory })
+	alice := h.login(1001, "Alice")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "group", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5,
+	}, http.StatusOK, &created)
+	base := "/api/v1/sessions/" + created.Session.ID
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, nil)
+
+	// Участник, пользователя которого нет в хранилище, не ломает обработку чата
+	ghost := &entity.Participant{UserID: "missing-user", UserName: "Ghost", JoinedAt: time.Now()}
+	if err := h.app.repos.sessions.AddParticipant(created.Session.ID, ghost); err != nil {
+		t.Fatalf("add participant: %v", err)
+	}
+
+	chat := h.fakeMax.ChatCreatedFromButton(alice.maxID, "Обсуждение", fmt.Sprintf("session_id:%s:discussion", created.Session.ID))
+	h.deliver(chat)
+
+	var members []string
+	for _, message := range h.outboxMessages() {
+		if message.SessionID == created.Session.ID && message.Action == "add_chat_members" {
+			members = append(members, message.Payload)
+		}
+	}
+	want := fmt.Sprintf(`{"chatId":%d,"maxUserIds":[%d]}`, chat.Chat.ChatID, alice.maxID)
+	if len(members) != 1 || members[0] != want {
+		t.Fatalf("add_chat_members: %v, want %s", members, want)
+	}
+}
+
+func TestChatMessagesReachSession(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "solo", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5,
+	}, http.StatusOK, &created)
+	base := "/api/v1/sessions/" + created.Session.ID
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, nil)
+	chat := h.fakeMax.ChatCreatedFromButton(alice.maxID, "Обсуждение", fmt.Sprintf("session_id:%s:discussion", created.Session.ID))
+	h.deliver(chat)
+	aliceWS := alice.dial(created.Session.ID)
+
+	update, err := h.fakeMax.UserMessage(chat.Chat.ChatID, alice.maxID, "Спасибо всем!")
+	if err != nil {
+		t.Fatalf("chat message: %v", err)
+	}
+	h.deliver(update)
+
+	ev := aliceWS.expect("chat_message", forSession(created.Session.ID))
+	message, _ := ev.Data["message"].(map[string]interface{})
+	if message["text"] != "Спасибо всем!" || message["userId"] != alice.userID {
+		t.Fatalf("chat_message: %+v", ev.Data)
+	}
+}
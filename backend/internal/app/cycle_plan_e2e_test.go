@@ -0,0 +1,144 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/config"
+)
+
+func TestCyclePlans(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Scheduler.Interval = 50 * time.Millisecond
+	})
+	alice := h.login(1001, "Alice")
+
+	type planResponse struct {
+		Session struct {
+			ID           string `json:"id"`
+			Status       string `json:"status"`
+			CurrentCycle int    `json:"currentCycle"`
+			Phase        string `json:"phase"`
+			PhaseEndsAt  string `json:"phaseEndsAt"`
+			CyclePlan    *struct {
+				Cycles         int  `json:"cycles"`
+				LongBreakEvery int  `json:"longBreakEvery"`
+				AutoComplete   bool `json:"autoComplete"`
+				Sequence       []struct {
+					Focus int `json:"focus"`
+					Break int `json:"break"`
+				} `json:"sequence"`
+			} `json:"cyclePlan"`
+		} `json:"session"`
+	}
+	plan := map[string]interface{}{
+		"cycles":         3,
+		"longBreak":      30,
+		"longBreakEvery": 2,
+		"sequence":       []map[string]int{{"focus": 50, "break": 10}, {"focus": 25, "break": 5}},
+		"autoComplete":   true,
+	}
+	body := func(plan interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"mode": "solo", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5, "cyclePlan": plan,
+		}
+	}
+
+	for name, invalid := range map[string]map[string]interface{}{
+		"autoComplete without cycles": {"autoComplete": true},
+		"long break without interval": {"longBreak": 30},
+		"empty focus step":            {"sequence": []map[string]int{{"focus": 0, "break": 5}}},
+		"too many cycles":             {"cycles": 101},
+	} {
+		status, resp := alice.do(http.MethodPost, "/api/v1/sessions", body(invalid), nil)
+		if status != http.StatusBadRequest || !strings.Contains(string(resp), "invalid_cycle_plan") {
+			t.Fatalf("%s: %d %s", name, status, resp)
+		}
+	}
+
+	var created planResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", body(plan), http.StatusOK, &created)
+	p := created.Session.CyclePlan
+	if p == nil || p.Cycles != 3 || p.LongBreakEvery != 2 || !p.AutoComplete || len(p.Sequence) != 2 || p.Sequence[1].Focus != 25 {
+		t.Fatalf("created: %+v", created.Session)
+	}
+	if created.Session.Phase != "" {
+		t.Fatalf("phase before start: %q", created.Session.Phase)
+	}
+
+	// Первый цикл — фокус 50 минут из последовательности
+	base := "/api/v1/sessions/" + created.Session.ID
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+	var started planResponse
+	alice.mustDo(http.MethodGet, base, nil, http.StatusOK, &started)
+	endsAt, err := time.Parse(time.RFC3339, started.Session.PhaseEndsAt)
+	if started.Session.CurrentCycle != 1 || started.Session.Phase != "focus" || err != nil ||
+		time.Until(endsAt) < 49*time.Minute || time.Until(endsAt) > 50*time.Minute {
+		t.Fatalf("started: %+v", started.Session)
+	}
+	// На паузе фаза стоит и конца у нее нет
+	alice.mustDo(http.MethodPost, base+"/pause", nil, http.StatusOK, nil)
+	var paused planResponse
+	alice.mustDo(http.MethodGet, base, nil, http.StatusOK, &paused)
+	if paused.Session.Phase != "focus" || paused.Session.PhaseEndsAt != "" {
+		t.Fatalf("paused: %+v", paused.Session)
+	}
+	alice.mustDo(http.MethodPost, base+"/resume", nil, http.StatusOK, nil)
+
+	// Через 70 минут: фокус 50 и перерыв 10 первого цикла, 10 минут фокуса второго
+	h.rewind(created.Session.ID, 70*time.Minute)
+	var second planResponse
+	alice.mustDo(http.MethodGet, base, nil, http.StatusOK, &second)
+	if second.Session.CurrentCycle != 2 || second.Session.Phase != "focus" {
+		t.Fatalf("second cycle: %+v", second.Session)
+	}
+	var report reportResponse
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, &report)
+	if report.Report.FocusTime != 60 || report.Report.BreakTime != 10 || report.Report.CyclesCompleted != 1 {
+		t.Fatalf("report of an unfinished plan: %+v", report.Report)
+	}
+
+	// План переходит в шаблон и из шаблона — в новую сессию
+	var saved struct {
+		Template struct {
+			ID        string `json:"id"`
+			CyclePlan *struct {
+				Cycles int `json:"cycles"`
+			} `json:"cyclePlan"`
+		} `json:"template"`
+	}
+	alice.mustDo(http.MethodPost, "/api/v1/templates/from-session/"+created.Session.ID, nil, http.StatusCreated, &saved)
+	if saved.Template.CyclePlan == nil || saved.Template.CyclePlan.Cycles != 3 {
+		t.Fatalf("template: %+v", saved.Template)
+	}
+	var fromTemplate planResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions/from-template", map[string]string{"templateId": saved.Template.ID},
+		http.StatusOK, &fromTemplate)
+	if fromTemplate.Session.CyclePlan == nil || len(fromTemplate.Session.CyclePlan.Sequence) != 2 {
+		t.Fatalf("session from template: %+v", fromTemplate.Session)
+	}
+
+	// План пройден: 50+10, 25+30 (длинный перерыв после второго цикла) и 50 минут фокуса
+	// без перерыва в конце. Планировщик завершает сессию сам
+	auto := "/api/v1/sessions/" + fromTemplate.Session.ID
+	alice.mustDo(http.MethodPost, auto+"/start", nil, http.StatusOK, nil)
+	h.rewind(fromTemplate.Session.ID, 4*time.Hour)
+	deadline := time.Now().Add(wsTimeout)
+	for {
+		var got planResponse
+		alice.mustDo(http.MethodGet, auto, nil, http.StatusOK, &got)
+		if got.Session.Status == "completed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("finished plan not completed: %+v", got.Session)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	alice.mustDo(http.MethodGet, auto+"/report", nil, http.StatusOK, &report)
+	if report.Report.FocusTime != 125 || report.Report.BreakTime != 40 || report.Report.CyclesCompleted != 3 {
+		t.Fatalf("report of a finished plan: %+v", report.Report)
+	}
+}
@@ -0,0 +1,253 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/pkg/maxapi/fake"
+)
+
+type sessionResponse struct {
+	Session struct {
+		ID           string   `json:"id"`
+		Status       string   `json:"status"`
+		CreatorID    string   `json:"creatorId"`
+		InviteLink   string   `json:"inviteLink"`
+		MaxChatID    *int64   `json:"maxChatId"`
+		Tags         []string `json:"tags"`
+		Language     string   `json:"language"`
+		ScheduledAt  string   `json:"scheduledAt"`
+		Participants []struct {
+			UserID  string `json:"userId"`
+			IsReady bool   `json:"isReady"`
+		} `json:"participants"`
+		Tasks []struct {
+			ID        string `json:"id"`
+			Title     string `json:"title"`
+			Completed bool   `json:"completed"`
+		} `json:"tasks"`
+	} `json:"session"`
+}
+
+type taskResponse struct {
+	Task struct {
+		ID        string `json:"id"`
+		Completed bool   `json:"completed"`
+	} `json:"task"`
+}
+
+type reportResponse struct {
+	Report struct {
+		SessionID       string `json:"sessionId"`
+		TasksCompleted  int    `json:"tasksCompleted"`
+		TasksTotal      int    `json:"tasksTotal"`
+		FocusTime       int    `json:"focusTime"`
+		BreakTime       int    `json:"breakTime"`
+		CyclesCompleted int    `json:"cyclesCompleted"`
+		Participants    []struct {
+			UserID         string `json:"userId"`
+			TasksCompleted int    `json:"tasksCompleted"`
+		} `json:"participants"`
+	} `json:"report"`
+}
+
+func TestGroupSessionLifecycle(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	// Alice создает групповую сессию с двумя задачами
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode":          "group",
+		"tasks":         []string{"Write spec", "Review PR"},
+		"focusDuration": 25,
+		"breakDuration": 5,
+		"groupName":     "Team",
+	}, http.StatusOK, &created)
+	session := created.Session
+	if session.Status != "pending" || session.CreatorID != alice.userID || len(session.Tasks) != 2 {
+		t.Fatalf("created session: %+v", session)
+	}
+	base := "/api/v1/sessions/" + session.ID
+	aliceWS := alice.dial(session.ID)
+
+	// Bob присоединяется по приглашению, Alice получает событие
+	var joined sessionResponse
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/join-by-invite", map[string]string{
+		"inviteLink": "invite_" + session.InviteLink,
+	}, http.StatusOK, &joined)
+	if len(joined.Session.Participants) != 2 {
+		t.Fatalf("participants after join: %+v", joined.Session.Participants)
+	}
+	ev := aliceWS.expect("participant_joined", forSession(session.ID))
+	if participant, _ := ev.Data["participant"].(map[string]interface{}); participant["userId"] != bob.userID {
+		t.Fatalf("participant_joined: %+v", ev.Data)
+	}
+	bobWS := bob.dial(session.ID)
+
+	var bobTask taskResponse
+	bob.mustDo(http.MethodPost, base+"/tasks", map[string]string{"title": "Fix bug"}, http.StatusOK, &bobTask)
+
+	// Готовность и старт
+	bob.mustDo(http.MethodPatch, base+"/ready", map[string]bool{"isReady": true}, http.StatusOK, nil)
+	ev = aliceWS.expect("participant_ready", forSession(session.ID))
+	if ev.Data["userId"] != bob.userID || ev.Data["isReady"] != true {
+		t.Fatalf("participant_ready: %+v", ev.Data)
+	}
+
+	bob.mustFail(http.MethodPost, base+"/start", nil, http.StatusForbidden, "not_session_creator")
+	var started sessionResponse
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, &started)
+	if started.Session.Status != "active" {
+		t.Fatalf("status after start: %q", started.Session.Status)
+	}
+	aliceWS.expect("session_started", forSession(session.ID))
+	bobWS.expect("session_started", forSession(session.ID))
+
+	// /sessions/active отдает сессию без обертки
+	var active struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	bob.mustDo(http.MethodGet, "/api/v1/sessions/active", nil, http.StatusOK, &active)
+	if active.ID != session.ID || active.Status != "active" {
+		t.Fatalf("active session for bob: %+v, want %q", active, session.ID)
+	}
+	// Повторный вход по приглашению для участника — не ошибка
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/join-by-invite", map[string]string{
+		"inviteLink": session.InviteLink,
+	}, http.StatusOK, nil)
+
+	// Пауза участником, продолжение создателем
+	var paused sessionResponse
+	bob.mustDo(http.MethodPost, base+"/pause", nil, http.StatusOK, &paused)
+	if paused.Session.Status != "paused" {
+		t.Fatalf("status after pause: %q", paused.Session.Status)
+	}
+	alice.mustDo(http.MethodPost, base+"/pause", nil, http.StatusOK, nil) // повторная пауза идемпотентна
+	var resumed sessionResponse
+	alice.mustDo(http.MethodPost, base+"/resume", nil, http.StatusOK, &resumed)
+	if resumed.Session.Status != "active" {
+		t.Fatalf("status after resume: %q", resumed.Session.Status)
+	}
+
+	// Каждый отмечает только свои задачи
+	aliceTask := session.Tasks[0].ID
+	bob.mustFail(http.MethodPatch, base+"/tasks/"+aliceTask, map[string]bool{"completed": true}, http.StatusForbidden, "task_not_owned")
+	var toggled taskResponse
+	alice.mustDo(http.MethodPatch, base+"/tasks/"+aliceTask, map[string]bool{"completed": true}, http.StatusOK, &toggled)
+	if !toggled.Task.Completed {
+		t.Fatalf("alice task not completed: %+v", toggled.Task)
+	}
+	bob.mustDo(http.MethodPatch, base+"/tasks/"+bobTask.Task.ID, map[string]bool{"completed": true}, http.StatusOK, nil)
+
+	var progress struct {
+		Progress []struct {
+			UserID         string `json:"userId"`
+			TasksCompleted int    `json:"tasksCompleted"`
+			TasksTotal     int    `json:"tasksTotal"`
+		} `json:"progress"`
+	}
+	bob.mustDo(http.MethodGet, base+"/participants/progress", nil, http.StatusOK, &progress)
+	for _, p := range progress.Progress {
+		want := map[string][2]int{alice.userID: {1, 2}, bob.userID: {1, 1}}[p.UserID]
+		if p.TasksCompleted != want[0] || p.TasksTotal != want[1] {
+			t.Fatalf("progress for %s: %d/%d, want %d/%d", p.UserID, p.TasksCompleted, p.TasksTotal, want[0], want[1])
+		}
+	}
+
+	// Завершение и отчет: за 27 минут — фокус 25 минут и 2 минуты перерыва
+	h.rewind(session.ID, 27*time.Minute)
+	var completed reportResponse
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, &completed)
+	var report reportResponse
+	bob.mustDo(http.MethodGet, base+"/report", nil, http.StatusOK, &report)
+	for name, r := range map[string]reportResponse{"complete": completed, "report": report} {
+		if r.Report.SessionID != session.ID || r.Report.TasksCompleted != 2 || r.Report.TasksTotal != 3 ||
+			r.Report.FocusTime != 25 || r.Report.BreakTime != 2 || r.Report.CyclesCompleted != 1 ||
+			len(r.Report.Participants) != 2 {
+			t.Fatalf("%s: %+v", name, r.Report)
+		}
+		for _, p := range r.Report.Participants {
+			if p.TasksCompleted != 1 {
+				t.Fatalf("%s: participant %s completed %d tasks, want 1", name, p.UserID, p.TasksCompleted)
+			}
+		}
+	}
+
+	carol := h.login(1003, "Carol")
+	carol.mustFail(http.MethodPost, base+"/join", nil, http.StatusConflict, "session_already_started")
+	carol.mustFail(http.MethodPost, base+"/complete", nil, http.StatusForbidden, "not_session_participant")
+	// Повторное завершение не сбрасывает отчет и не шлет сообщение с кнопкой чата еще раз
+	bob.mustFail(http.MethodPost, base+"/complete", nil, http.StatusConflict, "session_already_completed")
+
+	// Завершение ставит в outbox сообщение создателю с кнопкой чата
+	if actions := h.outboxActions(session.ID); !actions["send_message_to_user"] {
+		t.Fatalf("outbox after complete: %v", actions)
+	}
+
+	// MAX присылает webhook о созданном по кнопке чате — сессия получает chat_id
+	update := h.fakeMax.ChatCreatedFromButton(alice.maxID, "Обсуждение: Team", fmt.Sprintf("session_id:%s:discussion", session.ID))
+	resp, err := fake.DeliverWebhook(h.server.URL+"/api/v1/webhook/max", update)
+	if err != nil {
+		t.Fatalf("deliver webhook: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook status %d", resp.StatusCode)
+	}
+
+	var withChat sessionResponse
+	alice.mustDo(http.MethodGet, base, nil, http.StatusOK, &withChat)
+	if withChat.Session.MaxChatID == nil || *withChat.Session.MaxChatID != update.Chat.ChatID {
+		t.Fatalf("maxChatId after webhook: %v, want %d", withChat.Session.MaxChatID, update.Chat.ChatID)
+	}
+	if actions := h.outboxActions(session.ID); !actions["add_chat_members"] {
+		t.Fatalf("outbox after chat created: %v", actions)
+	}
+}
+
+func TestAuthRequired(t *testing.T) {
+	h := newHarness(t)
+	anonymous := h.anonymous()
+
+	anonymous.mustFail(http.MethodGet, "/api/v1/sessions/active", nil, http.StatusUnauthorized, "unauthorized")
+
+	forged := fake.InitDataForUser("wrong-bot-token", fake.InitDataUser{ID: 2001, FirstName: "Mallory"}, "")
+	status, body := anonymous.do(http.MethodPost, "/api/v1/auth/login", map[string]string{"initData": forged}, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("login with forged initData: status %d, body %s", status, body)
+	}
+
+	alice := h.login(1001, "Alice")
+	alice.mustFail(http.MethodGet, "/api/v1/sessions/active", nil, http.StatusNotFound, "active_session_not_found")
+	alice.mustFail(http.MethodGet, "/api/v1/sessions/00000000-0000-0000-0000-000000000000", nil, http.StatusNotFound, "session_not_found")
+	alice.mustFail(http.MethodPost, "/api/v1/sessions", map[string]interface{}{"mode": "group"}, http.StatusBadRequest, "invalid_request_body")
+}
+
+func TestPrivateSessionIsHidden(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode":          "group",
+		"tasks":         []string{"Secret"},
+		"focusDuration": 25,
+		"breakDuration": 5,
+		"isPrivate":     true,
+	}, http.StatusOK, &created)
+
+	bob.mustFail(http.MethodGet, "/api/v1/sessions/"+created.Session.ID, nil, http.StatusForbidden, "session_access_denied")
+	bob.mustFail(http.MethodGet, "/api/v1/sessions/"+created.Session.ID+"/report", nil, http.StatusForbidden, "session_access_denied")
+
+	// После входа по приглашению сессия доступна
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/join-by-invite", map[string]string{
+		"inviteLink": created.Session.InviteLink,
+	}, http.StatusOK, nil)
+	bob.mustDo(http.MethodGet, "/api/v1/sessions/"+created.Session.ID, nil, http.StatusOK, nil)
+}
@@ -0,0 +1,373 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/pkg/maxapi/fake"
+)
+
+const (
+	testBotToken   = "e2e-bot-token"
+	testAdminToken = "e2e-admin-token"
+	wsTimeout      = 5 * time.Second
+)
+
+// harness поднимает приложение целиком: роутер из build, memory-хранилище
+// и in-process фейк MAX API
+type harness struct {
+	t       *testing.T
+	server  *httptest.Server
+	fakeMax *fake.Server
+	app     *server
+	cfg     config.Config // конфигурация до build — база для проверок перезагрузки
+}
+
+// configure меняет конфигурацию до проверки и build
+func newHarness(t *testing.T, configure ...func(cfg *config.Config)) *harness {
+	t.Helper()
+
+	cfg := config.New()
+	cfg.SetDefaults()
+	cfg.Database.Driver = DriverMemory
+	cfg.MaxAPI.UseFake = true
+	cfg.MaxAPI.BotToken = testBotToken
+	cfg.App.JWTSecret = "e2e-jwt-secret"
+	cfg.App.AdminToken = testAdminToken
+	cfg.Outbox.Interval = 50 * time.Millisecond
+	cfg.Log.Level = "warn"
+	cfg.Log.Format = logger.FormatText
+	for _, apply := range configure {
+		apply(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	loaded := *cfg
+
+	log, err := logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	srv, err := build(cfg, log)
+	if err != nil {
+		t.Fatalf("build app: %v", err)
+	}
+	server := httptest.NewServer(srv.router)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), wsTimeout)
+		defer cancel()
+		if err := srv.shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+		server.Close()
+	})
+
+	return &harness{t: t, server: server, fakeMax: srv.fakeMax, app: srv, cfg: loaded}
+}
+
+// client — пользователь MAX со своими cookies
+type client struct {
+	h      *harness
+	http   *http.Client
+	userID string
+	maxID  int64
+}
+
+// login входит через /auth/login с initData, подписанной токеном бота
+func (h *harness) login(maxUserID int64, firstName string) *client {
+	h.t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		h.t.Fatalf("cookie jar: %v", err)
+	}
+	c := &client{h: h, http: &http.Client{Jar: jar}, maxID: maxUserID}
+
+	h.fakeMax.AddUser(maxUserID, firstName, "")
+	initData := fake.InitDataForUser(testBotToken, fake.InitDataUser{ID: maxUserID, FirstName: firstName}, "")
+
+	var resp struct {
+		User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	c.mustDo(http.MethodPost, "/api/v1/auth/login", map[string]string{"initData": initData}, http.StatusOK, &resp)
+	if resp.User.ID == "" {
+		h.t.Fatalf("login %s: empty user id", firstName)
+	}
+	c.userID = resp.User.ID
+
+	return c
+}
+
+// anonymous — клиент без входа
+func (h *harness) anonymous() *client {
+	return &client{h: h, http: &http.Client{}}
+}
+
+// do выполняет запрос к API и возвращает статус и тело ответа
+func (c *client) do(method, path string, body interface{}, header http.Header) (int, []byte) {
+	c.h.t.Helper()
+
+	resp, data := c.send(method, path, body, header)
+	return resp.StatusCode, data
+}
+
+// send как do, но возвращает ответ целиком — для проверки заголовков
+func (c *client) send(method, path string, body interface{}, header http.Header) (*http.Response, []byte) {
+	c.h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.h.t.Fatalf("marshal %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.h.server.URL+path, reader)
+	if err != nil {
+		c.h.t.Fatalf("new request %s %s: %v", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.h.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.h.t.Fatalf("read %s %s: %v", method, path, err)
+	}
+	return resp, data
+}
+
+// mustDo проверяет статус ответа и декодирует тело в out (если out не nil)
+func (c *client) mustDo(method, path string, body interface{}, wantStatus int, out interface{}) {
+	c.h.t.Helper()
+
+	status, data := c.do(method, path, body, nil)
+	if status != wantStatus {
+		c.h.t.Fatalf("%s %s: status %d, want %d; body: %s", method, path, status, wantStatus, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.h.t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+}
+
+// mustFail проверяет статус и code ошибки из ErrorMiddleware
+func (c *client) mustFail(method, path string, body interface{}, wantStatus int, wantCode string) {
+	c.h.t.Helper()
+
+	var resp struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	c.mustDo(method, path, body, wantStatus, &resp)
+	if resp.Code != wantCode {
+		c.h.t.Fatalf("%s %s: code %q, want %q (error %q)", method, path, resp.Code, wantCode, resp.Error)
+	}
+}
+
+// wsConn — WebSocket-соединение клиента
+type wsConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// event — сообщение, которое сервер шлет по WebSocket
+type event struct {
+	Event string                 `json:"event"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// dial открывает /ws с cookies клиента; sessionID подписывает на комнату сессии
+func (c *client) dial(sessionID string) *wsConn {
+	c.h.t.Helper()
+
+	wsURL := "ws" + strings.TrimPrefix(c.h.server.URL, "http") + "/api/v1/ws"
+	if sessionID != "" {
+		wsURL += "?sessionId=" + url.QueryEscape(sessionID)
+	}
+
+	dialer := websocket.Dialer{Jar: c.http.Jar, HandshakeTimeout: wsTimeout}
+	conn, resp, err := dialer.Dial(wsURL, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.h.t.Fatalf("dial websocket (status %d): %v", status, err)
+	}
+	c.h.t.Cleanup(func() { conn.Close() })
+
+	ws := &wsConn{t: c.h.t, conn: conn}
+	// Подключение регистрируется после апгрейда; ping/pong гарантирует,
+	// что сервер уже знает о клиенте и последующие события дойдут
+	ws.send("ping", nil)
+	ws.expect("pong", nil)
+	return ws
+}
+
+func (w *wsConn) send(name string, data map[string]interface{}) {
+	w.t.Helper()
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if err := w.conn.WriteJSON(event{Event: name, Data: data}); err != nil {
+		w.t.Fatalf("websocket send %s: %v", name, err)
+	}
+}
+
+// expect ждет событие name, для которого match возвращает true; остальные пропускает
+func (w *wsConn) expect(name string, match func(data map[string]interface{}) bool) event {
+	w.t.Helper()
+
+	deadline := time.Now().Add(wsTimeout)
+	var skipped []string
+	for {
+		w.conn.SetReadDeadline(deadline)
+		var ev event
+		if err := w.conn.ReadJSON(&ev); err != nil {
+			w.t.Fatalf("websocket: waiting for %q (skipped %v): %v", name, skipped, err)
+		}
+		if ev.Event == name && (match == nil || match(ev.Data)) {
+			return ev
+		}
+		skipped = append(skipped, ev.Event)
+	}
+}
+
+// forSession — фильтр событий по sessionId
+func forSession(sessionID string) func(data map[string]interface{}) bool {
+	return func(data map[string]interface{}) bool {
+		return fmt.Sprint(data["sessionId"]) == sessionID
+	}
+}
+
+// rewind сдвигает начало сессии на elapsed назад: ее активное время вырастает на elapsed
+func (h *harness) rewind(sessionID string, elapsed time.Duration) {
+	h.t.Helper()
+
+	session, err := h.app.repos.sessions.GetByID(sessionID)
+	if err != nil || session == nil || session.StartedAt == nil {
+		h.t.Fatalf("rewind %s: got %+v, %v", sessionID, session, err)
+	}
+	startedAt := session.StartedAt.Add(-elapsed)
+	session.StartedAt = &startedAt
+	if err := h.app.repos.sessions.Update(session); err != nil {
+		h.t.Fatalf("rewind %s: %v", sessionID, err)
+	}
+}
+
+// age отодвигает все моменты сессии на d в прошлое, как будто с них прошло d
+func (h *harness) age(sessionID string, d time.Duration) {
+	h.t.Helper()
+
+	session, err := h.app.repos.sessions.GetByID(sessionID)
+	if err != nil || session == nil {
+		h.t.Fatalf("age %s: got %+v, %v", sessionID, session, err)
+	}
+	for _, at := range []**time.Time{&session.StartedAt, &session.PausedAt, &session.CompletedAt, &session.CancelledAt} {
+		if *at != nil {
+			shifted := (*at).Add(-d)
+			*at = &shifted
+		}
+	}
+	if err := h.app.repos.sessions.Update(session); err != nil {
+		h.t.Fatalf("age %s: %v", sessionID, err)
+	}
+}
+
+// outboxMessage сообщение outbox в ответе админского API
+type outboxMessage struct {
+	Action    string `json:"action"`
+	Status    string `json:"status"`
+	SessionID string `json:"sessionId"`
+	Payload   string `json:"payload"`
+}
+
+// outboxActions возвращает действия outbox сессии через админский API
+func (h *harness) outboxActions(sessionID string) map[string]bool {
+	h.t.Helper()
+
+	actions := make(map[string]bool)
+	for _, message := range h.outboxMessages() {
+		if message.SessionID == sessionID {
+			actions[message.Action] = true
+		}
+	}
+	return actions
+}
+
+// botMessagesTo возвращает тексты сообщений бота пользователю, поставленных в outbox
+func (h *harness) botMessagesTo(maxUserID int64) []string {
+	h.t.Helper()
+
+	var texts []string
+	for _, message := range h.outboxMessages() {
+		if message.Action != "send_message_to_user" {
+			continue
+		}
+		var payload struct {
+			MaxUserID int64 `json:"maxUserId"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			h.t.Fatalf("decode outbox payload: %v", err)
+		}
+		if payload.MaxUserID == maxUserID {
+			texts = append(texts, payload.Message.Text)
+		}
+	}
+	return texts
+}
+
+// outboxMessages возвращает все сообщения outbox через админский API
+func (h *harness) outboxMessages() []outboxMessage {
+	h.t.Helper()
+
+	var resp struct {
+		Messages []outboxMessage `json:"messages"`
+	}
+	admin := h.anonymous()
+	status, body := admin.do(http.MethodGet, "/api/v1/admin/outbox?status=all&limit=100", nil, http.Header{
+		"X-Admin-Token": []string{testAdminToken},
+	})
+	if status != http.StatusOK {
+		h.t.Fatalf("list outbox: status %d, body %s", status, body)
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		h.t.Fatalf("decode outbox: %v", err)
+	}
+	return resp.Messages
+}
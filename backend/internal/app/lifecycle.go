@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rnegic/synchronous/internal/logger"
+)
+
+// lifecycle останавливает компоненты приложения в порядке, обратном регистрации:
+// сначала перестаем принимать запросы, затем гасим фоновые воркеры и исходящие
+// клиенты, БД — последней
+type lifecycle struct {
+	log   *slog.Logger
+	hooks []stopHook
+}
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// onStop регистрирует остановку компонента
+func (l *lifecycle) onStop(name string, stop func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, stopHook{name: name, stop: stop})
+}
+
+// onStopFunc регистрирует остановку без контекста и ошибки (Stop воркеров, Close клиентов)
+func (l *lifecycle) onStopFunc(name string, stop func()) {
+	l.onStop(name, func(context.Context) error {
+		stop()
+		return nil
+	})
+}
+
+// stop вызывает все хуки, даже если какие-то из них завершились ошибкой или
+// не уложились в ctx. Повторный вызов ничего не делает
+func (l *lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if err := runHook(ctx, hook); err != nil {
+			l.log.Error("failed to stop component", "name", hook.name, logger.Err(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.name, err))
+		}
+	}
+	l.hooks = nil
+
+	return errors.Join(errs...)
+}
+
+// runHook не дает зависшему компоненту задержать остановку остальных дольше ctx
+func runHook(ctx context.Context, hook stopHook) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/config"
+)
+
+func TestSessionListing(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	create := func(mode string, isPrivate bool) string {
+		var created sessionResponse
+		alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+			"mode":          mode,
+			"tasks":         []string{"One", "Two"},
+			"focusDuration": 25,
+			"breakDuration": 5,
+			"isPrivate":     isPrivate,
+		}, http.StatusOK, &created)
+		return created.Session.ID
+	}
+	solo := create("solo", false)
+	private := create("group", true)
+	open := create("group", false)
+	joined := create("group", false)
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/"+joined+"/join", nil, http.StatusOK, nil)
+
+	type listResponse struct {
+		Sessions []struct {
+			ID                string `json:"id"`
+			ParticipantsCount int    `json:"participantsCount"`
+			TasksTotal        int    `json:"tasksTotal"`
+			Participants      []struct {
+				UserID string `json:"userId"`
+			} `json:"participants"`
+		} `json:"sessions"`
+		Pagination struct {
+			HasNext    bool    `json:"hasNext"`
+			NextCursor *string `json:"nextCursor"`
+		} `json:"pagination"`
+	}
+	list := func(c *client, path string) ([]string, listResponse) {
+		t.Helper()
+		var resp listResponse
+		c.mustDo(http.MethodGet, path, nil, http.StatusOK, &resp)
+		ids := make([]string, 0, len(resp.Sessions))
+		for _, session := range resp.Sessions {
+			ids = append(ids, session.ID)
+		}
+		return ids, resp
+	}
+	assertList := func(what string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: got %v, want %v", what, got, want)
+		}
+	}
+
+	// История листается курсором от новых к старым
+	first, resp := list(alice, "/api/v1/sessions?limit=3")
+	if !resp.Pagination.HasNext || resp.Pagination.NextCursor == nil {
+		t.Fatalf("first page pagination: %+v", resp.Pagination)
+	}
+	rest, resp := list(alice, "/api/v1/sessions?limit=3&cursor="+*resp.Pagination.NextCursor)
+	if resp.Pagination.HasNext || resp.Pagination.NextCursor != nil {
+		t.Fatalf("last page pagination: %+v", resp.Pagination)
+	}
+	assertList("history", append(first, rest...), joined, open, private, solo)
+
+	got, _ := list(alice, "/api/v1/sessions?mode=group&status=pending,active")
+	assertList("history by mode", got, joined, open, private)
+	got, _ = list(bob, "/api/v1/sessions?role=participant")
+	assertList("Bob as participant", got, joined)
+	got, _ = list(bob, "/api/v1/sessions?role=creator")
+	assertList("Bob as creator", got)
+	got, _ = list(alice, "/api/v1/sessions?hasChat=true")
+	assertList("history with chat", got)
+
+	// Публичный список: только открытые групповые сессии в ожидании
+	got, resp = list(bob, "/api/v1/sessions/public")
+	assertList("public sessions", got, joined, open)
+	if summary := resp.Sessions[0]; summary.ParticipantsCount != 2 || len(summary.Participants) != 2 || summary.TasksTotal != 2 {
+		t.Fatalf("public session summary: %+v", summary)
+	}
+
+	alice.mustFail(http.MethodGet, "/api/v1/sessions?cursor=bogus", nil, http.StatusBadRequest, "invalid_cursor")
+	alice.mustFail(http.MethodGet, "/api/v1/sessions?status=bogus", nil, http.StatusBadRequest, "invalid_query")
+	alice.mustFail(http.MethodGet, "/api/v1/sessions?from=yesterday", nil, http.StatusBadRequest, "invalid_query")
+}
+
+func TestSessionDiscovery(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.App.MaxSessionSize = 2 })
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+	carol := h.login(1003, "Carol")
+
+	create := func(fields map[string]interface{}) sessionResponse {
+		t.Helper()
+		body := map[string]interface{}{
+			"mode":          "group",
+			"tasks":         []string{"Focus"},
+			"focusDuration": 25,
+			"breakDuration": 5,
+		}
+		for key, value := range fields {
+			body[key] = value
+		}
+		var created sessionResponse
+		alice.mustDo(http.MethodPost, "/api/v1/sessions", body, http.StatusOK, &created)
+		return created
+	}
+
+	later := time.Now().Add(3 * time.Hour).UTC().Format(time.RFC3339)
+	writers := create(map[string]interface{}{
+		"groupName":   "Morning writers",
+		"topic":       "Novel drafts",
+		"tags":        []string{" Writing ", "writing", "C++"},
+		"language":    "EN",
+		"scheduledAt": later,
+	})
+	if got := writers.Session; strings.Join(got.Tags, ",") != "writing,c++" || got.Language != "en" || got.ScheduledAt == "" {
+		t.Fatalf("created session metadata: %+v", got)
+	}
+	coders := create(map[string]interface{}{"topic": "Go homework", "tags": []string{"go"}, "language": "ru"}).Session.ID
+	full := create(map[string]interface{}{"tags": []string{"go"}}).Session.ID
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/"+full+"/join", nil, http.StatusOK, nil)
+	carol.mustFail(http.MethodPost, "/api/v1/sessions/"+full+"/join", nil, http.StatusConflict, "session_full")
+
+	type listResponse struct {
+		Sessions []struct {
+			ID        string   `json:"id"`
+			Tags      []string `json:"tags"`
+			OpenSeats int      `json:"openSeats"`
+			Joinable  bool     `json:"joinable"`
+		} `json:"sessions"`
+		Pagination struct {
+			NextCursor *string `json:"nextCursor"`
+		} `json:"pagination"`
+	}
+	list := func(query string) ([]string, listResponse) {
+		t.Helper()
+		var resp listResponse
+		carol.mustDo(http.MethodGet, "/api/v1/sessions/public?"+query, nil, http.StatusOK, &resp)
+		ids := make([]string, 0, len(resp.Sessions))
+		for _, session := range resp.Sessions {
+			ids = append(ids, session.ID)
+		}
+		return ids, resp
+	}
+	assertList := func(what string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: got %v, want %v", what, got, want)
+		}
+	}
+
+	got, _ := list("q=WRITERS")
+	assertList("search by name", got, writers.Session.ID)
+	got, _ = list("q=homework")
+	assertList("search by topic", got, coders)
+	got, _ = list("tags=GO")
+	assertList("tag filter", got, full, coders)
+	got, _ = list("language=en")
+	assertList("language filter", got, writers.Session.ID)
+	got, _ = list("joinable=true")
+	assertList("joinable now", got, coders)
+
+	got, resp := list("sort=popular")
+	assertList("popular first", got, full, coders, writers.Session.ID)
+	if s := resp.Sessions[0]; s.OpenSeats != 0 || s.Joinable {
+		t.Fatalf("full session summary: %+v", s)
+	}
+	if s := resp.Sessions[1]; s.OpenSeats != 1 || !s.Joinable || strings.Join(s.Tags, ",") != "go" {
+		t.Fatalf("open session summary: %+v", s)
+	}
+	if s := resp.Sessions[2]; s.OpenSeats != 1 || s.Joinable {
+		t.Fatalf("scheduled session summary: %+v", s)
+	}
+
+	// Курсор одной сортировки не годится для другой
+	got, resp = list("sort=seats&limit=2")
+	assertList("most open seats first", got, coders, writers.Session.ID)
+	got, _ = list("sort=seats&limit=2&cursor=" + *resp.Pagination.NextCursor)
+	assertList("most open seats, page 2", got, full)
+	carol.mustFail(http.MethodGet, "/api/v1/sessions/public?sort=start&cursor="+*resp.Pagination.NextCursor, nil,
+		http.StatusBadRequest, "invalid_cursor")
+	got, _ = list("sort=start")
+	assertList("earliest start first", got, coders, full, writers.Session.ID)
+
+	carol.mustFail(http.MethodGet, "/api/v1/sessions/public?sort=bogus", nil, http.StatusBadRequest, "invalid_query")
+	carol.mustFail(http.MethodGet, "/api/v1/sessions/public?tags=a%20b", nil, http.StatusBadRequest, "invalid_query")
+	alice.mustFail(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "group", "tasks": []string{}, "focusDuration": 25, "breakDuration": 5,
+		"scheduledAt": time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}, http.StatusBadRequest, "invalid_scheduled_at")
+}
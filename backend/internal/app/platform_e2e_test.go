@@ -0,0 +1,341 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/internal/health"
+)
+
+func TestShutdownClosesWebSockets(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	ws := alice.dial("")
+
+	ctx, cancel := context.WithTimeout(context.Background(), wsTimeout)
+	defer cancel()
+	if err := h.app.shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	// Клиент получает close frame с подсказкой переподключиться
+	ws.conn.SetReadDeadline(time.Now().Add(wsTimeout))
+	_, _, err := ws.conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseServiceRestart {
+		t.Fatalf("read after shutdown: %v, want close %d", err, websocket.CloseServiceRestart)
+	}
+
+	// Повторная остановка (из t.Cleanup) ничего не делает
+	if err := h.app.shutdown(ctx); err != nil {
+		t.Fatalf("second shutdown: %v", err)
+	}
+}
+
+func TestBuildFailsOnBadMaxAPIClient(t *testing.T) {
+	cfg := config.New()
+	cfg.SetDefaults()
+	cfg.Database.Driver = DriverMemory
+	cfg.MaxAPI.AccessToken = ""
+
+	// Ошибка клиента MAX возвращается из build, а не завершает процесс
+	srv, err := build(cfg, slog.New(slog.DiscardHandler))
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize Max API client") {
+		t.Fatalf("build = %v, %v, want Max API client error", srv, err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	h := newHarness(t)
+
+	// ID от прокси возвращается как есть, небезопасный заменяется сгенерированным
+	for sent, wantSame := range map[string]bool{"nginx-req-42": true, "bad id with spaces": false, "": false} {
+		req, err := http.NewRequest(http.MethodGet, h.server.URL+"/api/v1/health", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		if sent != "" {
+			req.Header.Set("X-Request-ID", sent)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("health: %v", err)
+		}
+		resp.Body.Close()
+
+		got := resp.Header.Get("X-Request-ID")
+		if got == "" || (got == sent) != wantSame {
+			t.Fatalf("X-Request-ID for %q: got %q", sent, got)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode":          "solo",
+		"tasks":         []string{"Focus"},
+		"focusDuration": 25,
+		"breakDuration": 5,
+	}, http.StatusOK, &created)
+	if status, _ := alice.do(http.MethodGet, "/api/v1/sessions/"+created.Session.ID+"/nope", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("unknown route: status %d", status)
+	}
+	alice.dial(created.Session.ID)
+
+	status, body := h.anonymous().do(http.MethodGet, "/metrics", nil, nil)
+	if status != http.StatusOK {
+		t.Fatalf("metrics: status %d", status)
+	}
+	for _, want := range []string{
+		`synchronous_http_request_duration_seconds_count{method="POST",route="/api/v1/sessions",status="200"} 1`,
+		`synchronous_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`synchronous_sessions{status="pending"} 1`,
+		`synchronous_sessions{status="active"} 0`,
+		`synchronous_websocket_clients 1`,
+		`synchronous_websocket_broadcast_queue_capacity 256`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics: missing %s", want)
+		}
+	}
+}
+
+func TestHealthProbes(t *testing.T) {
+	h := newHarness(t)
+	anon := h.anonymous()
+
+	var live map[string]interface{}
+	anon.mustDo(http.MethodGet, "/health/live", nil, http.StatusOK, &live)
+	if live["status"] != "ok" {
+		t.Fatalf("live: %v", live)
+	}
+
+	var ready health.Report
+	anon.mustDo(http.MethodGet, "/health/ready", nil, http.StatusOK, &ready)
+	if ready.Status != health.StatusOK {
+		t.Fatalf("ready: status %s, checks %+v", ready.Status, ready.Checks)
+	}
+	for _, name := range []string{"database", "max_api", "session_cleanup", "session_scheduler", "outbox"} {
+		check, ok := ready.Checks[name]
+		if !ok || check.Status != health.StatusOK {
+			t.Errorf("ready: check %s = %+v", name, check)
+		}
+	}
+	if !ready.Checks["database"].Critical || ready.Checks["max_api"].Critical {
+		t.Errorf("ready: unexpected criticality %+v", ready.Checks)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	h := newHarness(t)
+	const origin = "https://staging.focus-sync.ru"
+
+	preflight := func() string {
+		req, err := http.NewRequest(http.MethodOptions, h.server.URL+"/api/v1/sessions", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("preflight: %v", err)
+		}
+		resp.Body.Close()
+		return resp.Header.Get("Access-Control-Allow-Origin")
+	}
+
+	if got := preflight(); got != "" {
+		t.Fatalf("origin allowed before reload: %q", got)
+	}
+
+	next := h.cfg
+	next.Server.CORSOrigins = append([]string{origin}, h.cfg.Server.CORSOrigins...)
+	next.Cleanup.Interval = time.Minute
+	next.Log.Level = "error"
+	h.app.reloadConfig(&h.cfg, &next)
+
+	if got := preflight(); got != origin {
+		t.Fatalf("origin not allowed after reload: %q", got)
+	}
+	if h.app.log.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("log level not applied")
+	}
+	var ready health.Report
+	h.anonymous().mustDo(http.MethodGet, "/health/ready", nil, http.StatusOK, &ready)
+	if got := ready.Checks["session_cleanup"].Details["maxAgeSeconds"]; got != float64(120) {
+		t.Errorf("cleanup heartbeat tolerance after reload: %v", got)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	wsURL := "ws" + strings.TrimPrefix(h.server.URL, "http") + "/api/v1/ws"
+
+	// Чужая страница с cookie пользователя не должна открыть сокет, MAX WebApp — должна
+	for origin, wantStatus := range map[string]int{
+		"https://evil.example":     http.StatusForbidden,
+		"https://max.ru.evil.com":  http.StatusForbidden,
+		"https://webappcdn.max.ru": http.StatusSwitchingProtocols,
+		"http://localhost:5173":    http.StatusSwitchingProtocols,
+	} {
+		dialer := websocket.Dialer{Jar: alice.http.Jar, HandshakeTimeout: wsTimeout}
+		conn, resp, err := dialer.Dial(wsURL, http.Header{"Origin": {origin}})
+		if conn != nil {
+			conn.Close()
+		}
+		if resp == nil {
+			t.Fatalf("dial from %s: %v", origin, err)
+		}
+		if resp.StatusCode != wantStatus {
+			t.Errorf("dial from %s: status %d, want %d", origin, resp.StatusCode, wantStatus)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode":          "solo",
+		"tasks":         []string{"Focus"},
+		"focusDuration": 25,
+		"breakDuration": 5,
+	}, http.StatusOK, &created)
+	messages := "/api/v1/sessions/" + created.Session.ID + "/messages"
+
+	// Лимиты перезагружаются на лету; запас токенов урезается до нового всплеска
+	next := h.cfg
+	next.RateLimit.Auth = "2/1m"
+	next.RateLimit.Messages = "1/1m"
+	h.app.reloadConfig(&h.cfg, &next)
+
+	// Вход ограничен по IP: все клиенты теста приходят с 127.0.0.1
+	anon := h.anonymous()
+	for i := 0; i < 2; i++ {
+		if status, _ := anon.do(http.MethodPost, "/api/v1/auth/login", map[string]string{}, nil); status == http.StatusTooManyRequests {
+			t.Fatalf("login %d rate limited", i+1)
+		}
+	}
+	resp, body := anon.send(http.MethodPost, "/api/v1/auth/login", map[string]string{}, nil)
+	if resp.StatusCode != http.StatusTooManyRequests || !strings.Contains(string(body), `"rate_limited"`) {
+		t.Fatalf("login over the limit: status %d, body %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Retry-After") == "" || resp.Header.Get("RateLimit-Remaining") != "0" ||
+		resp.Header.Get("RateLimit-Limit") != "2" || resp.Header.Get("RateLimit-Policy") != "2;w=60" {
+		t.Fatalf("rate limit headers: %v", resp.Header)
+	}
+
+	// Сообщения ограничены по пользователю и только на POST
+	if status, _ := alice.do(http.MethodPost, messages, map[string]string{"text": "hi"}, nil); status == http.StatusTooManyRequests {
+		t.Fatalf("first message rate limited")
+	}
+	alice.mustFail(http.MethodPost, messages, map[string]string{"text": "again"}, http.StatusTooManyRequests, "rate_limited")
+	if status, _ := alice.do(http.MethodGet, messages, nil, nil); status == http.StatusTooManyRequests {
+		t.Fatal("GET messages rate limited by the messages group")
+	}
+	if status, _ := bob.do(http.MethodPost, messages, map[string]string{"text": "hi"}, nil); status == http.StatusTooManyRequests {
+		t.Fatal("Bob rate limited by Alice's bucket")
+	}
+
+	resp, _ = alice.send(http.MethodGet, "/api/v1/sessions/active", nil, nil)
+	if resp.Header.Get("RateLimit-Limit") != "600" {
+		t.Fatalf("api group headers: %v", resp.Header)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	create := map[string]interface{}{
+		"mode":          "solo",
+		"tasks":         []string{"Focus"},
+		"focusDuration": 25,
+		"breakDuration": 5,
+	}
+	key := http.Header{"Idempotency-Key": []string{"create-1"}}
+
+	resp, first := alice.send(http.MethodPost, "/api/v1/sessions", create, key)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first create: status %d, headers %v, body %s", resp.StatusCode, resp.Header, first)
+	}
+	resp, replayed := alice.send(http.MethodPost, "/api/v1/sessions", create, key)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retried create: status %d, headers %v", resp.StatusCode, resp.Header)
+	}
+	if string(replayed) != string(first) {
+		t.Fatalf("replayed body differs:\n%s\n%s", replayed, first)
+	}
+	var created sessionResponse
+	if err := json.Unmarshal(first, &created); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+
+	// Тот же ключ с другим запросом — ошибка клиента, а не повтор
+	create["focusDuration"] = 50
+	status, body := alice.do(http.MethodPost, "/api/v1/sessions", create, key)
+	if status != http.StatusUnprocessableEntity || !strings.Contains(string(body), `"idempotency_key_reused"`) {
+		t.Fatalf("reused key: status %d, body %s", status, body)
+	}
+
+	// Ключи у каждого пользователя свои
+	resp, _ = bob.send(http.MethodPost, "/api/v1/sessions", create, key)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("Bob's create with Alice's key: status %d, headers %v", resp.StatusCode, resp.Header)
+	}
+
+	// Повтор добавления задачи не создает дубликат
+	tasks := "/api/v1/sessions/" + created.Session.ID + "/tasks"
+	taskKey := http.Header{"Idempotency-Key": []string{"task-1"}}
+	for i := 0; i < 2; i++ {
+		if status, body := alice.do(http.MethodPost, tasks, map[string]string{"title": "Review"}, taskKey); status >= http.StatusBadRequest {
+			t.Fatalf("add task %d: status %d, body %s", i+1, status, body)
+		}
+	}
+	var session sessionResponse
+	alice.mustDo(http.MethodGet, "/api/v1/sessions/"+created.Session.ID, nil, http.StatusOK, &session)
+	if len(session.Session.Tasks) != 2 {
+		t.Fatalf("tasks after retried add: %d, want 2", len(session.Session.Tasks))
+	}
+
+	// Ошибки обработчика тоже повторяются как есть
+	missing := "/api/v1/sessions/" + uuid.New().String() + "/join"
+	errKey := http.Header{"Idempotency-Key": []string{"join-missing"}}
+	for i := 0; i < 2; i++ {
+		resp, body := alice.send(http.MethodPost, missing, nil, errKey)
+		if resp.StatusCode != http.StatusNotFound || (i == 1) != (resp.Header.Get("Idempotent-Replayed") == "true") {
+			t.Fatalf("join missing session %d: status %d, headers %v, body %s", i+1, resp.StatusCode, resp.Header, body)
+		}
+	}
+
+	status, _ = alice.do(http.MethodPost, "/api/v1/sessions", create, http.Header{"Idempotency-Key": []string{"bad key"}})
+	if status != http.StatusBadRequest {
+		t.Fatalf("invalid key: status %d, want 400", status)
+	}
+
+	// Тело читается целиком ради отпечатка, поэтому его размер ограничен
+	huge := map[string]interface{}{"name": strings.Repeat("a", 2<<20)}
+	status, _ = alice.do(http.MethodPost, "/api/v1/sessions", huge, http.Header{"Idempotency-Key": []string{"huge-1"}})
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body: status %d, want 413", status)
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/internal/logger"
+)
+
+// reloadConfig применяет настройки, которые можно менять на лету: CORS origin,
+// лимиты запросов к API и к MAX API, расписание очистки сессий и уровень логов. Остальные
+// изменения требуют перезапуска — о них только предупреждаем
+func (s *server) reloadConfig(current, next *config.Config) {
+	log := logger.Component(s.log, "config")
+	var applied []string
+
+	if !slices.Equal(current.Server.CORSOrigins, next.Server.CORSOrigins) {
+		s.origins.Set(next.Server.CORSOrigins)
+		applied = append(applied, "SERVER.CORS_ORIGINS")
+	}
+	if !reflect.DeepEqual(current.RateLimits(), next.RateLimits()) {
+		s.limiter.SetLimits(next.RateLimits())
+		applied = append(applied, "RATELIMIT")
+	}
+	if current.Cleanup != next.Cleanup {
+		s.cleanup.SetSchedule(next.Cleanup.Interval, next.Cleanup.MaxAge, next.Cleanup.IdleTimeout)
+		applied = append(applied, "CLEANUP")
+	}
+	if current.MaxAPI.RateLimit != next.MaxAPI.RateLimit {
+		s.maxAPI.SetRateLimit(next.MaxAPI.RateLimit)
+		applied = append(applied, "MAXAPI.RATE_LIMIT")
+	}
+	if current.Log.Level != next.Log.Level {
+		if err := logger.SetLevel(s.log, next.Log.Level); err != nil {
+			log.Error("failed to change log level", logger.Err(err))
+		} else {
+			applied = append(applied, "LOG.LEVEL")
+		}
+	}
+
+	// Сравниваем остальное, подставив в новую конфигурацию уже примененные значения
+	rest := *next
+	rest.Server.CORSOrigins = current.Server.CORSOrigins
+	rest.RateLimit.Auth = current.RateLimit.Auth
+	rest.RateLimit.API = current.RateLimit.API
+	rest.RateLimit.Messages = current.RateLimit.Messages
+	rest.Cleanup = current.Cleanup
+	rest.MaxAPI.RateLimit = current.MaxAPI.RateLimit
+	rest.Log.Level = current.Log.Level
+	if !reflect.DeepEqual(&rest, current) {
+		log.Warn("config file changed settings that require a restart")
+	}
+
+	if len(applied) > 0 {
+		log.Info("config reloaded", "applied", applied)
+	}
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/config"
+)
+
+func TestScheduledSessions(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Scheduler.Interval = 50 * time.Millisecond
+		cfg.Scheduler.Reminders = []string{"1s"}
+		cfg.Scheduler.NoShowTimeout = 500 * time.Millisecond
+	})
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+	carol := h.login(1003, "Carol")
+	aliceWS := alice.dial("")
+	carolWS := carol.dial("")
+
+	schedule := func(c *client, in time.Duration, quorum int) string {
+		t.Helper()
+		var created sessionResponse
+		c.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+			"mode":          "group",
+			"tasks":         []string{"Focus"},
+			"focusDuration": 25,
+			"breakDuration": 5,
+			"scheduledAt":   time.Now().Add(in).Format(time.RFC3339Nano),
+			"quorum":        quorum,
+		}, http.StatusOK, &created)
+		return created.Session.ID
+	}
+
+	// Кворум из двух готовых: напоминание за секунду, затем автостарт
+	quorum := schedule(alice, 1500*time.Millisecond, 2)
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/"+quorum+"/join", nil, http.StatusOK, nil)
+	for _, c := range []*client{alice, bob} {
+		c.mustDo(http.MethodPatch, "/api/v1/sessions/"+quorum+"/ready", map[string]bool{"isReady": true}, http.StatusOK, nil)
+	}
+	// Никто не отметил готовность — отмена через NoShowTimeout после начала
+	noShow := schedule(carol, time.Second, 0)
+
+	ev := aliceWS.expect("session_reminder", forSession(quorum))
+	if startsIn, _ := ev.Data["startsIn"].(float64); startsIn < 0 || startsIn > 1 {
+		t.Fatalf("session_reminder: %+v", ev.Data)
+	}
+	ev = aliceWS.expect("session_started", forSession(quorum))
+	if ev.Data["autoStarted"] != true {
+		t.Fatalf("session_started: %+v", ev.Data)
+	}
+	ev = carolWS.expect("session_cancelled", forSession(noShow))
+	if ev.Data["reason"] != "no_show" {
+		t.Fatalf("session_cancelled: %+v", ev.Data)
+	}
+
+	for id, want := range map[string]string{quorum: "active", noShow: "cancelled"} {
+		var got sessionResponse
+		alice.mustDo(http.MethodGet, "/api/v1/sessions/"+id, nil, http.StatusOK, &got)
+		if got.Session.Status != want {
+			t.Errorf("session %s: status %q, want %q", id, got.Session.Status, want)
+		}
+		if !h.outboxActions(id)["send_message_to_user"] {
+			t.Errorf("session %s: no bot message in outbox", id)
+		}
+	}
+
+	alice.mustFail(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "group", "tasks": []string{}, "focusDuration": 25, "breakDuration": 5, "quorum": 2,
+	}, http.StatusBadRequest, "invalid_quorum")
+}
+
+func TestSessionSeries(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+	carol := h.login(1003, "Carol")
+
+	type occurrence struct {
+		OccurrenceAt  string  `json:"occurrenceAt"`
+		ScheduledAt   string  `json:"scheduledAt"`
+		FocusDuration int     `json:"focusDuration"`
+		Status        string  `json:"status"`
+		SessionID     *string `json:"sessionId"`
+	}
+	type seriesResponse struct {
+		Series struct {
+			ID         string `json:"id"`
+			InviteLink string `json:"inviteLink"`
+			Recurrence string `json:"recurrence"`
+			EndedAt    string `json:"endedAt"`
+			Members    []struct {
+				UserID string `json:"userId"`
+			} `json:"members"`
+		} `json:"series"`
+		Upcoming []occurrence `json:"upcoming"`
+	}
+	createSeries := func(startsIn time.Duration, invite ...string) seriesResponse {
+		t.Helper()
+		var created seriesResponse
+		alice.mustDo(http.MethodPost, "/api/v1/series", map[string]interface{}{
+			"mode":          "group",
+			"tasks":         []string{"Plan", "Review"},
+			"focusDuration": 25,
+			"breakDuration": 5,
+			"groupName":     "Morning block",
+			"recurrence":    "RRULE:FREQ=DAILY",
+			"startsAt":      time.Now().Add(startsIn).Format(time.RFC3339Nano),
+			"timezone":      "Europe/Moscow",
+			"inviteUserIds": invite,
+		}, http.StatusCreated, &created)
+		return created
+	}
+	getSeries := func(c *client, id string) seriesResponse {
+		t.Helper()
+		var got seriesResponse
+		c.mustDo(http.MethodGet, "/api/v1/series/"+id, nil, http.StatusOK, &got)
+		return got
+	}
+	patchOccurrence := func(id, at string, body map[string]interface{}) occurrence {
+		t.Helper()
+		var resp struct {
+			Occurrence occurrence `json:"occurrence"`
+		}
+		alice.mustDo(http.MethodPatch, "/api/v1/series/"+id+"/occurrences/"+url.PathEscape(at), body, http.StatusOK, &resp)
+		return resp.Occurrence
+	}
+
+	// Экземпляры на горизонт (48 часов) создаются сразу: через час и через сутки.
+	// Приглашенный Боб получает код от бота, но участником без согласия не становится
+	series := createSeries(time.Hour, bob.userID)
+	id := series.Series.ID
+	if series.Series.Recurrence != "FREQ=DAILY" || series.Series.InviteLink == "" || len(series.Series.Members) != 1 {
+		t.Fatalf("created series: %+v", series.Series)
+	}
+	invites := h.botMessagesTo(bob.maxID)
+	if len(invites) != 1 || !strings.Contains(invites[0], series.Series.InviteLink) {
+		t.Fatalf("series invite to bob: %q", invites)
+	}
+	bob.mustFail(http.MethodGet, "/api/v1/series/"+id, nil, http.StatusForbidden, "series_access_denied")
+	var joined seriesResponse
+	bob.mustDo(http.MethodPost, "/api/v1/series/join-by-invite", map[string]string{"inviteLink": series.Series.InviteLink},
+		http.StatusOK, &joined)
+	if len(joined.Series.Members) != 2 {
+		t.Fatalf("members after join: %+v", joined.Series.Members)
+	}
+
+	upcoming := getSeries(alice, id).Upcoming
+	if len(upcoming) != 10 || upcoming[0].SessionID == nil || upcoming[1].SessionID == nil ||
+		upcoming[2].SessionID != nil || upcoming[2].Status != "upcoming" {
+		t.Fatalf("upcoming: %+v", upcoming)
+	}
+	first, second := upcoming[0], upcoming[1]
+
+	// Вступивший попадает в уже созданные экземпляры со своими задачами
+	var instance sessionResponse
+	bob.mustDo(http.MethodGet, "/api/v1/sessions/"+*first.SessionID, nil, http.StatusOK, &instance)
+	if instance.Session.Status != "pending" || len(instance.Session.Participants) != 2 || len(instance.Session.Tasks) != 2 ||
+		instance.Session.ScheduledAt == "" {
+		t.Fatalf("series instance: %+v", instance.Session)
+	}
+
+	// Пропуск отменяет экземпляр, отмена пропуска возвращает его
+	if got := patchOccurrence(id, second.OccurrenceAt, map[string]interface{}{"skipped": true}); got.Status != "skipped" {
+		t.Fatalf("skip: %+v", got)
+	}
+	alice.mustDo(http.MethodGet, "/api/v1/sessions/"+*second.SessionID, nil, http.StatusOK, &instance)
+	if instance.Session.Status != "cancelled" {
+		t.Fatalf("skipped instance status %q", instance.Session.Status)
+	}
+	if got := patchOccurrence(id, second.OccurrenceAt, map[string]interface{}{"skipped": false}); got.Status != "pending" {
+		t.Fatalf("unskip: %+v", got)
+	}
+
+	// Перенос и другая длительность одного повторения
+	moved := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	got := patchOccurrence(id, first.OccurrenceAt, map[string]interface{}{
+		"scheduledAt": moved.Format(time.RFC3339), "focusDuration": 50,
+	})
+	if at, _ := time.Parse(time.RFC3339, got.ScheduledAt); !at.Equal(moved) || got.FocusDuration != 50 {
+		t.Fatalf("moved occurrence: %+v", got)
+	}
+
+	bob.mustFail(http.MethodPatch, "/api/v1/series/"+id+"/occurrences/"+url.PathEscape(first.OccurrenceAt),
+		map[string]bool{"skipped": true}, http.StatusForbidden, "not_series_creator")
+	alice.mustFail(http.MethodPatch, "/api/v1/series/"+id+"/occurrences/"+url.PathEscape(time.Now().Format(time.RFC3339)),
+		map[string]bool{"skipped": true}, http.StatusNotFound, "occurrence_not_found")
+	carol.mustFail(http.MethodGet, "/api/v1/series/"+id, nil, http.StatusForbidden, "series_access_denied")
+
+	// История: завершенное повторение продолжает серию
+	sprint := createSeries(time.Second)
+	sprintFirst := getSeries(alice, sprint.Series.ID).Upcoming[0]
+	alice.mustDo(http.MethodPost, "/api/v1/sessions/"+*sprintFirst.SessionID+"/start", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, "/api/v1/sessions/"+*sprintFirst.SessionID+"/complete", nil, http.StatusOK, nil)
+	sprintAt, _ := time.Parse(time.RFC3339, sprintFirst.OccurrenceAt)
+	time.Sleep(time.Until(sprintAt) + 100*time.Millisecond)
+	var history struct {
+		Occurrences   []occurrence `json:"occurrences"`
+		Completed     int          `json:"completed"`
+		CurrentStreak int          `json:"currentStreak"`
+		LongestStreak int          `json:"longestStreak"`
+	}
+	alice.mustDo(http.MethodGet, "/api/v1/series/"+sprint.Series.ID+"/history", nil, http.StatusOK, &history)
+	if len(history.Occurrences) != 1 || history.Occurrences[0].Status != "completed" ||
+		history.Completed != 1 || history.CurrentStreak != 1 || history.LongestStreak != 1 {
+		t.Fatalf("history: %+v", history)
+	}
+
+	// Остановка отменяет ожидающие экземпляры и закрывает вступление
+	alice.mustDo(http.MethodPost, "/api/v1/series/"+id+"/stop", nil, http.StatusOK, nil)
+	stopped := getSeries(alice, id)
+	if stopped.Series.EndedAt == "" || len(stopped.Upcoming) != 0 {
+		t.Fatalf("stopped series: %+v", stopped)
+	}
+	alice.mustDo(http.MethodGet, "/api/v1/sessions/"+*second.SessionID, nil, http.StatusOK, &instance)
+	if instance.Session.Status != "cancelled" {
+		t.Fatalf("instance of stopped series: status %q", instance.Session.Status)
+	}
+	carol.mustFail(http.MethodPost, "/api/v1/series/join-by-invite", map[string]string{"inviteLink": series.Series.InviteLink},
+		http.StatusConflict, "series_ended")
+
+	for code, fields := range map[string]map[string]interface{}{
+		"invalid_recurrence": {"recurrence": "FREQ=HOURLY"},
+		"invalid_timezone":   {"timezone": "Mars/Olympus"},
+		"invalid_starts_at":  {"startsAt": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		req := map[string]interface{}{
+			"mode": "group", "focusDuration": 25, "breakDuration": 5, "recurrence": "FREQ=WEEKLY",
+			"startsAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		}
+		for name, value := range fields {
+			req[name] = value
+		}
+		alice.mustFail(http.MethodPost, "/api/v1/series", req, http.StatusBadRequest, code)
+	}
+}
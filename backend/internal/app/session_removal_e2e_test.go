@@ -0,0 +1,245 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/pkg/maxapi/fake"
+)
+
+func TestCancelSession(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+	bobWS := bob.dial("")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "group", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5,
+	}, http.StatusOK, &created)
+	base := "/api/v1/sessions/" + created.Session.ID
+	bob.mustDo(http.MethodPost, base+"/join", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+
+	h.rewind(created.Session.ID, 27*time.Minute)
+	bob.mustFail(http.MethodPost, base+"/cancel", map[string]string{"reason": "no time"}, http.StatusForbidden, "not_session_creator")
+	alice.mustFail(http.MethodPost, base+"/cancel", map[string]string{"reason": "  "}, http.StatusBadRequest, "invalid_reason")
+
+	var cancelled struct {
+		Session struct {
+			Status       string `json:"status"`
+			CancelledAt  string `json:"cancelledAt"`
+			CancelReason string `json:"cancelReason"`
+		} `json:"session"`
+	}
+	alice.mustDo(http.MethodPost, base+"/cancel", map[string]string{"reason": "Power outage"}, http.StatusOK, &cancelled)
+	if cancelled.Session.Status != "cancelled" || cancelled.Session.CancelReason != "Power outage" || cancelled.Session.CancelledAt == "" {
+		t.Fatalf("cancel: %+v", cancelled.Session)
+	}
+	ev := bobWS.expect("session_cancelled", forSession(created.Session.ID))
+	if ev.Data["reason"] != "Power outage" || ev.Data["cancelledBy"] != alice.userID {
+		t.Fatalf("session_cancelled: %+v", ev.Data)
+	}
+	if !h.outboxActions(created.Session.ID)["send_message_to_user"] {
+		t.Error("no bot message in outbox")
+	}
+
+	alice.mustFail(http.MethodPost, base+"/cancel", map[string]string{"reason": "again"}, http.StatusConflict, "session_already_cancelled")
+	alice.mustFail(http.MethodPost, base+"/complete", nil, http.StatusConflict, "session_cancelled")
+
+	// Через час после отмены отчет тот же: активное время кончилось отменой
+	h.age(created.Session.ID, time.Hour)
+	var report reportResponse
+	bob.mustDo(http.MethodGet, base+"/report", nil, http.StatusOK, &report)
+	if report.Report.FocusTime != 25 || report.Report.BreakTime != 2 || report.Report.CyclesCompleted != 1 {
+		t.Fatalf("report of a cancelled session: %+v", report.Report)
+	}
+	var got struct {
+		Session struct {
+			CurrentCycle int `json:"currentCycle"`
+		} `json:"session"`
+	}
+	bob.mustDo(http.MethodGet, base, nil, http.StatusOK, &got)
+	if got.Session.CurrentCycle != 1 {
+		t.Fatalf("cancelled session moved to cycle %d", got.Session.CurrentCycle)
+	}
+
+	var leaderboard struct {
+		Leaderboard []interface{} `json:"leaderboard"`
+	}
+	bob.mustDo(http.MethodGet, base+"/leaderboard", nil, http.StatusOK, &leaderboard)
+	if len(leaderboard.Leaderboard) != 0 {
+		t.Fatalf("leaderboard of a cancelled session: %+v", leaderboard.Leaderboard)
+	}
+}
+
+func TestCleanupCancelsAbandonedSessions(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Cleanup.Interval = 50 * time.Millisecond
+		cfg.Cleanup.MaxAge = 300 * time.Millisecond
+		cfg.Cleanup.IdleTimeout = 300 * time.Millisecond
+	})
+	alice := h.login(1001, "Alice")
+	carol := h.login(1003, "Carol")
+	dave := h.login(1004, "Dave")
+	erin := h.login(1005, "Erin")
+	frank := h.login(1006, "Frank")
+	aliceWS := alice.dial("")
+	dave.dial("")
+
+	create := func(c *client, start bool, plan map[string]interface{}) string {
+		t.Helper()
+		var created sessionResponse
+		c.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+			"mode": "solo", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5, "cyclePlan": plan,
+		}, http.StatusOK, &created)
+		if start {
+			c.mustDo(http.MethodPost, "/api/v1/sessions/"+created.Session.ID+"/start", nil, http.StatusOK, nil)
+		}
+		return created.Session.ID
+	}
+	// Не начатая: отменяется через MaxAge, даже если создатель подключен
+	pending := create(alice, false, nil)
+	// Начатая без подключений и активности — через IdleTimeout после конца фокуса;
+	// с подключением остается
+	idle := create(carol, true, nil)
+	h.rewind(idle, 26*time.Minute)
+	connected := create(dave, true, nil)
+	// План пройден без autoComplete: сессия завершается, а не отменяется
+	finished := create(erin, true, map[string]interface{}{"cycles": 1})
+	h.rewind(finished, 30*time.Minute)
+	// Идет долгий фокус: это работа, хотя в сессии ничего не меняется
+	focusing := create(frank, true, map[string]interface{}{
+		"sequence": []map[string]int{{"focus": 240, "break": 10}},
+	})
+	h.rewind(focusing, time.Hour)
+
+	ev := aliceWS.expect("session_cancelled", forSession(pending))
+	if ev.Data["reason"] != "abandoned" {
+		t.Fatalf("session_cancelled: %+v", ev.Data)
+	}
+
+	status := func(c *client, id string) string {
+		var got sessionResponse
+		c.mustDo(http.MethodGet, "/api/v1/sessions/"+id, nil, http.StatusOK, &got)
+		return got.Session.Status
+	}
+	for deadline := time.Now().Add(wsTimeout); status(carol, idle) != "cancelled"; {
+		if time.Now().After(deadline) {
+			t.Fatal("idle session was not cancelled")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := status(dave, connected); got != "active" {
+		t.Fatalf("session with a connected participant: status %q, want active", got)
+	}
+	if got := status(frank, focusing); got != "active" {
+		t.Fatalf("session in a long focus block: status %q, want active", got)
+	}
+
+	for deadline := time.Now().Add(wsTimeout); status(erin, finished) != "completed"; {
+		if time.Now().After(deadline) {
+			t.Fatal("session with a finished plan was not completed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	var report reportResponse
+	erin.mustDo(http.MethodGet, "/api/v1/sessions/"+finished+"/report", nil, http.StatusOK, &report)
+	if report.Report.FocusTime != 25 || report.Report.BreakTime != 0 || report.Report.CyclesCompleted != 1 {
+		t.Fatalf("report of a finished plan: %+v", report.Report)
+	}
+}
+
+func TestDeleteAndRestoreSession(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+	bobWS := bob.dial("")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "group", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5,
+	}, http.StatusOK, &created)
+	base := "/api/v1/sessions/" + created.Session.ID
+	bob.mustDo(http.MethodPost, base+"/join", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+
+	bob.mustFail(http.MethodDelete, base, nil, http.StatusForbidden, "not_session_creator")
+	alice.mustFail(http.MethodDelete, base, nil, http.StatusConflict, "session_in_progress")
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, nil)
+
+	var deleted struct {
+		RestoreUntil time.Time `json:"restoreUntil"`
+	}
+	alice.mustDo(http.MethodDelete, base, nil, http.StatusOK, &deleted)
+	if until := time.Until(deleted.RestoreUntil); until < 6*24*time.Hour || until > 7*24*time.Hour {
+		t.Fatalf("restoreUntil %v, want about 7 days from now", deleted.RestoreUntil)
+	}
+	bobWS.expect("session_deleted", forSession(created.Session.ID))
+
+	// Удаленная сессия не видна ни по ID, ни в отчете и истории
+	bob.mustFail(http.MethodGet, base, nil, http.StatusNotFound, "session_not_found")
+	alice.mustFail(http.MethodGet, base+"/report", nil, http.StatusNotFound, "session_not_found")
+	alice.mustFail(http.MethodDelete, base, nil, http.StatusNotFound, "session_not_found")
+	var history struct {
+		Sessions []struct {
+			ID string `json:"id"`
+		} `json:"sessions"`
+	}
+	alice.mustDo(http.MethodGet, "/api/v1/sessions", nil, http.StatusOK, &history)
+	for _, session := range history.Sessions {
+		if session.ID == created.Session.ID {
+			t.Fatal("deleted session is in history")
+		}
+	}
+
+	bob.mustFail(http.MethodPost, base+"/restore", nil, http.StatusForbidden, "not_session_creator")
+	var restored sessionResponse
+	alice.mustDo(http.MethodPost, base+"/restore", nil, http.StatusOK, &restored)
+	if restored.Session.ID != created.Session.ID || restored.Session.Status != "completed" {
+		t.Fatalf("restore: %+v", restored.Session)
+	}
+	var report reportResponse
+	bob.mustDo(http.MethodGet, base+"/report", nil, http.StatusOK, &report)
+	if report.Report.TasksTotal != 1 || len(report.Report.Participants) != 2 {
+		t.Fatalf("report after restore: %+v", report.Report)
+	}
+	alice.mustFail(http.MethodPost, base+"/restore", nil, http.StatusNotFound, "session_not_found")
+}
+
+func TestCleanupPurgesDeletedSessions(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Cleanup.Interval = 50 * time.Millisecond
+		cfg.App.SessionRestoreWindow = 300 * time.Millisecond
+	})
+	alice := h.login(1001, "Alice")
+
+	var created sessionResponse
+	alice.mustDo(http.MethodPost, "/api/v1/sessions", map[string]interface{}{
+		"mode": "solo", "tasks": []string{"Focus"}, "focusDuration": 25, "breakDuration": 5,
+	}, http.StatusOK, &created)
+	base := "/api/v1/sessions/" + created.Session.ID
+	alice.mustDo(http.MethodPost, base+"/start", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, base+"/complete", nil, http.StatusOK, nil)
+
+	update := h.fakeMax.ChatCreatedFromButton(alice.maxID, "Обсуждение", fmt.Sprintf("session_id:%s:discussion", created.Session.ID))
+	resp, err := fake.DeliverWebhook(h.server.URL+"/api/v1/webhook/max", update)
+	if err != nil {
+		t.Fatalf("deliver webhook: %v", err)
+	}
+	resp.Body.Close()
+
+	alice.mustDo(http.MethodDelete, base, nil, http.StatusOK, nil)
+
+	// После окна восстановления сессия стирается, а удаление чата уходит в outbox
+	for deadline := time.Now().Add(wsTimeout); !h.outboxActions(created.Session.ID)["delete_chat"]; {
+		if time.Now().After(deadline) {
+			t.Fatal("deleted session was not purged")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	alice.mustFail(http.MethodPost, base+"/restore", nil, http.StatusNotFound, "session_not_found")
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/rnegic/synchronous/internal/config"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	gormRepo "github.com/rnegic/synchronous/internal/repository/gorm"
+	"github.com/rnegic/synchronous/internal/repository/memory"
+)
+
+// DriverMemory хранит все данные в памяти процесса — для демо, фронтенда и e2e тестов
+const DriverMemory = "memory"
+
+// repositories набор репозиториев выбранного хранилища
+type repositories struct {
+	users         interfaces.UserRepository
+	sessions      interfaces.SessionRepository
+	tasks         interfaces.TaskRepository
+	messages      interfaces.MessageRepository
+	leaderboard   interfaces.LeaderboardRepository
+	outbox        interfaces.OutboxRepository
+	updateCursors interfaces.UpdateCursorRepository
+	transactor    interfaces.Transactor
+	rateLimits    interfaces.RateLimitStore
+	idempotency   interfaces.IdempotencyRepository
+	series        interfaces.SessionSeriesRepository
+	templates     interfaces.SessionTemplateRepository
+
+	db    *sql.DB // пул соединений для проверки готовности; nil для memory
+	close func() error
+}
+
+// newRepositories создает репозитории по Database.Driver
+func newRepositories(cfg *config.Config, log *slog.Logger) (*repositories, error) {
+	switch cfg.Database.Driver {
+	case DriverMemory:
+		log.Warn("using in-memory storage, data will be lost on restart")
+		return newMemoryRepositories(), nil
+
+	case gormRepo.DriverPostgres, gormRepo.DriverSQLite:
+		dsn := cfg.Database.DSN
+		if cfg.Database.Driver == gormRepo.DriverPostgres {
+			dsn = cfg.BuildDSN()
+		}
+
+		db, err := gormRepo.InitDB(cfg.Database.Driver, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize database: %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			gormRepo.Close(db)
+			return nil, fmt.Errorf("failed to get database instance: %v", err)
+		}
+		if cfg.Database.MaxOpenConns > 0 {
+			sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+			sqlDB.SetMaxIdleConns(cfg.Database.MaxOpenConns)
+		}
+		log.Info("database connection established", "driver", cfg.Database.Driver, "max_open_conns", cfg.Database.MaxOpenConns)
+
+		return &repositories{
+			users:         gormRepo.NewUserRepository(db),
+			sessions:      gormRepo.NewSessionRepository(db),
+			tasks:         gormRepo.NewTaskRepository(db),
+			messages:      gormRepo.NewMessageRepository(db),
+			leaderboard:   gormRepo.NewLeaderboardRepository(db),
+			outbox:        gormRepo.NewOutboxRepository(db),
+			updateCursors: gormRepo.NewUpdateCursorRepository(db),
+			transactor:    gormRepo.NewTransactor(db),
+			rateLimits:    newRateLimitStore(cfg, gormRepo.NewRateLimitStore(db)),
+			idempotency:   gormRepo.NewIdempotencyRepository(db),
+			series:        gormRepo.NewSessionSeriesRepository(db),
+			templates:     gormRepo.NewSessionTemplateRepository(db),
+			db:            sqlDB,
+			close:         func() error { return gormRepo.Close(db) },
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown database driver %q (expected %q, %q or %q)",
+			cfg.Database.Driver, DriverMemory, gormRepo.DriverPostgres, gormRepo.DriverSQLite)
+	}
+}
+
+// newRateLimitStore выбирает хранилище ограничителя: общее в БД (RATELIMIT.STORE=database)
+// для нескольких реплик или память процесса
+func newRateLimitStore(cfg *config.Config, shared interfaces.RateLimitStore) interfaces.RateLimitStore {
+	if cfg.RateLimit.Store == config.RateLimitStoreDatabase {
+		return shared
+	}
+	return memory.NewRateLimitStore()
+}
+
+func newMemoryRepositories() *repositories {
+	userRepo := memory.NewUserRepository()
+	taskRepo := memory.NewTaskRepository()
+	messageRepo := memory.NewMessageRepository()
+	sessionRepo := memory.NewSessionRepository(taskRepo, messageRepo)
+	outboxRepo := memory.NewOutboxRepository()
+	seriesRepo := memory.NewSessionSeriesRepository()
+
+	return &repositories{
+		users:         userRepo,
+		sessions:      sessionRepo,
+		tasks:         taskRepo,
+		messages:      messageRepo,
+		leaderboard:   memory.NewLeaderboardRepository(sessionRepo, taskRepo, userRepo),
+		outbox:        outboxRepo,
+		updateCursors: memory.NewUpdateCursorRepository(),
+		transactor:    memory.NewTransactor(sessionRepo, taskRepo, outboxRepo, seriesRepo),
+		rateLimits:    memory.NewRateLimitStore(),
+		idempotency:   memory.NewIdempotencyRepository(),
+		series:        seriesRepo,
+		templates:     memory.NewSessionTemplateRepository(),
+		close:         func() error { return nil },
+	}
+}
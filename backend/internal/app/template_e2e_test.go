@@ -0,0 +1,126 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSessionTemplates(t *testing.T) {
+	h := newHarness(t)
+	alice := h.login(1001, "Alice")
+	bob := h.login(1002, "Bob")
+
+	type templateResponse struct {
+		Template struct {
+			ID            string   `json:"id"`
+			Name          string   `json:"name"`
+			FocusDuration int      `json:"focusDuration"`
+			Tasks         []string `json:"tasks"`
+			Tags          []string `json:"tags"`
+			ShareLink     string   `json:"shareLink"`
+			Roster        []struct {
+				UserID string `json:"userId"`
+			} `json:"roster"`
+		} `json:"template"`
+	}
+	templateBody := map[string]interface{}{
+		"name":          "Morning",
+		"mode":          "group",
+		"tasks":         []string{"Plan", "Review"},
+		"focusDuration": 50,
+		"breakDuration": 10,
+		"groupName":     "Morning block",
+		"tags":          []string{"Go"},
+		"rosterIds":     []string{bob.userID},
+	}
+	fromTemplate := func(c *client, body map[string]interface{}) sessionResponse {
+		t.Helper()
+		var created sessionResponse
+		c.mustDo(http.MethodPost, "/api/v1/sessions/from-template", body, http.StatusOK, &created)
+		return created
+	}
+
+	var template templateResponse
+	alice.mustDo(http.MethodPost, "/api/v1/templates", templateBody, http.StatusCreated, &template)
+	id := template.Template.ID
+	if template.Template.ShareLink == "" || len(template.Template.Roster) != 1 || template.Template.Tags[0] != "go" {
+		t.Fatalf("created template: %+v", template.Template)
+	}
+
+	// Своя сессия по шаблону — с задачами шаблона; состав получает приглашение бота,
+	// а участником становится, только вступив сам
+	own := fromTemplate(alice, map[string]interface{}{"templateId": id})
+	if own.Session.CreatorID != alice.userID || len(own.Session.Participants) != 1 || len(own.Session.Tasks) != 2 {
+		t.Fatalf("session from own template: %+v", own.Session)
+	}
+	invites := h.botMessagesTo(bob.maxID)
+	if len(invites) != 1 || !strings.Contains(invites[0], own.Session.InviteLink) || !h.outboxActions(own.Session.ID)["send_message_to_user"] {
+		t.Fatalf("template invite to bob: %q", invites)
+	}
+	var bobView sessionResponse
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/join-by-invite", map[string]string{"inviteLink": own.Session.InviteLink},
+		http.StatusOK, &bobView)
+	if len(bobView.Session.Participants) != 2 {
+		t.Fatalf("roster member after join: %+v", bobView.Session)
+	}
+	bob.mustDo(http.MethodPost, "/api/v1/sessions/"+own.Session.ID+"/tasks", map[string]string{"title": "Read"}, http.StatusOK, nil)
+
+	// По ссылке шаблон виден и копируется без состава
+	var shared templateResponse
+	bob.mustDo(http.MethodGet, "/api/v1/templates/shared/"+template.Template.ShareLink, nil, http.StatusOK, &shared)
+	if shared.Template.ID != id || shared.Template.Roster != nil {
+		t.Fatalf("shared template: %+v", shared.Template)
+	}
+	if got := fromTemplate(bob, map[string]interface{}{"shareLink": template.Template.ShareLink}); len(got.Session.Participants) != 1 {
+		t.Fatalf("session from shared template: %+v", got.Session)
+	}
+	var copied templateResponse
+	bob.mustDo(http.MethodPost, "/api/v1/templates/shared/"+template.Template.ShareLink+"/copy", nil, http.StatusCreated, &copied)
+	if copied.Template.ID == id || copied.Template.Name != "Morning" || len(copied.Template.Roster) != 0 {
+		t.Fatalf("copied template: %+v", copied.Template)
+	}
+	bob.mustFail(http.MethodGet, "/api/v1/templates/"+id, nil, http.StatusForbidden, "template_not_owned")
+	bob.mustFail(http.MethodPost, "/api/v1/sessions/from-template", map[string]string{"templateId": id},
+		http.StatusForbidden, "template_not_owned")
+
+	// Шаблон из завершенной сессии: ее настройки, свои задачи и остальные участники
+	bob.mustFail(http.MethodPost, "/api/v1/templates/from-session/"+own.Session.ID, nil, http.StatusConflict, "session_not_completed")
+	alice.mustDo(http.MethodPost, "/api/v1/sessions/"+own.Session.ID+"/start", nil, http.StatusOK, nil)
+	alice.mustDo(http.MethodPost, "/api/v1/sessions/"+own.Session.ID+"/complete", nil, http.StatusOK, nil)
+	var saved templateResponse
+	bob.mustDo(http.MethodPost, "/api/v1/templates/from-session/"+own.Session.ID, nil, http.StatusCreated, &saved)
+	if saved.Template.Name != "Morning block" || saved.Template.FocusDuration != 50 || len(saved.Template.Tasks) != 1 ||
+		len(saved.Template.Roster) != 1 || saved.Template.Roster[0].UserID != alice.userID {
+		t.Fatalf("template from session: %+v", saved.Template)
+	}
+
+	var list struct {
+		Templates []struct {
+			ID string `json:"id"`
+		} `json:"templates"`
+	}
+	bob.mustDo(http.MethodGet, "/api/v1/templates", nil, http.StatusOK, &list)
+	if len(list.Templates) != 2 || list.Templates[0].ID != saved.Template.ID {
+		t.Fatalf("bob templates: %+v", list.Templates)
+	}
+
+	// Изменение заменяет шаблон целиком, ссылка остается прежней
+	templateBody["name"] = "Evening"
+	templateBody["rosterIds"] = nil
+	var updated templateResponse
+	alice.mustDo(http.MethodPut, "/api/v1/templates/"+id, templateBody, http.StatusOK, &updated)
+	if updated.Template.Name != "Evening" || len(updated.Template.Roster) != 0 ||
+		updated.Template.ShareLink != template.Template.ShareLink {
+		t.Fatalf("updated template: %+v", updated.Template)
+	}
+
+	alice.mustDo(http.MethodDelete, "/api/v1/templates/"+id, nil, http.StatusOK, nil)
+	alice.mustFail(http.MethodGet, "/api/v1/templates/"+id, nil, http.StatusNotFound, "template_not_found")
+	bob.mustFail(http.MethodGet, "/api/v1/templates/shared/"+template.Template.ShareLink, nil, http.StatusNotFound, "template_not_found")
+
+	templateBody["mode"] = "solo"
+	templateBody["rosterIds"] = []string{bob.userID}
+	alice.mustFail(http.MethodPost, "/api/v1/templates", templateBody, http.StatusBadRequest, "invalid_roster")
+	alice.mustFail(http.MethodPost, "/api/v1/sessions/from-template", map[string]string{}, http.StatusBadRequest, "invalid_template")
+}
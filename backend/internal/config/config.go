@@ -1,37 +1,119 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/spf13/viper"
 )
 
+// Группы маршрутов с отдельными лимитами запросов
+const (
+	RateLimitGroupAuth     = "auth"
+	RateLimitGroupAPI      = "api"
+	RateLimitGroupMessages = "messages"
+)
+
+// Хранилища ограничителя запросов
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+)
+
+// Окружения запуска: в production запрещены небезопасные значения по умолчанию
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
 type Config struct {
 	Server struct {
 		Address string
+		// CORSOrigins — origin фронтенда и MAX WebApp для CORS и WebSocket, допускаются
+		// шаблоны https://*.example.com; по умолчанию — профиль APP.ENV. Перезагружается на лету
+		CORSOrigins []string
+		// TrustedProxies — адреса и подсети прокси (nginx), которым доверяем X-Forwarded-For
+		// при определении IP клиента для ограничителя запросов
+		TrustedProxies []string
 	}
 	Database struct {
-		Driver   string
+		Driver   string // "postgres", "sqlite" (DSN — путь к файлу) или "memory" (без БД)
 		DSN      string
 		Host     string
 		Port     int
 		User     string
 		Password string
 		DBName   string
+		// MaxOpenConns ограничивает пул соединений (0 — без ограничения); при
+		// насыщении пула /health/ready отвечает 503
+		MaxOpenConns int
 	}
 	MaxAPI struct {
 		BaseURL     string
 		AccessToken string
 		BotToken    string
+		RateLimit   float64 // запросов в секунду к Max API (0 — без ограничения)
+		MaxAttempts int     // число попыток для временных ошибок, включая первую
+		UseFake     bool    // поднять in-process фейк MAX API (pkg/maxapi/fake) для локальной разработки
+		UpdatesMode string  // источник обновлений: "webhook" или "polling" (long polling /updates)
 	}
 	App struct {
+		Env            string // "development" или "production"
 		JWTSecret      string
 		JWTTTL         int // в секундах
 		RefreshTTL     int // в секундах
 		WebSocketPath  string
 		MaxSessionSize int
+		AdminToken     string // токен для /admin; пустой — админские маршруты закрыты
+		// IdempotencyTTL — сколько хранить ответ на запрос с Idempotency-Key для повторов
+		IdempotencyTTL time.Duration
+		// SessionRestoreWindow — сколько удаленную сессию можно восстановить; после
+		// этого очистка стирает ее окончательно вместе с задачами и сообщениями
+		SessionRestoreWindow time.Duration
+	}
+	// RateLimit — ограничение частоты запросов по группам маршрутов, правила вида
+	// "20/1m" ("off" — без ограничения); правила перезагружаются на лету
+	RateLimit struct {
+		Store    string // "memory" (свой счетчик у каждой реплики) или "database" (общий через БД)
+		Auth     string // /auth/*, по IP
+		API      string // защищенные маршруты, по пользователю
+		Messages string // POST /sessions/:id/messages (рассылка в MAX), по пользователю
 	}
+	// Cleanup — отмена брошенных сессий; перезагружается на лету
+	Cleanup struct {
+		Interval time.Duration // как часто проверять, например "15m"
+		MaxAge   time.Duration // возраст pending-сессии, после которого она отменяется
+		// IdleTimeout — сколько начатая сессия может простоять без изменений, задач и
+		// сообщений, пока никто не подключен по WebSocket
+		IdleTimeout time.Duration
+	}
+	// Scheduler — запланированные сессии: напоминания, автостарт по кворуму и отмена без участников
+	Scheduler struct {
+		Interval time.Duration // как часто проверять, например "30s"
+		// Reminders — за сколько до начала напоминать участникам, например ["1h", "10m"];
+		// пустой список — без напоминаний
+		Reminders []string
+		// NoShowTimeout — через сколько после запланированного начала отменять
+		// сессию, в которой никто не отметил готовность
+		NoShowTimeout time.Duration
+		// SeriesHorizon — на сколько вперед создавать экземпляры повторяющихся сессий
+		SeriesHorizon time.Duration
+	}
+	// Outbox — доставка действий Max API из outbox
+	Outbox struct {
+		Interval    time.Duration // как часто забирать готовые сообщения, например "5s"
+		MaxAttempts int           // после стольких неудачных попыток сообщение уходит в dead letters
+	}
+	Log struct {
+		Level  string // debug, info, warn или error; перезагружается на лету
+		Format string // "json" для продакшена или "text" для локальной разработки
+	}
+
+	file string // прочитанный файл конфигурации; пустой — только переменные окружения
 }
 
 func New() *Config {
@@ -39,85 +121,175 @@ func New() *Config {
 	return &Config{}
 }
 
+// Load читает конфигурацию: значения по умолчанию, затем файл filePath (если он
+// есть — без файла сервер настраивается только переменными окружения), затем
+// переменные окружения вида SECTION_KEY. Результат проверяется через Validate
 func (c *Config) Load(filePath string) error {
 
 	c.SetDefaults()
 
-	viper.SetConfigFile(filePath)
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AutomaticEnv()
+	v := viper.New()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
 
 	// Читаем переменные окружения напрямую (для Docker)
-	viper.BindEnv("DB_DSN")
-	viper.BindEnv("DATABASE.DSN", "DB_DSN")
-	viper.BindEnv("MAXAPI.BOT_TOKEN", "BOT_TOKEN")
+	v.BindEnv("DB_DSN")
+	v.BindEnv("DATABASE.DSN", "DB_DSN")
+	v.BindEnv("MAXAPI.BOT_TOKEN", "BOT_TOKEN")
+	v.BindEnv("APP.JWT_SECRET", "APP_JWT_SECRET", "JWT_SECRET")
 
-	err := viper.ReadInConfig()
-	if err != nil {
+	if _, err := os.Stat(filePath); err == nil {
+		v.SetConfigFile(filePath)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file: %v", err)
+		}
+		c.file = filePath
+	} else if !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("failed to read config file: %v", err)
 	}
 
 	// Маппинг настроек
-	if viper.IsSet("SERVER.ADDRESS") {
-		c.Server.Address = viper.GetString("SERVER.ADDRESS")
+	if v.IsSet("SERVER.ADDRESS") {
+		c.Server.Address = v.GetString("SERVER.ADDRESS")
 	}
 
 	// Database settings
-	if viper.IsSet("DATABASE.DRIVER") {
-		c.Database.Driver = viper.GetString("DATABASE.DRIVER")
+	if v.IsSet("DATABASE.DRIVER") {
+		c.Database.Driver = v.GetString("DATABASE.DRIVER")
+	}
+	if v.IsSet("DATABASE.DSN") {
+		c.Database.DSN = v.GetString("DATABASE.DSN")
 	}
-	if viper.IsSet("DATABASE.DSN") {
-		c.Database.DSN = viper.GetString("DATABASE.DSN")
+	if v.IsSet("DATABASE.HOST") {
+		c.Database.Host = v.GetString("DATABASE.HOST")
 	}
-	if viper.IsSet("DATABASE.HOST") {
-		c.Database.Host = viper.GetString("DATABASE.HOST")
+	if v.IsSet("DATABASE.PORT") {
+		c.Database.Port = v.GetInt("DATABASE.PORT")
 	}
-	if viper.IsSet("DATABASE.PORT") {
-		c.Database.Port = viper.GetInt("DATABASE.PORT")
+	if v.IsSet("DATABASE.USER") {
+		c.Database.User = v.GetString("DATABASE.USER")
 	}
-	if viper.IsSet("DATABASE.USER") {
-		c.Database.User = viper.GetString("DATABASE.USER")
+	if v.IsSet("DATABASE.PASSWORD") {
+		c.Database.Password = v.GetString("DATABASE.PASSWORD")
 	}
-	if viper.IsSet("DATABASE.PASSWORD") {
-		c.Database.Password = viper.GetString("DATABASE.PASSWORD")
+	if v.IsSet("DATABASE.DB_NAME") {
+		c.Database.DBName = v.GetString("DATABASE.DB_NAME")
 	}
-	if viper.IsSet("DATABASE.DB_NAME") {
-		c.Database.DBName = viper.GetString("DATABASE.DB_NAME")
+	if v.IsSet("DATABASE.MAX_OPEN_CONNS") {
+		c.Database.MaxOpenConns = v.GetInt("DATABASE.MAX_OPEN_CONNS")
 	}
 
-	if viper.IsSet("MAXAPI.BASE_URL") {
-		c.MaxAPI.BaseURL = viper.GetString("MAXAPI.BASE_URL")
+	if v.IsSet("MAXAPI.BASE_URL") {
+		c.MaxAPI.BaseURL = v.GetString("MAXAPI.BASE_URL")
 	}
-	if viper.IsSet("MAXAPI.ACCESS_TOKEN") {
-		c.MaxAPI.AccessToken = viper.GetString("MAXAPI.ACCESS_TOKEN")
+	if v.IsSet("MAXAPI.ACCESS_TOKEN") {
+		c.MaxAPI.AccessToken = v.GetString("MAXAPI.ACCESS_TOKEN")
+	}
+	if v.IsSet("MAXAPI.RATE_LIMIT") {
+		c.MaxAPI.RateLimit = v.GetFloat64("MAXAPI.RATE_LIMIT")
+	}
+	if v.IsSet("MAXAPI.MAX_ATTEMPTS") {
+		c.MaxAPI.MaxAttempts = v.GetInt("MAXAPI.MAX_ATTEMPTS")
 	}
 	// Читаем BOT_TOKEN из конфига (будет перезаписан переменной окружения, если она есть)
-	if botToken := viper.GetString("MAXAPI.BOT_TOKEN"); botToken != "" {
+	if botToken := v.GetString("MAXAPI.BOT_TOKEN"); botToken != "" {
 		c.MaxAPI.BotToken = botToken
 	}
-	if viper.IsSet("APP.JWT_SECRET") {
-		c.App.JWTSecret = viper.GetString("APP.JWT_SECRET")
+	if v.IsSet("MAXAPI.UPDATES_MODE") {
+		c.MaxAPI.UpdatesMode = v.GetString("MAXAPI.UPDATES_MODE")
+	}
+	if v.IsSet("MAXAPI.USE_FAKE") {
+		c.MaxAPI.UseFake = v.GetBool("MAXAPI.USE_FAKE")
+	}
+	if v.IsSet("APP.ENV") {
+		c.App.Env = v.GetString("APP.ENV")
+	}
+	if v.IsSet("SERVER.TRUSTED_PROXIES") {
+		c.Server.TrustedProxies = splitList(v.GetStringSlice("SERVER.TRUSTED_PROXIES"))
+	}
+	if v.IsSet("RATELIMIT.STORE") {
+		c.RateLimit.Store = v.GetString("RATELIMIT.STORE")
+	}
+	if v.IsSet("RATELIMIT.AUTH") {
+		c.RateLimit.Auth = v.GetString("RATELIMIT.AUTH")
+	}
+	if v.IsSet("RATELIMIT.API") {
+		c.RateLimit.API = v.GetString("RATELIMIT.API")
+	}
+	if v.IsSet("RATELIMIT.MESSAGES") {
+		c.RateLimit.Messages = v.GetString("RATELIMIT.MESSAGES")
+	}
+	// Явный список заменяет профиль окружения целиком
+	if v.IsSet("SERVER.CORS_ORIGINS") {
+		c.Server.CORSOrigins = splitList(v.GetStringSlice("SERVER.CORS_ORIGINS"))
+	} else {
+		c.Server.CORSOrigins = DefaultCORSOrigins(c.App.Env)
+	}
+	if v.IsSet("APP.JWT_SECRET") {
+		c.App.JWTSecret = v.GetString("APP.JWT_SECRET")
+	}
+	if v.IsSet("APP.JWT_TTL") {
+		c.App.JWTTTL = v.GetInt("APP.JWT_TTL")
 	}
-	if viper.IsSet("APP.JWT_TTL") {
-		c.App.JWTTTL = viper.GetInt("APP.JWT_TTL")
+	if v.IsSet("APP.REFRESH_TTL") {
+		c.App.RefreshTTL = v.GetInt("APP.REFRESH_TTL")
 	}
-	if viper.IsSet("APP.REFRESH_TTL") {
-		c.App.RefreshTTL = viper.GetInt("APP.REFRESH_TTL")
+	if v.IsSet("APP.WEBSOCKET_PATH") {
+		c.App.WebSocketPath = v.GetString("APP.WEBSOCKET_PATH")
 	}
-	if viper.IsSet("APP.WEBSOCKET_PATH") {
-		c.App.WebSocketPath = viper.GetString("APP.WEBSOCKET_PATH")
+	if v.IsSet("APP.MAX_SESSION_SIZE") {
+		c.App.MaxSessionSize = v.GetInt("APP.MAX_SESSION_SIZE")
 	}
-	if viper.IsSet("APP.MAX_SESSION_SIZE") {
-		c.App.MaxSessionSize = viper.GetInt("APP.MAX_SESSION_SIZE")
+	if v.IsSet("APP.ADMIN_TOKEN") {
+		c.App.AdminToken = v.GetString("APP.ADMIN_TOKEN")
+	}
+	if v.IsSet("APP.IDEMPOTENCY_TTL") {
+		c.App.IdempotencyTTL = v.GetDuration("APP.IDEMPOTENCY_TTL")
+	}
+	if v.IsSet("APP.SESSION_RESTORE_WINDOW") {
+		c.App.SessionRestoreWindow = v.GetDuration("APP.SESSION_RESTORE_WINDOW")
+	}
+	if v.IsSet("CLEANUP.INTERVAL") {
+		c.Cleanup.Interval = v.GetDuration("CLEANUP.INTERVAL")
+	}
+	if v.IsSet("CLEANUP.MAX_AGE") {
+		c.Cleanup.MaxAge = v.GetDuration("CLEANUP.MAX_AGE")
+	}
+	if v.IsSet("CLEANUP.IDLE_TIMEOUT") {
+		c.Cleanup.IdleTimeout = v.GetDuration("CLEANUP.IDLE_TIMEOUT")
+	}
+	if v.IsSet("SCHEDULER.INTERVAL") {
+		c.Scheduler.Interval = v.GetDuration("SCHEDULER.INTERVAL")
+	}
+	if v.IsSet("SCHEDULER.REMINDERS") {
+		c.Scheduler.Reminders = splitList(v.GetStringSlice("SCHEDULER.REMINDERS"))
+	}
+	if v.IsSet("SCHEDULER.NO_SHOW_TIMEOUT") {
+		c.Scheduler.NoShowTimeout = v.GetDuration("SCHEDULER.NO_SHOW_TIMEOUT")
+	}
+	if v.IsSet("SCHEDULER.SERIES_HORIZON") {
+		c.Scheduler.SeriesHorizon = v.GetDuration("SCHEDULER.SERIES_HORIZON")
+	}
+	if v.IsSet("OUTBOX.INTERVAL") {
+		c.Outbox.Interval = v.GetDuration("OUTBOX.INTERVAL")
+	}
+	if v.IsSet("OUTBOX.MAX_ATTEMPTS") {
+		c.Outbox.MaxAttempts = v.GetInt("OUTBOX.MAX_ATTEMPTS")
+	}
+	if v.IsSet("LOG.LEVEL") {
+		c.Log.Level = v.GetString("LOG.LEVEL")
+	}
+	if v.IsSet("LOG.FORMAT") {
+		c.Log.Format = v.GetString("LOG.FORMAT")
 	}
 
 	// Проверяем переменную окружения DB_DSN (приоритет над config.toml)
-	if envDSN := viper.GetString("DB_DSN"); envDSN != "" {
+	if envDSN := v.GetString("DB_DSN"); envDSN != "" {
 		c.Database.DSN = envDSN
 	}
 
 	// Проверяем переменную окружения BOT_TOKEN (приоритет над config.toml)
-	if envBotToken := viper.GetString("BOT_TOKEN"); envBotToken != "" {
+	if envBotToken := v.GetString("BOT_TOKEN"); envBotToken != "" {
 		c.MaxAPI.BotToken = envBotToken
 	}
 
@@ -126,7 +298,52 @@ func (c *Config) Load(filePath string) error {
 		c.Database.DSN = c.BuildDSN()
 	}
 
-	return nil
+	return c.Validate()
+}
+
+// RateLimits возвращает правила по группам маршрутов; некорректные правила
+// отсекает Validate, здесь они считаются выключенными
+func (c *Config) RateLimits() map[string]entity.RateLimit {
+	limits := make(map[string]entity.RateLimit, 3)
+	for group, value := range map[string]string{
+		RateLimitGroupAuth:     c.RateLimit.Auth,
+		RateLimitGroupAPI:      c.RateLimit.API,
+		RateLimitGroupMessages: c.RateLimit.Messages,
+	} {
+		limits[group], _ = entity.ParseRateLimit(value)
+	}
+	return limits
+}
+
+// ReminderOffsets возвращает смещения напоминаний; некорректные значения отсекает
+// Validate, здесь они пропускаются
+func (c *Config) ReminderOffsets() []time.Duration {
+	offsets := make([]time.Duration, 0, len(c.Scheduler.Reminders))
+	for _, value := range c.Scheduler.Reminders {
+		if offset, err := time.ParseDuration(value); err == nil && offset > 0 {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+// File возвращает путь к прочитанному файлу конфигурации; пустая строка —
+// конфигурация только из переменных окружения
+func (c *Config) File() string {
+	return c.file
+}
+
+// splitList разбирает список из TOML-массива или переменной окружения "a,b c"
+func splitList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
 }
 
 // BuildDSN строит строку подключения к БД из отдельных параметров
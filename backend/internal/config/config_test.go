@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func TestLoadFromEnvOnly(t *testing.T) {
+	t.Setenv("SERVER_ADDRESS", ":9090")
+	t.Setenv("SERVER_CORS_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("DB_DSN", "postgres://user:pass@db:5432/app?sslmode=disable")
+	t.Setenv("CLEANUP_INTERVAL", "5m")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("SCHEDULER_REMINDERS", "1h, 10m")
+
+	cfg := New()
+	if err := cfg.Load(filepath.Join(t.TempDir(), "missing.toml")); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.File() != "" {
+		t.Errorf("File() = %q, want empty for env-only config", cfg.File())
+	}
+	if cfg.Server.Address != ":9090" || cfg.Database.DSN == "" || cfg.Cleanup.Interval != 5*time.Minute || cfg.Log.Level != "debug" {
+		t.Errorf("env not applied: %+v", cfg)
+	}
+	if got := strings.Join(cfg.Server.CORSOrigins, " "); got != "https://a.example https://b.example" {
+		t.Errorf("CORS origins = %q", got)
+	}
+	if got := cfg.ReminderOffsets(); len(got) != 2 || got[0] != time.Hour || got[1] != 10*time.Minute {
+		t.Errorf("reminder offsets = %v", got)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := New()
+	cfg.SetDefaults()
+	cfg.App.Env = EnvProduction
+	cfg.Database.DSN = "not a dsn"
+	cfg.App.JWTTTL = 0
+	cfg.Cleanup.Interval = 0
+	cfg.Log.Level = "loud"
+	cfg.Server.CORSOrigins = []string{"https://ok.example", "ftp://bad.example/path"}
+
+	err := cfg.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() = %v, want *ValidationError", err)
+	}
+	for _, want := range []string{
+		"DATABASE.DSN", "APP.JWT_TTL", "CLEANUP.INTERVAL", "LOG.LEVEL",
+		"SERVER.CORS_ORIGINS", "APP.JWT_SECRET must be changed", "MAXAPI.BOT_TOKEN",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing problem %q in:\n%v", want, err)
+		}
+	}
+	if len(validationErr.Problems) != 7 {
+		t.Errorf("got %d problems:\n%v", len(validationErr.Problems), err)
+	}
+}
+
+func TestValidateDoesNotLeakDSN(t *testing.T) {
+	cfg := New()
+	cfg.SetDefaults()
+	cfg.Database.DSN = "postgres://user:s3cret@/app"
+
+	err := cfg.Validate()
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestDevelopmentDefaultsAreValid(t *testing.T) {
+	cfg := New()
+	cfg.SetDefaults()
+	cfg.Database.Driver = "memory"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cfg.App.Env = EnvProduction
+	cfg.App.JWTSecret = strings.Repeat("x", minProductionSecretLength)
+	cfg.MaxAPI.BotToken = "bot-token"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate production: %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write("[database]\ndriver = \"memory\"\n[log]\nlevel = \"info\"\n")
+
+	cfg := New()
+	if err := cfg.Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Редактор может сгенерировать несколько событий на одну запись — ждем нужное
+	type reload struct {
+		next *Config
+		err  error
+	}
+	reloads := make(chan reload, 16)
+	watching := cfg.Watch(
+		func(next *Config) { reloads <- reload{next: next} },
+		func(err error) { reloads <- reload{err: err} },
+	)
+	if !watching {
+		t.Fatal("Watch() = false for a config file")
+	}
+	waitFor := func(match func(reload) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case r := <-reloads:
+				if match(r) {
+					return
+				}
+			case <-timeout:
+				t.Fatal("no matching reload")
+			}
+		}
+	}
+
+	write("[database]\ndriver = \"memory\"\n[log]\nlevel = \"bogus\"\n")
+	waitFor(func(r reload) bool {
+		if r.next != nil && r.next.Log.Level == "bogus" {
+			t.Fatalf("invalid config applied")
+		}
+		return r.err != nil && strings.Contains(r.err.Error(), "LOG.LEVEL")
+	})
+
+	write("[database]\ndriver = \"memory\"\n[log]\nlevel = \"debug\"\n")
+	waitFor(func(r reload) bool { return r.next != nil && r.next.Log.Level == "debug" })
+}
+
+func TestCORSProfiles(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("DATABASE_DRIVER", "memory")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", minProductionSecretLength))
+	t.Setenv("BOT_TOKEN", "bot-token")
+
+	cfg := New()
+	if err := cfg.Load(filepath.Join(t.TempDir(), "missing.toml")); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, origin := range cfg.Server.CORSOrigins {
+		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
+			t.Errorf("production profile allows %s", origin)
+		}
+	}
+
+	for origin, valid := range map[string]bool{
+		"https://*.max.ru":        true,
+		"http://*.example.com:80": true,
+		"*":                       false,
+		"https://*":               false,
+		"https://*.ru":            false,
+		"https://st.*.max.ru":     false,
+		"https://a.max.ru/path":   false,
+	} {
+		if err := validateOrigin(origin); (err == nil) != valid {
+			t.Errorf("validateOrigin(%q) = %v, want valid=%v", origin, err, valid)
+		}
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	for value, want := range map[string]entity.RateLimit{
+		"20/1m":  {Requests: 20, Period: time.Minute, Burst: 20},
+		"5/s":    {Requests: 5, Period: time.Second, Burst: 5},
+		"100/1h": {Requests: 100, Period: time.Hour, Burst: 100},
+		"off":    {},
+		"":       {},
+	} {
+		got, err := entity.ParseRateLimit(value)
+		if err != nil || got != want {
+			t.Errorf("ParseRateLimit(%q) = %+v, %v; want %+v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"20", "0/1m", "20/2h", "x/1m", "20/soon"} {
+		if _, err := entity.ParseRateLimit(value); err == nil {
+			t.Errorf("ParseRateLimit(%q): want error", value)
+		}
+	}
+
+	cfg := New()
+	cfg.SetDefaults()
+	cfg.Database.Driver = "memory"
+	cfg.RateLimit.Store = RateLimitStoreDatabase
+	cfg.RateLimit.Messages = "lots"
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "nginx"}
+	cfg.Scheduler.Reminders = []string{"15m", "soon"}
+	cfg.Scheduler.SeriesHorizon = 0
+	cfg.Outbox.MaxAttempts = 0
+	err := cfg.Validate()
+	for _, want := range []string{"RATELIMIT.STORE", "RATELIMIT.MESSAGES", "SERVER.TRUSTED_PROXIES", "SCHEDULER.REMINDERS",
+		"SCHEDULER.SERIES_HORIZON", "OUTBOX.MAX_ATTEMPTS"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want problem %s", err, want)
+		}
+	}
+}
@@ -1,12 +1,61 @@
 package config
 
+import "time"
+
+// DefaultJWTSecret — заглушка для локальной разработки, в production запрещена
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// DefaultCORSOrigins — профиль origin для окружения, если SERVER.CORS_ORIGINS не задан.
+// Шаблон https://*.max.ru покрывает CDN MAX (st.max.ru, webappcdn.max.ru)
+func DefaultCORSOrigins(env string) []string {
+	origins := []string{
+		"http://focus-sync.ru",  // Production HTTP
+		"https://focus-sync.ru", // Production HTTPS
+		"https://max.ru",        // MAX main domain
+		"https://*.max.ru",      // MAX CDN and WebApp CDN
+	}
+	if env != EnvProduction {
+		origins = append(origins,
+			"http://localhost:3000", // Frontend container
+			"http://localhost:5173", // Vite dev server
+			"http://127.0.0.1:5173",
+		)
+	}
+	return origins
+}
+
 func (c *Config) SetDefaults() {
 
 	c.Server.Address = ":8080"
+	c.Server.CORSOrigins = DefaultCORSOrigins(EnvDevelopment)
+	c.Server.TrustedProxies = []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	c.Database.Driver = "postgres"
+	c.Database.MaxOpenConns = 25
 	c.MaxAPI.BaseURL = "https://platform-api.max.ru"
-	c.App.JWTSecret = "your-secret-key-change-in-production"
+	c.MaxAPI.RateLimit = 30
+	c.MaxAPI.MaxAttempts = 4
+	c.MaxAPI.UpdatesMode = "webhook"
+	c.App.Env = EnvDevelopment
+	c.App.JWTSecret = DefaultJWTSecret
 	c.App.JWTTTL = 900        // 15 minutes for access token
 	c.App.RefreshTTL = 604800 // 7 days for refresh token
 	c.App.WebSocketPath = "/ws"
 	c.App.MaxSessionSize = 20
+	c.App.IdempotencyTTL = 24 * time.Hour
+	c.App.SessionRestoreWindow = 7 * 24 * time.Hour
+	c.RateLimit.Store = RateLimitStoreMemory
+	c.RateLimit.Auth = "20/1m"
+	c.RateLimit.API = "600/1m"
+	c.RateLimit.Messages = "30/1m"
+	c.Cleanup.Interval = 15 * time.Minute
+	c.Cleanup.MaxAge = time.Hour
+	c.Cleanup.IdleTimeout = 2 * time.Hour
+	c.Scheduler.Interval = 30 * time.Second
+	c.Scheduler.Reminders = []string{"15m", "1m"}
+	c.Scheduler.NoShowTimeout = 15 * time.Minute
+	c.Scheduler.SeriesHorizon = 48 * time.Hour
+	c.Outbox.Interval = 5 * time.Second
+	c.Outbox.MaxAttempts = 10
+	c.Log.Level = "info"
+	c.Log.Format = "json"
 }
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watch следит за файлом конфигурации и при его изменении вызывает onChange с
+// новой, уже проверенной конфигурацией. Если файл не читается или не проходит
+// Validate, вызывается onError, а действующие настройки остаются прежними.
+// Без файла (только переменные окружения) следить не за чем — Watch возвращает false
+func (c *Config) Watch(onChange func(next *Config), onError func(err error)) bool {
+	if c.file == "" {
+		return false
+	}
+
+	v := viper.New()
+	v.SetConfigFile(c.file)
+	v.OnConfigChange(func(fsnotify.Event) {
+		next := New()
+		if err := next.Load(c.file); err != nil {
+			onError(err)
+			return
+		}
+		onChange(next)
+	})
+	v.WatchConfig()
+
+	return true
+}
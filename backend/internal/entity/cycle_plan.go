@@ -0,0 +1,179 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ограничения плана циклов
+const (
+	MaxPlanCycles   = 100
+	MaxPlanSequence = 20
+	MaxPlanMinutes  = 480 // предел одной фазы
+)
+
+// Фазы сессии по плану циклов
+const (
+	CyclePhaseFocus = "focus"
+	CyclePhaseBreak = "break"
+	CyclePhaseDone  = "done" // план из Cycles циклов пройден
+)
+
+// CycleStep фокус и следующий за ним перерыв одного цикла, в минутах
+type CycleStep struct {
+	Focus int `json:"focus"`
+	Break int `json:"break"`
+}
+
+// CyclePlan план циклов сессии. Цикл берет фокус и перерыв из Sequence по кругу, а без
+// нее — FocusDuration/BreakDuration сессии; после каждого LongBreakEvery-го цикла перерыв
+// длится LongBreak. С Cycles > 0 план заканчивается фокусом последнего цикла, с 0 циклы
+// идут, пока сессию не завершат
+type CyclePlan struct {
+	Cycles         int         `json:"cycles"`
+	LongBreak      int         `json:"longBreak"`      // в минутах
+	LongBreakEvery int         `json:"longBreakEvery"` // 0 — без длинных перерывов
+	Sequence       []CycleStep `json:"sequence"`
+	AutoComplete   bool        `json:"autoComplete"` // завершить сессию, когда план пройден
+}
+
+// Validate проверяет план; ошибка — invalid_cycle_plan
+func (p *CyclePlan) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return NewValidationError("invalid_cycle_plan", "invalid cycle plan: "+fmt.Sprintf(format, args...),
+			map[string]string{"cyclePlan": "invalid"})
+	}
+
+	if p.Cycles < 0 || p.Cycles > MaxPlanCycles {
+		return invalid("cycles must be 0-%d", MaxPlanCycles)
+	}
+	if p.AutoComplete && p.Cycles == 0 {
+		return invalid("autoComplete requires a number of cycles")
+	}
+	if p.LongBreakEvery < 0 || p.LongBreakEvery > MaxPlanCycles {
+		return invalid("longBreakEvery must be 0-%d", MaxPlanCycles)
+	}
+	if p.LongBreakEvery > 0 && (p.LongBreak < 1 || p.LongBreak > MaxPlanMinutes) {
+		return invalid("longBreak must be 1-%d minutes", MaxPlanMinutes)
+	}
+	if p.LongBreakEvery == 0 && p.LongBreak != 0 {
+		return invalid("longBreak requires longBreakEvery")
+	}
+	if len(p.Sequence) > MaxPlanSequence {
+		return invalid("sequence can have at most %d steps", MaxPlanSequence)
+	}
+	for _, step := range p.Sequence {
+		if step.Focus < 1 || step.Focus > MaxPlanMinutes || step.Break < 0 || step.Break > MaxPlanMinutes {
+			return invalid("sequence focus must be 1-%d and break 0-%d minutes", MaxPlanMinutes, MaxPlanMinutes)
+		}
+	}
+	return nil
+}
+
+// CycleProgress выполненная часть плана
+type CycleProgress struct {
+	CyclesCompleted int           // циклов с пройденным фокусом
+	CurrentCycle    int           // с 1; для CyclePhaseDone — последний цикл
+	Phase           string        // CyclePhaseFocus, CyclePhaseBreak или CyclePhaseDone
+	PhaseLeft       time.Duration // до конца текущей фазы
+	FocusTime       time.Duration
+	BreakTime       time.Duration
+}
+
+// CycleStep фокус и перерыв цикла i (с 0) по плану сессии
+func (s *Session) CycleStep(i int) CycleStep {
+	step := CycleStep{Focus: s.FocusDuration, Break: s.BreakDuration}
+	plan := s.CyclePlan
+	if plan == nil {
+		return step
+	}
+	if len(plan.Sequence) > 0 {
+		step = plan.Sequence[i%len(plan.Sequence)]
+	}
+	if plan.LongBreakEvery > 0 && (i+1)%plan.LongBreakEvery == 0 {
+		step.Break = plan.LongBreak
+	}
+	return step
+}
+
+// ActiveTime время сессии без пауз: до завершения, отмены, текущей паузы или до now
+func (s *Session) ActiveTime(now time.Time) time.Duration {
+	if s.StartedAt == nil {
+		return 0
+	}
+	end := now
+	switch {
+	case s.CompletedAt != nil:
+		end = *s.CompletedAt
+	case s.CancelledAt != nil:
+		end = *s.CancelledAt
+	case s.PausedAt != nil:
+		end = *s.PausedAt
+	}
+	active := end.Sub(*s.StartedAt) - time.Duration(s.TotalPauseTime)*time.Millisecond
+	if active < 0 {
+		return 0
+	}
+	return active
+}
+
+// PlanDuration активное время всего плана; 0 — у плана нет конца
+func (s *Session) PlanDuration() time.Duration {
+	if s.CyclePlan == nil || s.CyclePlan.Cycles == 0 {
+		return 0
+	}
+	var total time.Duration
+	for i := 0; i < s.CyclePlan.Cycles; i++ {
+		step := s.CycleStep(i)
+		total += time.Duration(step.Focus) * time.Minute
+		if i < s.CyclePlan.Cycles-1 {
+			total += time.Duration(step.Break) * time.Minute
+		}
+	}
+	return total
+}
+
+// PlanFinishedAt момент, когда план из Cycles циклов пройден, если это случилось к now.
+// Пауза после конца плана его не сдвигает
+func (s *Session) PlanFinishedAt(now time.Time) (time.Time, bool) {
+	planDuration := s.PlanDuration()
+	if planDuration == 0 || s.StartedAt == nil || s.ActiveTime(now) < planDuration {
+		return time.Time{}, false
+	}
+	return s.StartedAt.Add(time.Duration(s.TotalPauseTime)*time.Millisecond + planDuration), true
+}
+
+// Progress проходит план сессии на active активного времени
+func (s *Session) Progress(active time.Duration) CycleProgress {
+	var progress CycleProgress
+	for i := 0; ; i++ {
+		progress.CurrentCycle = i + 1
+		step := s.CycleStep(i)
+
+		focus := time.Duration(step.Focus) * time.Minute
+		if active < focus || focus <= 0 {
+			progress.Phase = CyclePhaseFocus
+			progress.PhaseLeft = max(focus-active, 0)
+			progress.FocusTime += active
+			return progress
+		}
+		progress.FocusTime += focus
+		active -= focus
+		progress.CyclesCompleted++
+
+		if s.CyclePlan != nil && s.CyclePlan.Cycles > 0 && progress.CyclesCompleted == s.CyclePlan.Cycles {
+			progress.Phase = CyclePhaseDone
+			return progress
+		}
+
+		rest := time.Duration(step.Break) * time.Minute
+		if active < rest {
+			progress.Phase = CyclePhaseBreak
+			progress.PhaseLeft = rest - active
+			progress.BreakTime += active
+			return progress
+		}
+		progress.BreakTime += rest
+		active -= rest
+	}
+}
@@ -0,0 +1,254 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// planSession сессия 25/5 с планом plan
+func planSession(plan *CyclePlan) *Session {
+	return &Session{FocusDuration: 25, BreakDuration: 5, CyclePlan: plan}
+}
+
+func TestCyclePlanValidate(t *testing.T) {
+	valid := []CyclePlan{
+		{},
+		{Cycles: 4, AutoComplete: true},
+		{LongBreakEvery: 4, LongBreak: 15},
+		{Cycles: MaxPlanCycles, Sequence: []CycleStep{{Focus: 50, Break: 10}, {Focus: MaxPlanMinutes, Break: 0}}},
+	}
+	for _, plan := range valid {
+		if err := plan.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", plan, err)
+		}
+	}
+
+	invalid := []CyclePlan{
+		{Cycles: -1},
+		{Cycles: MaxPlanCycles + 1},
+		{AutoComplete: true},
+		{LongBreakEvery: -1},
+		{LongBreakEvery: 2},
+		{LongBreakEvery: 2, LongBreak: MaxPlanMinutes + 1},
+		{LongBreak: 15},
+		{Sequence: make([]CycleStep, MaxPlanSequence+1)},
+		{Sequence: []CycleStep{{Focus: 0, Break: 5}}},
+		{Sequence: []CycleStep{{Focus: 25, Break: -1}}},
+		{Sequence: []CycleStep{{Focus: 25, Break: MaxPlanMinutes + 1}}},
+	}
+	for _, plan := range invalid {
+		err := plan.Validate()
+		var domainErr *DomainError
+		if !errors.As(err, &domainErr) || domainErr.Code != "invalid_cycle_plan" {
+			t.Errorf("Validate(%+v) = %v, want invalid_cycle_plan", plan, err)
+		}
+	}
+}
+
+func TestSessionPlanDuration(t *testing.T) {
+	sequence := []CycleStep{{Focus: 50, Break: 10}, {Focus: 25, Break: 5}}
+
+	tests := []struct {
+		name   string
+		plan   *CyclePlan
+		expect time.Duration
+	}{
+		{name: "no plan", plan: nil, expect: 0},
+		{name: "open-ended", plan: &CyclePlan{Sequence: sequence}, expect: 0},
+		{name: "single cycle has no break", plan: &CyclePlan{Cycles: 1}, expect: 25 * time.Minute},
+		{name: "session durations", plan: &CyclePlan{Cycles: 3}, expect: 85 * time.Minute},
+		{name: "sequence", plan: &CyclePlan{Cycles: 3, Sequence: sequence}, expect: 140 * time.Minute},
+		{
+			name:   "long break every 2",
+			plan:   &CyclePlan{Cycles: 3, Sequence: sequence, LongBreakEvery: 2, LongBreak: 30},
+			expect: 165 * time.Minute,
+		},
+		{
+			// Длинный перерыв после последнего цикла не считается
+			name:   "long break after last cycle",
+			plan:   &CyclePlan{Cycles: 4, LongBreakEvery: 4, LongBreak: 30},
+			expect: 115 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planSession(tt.plan).PlanDuration(); got != tt.expect {
+				t.Fatalf("PlanDuration() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSessionProgress(t *testing.T) {
+	sequence := []CycleStep{{Focus: 50, Break: 10}, {Focus: 25, Break: 5}}
+	longBreaks := &CyclePlan{Cycles: 3, Sequence: sequence, LongBreakEvery: 2, LongBreak: 30}
+
+	tests := []struct {
+		name   string
+		plan   *CyclePlan
+		active time.Duration
+		expect CycleProgress
+	}{
+		{
+			name: "start", plan: nil, active: 0,
+			expect: CycleProgress{CurrentCycle: 1, Phase: CyclePhaseFocus, PhaseLeft: 25 * time.Minute},
+		},
+		{
+			name: "first break", plan: nil, active: 27 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 1, CurrentCycle: 1, Phase: CyclePhaseBreak,
+				PhaseLeft: 3 * time.Minute, FocusTime: 25 * time.Minute, BreakTime: 2 * time.Minute},
+		},
+		{
+			name: "open-ended runs on", plan: &CyclePlan{}, active: 100 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 3, CurrentCycle: 4, Phase: CyclePhaseFocus,
+				PhaseLeft: 15 * time.Minute, FocusTime: 85 * time.Minute, BreakTime: 15 * time.Minute},
+		},
+		{
+			name: "sequence", plan: &CyclePlan{Cycles: 3, Sequence: sequence}, active: 70 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 1, CurrentCycle: 2, Phase: CyclePhaseFocus,
+				PhaseLeft: 15 * time.Minute, FocusTime: 60 * time.Minute, BreakTime: 10 * time.Minute},
+		},
+		{
+			name: "sequence wraps around", plan: &CyclePlan{Sequence: sequence}, active: 95 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 2, CurrentCycle: 3, Phase: CyclePhaseFocus,
+				PhaseLeft: 45 * time.Minute, FocusTime: 80 * time.Minute, BreakTime: 15 * time.Minute},
+		},
+		{
+			name: "long break every 2", plan: longBreaks, active: 100 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 2, CurrentCycle: 2, Phase: CyclePhaseBreak,
+				PhaseLeft: 15 * time.Minute, FocusTime: 75 * time.Minute, BreakTime: 25 * time.Minute},
+		},
+		{
+			name: "last cycle focus", plan: longBreaks, active: 164 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 2, CurrentCycle: 3, Phase: CyclePhaseFocus,
+				PhaseLeft: time.Minute, FocusTime: 124 * time.Minute, BreakTime: 40 * time.Minute},
+		},
+		{
+			// План кончается фокусом последнего цикла, без перерыва после него
+			name: "done without trailing break", plan: longBreaks, active: 165 * time.Minute,
+			expect: CycleProgress{CyclesCompleted: 3, CurrentCycle: 3, Phase: CyclePhaseDone,
+				FocusTime: 125 * time.Minute, BreakTime: 40 * time.Minute},
+		},
+		{
+			name: "done stops counting", plan: longBreaks, active: 10 * time.Hour,
+			expect: CycleProgress{CyclesCompleted: 3, CurrentCycle: 3, Phase: CyclePhaseDone,
+				FocusTime: 125 * time.Minute, BreakTime: 40 * time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planSession(tt.plan).Progress(tt.active); got != tt.expect {
+				t.Fatalf("Progress(%v) = %+v, want %+v", tt.active, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSessionActiveTime(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Hour)
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	pause := (10 * time.Minute).Milliseconds()
+
+	tests := []struct {
+		name    string
+		session Session
+		expect  time.Duration
+	}{
+		{name: "not started", session: Session{}, expect: 0},
+		{name: "running", session: Session{StartedAt: at(0)}, expect: 2 * time.Hour},
+		{name: "past pauses", session: Session{StartedAt: at(0), TotalPauseTime: pause}, expect: 110 * time.Minute},
+		{
+			// На паузе время стоит на PausedAt
+			name:    "paused",
+			session: Session{StartedAt: at(0), PausedAt: at(40 * time.Minute), TotalPauseTime: pause},
+			expect:  30 * time.Minute,
+		},
+		{
+			name:    "completed",
+			session: Session{StartedAt: at(0), CompletedAt: at(time.Hour), TotalPauseTime: pause},
+			expect:  50 * time.Minute,
+		},
+		{
+			// Отмена снимает паузу, время стоит на CancelledAt
+			name:    "cancelled",
+			session: Session{StartedAt: at(0), CancelledAt: at(time.Hour), TotalPauseTime: pause},
+			expect:  50 * time.Minute,
+		},
+		{name: "pauses longer than session", session: Session{StartedAt: at(0), CompletedAt: at(time.Minute), TotalPauseTime: pause}, expect: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.ActiveTime(now); got != tt.expect {
+				t.Fatalf("ActiveTime() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+
+	// Прогресс сессии на паузе не меняется, пока она стоит
+	paused := planSession(nil)
+	paused.StartedAt, paused.PausedAt, paused.TotalPauseTime = at(0), at(40*time.Minute), pause
+	for _, later := range []time.Time{now, now.Add(time.Hour)} {
+		progress := paused.Progress(paused.ActiveTime(later))
+		if progress.CurrentCycle != 2 || progress.Phase != CyclePhaseFocus || progress.FocusTime != 25*time.Minute ||
+			progress.BreakTime != 5*time.Minute {
+			t.Fatalf("paused progress at %s: %+v", later.Format(time.RFC3339), progress)
+		}
+	}
+}
+
+func TestSessionPlanFinishedAt(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	pause := (10 * time.Minute).Milliseconds()
+	// 25 + 5 + 25 минут
+	twoCycles := &CyclePlan{Cycles: 2}
+
+	tests := []struct {
+		name     string
+		session  Session
+		now      time.Time
+		expect   time.Time
+		finished bool
+	}{
+		{name: "not started", session: Session{CyclePlan: twoCycles}, now: *at(time.Hour)},
+		{name: "open-ended", session: Session{CyclePlan: &CyclePlan{}, StartedAt: at(0)}, now: *at(10 * time.Hour)},
+		{name: "running", session: Session{CyclePlan: twoCycles, StartedAt: at(0)}, now: *at(54 * time.Minute)},
+		{
+			name: "finished", session: Session{CyclePlan: twoCycles, StartedAt: at(0)},
+			now: *at(2 * time.Hour), expect: *at(55 * time.Minute), finished: true,
+		},
+		{
+			name: "past pauses shift the end", session: Session{CyclePlan: twoCycles, StartedAt: at(0), TotalPauseTime: pause},
+			now: *at(2 * time.Hour), expect: *at(65 * time.Minute), finished: true,
+		},
+		{
+			name: "paused before the end", session: Session{CyclePlan: twoCycles, StartedAt: at(0), PausedAt: at(50 * time.Minute)},
+			now: *at(2 * time.Hour),
+		},
+		{
+			name: "paused after the end", session: Session{CyclePlan: twoCycles, StartedAt: at(0), PausedAt: at(time.Hour)},
+			now: *at(2 * time.Hour), expect: *at(55 * time.Minute), finished: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.session.FocusDuration, tt.session.BreakDuration = 25, 5
+			got, finished := tt.session.PlanFinishedAt(tt.now)
+			if finished != tt.finished || !got.Equal(tt.expect) {
+				t.Fatalf("PlanFinishedAt() = %v, %v, want %v, %v", got, finished, tt.expect, tt.finished)
+			}
+		})
+	}
+}
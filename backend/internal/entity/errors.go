@@ -0,0 +1,62 @@
+package entity
+
+import "errors"
+
+// Категории доменных ошибок. Сервисы оборачивают их (через %w или DomainError),
+// транспорт по категории выбирает HTTP статус
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidState = errors.New("invalid state")
+	ErrValidation   = errors.New("validation failed")
+	ErrUpstream     = errors.New("upstream error")
+)
+
+// DomainError ошибка сервисного слоя с машиночитаемым кодом
+type DomainError struct {
+	Kind    error             // одна из категорий Err*
+	Code    string            // стабильный код для клиента, например "session_not_found"
+	Message string            // сообщение для клиента
+	Fields  map[string]string // ошибки по полям (для ErrValidation)
+	Err     error             // исходная ошибка, клиенту не показывается
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap позволяет проверять и категорию, и исходную ошибку через errors.Is/As
+func (e *DomainError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+func NewNotFoundError(code, message string) *DomainError {
+	return &DomainError{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func NewForbiddenError(code, message string) *DomainError {
+	return &DomainError{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *DomainError {
+	return &DomainError{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func NewInvalidStateError(code, message string) *DomainError {
+	return &DomainError{Kind: ErrInvalidState, Code: code, Message: message}
+}
+
+func NewValidationError(code, message string, fields map[string]string) *DomainError {
+	return &DomainError{Kind: ErrValidation, Code: code, Message: message, Fields: fields}
+}
+
+func NewUpstreamError(code, message string, err error) *DomainError {
+	return &DomainError{Kind: ErrUpstream, Code: code, Message: message, Err: err}
+}
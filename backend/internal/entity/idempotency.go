@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "in_progress"
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord ответ на запрос с заголовком Idempotency-Key. Пока запрос
+// выполняется, запись in_progress; после — хранит ответ для повторов до ExpiresAt
+type IdempotencyRecord struct {
+	Key            string            `gorm:"column:idempotency_key;type:varchar(300);primaryKey" json:"key"` // userID + ":" + значение заголовка
+	Fingerprint    string            `gorm:"type:varchar(64);not null" json:"fingerprint"`                   // sha256 метода, пути и тела запроса
+	Status         IdempotencyStatus `gorm:"type:varchar(20);not null" json:"status"`
+	ResponseStatus int               `gorm:"not null;default:0" json:"responseStatus"`
+	ContentType    string            `gorm:"type:varchar(100);not null;default:''" json:"contentType"`
+	ResponseBody   string            `gorm:"type:text;not null;default:''" json:"responseBody"`
+	CreatedAt      time.Time         `gorm:"not null" json:"createdAt"`
+	ExpiresAt      time.Time         `gorm:"not null;index" json:"expiresAt"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_records"
+}
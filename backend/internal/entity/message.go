@@ -8,13 +8,13 @@ import (
 
 type Message struct {
 	ID           string         `gorm:"type:varchar(36);primaryKey" json:"id"`
-	SessionID    string         `gorm:"type:varchar(36);not null;index:idx_session_id" json:"sessionId"`
-	UserID       string         `gorm:"type:varchar(36);not null;index:idx_user_id" json:"userId"`
+	SessionID    string         `gorm:"type:varchar(36);not null;index:idx_messages_session_id" json:"sessionId"`
+	UserID       string         `gorm:"type:varchar(36);not null;index:idx_messages_user_id" json:"userId"`
 	UserName     string         `gorm:"type:varchar(255);not null" json:"userName"`
 	AvatarURL    *string        `gorm:"type:text" json:"avatarUrl"`
 	Text         string         `gorm:"type:text;not null" json:"text"`
-	MaxMessageID *string        `gorm:"type:varchar(255);index:idx_max_message_id" json:"maxMessageId,omitempty"` // ID сообщения в Max API
-	CreatedAt    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_created_at" json:"createdAt"`
+	MaxMessageID *string        `gorm:"type:varchar(255);uniqueIndex:idx_messages_max_message_id" json:"maxMessageId,omitempty"` // ID сообщения в Max API
+	CreatedAt    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_messages_created_at" json:"createdAt"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
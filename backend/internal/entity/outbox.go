@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+type OutboxAction string
+
+const (
+	OutboxActionSendMessageToUser OutboxAction = "send_message_to_user"
+	OutboxActionAddChatMembers    OutboxAction = "add_chat_members"
+	OutboxActionDeleteChat        OutboxAction = "delete_chat"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	OutboxStatusDead      OutboxStatus = "dead" // исчерпаны попытки или ошибка не исправится повтором
+)
+
+// OutboxMessage отложенное действие в Max API, записанное в одной транзакции с изменением состояния
+type OutboxMessage struct {
+	ID            string       `gorm:"type:varchar(36);primaryKey" json:"id"`
+	Action        OutboxAction `gorm:"type:varchar(50);not null" json:"action"`
+	SessionID     *string      `gorm:"type:varchar(36);index:idx_outbox_session_id" json:"sessionId,omitempty"`
+	Payload       string       `gorm:"type:text;not null" json:"payload"` // JSON с параметрами вызова
+	Status        OutboxStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts      int          `gorm:"not null;default:0" json:"attempts"`
+	LastError     *string      `gorm:"type:text" json:"lastError,omitempty"`
+	NextAttemptAt time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"nextAttemptAt"`
+	DeliveredAt   *time.Time   `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
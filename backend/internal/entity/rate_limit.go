@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitMaxPeriod — наибольший период правила. За это время любой bucket
+// заполняется, поэтому простаивающие дольше bucket'ы хранилище может удалить
+const RateLimitMaxPeriod = time.Hour
+
+// RateLimit правило token bucket: Requests запросов за Period, всплеск до Burst
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled сообщает, задано ли ограничение
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// ParseRateLimit разбирает правило вида "20/1m" или "100/10s"; "", "0" и "off"
+// означают отсутствие ограничения. Всплеск равен числу запросов за период
+func ParseRateLimit(value string) (RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" || strings.EqualFold(value, "off") {
+		return RateLimit{}, nil
+	}
+
+	requestsPart, periodPart, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: want requests/period, e.g. 20/1m", value)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsPart))
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: requests must be a positive integer", value)
+	}
+	periodPart = strings.TrimSpace(periodPart)
+	// "20/m" и "20/s" — без числа перед единицей
+	if periodPart != "" && (periodPart[0] < '0' || periodPart[0] > '9') {
+		periodPart = "1" + periodPart
+	}
+	period, err := time.ParseDuration(periodPart)
+	if err != nil || period <= 0 || period > RateLimitMaxPeriod {
+		return RateLimit{}, fmt.Errorf("rate limit %q: period must be a positive duration up to %s", value, RateLimitMaxPeriod)
+	}
+
+	return RateLimit{Requests: requests, Period: period, Burst: requests}, nil
+}
+
+// RateLimitResult итог попытки забрать токен
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // целых токенов после запроса
+	ResetAfter time.Duration // через сколько bucket заполнится полностью
+	RetryAfter time.Duration // через сколько появится токен, если запрос отклонен
+}
+
+// RateLimitBucket состояние token bucket одного ключа (пользователь или IP в группе маршрутов)
+type RateLimitBucket struct {
+	Key       string    `gorm:"column:bucket_key;type:varchar(255);primaryKey" json:"key"`
+	Tokens    float64   `gorm:"not null" json:"tokens"`
+	UpdatedAt time.Time `gorm:"not null;index" json:"updatedAt"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// Take пополняет bucket за прошедшее время и забирает токен, если он есть
+func (b *RateLimitBucket) Take(limit RateLimit, now time.Time) RateLimitResult {
+	capacity := float64(limit.Burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+	perSecond := float64(limit.Requests) / limit.Period.Seconds()
+
+	if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*perSecond)
+	}
+	if b.Tokens > capacity {
+		b.Tokens = capacity
+	}
+	b.UpdatedAt = now
+
+	result := RateLimitResult{}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.Tokens) / perSecond)
+	}
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.ResetAfter = secondsToDuration((capacity - b.Tokens) / perSecond)
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
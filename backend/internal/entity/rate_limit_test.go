@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitBucketTake(t *testing.T) {
+	limit := RateLimit{Requests: 2, Period: time.Second, Burst: 2}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := &RateLimitBucket{Tokens: 2, UpdatedAt: start}
+
+	for i, want := range []RateLimitResult{
+		{Allowed: true, Remaining: 1, ResetAfter: 500 * time.Millisecond},
+		{Allowed: true, Remaining: 0, ResetAfter: time.Second},
+		{Allowed: false, Remaining: 0, ResetAfter: time.Second, RetryAfter: 500 * time.Millisecond},
+	} {
+		if got := bucket.Take(limit, start); got != want {
+			t.Fatalf("take %d: got %+v, want %+v", i+1, got, want)
+		}
+	}
+
+	// За 250ms набирается половина токена — мало; за 500ms — целый
+	if got := bucket.Take(limit, start.Add(250*time.Millisecond)); got.Allowed || got.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("take after 250ms: got %+v", got)
+	}
+	if got := bucket.Take(limit, start.Add(500*time.Millisecond)); !got.Allowed {
+		t.Fatalf("take after 500ms: got %+v", got)
+	}
+
+	// Долгий простой не копит больше Burst
+	got := bucket.Take(limit, start.Add(time.Hour))
+	if !got.Allowed || got.Remaining != 1 || bucket.Tokens != 1 {
+		t.Fatalf("take after an hour: got %+v, tokens %v", got, bucket.Tokens)
+	}
+}
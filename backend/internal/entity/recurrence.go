@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Частоты правила повторения
+const (
+	FrequencyDaily  = "DAILY"
+	FrequencyWeekly = "WEEKLY"
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Recurrence — подмножество RRULE (RFC 5545): FREQ=DAILY|WEEKLY, INTERVAL, BYDAY
+// (дни недели без номеров), COUNT и UNTIL. Время и первый день берутся из начала серии
+type Recurrence struct {
+	Frequency string
+	Interval  int            // каждые Interval дней или недель
+	ByDay     []time.Weekday // пусто — день недели начала серии (для WEEKLY) или каждый день (для DAILY)
+	Count     int            // 0 — без ограничения
+	Until     *time.Time     // последнее допустимое начало включительно
+}
+
+// ParseRecurrence разбирает правило вида "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR";
+// префикс "RRULE:" допускается. UNTIL — YYYYMMDD или YYYYMMDDTHHMMSSZ
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	invalid := func(format string, args ...interface{}) (*Recurrence, error) {
+		return nil, NewValidationError("invalid_recurrence", "invalid recurrence rule: "+fmt.Sprintf(format, args...),
+			map[string]string{"recurrence": "invalid"})
+	}
+
+	r := &Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.ToUpper(part), "=")
+		if !ok || value == "" || seen[key] {
+			return invalid("%q", part)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			if value != FrequencyDaily && value != FrequencyWeekly {
+				return invalid("only DAILY and WEEKLY are supported")
+			}
+			r.Frequency = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 52 {
+				return invalid("INTERVAL must be 1-52")
+			}
+			r.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return invalid("BYDAY %q is not one of MO,TU,WE,TH,FR,SA,SU", code)
+				}
+				if !slices.Contains(r.ByDay, day) {
+					r.ByDay = append(r.ByDay, day)
+				}
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 || count > 1000 {
+				return invalid("COUNT must be 1-1000")
+			}
+			r.Count = count
+		case "UNTIL":
+			until, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				if until, err = time.Parse("20060102", value); err != nil {
+					return invalid("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+				}
+				// Дата без времени включает весь день
+				until = until.Add(24*time.Hour - time.Nanosecond)
+			}
+			r.Until = &until
+		default:
+			return invalid("%s is not supported", key)
+		}
+	}
+
+	if r.Frequency == "" {
+		return invalid("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return invalid("COUNT and UNTIL are mutually exclusive")
+	}
+	slices.Sort(r.ByDay)
+	return r, nil
+}
+
+// String возвращает правило в каноническом виде
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Frequency}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences возвращает начала повторений серии, начатой в start, в интервале
+// (after, until]. Время суток и часовой пояс берутся из start, так что переход
+// на летнее время не сдвигает начало
+func (r *Recurrence) Occurrences(start, after, until time.Time) []time.Time {
+	if r.Until != nil && r.Until.Before(until) {
+		until = *r.Until
+	}
+
+	var occurrences []time.Time
+	count := 0
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	// Недели считаются с понедельника недели начала серии
+	weekStart := startDay.AddDate(0, 0, -((int(startDay.Weekday()) + 6) % 7))
+	for day := startDay; ; day = day.AddDate(0, 0, 1) {
+		at := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		if at.After(until) {
+			break
+		}
+		if !r.matches(day, startDay, weekStart) {
+			continue
+		}
+
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if at.After(after) {
+			occurrences = append(occurrences, at)
+		}
+	}
+	return occurrences
+}
+
+// matches проверяет, есть ли повторение в день day (дни в UTC без времени)
+func (r *Recurrence) matches(day, startDay, weekStart time.Time) bool {
+	days := int(day.Sub(startDay).Hours() / 24)
+	switch r.Frequency {
+	case FrequencyDaily:
+		return days%r.Interval == 0 && (len(r.ByDay) == 0 || slices.Contains(r.ByDay, day.Weekday()))
+	default:
+		weeks := int(day.Sub(weekStart).Hours()/24) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == startDay.Weekday()
+		}
+		return slices.Contains(r.ByDay, day.Weekday())
+	}
+}
+
+// IsOccurrence проверяет, что at — одно из повторений серии, начатой в start
+func (r *Recurrence) IsOccurrence(start, at time.Time) bool {
+	occurrences := r.Occurrences(start, at.Add(-time.Nanosecond), at)
+	return len(occurrences) == 1 && occurrences[0].Equal(at)
+}
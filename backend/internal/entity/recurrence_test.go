@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for rule, want := range map[string]string{
+		"RRULE:FREQ=DAILY":                    "FREQ=DAILY",
+		"freq=weekly;interval=1":              "FREQ=WEEKLY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR,MO":  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+		"FREQ=WEEKLY;BYDAY=SU,MO,SU":          "FREQ=WEEKLY;BYDAY=SU,MO",
+		"FREQ=DAILY;COUNT=5":                  "FREQ=DAILY;COUNT=5",
+		"FREQ=DAILY;UNTIL=20260304T090000Z":   "FREQ=DAILY;UNTIL=20260304T090000Z",
+		"FREQ=DAILY;UNTIL=20260304":           "FREQ=DAILY;UNTIL=20260304T235959Z",
+		" RRULE:FREQ=DAILY;;INTERVAL=3 ":      "FREQ=DAILY;INTERVAL=3",
+		"FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,SA": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,SA",
+	} {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q): %v", rule, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("ParseRecurrence(%q).String() = %q, want %q", rule, got, want)
+		}
+	}
+
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=53",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=1001",
+		"FREQ=DAILY;UNTIL=2026-03-04",
+		"FREQ=DAILY;COUNT=3;UNTIL=20260304",
+		"FREQ=DAILY;BYMONTH=3",
+		"FREQ",
+	} {
+		if _, err := ParseRecurrence(rule); err == nil {
+			t.Errorf("ParseRecurrence(%q): want error", rule)
+		}
+	}
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	// Понедельник
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name   string
+		rule   string
+		start  time.Time
+		after  time.Time // нулевое — сразу перед start
+		until  time.Time
+		expect []string
+	}{
+		{
+			name: "daily", rule: "FREQ=DAILY", start: monday, until: monday.Add(3 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z", "2026-03-04T09:00:00Z", "2026-03-05T09:00:00Z"},
+		},
+		{
+			name: "daily interval", rule: "FREQ=DAILY;INTERVAL=2", start: monday, until: monday.Add(5 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-04T09:00:00Z", "2026-03-06T09:00:00Z"},
+		},
+		{
+			name: "daily weekends", rule: "FREQ=DAILY;BYDAY=SA,SU", start: monday, until: monday.Add(7 * day),
+			expect: []string{"2026-03-07T09:00:00Z", "2026-03-08T09:00:00Z"},
+		},
+		{
+			name: "weekly on start weekday", rule: "FREQ=WEEKLY", start: monday, until: monday.Add(14 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-09T09:00:00Z", "2026-03-16T09:00:00Z"},
+		},
+		{
+			name: "weekly byday", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR", start: monday, until: monday.Add(7 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-04T09:00:00Z", "2026-03-06T09:00:00Z", "2026-03-09T09:00:00Z"},
+		},
+		{
+			// Недели считаются с понедельника недели начала: вторник до начала не входит
+			name: "biweekly from midweek", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			start: monday.Add(2 * day), until: monday.Add(20 * day),
+			expect: []string{"2026-03-05T09:00:00Z", "2026-03-17T09:00:00Z", "2026-03-19T09:00:00Z"},
+		},
+		{
+			name: "count", rule: "FREQ=DAILY;COUNT=3", start: monday, until: monday.Add(10 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z", "2026-03-04T09:00:00Z"},
+		},
+		{
+			// COUNT считается от начала серии, а не от after
+			name: "count after first", rule: "FREQ=DAILY;COUNT=3", start: monday, after: monday, until: monday.Add(10 * day),
+			expect: []string{"2026-03-03T09:00:00Z", "2026-03-04T09:00:00Z"},
+		},
+		{
+			name: "until date includes the whole day", rule: "FREQ=DAILY;UNTIL=20260304", start: monday, until: monday.Add(10 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z", "2026-03-04T09:00:00Z"},
+		},
+		{
+			name: "until time is inclusive", rule: "FREQ=DAILY;UNTIL=20260303T090000Z", start: monday, until: monday.Add(10 * day),
+			expect: []string{"2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z"},
+		},
+		{
+			name: "window inside series", rule: "FREQ=DAILY", start: monday,
+			after: monday.Add(2 * day), until: monday.Add(4 * day),
+			expect: []string{"2026-03-05T09:00:00Z", "2026-03-06T09:00:00Z"},
+		},
+		{
+			// Переход на летнее время 29 марта: 09:00 по Берлину остается 09:00
+			name: "dst spring forward", rule: "FREQ=DAILY",
+			start: time.Date(2026, 3, 28, 9, 0, 0, 0, berlin), until: time.Date(2026, 3, 30, 9, 0, 0, 0, berlin),
+			expect: []string{"2026-03-28T09:00:00+01:00", "2026-03-29T09:00:00+02:00", "2026-03-30T09:00:00+02:00"},
+		},
+		{
+			// Возврат на зимнее время 25 октября
+			name: "dst fall back", rule: "FREQ=WEEKLY",
+			start: time.Date(2026, 10, 18, 9, 0, 0, 0, berlin), until: time.Date(2026, 11, 1, 9, 0, 0, 0, berlin),
+			expect: []string{"2026-10-18T09:00:00+02:00", "2026-10-25T09:00:00+01:00", "2026-11-01T09:00:00+01:00"},
+		},
+		{
+			name: "empty window", rule: "FREQ=WEEKLY", start: monday, after: monday, until: monday.Add(6 * day),
+			expect: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+			after := tt.after
+			if after.IsZero() {
+				after = tt.start.Add(-time.Nanosecond)
+			}
+
+			var got []string
+			for _, at := range r.Occurrences(tt.start, after, tt.until) {
+				got = append(got, at.Format(time.RFC3339))
+			}
+			if !slices.Equal(got, tt.expect) {
+				t.Fatalf("Occurrences: got %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRecurrenceIsOccurrence(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	r, err := ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3")
+	if err != nil {
+		t.Fatalf("ParseRecurrence: %v", err)
+	}
+
+	for at, want := range map[time.Time]bool{
+		start:                                true,
+		start.AddDate(0, 0, 2):               true,
+		start.AddDate(0, 0, 7):               true,
+		start.AddDate(0, 0, 9):               false, // за пределами COUNT
+		start.AddDate(0, 0, 1):               false, // вторник
+		start.Add(time.Hour):                 false, // другое время суток
+		start.AddDate(0, 0, -7):              false, // до начала серии
+		start.AddDate(0, 0, 2).Add(-1):       false,
+		start.In(time.FixedZone("", 3*3600)): true, // тот же момент в другом поясе
+	} {
+		if got := r.IsOccurrence(start, at); got != want {
+			t.Errorf("IsOccurrence(%s) = %v, want %v", at.Format(time.RFC3339Nano), got, want)
+		}
+	}
+}
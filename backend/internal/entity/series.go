@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"time"
+	_ "time/tzdata" // часовые пояса серий не зависят от tzdata в образе
+)
+
+// SessionSeries повторяющаяся сессия: по правилу Recurrence планировщик заранее
+// создает экземпляры Session с настройками, участниками и задачами серии
+type SessionSeries struct {
+	ID            string      `gorm:"type:varchar(36);primaryKey" json:"id"`
+	CreatorID     string      `gorm:"type:varchar(36);not null;index:idx_session_series_creator_id" json:"creatorId"`
+	Mode          SessionMode `gorm:"type:session_mode;not null" json:"mode"`
+	FocusDuration int         `gorm:"not null" json:"focusDuration"` // в минутах
+	BreakDuration int         `gorm:"not null" json:"breakDuration"` // в минутах
+	GroupName     *string     `gorm:"type:varchar(255)" json:"groupName"`
+	Topic         *string     `gorm:"type:varchar(255)" json:"topic"`
+	Language      *string     `gorm:"type:varchar(8)" json:"language"`
+	Tags          []string    `gorm:"serializer:json;type:text" json:"tags"`
+	IsPrivate     bool        `gorm:"not null;default:false" json:"isPrivate"`
+	Quorum        int         `gorm:"not null;default:0" json:"quorum"`
+	Tasks         []string    `gorm:"serializer:json;type:text;not null" json:"tasks"` // задачи каждого участника в каждом экземпляре
+	Recurrence    string      `gorm:"type:varchar(255);not null" json:"recurrence"`    // RRULE, см. ParseRecurrence
+	StartsAt      time.Time   `gorm:"not null" json:"startsAt"`                        // первое повторение; задает время суток
+	Timezone      string      `gorm:"type:varchar(64);not null" json:"timezone"`       // IANA, в нем считаются повторения
+	InviteLink    string      `gorm:"type:varchar(50);uniqueIndex:idx_session_series_invite_link;not null" json:"inviteLink"`
+	SpawnedUntil  *time.Time  `json:"-"`       // повторения до этого момента включительно уже созданы
+	EndedAt       *time.Time  `json:"endedAt"` // серия остановлена
+	CreatedAt     time.Time   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+
+	// Relations
+	Members []SeriesMember `gorm:"foreignKey:SeriesID;constraint:OnDelete:CASCADE" json:"members"`
+}
+
+func (SessionSeries) TableName() string {
+	return "session_series"
+}
+
+// Location часовой пояс серии; Timezone проверяется при создании
+func (s *SessionSeries) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// HasMember проверяет, входит ли пользователь в состав серии
+func (s *SessionSeries) HasMember(userID string) bool {
+	for _, member := range s.Members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// SeriesMember участник серии: добавляется во все будущие экземпляры
+type SeriesMember struct {
+	SeriesID  string    `gorm:"type:varchar(36);primaryKey" json:"-"`
+	UserID    string    `gorm:"type:varchar(36);primaryKey;index:idx_session_series_members_user_id" json:"userId"`
+	UserName  string    `gorm:"type:varchar(255);not null" json:"userName"`
+	AvatarURL *string   `gorm:"type:text" json:"avatarUrl"`
+	JoinedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"joinedAt"`
+}
+
+func (SeriesMember) TableName() string {
+	return "session_series_members"
+}
+
+// SeriesException изменение одного повторения серии: пропуск или другие время и длительности
+type SeriesException struct {
+	SeriesID      string     `gorm:"type:varchar(36);primaryKey" json:"-"`
+	OccurrenceAt  time.Time  `gorm:"primaryKey" json:"occurrenceAt"` // начало повторения по правилу
+	Skipped       bool       `gorm:"not null;default:false" json:"skipped"`
+	ScheduledAt   *time.Time `json:"scheduledAt"` // перенесенное начало
+	FocusDuration *int       `json:"focusDuration"`
+	BreakDuration *int       `json:"breakDuration"`
+}
+
+func (SeriesException) TableName() string {
+	return "session_series_exceptions"
+}
+
+// OccurrenceChange изменение повторения; nil-поля не меняются
+type OccurrenceChange struct {
+	Skipped       *bool
+	ScheduledAt   *time.Time
+	FocusDuration *int
+	BreakDuration *int
+}
+
+// NewSeriesParams параметры создания серии
+type NewSeriesParams struct {
+	Session    NewSessionParams // ScheduledAt не используется
+	Recurrence string
+	StartsAt   time.Time
+	Timezone   string
+	// InviteUserIDs — кому бот пришлет приглашение; в серию они вступают сами по InviteLink
+	InviteUserIDs []string
+}
+
+// Статусы повторения серии; у созданных экземпляров — статус сессии
+const (
+	OccurrenceStatusUpcoming = "upcoming" // еще не создано
+	OccurrenceStatusSkipped  = "skipped"
+	OccurrenceStatusMissed   = "missed" // время прошло, а экземпляра нет
+)
+
+// SeriesOccurrence повторение серии в расписании и истории
+type SeriesOccurrence struct {
+	OccurrenceAt  time.Time `json:"occurrenceAt"`
+	ScheduledAt   time.Time `json:"scheduledAt"`
+	FocusDuration int       `json:"focusDuration"`
+	BreakDuration int       `json:"breakDuration"`
+	Status        string    `json:"status"`
+	SessionID     *string   `json:"sessionId"`
+}
+
+// SeriesHistory прошедшие повторения (новые первыми) и серии завершенных подряд.
+// Пропущенные повторения серию не прерывают, отмененные и несостоявшиеся — прерывают
+type SeriesHistory struct {
+	Occurrences   []SeriesOccurrence `json:"occurrences"`
+	Completed     int                `json:"completed"`
+	Missed        int                `json:"missed"` // отмененные и несостоявшиеся
+	Skipped       int                `json:"skipped"`
+	CurrentStreak int                `json:"currentStreak"`
+	LongestStreak int                `json:"longestStreak"`
+}
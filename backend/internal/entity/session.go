@@ -31,15 +31,25 @@ type Session struct {
 	BreakDuration  int            `gorm:"not null" json:"breakDuration"` // в минутах
 	GroupName      *string        `gorm:"type:varchar(255)" json:"groupName"`
 	IsPrivate      bool           `gorm:"not null;default:false" json:"isPrivate"`
+	Topic          *string        `gorm:"type:varchar(255)" json:"topic"`
+	Language       *string        `gorm:"type:varchar(8);index:idx_sessions_language" json:"language"` // код языка ISO 639-1, например "ru"
+	ScheduledAt    *time.Time     `gorm:"index:idx_sessions_scheduled_at" json:"scheduledAt"`          // запланированное начало
+	Quorum         int            `gorm:"not null;default:0" json:"quorum"`                            // готовых участников для автостарта в ScheduledAt; 0 — без автостарта
+	RemindedAt     *time.Time     `json:"-"`                                                           // когда участникам ушло последнее напоминание
+	SeriesID       *string        `gorm:"type:varchar(36);uniqueIndex:idx_sessions_series_occurrence" json:"seriesId"`
+	OccurrenceAt   *time.Time     `gorm:"uniqueIndex:idx_sessions_series_occurrence" json:"occurrenceAt"`
 	CreatorID      string         `gorm:"type:varchar(36);not null;index:idx_creator_id" json:"creatorId"`
 	InviteLink     string         `gorm:"type:varchar(50);uniqueIndex:idx_invite_link;not null" json:"inviteLink"`
 	MaxChatID      *int64         `gorm:"index:idx_max_chat_id" json:"maxChatId,omitempty"` // ID чата в Max API
 	MaxChatLink    *string        `gorm:"type:varchar(500)" json:"maxChatLink,omitempty"`   // Ссылка на чат в Max
 	StartedAt      *time.Time     `json:"startedAt"`
 	CompletedAt    *time.Time     `json:"completedAt"`
+	CancelledAt    *time.Time     `json:"cancelledAt"`
+	CancelReason   *string        `gorm:"type:varchar(255)" json:"cancelReason"` // причина от создателя или код вроде no_show
 	PausedAt       *time.Time     `json:"pausedAt"`
 	TotalPauseTime int64          `gorm:"not null;default:0" json:"totalPauseTime"` // в миллисекундах
 	CurrentCycle   int            `gorm:"not null;default:0" json:"currentCycle"`
+	CyclePlan      *CyclePlan     `gorm:"serializer:json;type:text" json:"cyclePlan"` // nil — циклы FocusDuration/BreakDuration без конца
 	CreatedAt      time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_created_at" json:"createdAt"`
 	UpdatedAt      time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
@@ -47,6 +57,7 @@ type Session struct {
 	// Relations
 	Tasks        []Task        `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"tasks"`
 	Participants []Participant `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"participants"`
+	Tags         []SessionTag  `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"tags"`
 	Creator      *User         `gorm:"foreignKey:CreatorID" json:"creator,omitempty"`
 }
 
@@ -54,9 +65,28 @@ func (Session) TableName() string {
 	return "sessions"
 }
 
+// SessionTag тег сессии для поиска в публичном списке; хранится в нижнем регистре
+type SessionTag struct {
+	SessionID string `gorm:"type:varchar(36);primaryKey" json:"-"`
+	Tag       string `gorm:"type:varchar(32);primaryKey;index:idx_session_tags_tag" json:"tag"`
+}
+
+func (SessionTag) TableName() string {
+	return "session_tags"
+}
+
+// TagNames возвращает теги сессии строками
+func (s *Session) TagNames() []string {
+	names := make([]string, 0, len(s.Tags))
+	for _, tag := range s.Tags {
+		names = append(names, tag.Tag)
+	}
+	return names
+}
+
 type Participant struct {
-	SessionID string     `gorm:"type:varchar(36);primaryKey;index:idx_session_id" json:"sessionId"`
-	UserID    string     `gorm:"type:varchar(36);primaryKey;index:idx_user_id" json:"userId"`
+	SessionID string     `gorm:"type:varchar(36);primaryKey;index:idx_session_participants_session_id" json:"sessionId"`
+	UserID    string     `gorm:"type:varchar(36);primaryKey;index:idx_session_participants_user_id" json:"userId"`
 	UserName  string     `gorm:"type:varchar(255);not null" json:"userName"`
 	AvatarURL *string    `gorm:"type:text" json:"avatarUrl"`
 	IsReady   bool       `gorm:"not null;default:false" json:"isReady"`
@@ -74,8 +104,8 @@ func (Participant) TableName() string {
 
 type Task struct {
 	ID          string         `gorm:"type:varchar(36);primaryKey" json:"id"`
-	SessionID   string         `gorm:"type:varchar(36);not null;index:idx_session_id" json:"sessionId"`
-	UserID      *string        `gorm:"type:varchar(255);index:idx_user_id" json:"userId,omitempty"` // Owner of the task
+	SessionID   string         `gorm:"type:varchar(36);not null;index:idx_tasks_session_id" json:"sessionId"`
+	UserID      *string        `gorm:"type:varchar(255);index:idx_tasks_user_id" json:"userId,omitempty"` // Owner of the task
 	Title       string         `gorm:"type:varchar(500);not null" json:"title"`
 	Completed   bool           `gorm:"not null;default:false;index:idx_completed" json:"completed"`
 	CompletedAt *time.Time     `json:"completedAt"`
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения метаданных сессии
+const (
+	MaxSessionTags      = 10
+	MaxSessionTagLength = 32
+	MaxSessionTopic     = 255
+	MaxCancelReason     = 255
+)
+
+// NewSessionParams параметры создания сессии
+type NewSessionParams struct {
+	Mode          SessionMode
+	Tasks         []string
+	FocusDuration int // в минутах
+	BreakDuration int // в минутах
+	GroupName     *string
+	IsPrivate     bool
+	Topic         *string
+	Tags          []string
+	Language      *string
+	ScheduledAt   *time.Time
+	Quorum        int // готовых участников для автостарта в ScheduledAt; 0 — без автостарта
+	CyclePlan     *CyclePlan
+}
+
+// NormalizeSessionTags приводит теги к нижнему регистру, убирает пустые и повторы.
+// Тег — до MaxSessionTagLength букв, цифр и символов -_+#. (c++, c#, node.js)
+func NormalizeSessionTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxSessionTagLength || strings.IndexFunc(tag, invalidTagRune) >= 0 {
+			return nil, NewValidationError("invalid_tags", "tag must be 1-32 letters, digits or -_+#. characters",
+				map[string]string{"tags": "invalid"})
+		}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxSessionTags {
+		return nil, NewValidationError("invalid_tags", "too many tags", map[string]string{"tags": "max"})
+	}
+	return normalized, nil
+}
+
+func invalidTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_+#.", r)
+}
+
+// NormalizeLanguage принимает код языка ISO 639 из 2-3 латинских букв
+func NormalizeLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if len(language) < 2 || len(language) > 3 || strings.IndexFunc(language, func(r rune) bool {
+		return r < 'a' || r > 'z'
+	}) >= 0 {
+		return "", NewValidationError("invalid_language", "language must be an ISO 639 code like \"en\"",
+			map[string]string{"language": "invalid"})
+	}
+	return language, nil
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSessionTags(t *testing.T) {
+	got, err := NormalizeSessionTags([]string{" Go ", "go", "", "C++", "node.js", "c#"})
+	if err != nil || !slices.Equal(got, []string{"go", "c++", "node.js", "c#"}) {
+		t.Fatalf("NormalizeSessionTags: got %v, %v", got, err)
+	}
+
+	tooMany := make([]string, MaxSessionTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	for name, tags := range map[string][]string{
+		"space":    {"two words"},
+		"symbol":   {"go!"},
+		"too long": {strings.Repeat("я", MaxSessionTagLength+1)},
+		"too many": tooMany,
+	} {
+		if _, err := NormalizeSessionTags(tags); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	for value, want := range map[string]string{"en": "en", " RU ": "ru", "fil": "fil"} {
+		if got, err := NormalizeLanguage(value); err != nil || got != want {
+			t.Errorf("NormalizeLanguage(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "e", "engl", "e1", "ру"} {
+		if _, err := NormalizeLanguage(value); err == nil {
+			t.Errorf("NormalizeLanguage(%q): want error", value)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package entity
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionRole роль пользователя в сессии для фильтра списка
+type SessionRole string
+
+const (
+	SessionRoleAny         SessionRole = ""
+	SessionRoleCreator     SessionRole = "creator"
+	SessionRoleParticipant SessionRole = "participant" // участник чужой сессии
+)
+
+// SessionSort порядок списка сессий. При равном ключе — created_at DESC, id DESC
+type SessionSort string
+
+const (
+	SessionSortCreated SessionSort = ""        // новые первыми
+	SessionSortStart   SessionSort = "start"   // ближайшее начало первым: scheduled_at, без него — created_at
+	SessionSortSeats   SessionSort = "seats"   // больше свободных мест первыми
+	SessionSortPopular SessionSort = "popular" // больше участников первыми
+)
+
+// JoinableWindow — сессия без свободного начала считается «можно присоединиться
+// сейчас», если до запланированного старта осталось не больше этого времени
+const JoinableWindow = 15 * time.Minute
+
+// SessionFilter условия выборки списка сессий; пустые поля не ограничивают
+type SessionFilter struct {
+	UserID      string      // сессии пользователя в роли Role; пустой — все сессии
+	Role        SessionRole // учитывается только вместе с UserID
+	Statuses    []SessionStatus
+	Mode        SessionMode
+	CreatedFrom *time.Time // включительно
+	CreatedTo   *time.Time // не включая
+	HasChat     *bool      // есть ли чат в MAX
+	PublicOnly  bool       // только публичные сессии
+	Search      string     // подстрока названия или темы без учета регистра
+	Tags        []string   // сессия должна иметь все теги
+	Language    string
+	// JoinableAt — только ожидающие сессии со свободными местами (из Capacity),
+	// которые начнутся не позже JoinableAt + JoinableWindow
+	JoinableAt *time.Time
+	Capacity   int
+	Sort       SessionSort
+	After      *SessionCursor
+	Limit      int
+}
+
+// SessionCursor позиция в списке сессий: следующая страница начинается после
+// сессии с этим ключом сортировки, created_at и id
+type SessionCursor struct {
+	Sort      SessionSort
+	StartAt   time.Time // ключ SessionSortStart
+	Count     int       // ключ SessionSortSeats и SessionSortPopular — число участников
+	CreatedAt time.Time
+	ID        string
+}
+
+// ErrInvalidCursor курсор не удалось разобрать или он от другой сортировки
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// NewSessionCursor курсор, указывающий на сессию в списке с сортировкой sort
+func NewSessionCursor(summary *SessionSummary, sort SessionSort) *SessionCursor {
+	cursor := &SessionCursor{Sort: sort, CreatedAt: summary.CreatedAt, ID: summary.ID}
+	switch sort {
+	case SessionSortStart:
+		cursor.StartAt = summary.StartAt()
+	case SessionSortSeats, SessionSortPopular:
+		cursor.Count = summary.ParticipantsCount
+	}
+	return cursor
+}
+
+// Encode непрозрачная для клиента строка курсора
+func (c SessionCursor) Encode() string {
+	key := ""
+	switch c.Sort {
+	case SessionSortStart:
+		key = c.StartAt.Format(time.RFC3339Nano)
+	case SessionSortSeats, SessionSortPopular:
+		key = strconv.Itoa(c.Count)
+	}
+	raw := strings.Join([]string{string(c.Sort), key, c.CreatedAt.Format(time.RFC3339Nano), c.ID}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseSessionCursor разбирает строку из Encode; курсор годится только для той же сортировки
+func ParseSessionCursor(value string, sort SessionSort) (*SessionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 || SessionSort(parts[0]) != sort || parts[3] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	// Часовой пояс сохраняется: курсор сравнивается со значением из той же БД
+	cursor := &SessionCursor{Sort: sort, ID: parts[3]}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[2]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	switch sort {
+	case SessionSortStart:
+		if cursor.StartAt, err = time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	case SessionSortSeats, SessionSortPopular:
+		if cursor.Count, err = strconv.Atoi(parts[1]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// SessionSummary сессия для списков: без задач, с короткими данными участников
+// и счетчиками вместо полных связей
+type SessionSummary struct {
+	ID                string
+	Mode              SessionMode
+	Status            SessionStatus
+	FocusDuration     int
+	BreakDuration     int
+	GroupName         *string
+	Topic             *string
+	Language          *string
+	ScheduledAt       *time.Time
+	IsPrivate         bool
+	CreatorID         string
+	MaxChatID         *int64
+	MaxChatLink       *string
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	ParticipantsCount int
+	TasksTotal        int
+	TasksCompleted    int
+
+	Participants []ParticipantSummary `gorm:"-"`
+	Tags         []string             `gorm:"-"`
+	// Заполняет сервис по APP.MAX_SESSION_SIZE
+	OpenSeats int  `gorm:"-"`
+	Joinable  bool `gorm:"-"` // можно присоединиться сейчас, см. JoinableWindow
+}
+
+// StartAt — ключ сортировки SessionSortStart
+func (s *SessionSummary) StartAt() time.Time {
+	if s.ScheduledAt != nil {
+		return *s.ScheduledAt
+	}
+	return s.CreatedAt
+}
+
+// ParticipantSummary участник в списке сессий
+type ParticipantSummary struct {
+	SessionID string
+	UserID    string
+	UserName  string
+	AvatarURL *string
+}
+
+// SessionPage страница списка сессий; NextCursor nil на последней странице
+type SessionPage struct {
+	Sessions   []*SessionSummary
+	NextCursor *SessionCursor
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionCursor(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 123456000, time.UTC)
+	scheduledAt := createdAt.Add(2 * time.Hour)
+	summary := &SessionSummary{ID: "s1", CreatedAt: createdAt, ScheduledAt: &scheduledAt, ParticipantsCount: 4}
+
+	for _, sort := range []SessionSort{SessionSortStart, SessionSortSeats, SessionSortPopular} {
+		cursor := NewSessionCursor(summary, sort)
+		got, err := ParseSessionCursor(cursor.Encode(), sort)
+		if err != nil {
+			t.Fatalf("%s: ParseSessionCursor: %v", sort, err)
+		}
+		if got.ID != "s1" || !got.CreatedAt.Equal(createdAt) || !got.StartAt.Equal(cursor.StartAt) || got.Count != cursor.Count {
+			t.Fatalf("%s: got %+v, want %+v", sort, got, cursor)
+		}
+	}
+	if cursor := NewSessionCursor(summary, SessionSortStart); !cursor.StartAt.Equal(scheduledAt) {
+		t.Fatalf("start cursor: StartAt %v, want scheduledAt %v", cursor.StartAt, scheduledAt)
+	}
+
+	encoded := NewSessionCursor(summary, SessionSortStart).Encode()
+	for name, value := range map[string]string{
+		"other sort": encoded,
+		"not base64": "%%%",
+		"garbage":    "Z2FyYmFnZQ",
+	} {
+		if _, err := ParseSessionCursor(value, SessionSortSeats); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
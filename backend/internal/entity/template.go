@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+// MaxTemplateName предел длины названия шаблона
+const MaxTemplateName = 255
+
+// SessionTemplate сохраненные настройки сессии: из шаблона сессия создается одним
+// вызовом. Шаблоном можно поделиться по ShareLink, состав Roster видит только владелец
+type SessionTemplate struct {
+	ID            string           `gorm:"type:varchar(36);primaryKey" json:"id"`
+	OwnerID       string           `gorm:"type:varchar(36);not null;index:idx_session_templates_owner_id" json:"ownerId"`
+	Name          string           `gorm:"type:varchar(255);not null" json:"name"`
+	Mode          SessionMode      `gorm:"type:session_mode;not null" json:"mode"`
+	FocusDuration int              `gorm:"not null" json:"focusDuration"` // в минутах
+	BreakDuration int              `gorm:"not null" json:"breakDuration"` // в минутах
+	GroupName     *string          `gorm:"type:varchar(255)" json:"groupName"`
+	Topic         *string          `gorm:"type:varchar(255)" json:"topic"`
+	Language      *string          `gorm:"type:varchar(8)" json:"language"`
+	Tags          []string         `gorm:"serializer:json;type:text" json:"tags"`
+	IsPrivate     bool             `gorm:"not null;default:false" json:"isPrivate"`
+	Tasks         []string         `gorm:"serializer:json;type:text;not null" json:"tasks"` // задачи каждого участника
+	CyclePlan     *CyclePlan       `gorm:"serializer:json;type:text" json:"cyclePlan"`
+	Roster        []TemplateMember `gorm:"serializer:json;type:text;not null" json:"roster"` // кого пригласить в сессию, кроме владельца
+	ShareLink     string           `gorm:"type:varchar(50);uniqueIndex:idx_session_templates_share_link;not null" json:"shareLink"`
+	CreatedAt     time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (SessionTemplate) TableName() string {
+	return "session_templates"
+}
+
+// TemplateMember участник из состава шаблона; имя и аватар — на момент сохранения
+type TemplateMember struct {
+	UserID    string  `json:"userId"`
+	UserName  string  `json:"userName"`
+	AvatarURL *string `json:"avatarUrl"`
+}
+
+// NewTemplateParams параметры создания и изменения шаблона
+type NewTemplateParams struct {
+	Name      string
+	Session   NewSessionParams // ScheduledAt и Quorum не используются
+	RosterIDs []string         // кого приглашать в сессии, кроме владельца
+}
+
+// TemplateSessionParams создание сессии из шаблона: по TemplateID или ShareLink.
+// Состав шаблона добавляется, только если сессию создает владелец
+type TemplateSessionParams struct {
+	TemplateID  string
+	ShareLink   string
+	ScheduledAt *time.Time
+	Quorum      int
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// UpdateCursor позиция чтения обновлений Max API при long polling
+type UpdateCursor struct {
+	Source    string    `gorm:"type:varchar(50);primaryKey" json:"source"`
+	Marker    int64     `gorm:"not null" json:"marker"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (UpdateCursor) TableName() string {
+	return "update_cursors"
+}
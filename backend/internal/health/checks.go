@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Database пингует БД и проверяет пул: если все соединения заняты и с прошлой
+// проверки появились ожидающие запросы, пул считается насыщенным
+func Database(db *sql.DB) CheckFunc {
+	var mu sync.Mutex
+	var lastWaitCount int64
+
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		stats := db.Stats()
+		details := map[string]interface{}{
+			"openConnections":    stats.OpenConnections,
+			"inUse":              stats.InUse,
+			"idle":               stats.Idle,
+			"maxOpenConnections": stats.MaxOpenConnections,
+			"waitCount":          stats.WaitCount,
+			"waitDurationMs":     stats.WaitDuration.Milliseconds(),
+		}
+
+		mu.Lock()
+		waited := stats.WaitCount - lastWaitCount
+		lastWaitCount = stats.WaitCount
+		mu.Unlock()
+
+		if err := db.PingContext(ctx); err != nil {
+			return details, fmt.Errorf("ping: %w", err)
+		}
+		if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections && waited > 0 {
+			return details, fmt.Errorf("connection pool saturated: %d/%d in use, %d waits since last check",
+				stats.InUse, stats.MaxOpenConnections, waited)
+		}
+		return details, nil
+	}
+}
+
+// Cached запоминает результат fn на ttl, чтобы частые пробы не нагружали
+// внешний сервис. Одновременные пробы ждут один вызов
+func Cached(ttl time.Duration, fn CheckFunc) CheckFunc {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var details map[string]interface{}
+	var lastErr error
+
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if checkedAt.IsZero() || time.Since(checkedAt) >= ttl {
+			details, lastErr = fn(ctx)
+			checkedAt = time.Now()
+		}
+
+		result := make(map[string]interface{}, len(details)+1)
+		for key, value := range details {
+			result[key] = value
+		}
+		result["checkedAt"] = checkedAt
+		return result, lastErr
+	}
+}
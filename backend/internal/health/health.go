@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы проверки и отчета целиком
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // упала некритичная проверка: трафик принимаем
+	StatusDown     = "down"     // упала критичная проверка: трафик не принимаем
+)
+
+// checkTimeout ограничивает одну проверку, чтобы зависшая зависимость не держала пробу
+const checkTimeout = 3 * time.Second
+
+// CheckFunc проверяет зависимость; details попадают в ответ как есть
+type CheckFunc func(ctx context.Context) (details map[string]interface{}, err error)
+
+// Checker выполняет зарегистрированные проверки готовности
+type Checker struct {
+	checks []check
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Result — итог одной проверки
+type Result struct {
+	Status     string                 `json:"status"`
+	Critical   bool                   `json:"critical"`
+	DurationMs int64                  `json:"durationMs"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report — ответ /health/ready
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checkedAt"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// NewChecker создает пустой набор проверок
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add регистрирует проверку. Ошибка критичной проверки переводит отчет в down,
+// некритичной — в degraded
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Run выполняет все проверки параллельно
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]Result, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := ch.run(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[ch.name] = result
+			if result.Status == StatusOK {
+				return
+			}
+			if ch.critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c check) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := c.fn(ctx)
+		done <- outcome{details: details, err: err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = ctx.Err()
+	}
+
+	result := Result{
+		Status:     StatusOK,
+		Critical:   c.critical,
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    out.details,
+	}
+	if out.err != nil {
+		result.Status = StatusDown
+		result.Error = out.err.Error()
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func okCheck(context.Context) (map[string]interface{}, error) { return nil, nil }
+
+func failCheck(context.Context) (map[string]interface{}, error) { return nil, errors.New("boom") }
+
+func TestCheckerStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		critical CheckFunc
+		optional CheckFunc
+		want     string
+	}{
+		{"all ok", okCheck, okCheck, StatusOK},
+		{"optional failed", okCheck, failCheck, StatusDegraded},
+		{"critical failed", failCheck, okCheck, StatusDown},
+		{"both failed", failCheck, failCheck, StatusDown},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker()
+			checker.Add("critical", true, tc.critical)
+			checker.Add("optional", false, tc.optional)
+
+			report := checker.Run(context.Background())
+			if report.Status != tc.want {
+				t.Fatalf("status %s, want %s", report.Status, tc.want)
+			}
+			if len(report.Checks) != 2 {
+				t.Fatalf("checks %+v", report.Checks)
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := NewChecker()
+	checker.Add("slow", true, func(ctx context.Context) (map[string]interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report := checker.Run(ctx)
+	if report.Status != StatusDown || report.Checks["slow"].Error == "" {
+		t.Fatalf("report %+v", report)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	hb := NewHeartbeat(time.Minute)
+	if _, err := hb.Check(context.Background()); err == nil {
+		t.Fatal("expected error before first beat")
+	}
+
+	hb.Beat()
+	if _, err := hb.Check(context.Background()); err != nil {
+		t.Fatalf("fresh heartbeat: %v", err)
+	}
+
+	hb.last.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if _, err := hb.Check(context.Background()); err == nil {
+		t.Fatal("expected error for stale heartbeat")
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(time.Hour, func(context.Context) (map[string]interface{}, error) {
+		calls++
+		return map[string]interface{}{"calls": calls}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		details, err := check(context.Background())
+		if err != nil || details["calls"] != 1 || details["checkedAt"] == nil {
+			t.Fatalf("call %d: %v %v", i, details, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("underlying check called %d times", calls)
+	}
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat отмечает, что цикл фонового воркера жив. Воркер вызывает Beat на каждой
+// итерации; если отметки нет дольше maxAge, проверка считает воркер зависшим
+type Heartbeat struct {
+	maxAge atomic.Int64 // time.Duration
+	last   atomic.Int64 // UnixNano последней отметки, 0 — воркер не запускался
+}
+
+// NewHeartbeat создает отметку с допустимым интервалом между итерациями
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{}
+	h.SetMaxAge(maxAge)
+	return h
+}
+
+// SetMaxAge меняет допуск, когда воркер меняет интервал на лету
+func (h *Heartbeat) SetMaxAge(maxAge time.Duration) {
+	h.maxAge.Store(int64(maxAge))
+}
+
+// Beat отмечает итерацию цикла
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Check — CheckFunc для Checker
+func (h *Heartbeat) Check(context.Context) (map[string]interface{}, error) {
+	last := h.last.Load()
+	if last == 0 {
+		return nil, errors.New("worker is not running")
+	}
+
+	maxAge := time.Duration(h.maxAge.Load())
+	lastBeat := time.Unix(0, last)
+	age := time.Since(lastBeat)
+	details := map[string]interface{}{
+		"lastBeat":      lastBeat,
+		"ageSeconds":    int64(age.Seconds()),
+		"maxAgeSeconds": int64(maxAge.Seconds()),
+	}
+	if age > maxAge {
+		return details, fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+	return details, nil
+}
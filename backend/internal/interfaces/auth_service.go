@@ -1,11 +1,13 @@
 package interfaces
 
 import (
+	"context"
+
 	"github.com/rnegic/synchronous/internal/entity"
 )
 
 type AuthService interface {
-	Login(initData, deviceID string) (*entity.AuthTokens, *entity.User, error)
+	Login(ctx context.Context, initData, deviceID string) (*entity.AuthTokens, *entity.User, error)
 	RefreshToken(refreshToken string) (*entity.AuthTokens, error)
 	ValidateToken(token string) (string, error) // возвращает userID
 	Logout(userID string) error
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type IdempotencyRepository interface {
+	// Begin занимает ключ: сохраняет record и возвращает nil. Если по ключу уже есть
+	// неистекшая запись, возвращает ее и ничего не меняет; истекшая заменяется
+	Begin(record *entity.IdempotencyRecord, now time.Time) (*entity.IdempotencyRecord, error)
+	// Complete сохраняет ответ и переводит запись в completed
+	Complete(key string, responseStatus int, contentType, responseBody string) error
+	// Delete освобождает ключ, чтобы повтор выполнил запрос заново
+	Delete(key string) error
+}
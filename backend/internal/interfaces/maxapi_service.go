@@ -1,6 +1,10 @@
 package interfaces
 
-import "github.com/rnegic/synchronous/pkg/maxapi"
+import (
+	"context"
+
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
 
 type MaxAPIService interface {
 	GetBotInfo() (*maxapi.BotInfo, error)
@@ -16,4 +20,10 @@ type MaxAPIService interface {
 	EditChat(chatID int64, title *string, icon interface{}) (*maxapi.Chat, error)
 	DeleteChat(chatID int64) error
 	RemoveMember(chatID int64, userID int64) error
+	// GetUpdates принимает ctx, чтобы long poll можно было прервать при остановке
+	GetUpdates(ctx context.Context, marker *int64, limit, timeout int) (*maxapi.UpdatesResponse, error)
+	// SetRateLimit меняет клиентский лимит запросов в секунду (при перезагрузке конфигурации)
+	SetRateLimit(rps float64)
+	// Close прерывает текущие вызовы; вызывается при остановке приложения
+	Close()
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/pkg/maxapi"
 )
 
 type MessageService interface {
@@ -17,4 +18,9 @@ type MessageService interface {
 
 	// GetChatInfo возвращает информацию о чате Max для сессии
 	GetChatInfo(sessionID string, userID string) (*entity.MaxChatInfo, error)
+
+	// SaveIncomingMessage сохраняет сообщение из чата Max (пришедшее через webhook)
+	// в историю сессии, к которой привязан чат. Возвращает nil, если чат не привязан
+	// к сессии, отправитель неизвестен или сообщение уже было сохранено ранее
+	SaveIncomingMessage(message *maxapi.Message) (*entity.Message, error)
 }
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type OutboxRepository interface {
+	Create(message *entity.OutboxMessage) error
+	GetByID(id string) (*entity.OutboxMessage, error)
+	// ClaimDue выбирает готовые к отправке сообщения и откладывает их на lease,
+	// чтобы параллельные воркеры не взяли их повторно
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error)
+	Update(message *entity.OutboxMessage) error
+	List(status entity.OutboxStatus, page, limit int) ([]*entity.OutboxMessage, int, error)
+}
+
+// TxRepositories репозитории, привязанные к одной транзакции
+type TxRepositories struct {
+	Sessions SessionRepository
+	Tasks    TaskRepository
+	Outbox   OutboxRepository
+	Series   SessionSeriesRepository
+}
+
+// Transactor выполняет fn в транзакции; ошибка из fn откатывает все изменения
+type Transactor interface {
+	WithinTransaction(fn func(repos TxRepositories) error) error
+}
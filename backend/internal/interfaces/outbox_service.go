@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+type OutboxService interface {
+	// SendMessageToUser ставит в outbox сообщение бота пользователю Max
+	SendMessageToUser(maxUserID int64, message *maxapi.SendMessageRequest) error
+	List(status entity.OutboxStatus, page, limit int) ([]*entity.OutboxMessage, int, error)
+	Retry(id string) (*entity.OutboxMessage, error)
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+// RateLimitStore хранит token bucket'ы ограничителя запросов. Память процесса
+// подходит для одной реплики; общее хранилище (БД) — для нескольких
+type RateLimitStore interface {
+	// Take забирает токен из bucket'а key по правилу limit. Новый bucket полон
+	Take(key string, limit entity.RateLimit, now time.Time) (entity.RateLimitResult, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type SessionSeriesRepository interface {
+	Create(series *entity.SessionSeries) error // вместе с Members
+	GetByID(id string) (*entity.SessionSeries, error)
+	GetByInviteLink(inviteLink string) (*entity.SessionSeries, error)
+	// ListByUserID возвращает серии, где пользователь создатель или участник, новые первыми
+	ListByUserID(userID string) ([]*entity.SessionSeries, error)
+	ListActive() ([]*entity.SessionSeries, error) // без EndedAt
+	Update(series *entity.SessionSeries) error    // Members не меняются
+	AddMember(member *entity.SeriesMember) error  // повторное добавление ничего не меняет
+	// SaveException создает или заменяет изменение повторения
+	SaveException(exception *entity.SeriesException) error
+	GetExceptions(seriesID string) ([]*entity.SeriesException, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type SeriesService interface {
+	CreateSeries(userID string, params entity.NewSeriesParams) (*entity.SessionSeries, error)
+	// GetSeries возвращает серию и ближайшие повторения
+	GetSeries(seriesID string, userID string) (*entity.SessionSeries, []entity.SeriesOccurrence, error)
+	ListSeries(userID string) ([]*entity.SessionSeries, error)
+	JoinSeries(inviteLink string, userID string) (*entity.SessionSeries, error)
+	StopSeries(seriesID string, userID string) error
+	UpdateOccurrence(seriesID string, userID string, occurrenceAt time.Time, change entity.OccurrenceChange) (*entity.SeriesOccurrence, error)
+	GetSeriesHistory(seriesID string, userID string) (*entity.SeriesHistory, error)
+	// SpawnUpcoming создает экземпляры активных серий на горизонт вперед от now
+	SpawnUpcoming(now time.Time) error
+}
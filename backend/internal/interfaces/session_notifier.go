@@ -0,0 +1,12 @@
+package interfaces
+
+// SessionNotifier доставляет события сессий пользователям по WebSocket
+type SessionNotifier interface {
+	SendToUser(userID string, event string, data interface{})
+}
+
+// SessionPresence сообщает, открыт ли WebSocket у кого-то в сессии: подписка на ее
+// события или соединение любого из участников userIDs
+type SessionPresence interface {
+	HasConnections(sessionID string, userIDs []string) bool
+}
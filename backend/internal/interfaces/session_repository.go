@@ -10,14 +10,33 @@ type SessionRepository interface {
 	Create(session *entity.Session) error
 	GetByID(id string) (*entity.Session, error)
 	GetByInviteLink(inviteLink string) (*entity.Session, error)
+	GetByMaxChatID(chatID int64) (*entity.Session, error)
 	GetActiveByUserID(userID string) (*entity.Session, error)
-	GetHistory(userID string, page, limit int) ([]*entity.Session, int, error)
-	GetAll() ([]*entity.Session, error)
+	// List возвращает до filter.Limit сессий после filter.After, от новых к старым
+	List(filter entity.SessionFilter) ([]*entity.SessionSummary, error)
 	Update(session *entity.Session) error
+	// UpdateIfStatus сохраняет сессию, только если в хранилище у нее все еще статус status;
+	// false — статус уже сменили, ничего не записано
+	UpdateIfStatus(session *entity.Session, status entity.SessionStatus) (bool, error)
 	AddParticipant(sessionID string, participant *entity.Participant) error
 	RemoveParticipant(sessionID string, userID string) error
 	UpdateParticipantReady(sessionID string, userID string, isReady bool) error
 	GetSessionsByStatus(status entity.SessionStatus) ([]*entity.Session, error)
+	// GetScheduledBefore возвращает ожидающие сессии с ScheduledAt не позже until, ближайшие первыми
+	GetScheduledBefore(until time.Time) ([]*entity.Session, error)
+	// GetBySeriesID возвращает экземпляры серии по порядку повторений
+	GetBySeriesID(seriesID string) ([]*entity.Session, error)
+	CountByStatus() (map[entity.SessionStatus]int, error) // статусы без сессий в карту не попадают
+	// Delete мягко удаляет сессию вместе с ее задачами и сообщениями
+	Delete(id string) error
+	// GetDeletedByID возвращает мягко удаленную сессию с DeletedAt; nil — такой нет
+	GetDeletedByID(id string) (*entity.Session, error)
+	// Restore возвращает удаленную сессию и задачи с сообщениями, удаленные вместе с ней
+	Restore(id string) error
+	// GetDeletedBefore возвращает сессии, удаленные раньше before, давно удаленные первыми
+	GetDeletedBefore(before time.Time) ([]*entity.Session, error)
+	// Purge окончательно стирает удаленную сессию со всеми связанными данными
+	Purge(id string) error
 }
 
 type TaskRepository interface {
@@ -34,6 +53,7 @@ type MessageRepository interface {
 	Create(message *entity.Message) error
 	GetBySessionID(sessionID string, before *time.Time, limit int) ([]*entity.Message, error)
 	GetByID(id string) (*entity.Message, error)
+	GetByMaxMessageID(maxMessageID string) (*entity.Message, error)
 }
 
 type LeaderboardRepository interface {
@@ -1,15 +1,17 @@
 package interfaces
 
 import (
+	"time"
+
 	"github.com/rnegic/synchronous/internal/entity"
 )
 
 type SessionService interface {
-	CreateSession(userID string, mode entity.SessionMode, tasks []string, focusDuration, breakDuration int, groupName *string, isPrivate bool) (*entity.Session, error)
+	CreateSession(userID string, params entity.NewSessionParams) (*entity.Session, error)
 	GetSession(sessionID string, userID string) (*entity.Session, error)
 	GetActiveSession(userID string) (*entity.Session, error)
-	GetHistory(userID string, page, limit int) ([]*entity.Session, int, error)
-	GetPublicSessions(page, limit int) ([]*entity.Session, int, error)
+	GetHistory(userID string, filter entity.SessionFilter) (*entity.SessionPage, error)
+	GetPublicSessions(filter entity.SessionFilter) (*entity.SessionPage, error)
 	JoinSession(sessionID string, userID string) (*entity.Session, error)
 	JoinByInviteLink(inviteLink string, userID string) (*entity.Session, error)
 	SetReady(sessionID string, userID string, isReady bool) error
@@ -18,6 +20,16 @@ type SessionService interface {
 	ResumeSession(sessionID string, userID string) error
 	CompleteSession(sessionID string, userID string) (*entity.SessionReport, error)
 	GetSessionReport(sessionID string, userID string) (*entity.SessionReport, error)
+	// CancelSession отменяет сессию по решению создателя с причиной reason
+	CancelSession(sessionID string, userID string, reason string) (*entity.Session, error)
+	// CompleteFinishedPlans завершает сессии, план циклов которых с AutoComplete пройден к now
+	CompleteFinishedPlans(now time.Time) ([]*entity.Session, error)
+	// CompleteFinishedPlan завершает сессию, если ее план циклов пройден к now; false — не пройден
+	CompleteFinishedPlan(session *entity.Session, now time.Time) (bool, error)
+	// DeleteSession мягко удаляет сессию; возвращает ее и срок, до которого ее можно восстановить
+	DeleteSession(sessionID string, userID string) (*entity.Session, time.Time, error)
+	// RestoreSession восстанавливает удаленную сессию в пределах окна восстановления
+	RestoreSession(sessionID string, userID string) (*entity.Session, error)
 	DeleteChatAfterDiscussion(sessionID string, userID string) error
 	HandleChatCreated(update interface{}) error
 	UpdateTask(sessionID string, taskID string, userID string, completed bool) (*entity.Task, error)
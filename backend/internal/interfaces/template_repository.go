@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type SessionTemplateRepository interface {
+	Create(template *entity.SessionTemplate) error
+	GetByID(id string) (*entity.SessionTemplate, error)
+	GetByShareLink(shareLink string) (*entity.SessionTemplate, error)
+	ListByOwnerID(ownerID string) ([]*entity.SessionTemplate, error) // новые первыми
+	Update(template *entity.SessionTemplate) error
+	Delete(id string) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+type TemplateService interface {
+	CreateTemplate(userID string, params entity.NewTemplateParams) (*entity.SessionTemplate, error)
+	// CreateTemplateFromSession сохраняет настройки, задачи пользователя и состав завершенной сессии
+	CreateTemplateFromSession(userID string, sessionID string, name string) (*entity.SessionTemplate, error)
+	GetTemplate(templateID string, userID string) (*entity.SessionTemplate, error)
+	ListTemplates(userID string) ([]*entity.SessionTemplate, error)
+	UpdateTemplate(templateID string, userID string, params entity.NewTemplateParams) (*entity.SessionTemplate, error)
+	DeleteTemplate(templateID string, userID string) error
+	GetSharedTemplate(shareLink string) (*entity.SessionTemplate, error)
+	// CopySharedTemplate сохраняет чужой шаблон себе, без состава
+	CopySharedTemplate(shareLink string, userID string) (*entity.SessionTemplate, error)
+	CreateSessionFromTemplate(userID string, params entity.TemplateSessionParams) (*entity.Session, error)
+}
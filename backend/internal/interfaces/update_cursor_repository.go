@@ -0,0 +1,7 @@
+package interfaces
+
+type UpdateCursorRepository interface {
+	// GetMarker возвращает nil, если маркер для source еще не сохранялся
+	GetMarker(source string) (*int64, error)
+	SaveMarker(source string, marker int64) error
+}
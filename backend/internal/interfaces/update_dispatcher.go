@@ -0,0 +1,11 @@
+package interfaces
+
+import "context"
+
+// UpdateDispatcher обрабатывает обновления Max API независимо от источника:
+// webhook или long polling. update — результат maxapi.ParseUpdate
+type UpdateDispatcher interface {
+	// Dispatch возвращает handled=false для типов обновлений, которые не обрабатываются.
+	// ctx несет request_id webhook-запроса для логов
+	Dispatch(ctx context.Context, update interface{}) (handled bool, err error)
+}
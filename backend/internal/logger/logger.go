@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatJSON = "json" // для продакшена: одна запись — одна строка JSON
+	FormatText = "text" // для локальной разработки
+)
+
+// New создает логгер: level — debug, info, warn или error; format — json или text.
+// Все записи проходят через Redact, request_id берется из контекста записи
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(lvl)
+
+	options := &slog.HandlerOptions{Level: levelVar, ReplaceAttr: Redact}
+
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: want %q or %q", format, FormatJSON, FormatText)
+	}
+
+	return slog.New(contextHandler{handler, levelVar}), nil
+}
+
+// ParseLevel разбирает уровень: debug, info, warn или error (без учета регистра)
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	return lvl, nil
+}
+
+// SetLevel меняет уровень на лету — для логгера из New и всех производных от него
+func SetLevel(log *slog.Logger, level string) error {
+	handler, ok := log.Handler().(contextHandler)
+	if !ok {
+		return fmt.Errorf("logger was not created by logger.New")
+	}
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	handler.level.Set(lvl)
+	return nil
+}
+
+// Component возвращает логгер с полем component — аналог префиксов [Outbox], [WebSocket]
+func Component(log *slog.Logger, name string) *slog.Logger {
+	return log.With(slog.String("component", name))
+}
+
+// Err — атрибут с ошибкой под единым ключом
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Redacted подставляется вместо значений, которые нельзя писать в лог
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys — фрагменты имен атрибутов с секретами. Имя сравнивается
+// в нижнем регистре без "_" и "-", так что initData, init_data и X-Init-Data совпадут
+var sensitiveKeys = []string{
+	"token",
+	"secret",
+	"password",
+	"initdata",
+	"cookie",
+	"authorization",
+	"hash",
+	"dsn",
+}
+
+// Redact — ReplaceAttr для slog: скрывает значения секретных атрибутов
+// и строки, похожие на JWT или initData, под какими бы ключами они ни пришли
+func Redact(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 {
+		switch attr.Key {
+		case slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey:
+			return attr
+		}
+	}
+
+	if isSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	if attr.Value.Kind() == slog.KindString && isSensitiveValue(attr.Value.String()) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+func isSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(normalized, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveValue(value string) bool {
+	// JWT: header.payload.signature, header всегда начинается с {"
+	if strings.HasPrefix(value, "eyJ") && strings.Count(value, ".") == 2 {
+		return true
+	}
+	// initData MAX WebApp: подписанная query-строка
+	return strings.Contains(value, "hash=") && (strings.Contains(value, "auth_date=") || strings.Contains(value, "user="))
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+// SessionCounter — источник числа сессий по статусам (SessionRepository)
+type SessionCounter interface {
+	CountByStatus() (map[entity.SessionStatus]int, error)
+}
+
+// WebSocketStats — состояние WebSocket-хаба
+type WebSocketStats interface {
+	ClientCount() int
+	BroadcastQueueDepth() int
+	BroadcastQueueCapacity() int
+}
+
+// sessionStatuses — статусы, которые всегда присутствуют в метрике, даже с нулем
+var sessionStatuses = []entity.SessionStatus{
+	entity.SessionStatusPending,
+	entity.SessionStatusActive,
+	entity.SessionStatusPaused,
+	entity.SessionStatusCompleted,
+	entity.SessionStatusCancelled,
+}
+
+// RegisterSessions добавляет число сессий по статусам; считается при каждом сборе метрик
+func (m *Metrics) RegisterSessions(counter SessionCounter) {
+	m.registry.MustRegister(&sessionsCollector{
+		counter: counter,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "sessions"),
+			"Sessions by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+// RegisterWebSocket добавляет число подключенных клиентов и заполненность очереди рассылки
+func (m *Metrics) RegisterWebSocket(stats WebSocketStats) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      "clients",
+			Help:      "Connected WebSocket clients.",
+		}, func() float64 { return float64(stats.ClientCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      "broadcast_queue_depth",
+			Help:      "Messages waiting in the broadcast queue.",
+		}, func() float64 { return float64(stats.BroadcastQueueDepth()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      "broadcast_queue_capacity",
+			Help:      "Capacity of the broadcast queue; senders block when depth reaches it.",
+		}, func() float64 { return float64(stats.BroadcastQueueCapacity()) }),
+	)
+}
+
+type sessionsCollector struct {
+	counter SessionCounter
+	desc    *prometheus.Desc
+}
+
+func (c *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.counter.CountByStatus()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for _, status := range sessionStatuses {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+const namespace = "synchronous"
+
+// Metrics — метрики приложения. Регистрируются в собственном реестре, а не
+// в prometheus.DefaultRegisterer, чтобы несколько экземпляров (e2e тесты) не конфликтовали
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration *prometheus.HistogramVec
+
+	maxAPIDuration *prometheus.HistogramVec
+	maxAPIErrors   *prometheus.CounterVec
+
+	broadcastBlocked prometheus.Counter
+
+	rateLimited *prometheus.CounterVec
+
+	cleanupRuns     *prometheus.CounterVec
+	cleanupSessions prometheus.Counter
+	cleanupDuration prometheus.Histogram
+}
+
+// New создает метрики и регистрирует стандартные коллекторы Go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		maxAPIDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "maxapi",
+			Name:      "call_duration_seconds",
+			Help:      "MAX API call latency including retries, by client method and result (ok or error).",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"method", "result"}),
+		maxAPIErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "maxapi",
+			Name:      "errors_total",
+			Help:      "Failed MAX API calls by client method and error kind.",
+		}, []string{"method", "kind"}),
+
+		broadcastBlocked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      "broadcast_blocked_total",
+			Help:      "Broadcasts that found the queue full and had to wait.",
+		}),
+
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "Requests rejected with 429 by route group.",
+		}, []string{"group"}),
+
+		cleanupRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "session_cleanup",
+			Name:      "runs_total",
+			Help:      "Session cleanup passes by result (ok or error).",
+		}, []string{"result"}),
+		cleanupSessions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "session_cleanup",
+			Name:      "sessions_total",
+			Help:      "Stale sessions closed by the cleanup job.",
+		}),
+		cleanupDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "session_cleanup",
+			Name:      "duration_seconds",
+			Help:      "Duration of a session cleanup pass.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.maxAPIDuration,
+		m.maxAPIErrors,
+		m.broadcastBlocked,
+		m.rateLimited,
+		m.cleanupRuns,
+		m.cleanupSessions,
+		m.cleanupDuration,
+	)
+
+	return m
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP запрос. route — шаблон
+// маршрута gin (/sessions/:id), а не фактический путь, чтобы не плодить серии
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveMaxAPICall учитывает вызов MAX API; подходит как maxapi.Observer
+func (m *Metrics) ObserveMaxAPICall(op string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+		m.maxAPIErrors.WithLabelValues(op, maxapi.ErrorKind(err)).Inc()
+	}
+	m.maxAPIDuration.WithLabelValues(op, result).Observe(duration.Seconds())
+}
+
+// BroadcastBlocked учитывает рассылку, которой пришлось ждать места в очереди
+func (m *Metrics) BroadcastBlocked() {
+	m.broadcastBlocked.Inc()
+}
+
+// RateLimited учитывает запрос, отклоненный ограничителем группы маршрутов
+func (m *Metrics) RateLimited(group string) {
+	m.rateLimited.WithLabelValues(group).Inc()
+}
+
+// ObserveCleanup учитывает проход очистки сессий
+func (m *Metrics) ObserveCleanup(cleaned int, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.cleanupRuns.WithLabelValues(result).Inc()
+	m.cleanupSessions.Add(float64(cleaned))
+	m.cleanupDuration.Observe(duration.Seconds())
+}
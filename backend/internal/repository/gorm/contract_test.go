@@ -0,0 +1,58 @@
+package gorm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gormRepo "github.com/rnegic/synchronous/internal/repository/gorm"
+	"github.com/rnegic/synchronous/internal/repository/repotest"
+)
+
+// TEST_DB_DSN указывает на postgres с примененными миграциями goose
+// (например, DB_DSN из Makefile); без него контракт проверяется только на SQLite
+const postgresDSNEnv = "TEST_DB_DSN"
+
+func TestContractSQLite(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		return repositories(t, gormRepo.DriverSQLite, filepath.Join(t.TempDir(), "contract.db"))
+	})
+}
+
+func TestContractPostgres(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		return repositories(t, gormRepo.DriverPostgres, dsn)
+	})
+}
+
+func repositories(t *testing.T, driver, dsn string) repotest.Repositories {
+	t.Helper()
+
+	db, err := gormRepo.InitDB(driver, dsn)
+	if err != nil {
+		t.Fatalf("init %s: %v", driver, err)
+	}
+	t.Cleanup(func() {
+		if err := gormRepo.Close(db); err != nil {
+			t.Errorf("close %s: %v", driver, err)
+		}
+	})
+
+	return repotest.Repositories{
+		Users:       gormRepo.NewUserRepository(db),
+		Sessions:    gormRepo.NewSessionRepository(db),
+		Tasks:       gormRepo.NewTaskRepository(db),
+		Messages:    gormRepo.NewMessageRepository(db),
+		Leaderboard: gormRepo.NewLeaderboardRepository(db),
+		RateLimits:  gormRepo.NewRateLimitStore(db),
+		Idempotency: gormRepo.NewIdempotencyRepository(db),
+		Series:      gormRepo.NewSessionSeriesRepository(db),
+		Templates:   gormRepo.NewSessionTemplateRepository(db),
+		Outbox:      gormRepo.NewOutboxRepository(db),
+	}
+}
@@ -2,21 +2,35 @@ package gorm
 
 import (
 	"fmt"
-	"log"
+	"strings"
 
+	"github.com/glebarez/sqlite"
+	"github.com/rnegic/synchronous/internal/entity"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// DB глобальный экземпляр подключения к БД
-var DB *gorm.DB
+// Драйверы БД для InitDB
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
 
-// InitDB инициализирует подключение к базе данных
-func InitDB(dsn string) (*gorm.DB, error) {
-	var err error
+// InitDB инициализирует подключение к базе данных.
+// Для postgres схема создается миграциями goose, для sqlite — AutoMigrate по сущностям
+func InitDB(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(dsn))
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %q", driver)
+	}
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -24,7 +38,7 @@ func InitDB(dsn string) (*gorm.DB, error) {
 	}
 
 	// Проверка подключения
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
@@ -33,18 +47,62 @@ func InitDB(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("✅ Database connection established")
+	if driver == DriverSQLite {
+		if err := AutoMigrate(db); err != nil {
+			return nil, fmt.Errorf("failed to migrate sqlite schema: %w", err)
+		}
+	}
+
+	return db, nil
+}
 
-	return DB, nil
+// AutoMigrate создает таблицы по сущностям. Миграции goose написаны под postgres,
+// поэтому для sqlite схема строится из gorm-тегов
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&entity.User{},
+		&entity.UserStats{},
+		&entity.Session{},
+		&entity.Participant{},
+		&entity.SessionTag{},
+		&entity.SessionSeries{},
+		&entity.SeriesMember{},
+		&entity.SeriesException{},
+		&entity.SessionTemplate{},
+		&entity.Task{},
+		&entity.Message{},
+		&entity.OutboxMessage{},
+		&entity.UpdateCursor{},
+		&entity.RateLimitBucket{},
+		&entity.IdempotencyRecord{},
+	)
+}
+
+// sqliteDSN добавляет прагмы, без которых sqlite ведет себя иначе, чем postgres:
+// внешние ключи, WAL для параллельного чтения во время транзакции и ожидание блокировки.
+// _txlock=immediate берет блокировку записи в начале транзакции — аналог FOR UPDATE в ClaimDue
+func sqliteDSN(dsn string) string {
+	if dsn == "" {
+		dsn = "synchronous.db"
+	}
+	if strings.Contains(dsn, "_pragma=") {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
 }
 
-// Close закрывает подключение к базе данных
-func Close() error {
-	if DB == nil {
+// Close закрывает пул подключений, созданный InitDB
+func Close(db *gorm.DB) error {
+	if db == nil {
 		return nil
 	}
 
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,78 @@
+package gorm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type idempotencyRepository struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewIdempotencyRepository(db *gorm.DB) interfaces.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Begin полагается на первичный ключ: из двух одновременных запросов с одним
+// ключом запись создаст только один, второй получит ее в ответ
+func (r *idempotencyRepository) Begin(record *entity.IdempotencyRecord, now time.Time) (*entity.IdempotencyRecord, error) {
+	r.prune(now)
+
+	var existing *entity.IdempotencyRecord
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("idempotency_key = ? AND expires_at <= ?", record.Key, now).
+			Delete(&entity.IdempotencyRecord{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil || result.RowsAffected == 1 {
+			return result.Error
+		}
+
+		existing = &entity.IdempotencyRecord{}
+		return tx.Where("idempotency_key = ?", record.Key).First(existing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (r *idempotencyRepository) Complete(key string, responseStatus int, contentType, responseBody string) error {
+	return r.db.Model(&entity.IdempotencyRecord{}).
+		Where("idempotency_key = ?", key).
+		Updates(map[string]interface{}{
+			"status":          entity.IdempotencyStatusCompleted,
+			"response_status": responseStatus,
+			"content_type":    contentType,
+			"response_body":   responseBody,
+		}).Error
+}
+
+func (r *idempotencyRepository) Delete(key string) error {
+	return r.db.Where("idempotency_key = ?", key).Delete(&entity.IdempotencyRecord{}).Error
+}
+
+// prune раз в час удаляет истекшие записи. Ошибка не важна — их удалит следующая попытка
+func (r *idempotencyRepository) prune(now time.Time) {
+	r.mu.Lock()
+	if now.Sub(r.lastPrune) < time.Hour {
+		r.mu.Unlock()
+		return
+	}
+	r.lastPrune = now
+	r.mu.Unlock()
+
+	r.db.Where("expires_at <= ?", now).Delete(&entity.IdempotencyRecord{})
+}
@@ -31,7 +31,7 @@ func (r *leaderboardRepository) GetSessionLeaderboard(sessionID string) ([]*enti
 		Joins("LEFT JOIN tasks ON tasks.session_id = sessions.id").
 		Where("session_participants.session_id = ?", sessionID).
 		Group("session_participants.user_id, session_participants.user_name, session_participants.avatar_url").
-		Order("tasks_completed DESC, focus_time DESC").
+		Order("tasks_completed DESC, focus_time DESC, session_participants.user_id").
 		Scan(&entries).Error
 
 	if err != nil {
@@ -71,10 +71,12 @@ func (r *leaderboardRepository) GetGlobalLeaderboard(period entity.LeaderboardPe
 		// LeaderboardPeriodAll - без фильтра
 	}
 
-	err := query.
-		Order("tasks_completed DESC, focus_time DESC").
-		Limit(limit).
-		Scan(&entries).Error
+	query = query.Order("tasks_completed DESC, focus_time DESC, users.id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err := query.Scan(&entries).Error
 
 	if err != nil {
 		return nil, err
@@ -95,6 +97,10 @@ func (r *leaderboardRepository) UpdateUserScore(userID string, sessionID string,
 	if err := r.db.Where("id = ?", sessionID).First(&session).Error; err != nil {
 		return err
 	}
+	// Отмененные сессии в статистику не идут
+	if session.Status == entity.SessionStatusCancelled {
+		return nil
+	}
 
 	// Подсчитываем выполненные задачи
 	var tasksCompleted int64
@@ -28,7 +28,13 @@ func (r *messageRepository) GetBySessionID(sessionID string, before *time.Time,
 		query = query.Where("created_at < ?", *before)
 	}
 
-	err := query.Order("created_at DESC").Limit(limit).Find(&messages).Error
+	// limit <= 0 — без ограничения, как в memory-репозитории
+	query = query.Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err := query.Find(&messages).Error
 	if err != nil {
 		return nil, err
 	}
@@ -52,3 +58,15 @@ func (r *messageRepository) GetByID(id string) (*entity.Message, error) {
 	}
 	return &message, nil
 }
+
+func (r *messageRepository) GetByMaxMessageID(maxMessageID string) (*entity.Message, error) {
+	var message entity.Message
+	err := r.db.Where("max_message_id = ?", maxMessageID).First(&message).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, nil
+}
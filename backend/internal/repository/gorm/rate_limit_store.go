@@ -0,0 +1,67 @@
+package gorm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rateLimitStore — общий для реплик ограничитель: bucket читается под блокировкой
+// строки (FOR UPDATE в postgres, immediate-транзакция в sqlite)
+type rateLimitStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewRateLimitStore(db *gorm.DB) interfaces.RateLimitStore {
+	return &rateLimitStore{db: db}
+}
+
+func (r *rateLimitStore) Take(key string, limit entity.RateLimit, now time.Time) (entity.RateLimitResult, error) {
+	var result entity.RateLimitResult
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		bucket := entity.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key = ?", key).
+			First(&bucket).Error
+		if err != nil {
+			return err
+		}
+
+		result = bucket.Take(limit, now)
+		return tx.Model(&entity.RateLimitBucket{}).
+			Where("bucket_key = ?", key).
+			Updates(map[string]interface{}{"tokens": bucket.Tokens, "updated_at": bucket.UpdatedAt}).Error
+	})
+	if err != nil {
+		return entity.RateLimitResult{}, err
+	}
+
+	r.prune(now)
+	return result, nil
+}
+
+// prune раз в RateLimitMaxPeriod удаляет заполнившиеся bucket'ы. Ошибка не важна —
+// строки удалит следующая попытка
+func (r *rateLimitStore) prune(now time.Time) {
+	r.mu.Lock()
+	if now.Sub(r.lastPrune) < entity.RateLimitMaxPeriod {
+		r.mu.Unlock()
+		return
+	}
+	r.lastPrune = now
+	r.mu.Unlock()
+
+	r.db.Where("updated_at < ?", now.Add(-entity.RateLimitMaxPeriod)).Delete(&entity.RateLimitBucket{})
+}
@@ -0,0 +1,93 @@
+package gorm
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sessionSeriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionSeriesRepository(db *gorm.DB) interfaces.SessionSeriesRepository {
+	return &sessionSeriesRepository{db: db}
+}
+
+func (r *sessionSeriesRepository) Create(series *entity.SessionSeries) error {
+	return r.db.Create(series).Error
+}
+
+func (r *sessionSeriesRepository) GetByID(id string) (*entity.SessionSeries, error) {
+	return r.first("id = ?", id)
+}
+
+func (r *sessionSeriesRepository) GetByInviteLink(inviteLink string) (*entity.SessionSeries, error) {
+	return r.first("invite_link = ?", inviteLink)
+}
+
+func (r *sessionSeriesRepository) first(query string, args ...interface{}) (*entity.SessionSeries, error) {
+	var series entity.SessionSeries
+	err := r.preloadMembers().Where(query, args...).First(&series).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *sessionSeriesRepository) ListByUserID(userID string) ([]*entity.SessionSeries, error) {
+	var series []*entity.SessionSeries
+	err := r.preloadMembers().
+		Where("creator_id = ? OR EXISTS (SELECT 1 FROM session_series_members m WHERE m.series_id = session_series.id AND m.user_id = ?)",
+			userID, userID).
+		Order("created_at DESC, id DESC").
+		Find(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *sessionSeriesRepository) ListActive() ([]*entity.SessionSeries, error) {
+	var series []*entity.SessionSeries
+	err := r.preloadMembers().
+		Where("ended_at IS NULL").
+		Order("created_at, id").
+		Find(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *sessionSeriesRepository) Update(series *entity.SessionSeries) error {
+	return r.db.Omit("Members").Save(series).Error
+}
+
+func (r *sessionSeriesRepository) AddMember(member *entity.SeriesMember) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error
+}
+
+func (r *sessionSeriesRepository) SaveException(exception *entity.SeriesException) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(exception).Error
+}
+
+func (r *sessionSeriesRepository) GetExceptions(seriesID string) ([]*entity.SeriesException, error) {
+	var exceptions []*entity.SeriesException
+	err := r.db.Where("series_id = ?", seriesID).Order("occurrence_at").Find(&exceptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// preloadMembers загружает участников в порядке вступления
+func (r *sessionSeriesRepository) preloadMembers() *gorm.DB {
+	return r.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("joined_at, user_id")
+	})
+}
@@ -1,9 +1,13 @@
 package gorm
 
 import (
+	"strings"
+	"time"
+
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type sessionRepository struct {
@@ -20,7 +24,7 @@ func (r *sessionRepository) Create(session *entity.Session) error {
 
 func (r *sessionRepository) GetByID(id string) (*entity.Session, error) {
 	var session entity.Session
-	err := r.db.Preload("Tasks").Preload("Participants").Where("id = ?", id).First(&session).Error
+	err := r.db.Preload("Tasks").Preload("Participants").Preload("Tags").Where("id = ?", id).First(&session).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -32,7 +36,19 @@ func (r *sessionRepository) GetByID(id string) (*entity.Session, error) {
 
 func (r *sessionRepository) GetByInviteLink(inviteLink string) (*entity.Session, error) {
 	var session entity.Session
-	err := r.db.Preload("Tasks").Preload("Participants").Where("invite_link = ?", inviteLink).First(&session).Error
+	err := r.db.Preload("Tasks").Preload("Participants").Preload("Tags").Where("invite_link = ?", inviteLink).First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByMaxChatID(chatID int64) (*entity.Session, error) {
+	var session entity.Session
+	err := r.db.Preload("Participants").Where("max_chat_id = ?", chatID).First(&session).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -44,7 +60,7 @@ func (r *sessionRepository) GetByInviteLink(inviteLink string) (*entity.Session,
 
 func (r *sessionRepository) GetActiveByUserID(userID string) (*entity.Session, error) {
 	var session entity.Session
-	err := r.db.Preload("Tasks").Preload("Participants").
+	err := r.db.Preload("Tasks").Preload("Participants").Preload("Tags").
 		Joins("JOIN session_participants ON sessions.id = session_participants.session_id").
 		Where("session_participants.user_id = ? AND sessions.status IN ?",
 			userID,
@@ -60,40 +76,176 @@ func (r *sessionRepository) GetActiveByUserID(userID string) (*entity.Session, e
 	return &session, nil
 }
 
-func (r *sessionRepository) GetHistory(userID string, page, limit int) ([]*entity.Session, int, error) {
-	var sessions []*entity.Session
-	var total int64
+// participantsCount — число участников сессии; используется и в выборке, и как ключ сортировки
+const participantsCount = "(SELECT COUNT(*) FROM session_participants sp WHERE sp.session_id = sessions.id)"
 
-	offset := (page - 1) * limit
+// sessionSummaryColumns — поля сессии для списков и счетчики связей подзапросами
+const sessionSummaryColumns = `sessions.id, sessions.mode, sessions.status, sessions.focus_duration,
+	sessions.break_duration, sessions.group_name, sessions.topic, sessions.language, sessions.scheduled_at,
+	sessions.is_private, sessions.creator_id, sessions.max_chat_id, sessions.max_chat_link,
+	sessions.started_at, sessions.completed_at, sessions.created_at,
+	` + participantsCount + ` AS participants_count,
+	(SELECT COUNT(*) FROM tasks t WHERE t.session_id = sessions.id AND t.deleted_at IS NULL) AS tasks_total,
+	(SELECT COUNT(*) FROM tasks t WHERE t.session_id = sessions.id AND t.deleted_at IS NULL AND t.completed = ?) AS tasks_completed`
 
-	// Подсчет общего количества
-	err := r.db.Model(&entity.Session{}).
-		Joins("JOIN session_participants ON sessions.id = session_participants.session_id").
-		Where("session_participants.user_id = ?", userID).
-		Count(&total).Error
+const participantExists = "EXISTS (SELECT 1 FROM session_participants sp WHERE sp.session_id = sessions.id AND sp.user_id = ?)"
+
+// likeEscaper экранирует спецсимволы LIKE в строке поиска
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List выбирает страницу по ключу сортировки, created_at и id без OFFSET; задачи
+// не загружаются, участники и теги — одним запросом на всю страницу
+func (r *sessionRepository) List(filter entity.SessionFilter) ([]*entity.SessionSummary, error) {
+	query := r.db.Model(&entity.Session{}).Select(sessionSummaryColumns, true)
+
+	if filter.UserID != "" {
+		switch filter.Role {
+		case entity.SessionRoleCreator:
+			query = query.Where("sessions.creator_id = ?", filter.UserID)
+		case entity.SessionRoleParticipant:
+			query = query.Where("sessions.creator_id <> ? AND "+participantExists, filter.UserID, filter.UserID)
+		default:
+			query = query.Where("(sessions.creator_id = ? OR "+participantExists+")", filter.UserID, filter.UserID)
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("sessions.status IN ?", filter.Statuses)
+	}
+	if filter.Mode != "" {
+		query = query.Where("sessions.mode = ?", filter.Mode)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("sessions.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("sessions.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.HasChat != nil {
+		if *filter.HasChat {
+			query = query.Where("sessions.max_chat_id IS NOT NULL")
+		} else {
+			query = query.Where("sessions.max_chat_id IS NULL")
+		}
+	}
+	if filter.PublicOnly {
+		query = query.Where("sessions.is_private = ?", false)
+	}
+	if filter.Search != "" {
+		// LOWER в sqlite понимает только ASCII; в postgres — любой регистр
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Search)) + "%"
+		query = query.Where(`(LOWER(sessions.group_name) LIKE ? ESCAPE '\' OR LOWER(sessions.topic) LIKE ? ESCAPE '\')`,
+			pattern, pattern)
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where("EXISTS (SELECT 1 FROM session_tags st WHERE st.session_id = sessions.id AND st.tag = ?)", tag)
+	}
+	if filter.Language != "" {
+		query = query.Where("sessions.language = ?", filter.Language)
+	}
+	if filter.JoinableAt != nil {
+		query = query.Where("sessions.status = ? AND (sessions.scheduled_at IS NULL OR sessions.scheduled_at <= ?)",
+			entity.SessionStatusPending, filter.JoinableAt.Add(entity.JoinableWindow))
+		if filter.Capacity > 0 {
+			query = query.Where(participantsCount+" < ?", filter.Capacity)
+		}
+	}
+
+	// Ключ сортировки и направление; при равенстве — created_at DESC, id DESC
+	key, direction, op := "", "", ""
+	var keyValue interface{}
+	switch filter.Sort {
+	case entity.SessionSortStart:
+		key, direction, op = "COALESCE(sessions.scheduled_at, sessions.created_at)", "ASC", ">"
+		if filter.After != nil {
+			keyValue = filter.After.StartAt
+		}
+	case entity.SessionSortSeats:
+		key, direction, op = participantsCount, "ASC", ">"
+		if filter.After != nil {
+			keyValue = filter.After.Count
+		}
+	case entity.SessionSortPopular:
+		key, direction, op = participantsCount, "DESC", "<"
+		if filter.After != nil {
+			keyValue = filter.After.Count
+		}
+	}
+
+	if after := filter.After; after != nil {
+		tail := "(sessions.created_at < ? OR (sessions.created_at = ? AND sessions.id < ?))"
+		if key == "" {
+			query = query.Where(tail, after.CreatedAt, after.CreatedAt, after.ID)
+		} else {
+			query = query.Where("("+key+" "+op+" ? OR ("+key+" = ? AND "+tail+"))",
+				keyValue, keyValue, after.CreatedAt, after.CreatedAt, after.ID)
+		}
+	}
+	if key != "" {
+		query = query.Order(key + " " + direction)
+	}
+
+	summaries := make([]*entity.SessionSummary, 0)
+	err := query.Order("sessions.created_at DESC, sessions.id DESC").
+		Limit(filter.Limit).
+		Scan(&summaries).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return summaries, nil
 	}
 
-	// Получение сессий
-	err = r.db.Preload("Tasks").Preload("Participants").
-		Joins("JOIN session_participants ON sessions.id = session_participants.session_id").
-		Where("session_participants.user_id = ?", userID).
-		Order("sessions.created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&sessions).Error
+	ids := make([]string, len(summaries))
+	byID := make(map[string]*entity.SessionSummary, len(summaries))
+	for i, summary := range summaries {
+		ids[i] = summary.ID
+		summary.Participants = []entity.ParticipantSummary{}
+		summary.Tags = []string{}
+		byID[summary.ID] = summary
+	}
+
+	var participants []entity.ParticipantSummary
+	err = r.db.Model(&entity.Participant{}).
+		Select("session_id, user_id, user_name, avatar_url").
+		Where("session_id IN ?", ids).
+		Order("joined_at, user_id").
+		Scan(&participants).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	for _, participant := range participants {
+		summary := byID[participant.SessionID]
+		summary.Participants = append(summary.Participants, participant)
+	}
+
+	var tags []entity.SessionTag
+	if err := r.db.Where("session_id IN ?", ids).Order("tag").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		summary := byID[tag.SessionID]
+		summary.Tags = append(summary.Tags, tag.Tag)
 	}
 
-	return sessions, int(total), nil
+	return summaries, nil
 }
 
 func (r *sessionRepository) Update(session *entity.Session) error {
 	return r.db.Save(session).Error
 }
 
+func (r *sessionRepository) UpdateIfStatus(session *entity.Session, status entity.SessionStatus) (bool, error) {
+	// Одним UPDATE ... WHERE status = ?, чтобы не перезаписать сессию, которую успели изменить
+	result := r.db.Model(session).
+		Where("status = ?", status).
+		Select("*").Omit(clause.Associations).
+		Updates(session)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (r *sessionRepository) AddParticipant(sessionID string, participant *entity.Participant) error {
 	participant.SessionID = sessionID
 	return r.db.Create(participant).Error
@@ -114,6 +266,7 @@ func (r *sessionRepository) GetSessionsByStatus(status entity.SessionStatus) ([]
 	var sessions []*entity.Session
 	err := r.db.Preload("Tasks").Preload("Participants").
 		Where("status = ?", status).
+		Order("created_at DESC").
 		Find(&sessions).Error
 	if err != nil {
 		return nil, err
@@ -121,13 +274,124 @@ func (r *sessionRepository) GetSessionsByStatus(status entity.SessionStatus) ([]
 	return sessions, nil
 }
 
-func (r *sessionRepository) GetAll() ([]*entity.Session, error) {
+func (r *sessionRepository) GetScheduledBefore(until time.Time) ([]*entity.Session, error) {
 	var sessions []*entity.Session
-	err := r.db.Preload("Tasks").Preload("Participants").
-		Order("created_at DESC").
+	err := r.db.Preload("Participants").
+		Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", entity.SessionStatusPending, until).
+		Order("scheduled_at, id").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) GetBySeriesID(seriesID string) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	err := r.db.Preload("Participants").
+		Where("series_id = ?", seriesID).
+		Order("occurrence_at, id").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) CountByStatus() (map[entity.SessionStatus]int, error) {
+	var rows []struct {
+		Status entity.SessionStatus
+		Count  int
+	}
+	err := r.db.Model(&entity.Session{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[entity.SessionStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// Delete ставит сессии, ее задачам и сообщениям одну отметку deleted_at. Удаленные раньше
+// задачи и сообщения сохраняют свою отметку, поэтому Restore их не вернет
+func (r *sessionRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, model := range []interface{}{&entity.Task{}, &entity.Message{}} {
+			if err := tx.Model(model).Where("session_id = ?", id).UpdateColumn("deleted_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entity.Session{}).Where("id = ?", id).UpdateColumn("deleted_at", now).Error
+	})
+}
+
+func (r *sessionRepository) GetDeletedByID(id string) (*entity.Session, error) {
+	var session entity.Session
+	err := r.db.Unscoped().Preload("Participants").Preload("Tags").
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Restore(id string) error {
+	session, err := r.GetDeletedByID(id)
+	if err != nil || session == nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&entity.Task{}, &entity.Message{}} {
+			err := tx.Unscoped().Model(model).
+				Where("session_id = ? AND deleted_at >= ?", id, session.DeletedAt.Time).
+				UpdateColumn("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Model(&entity.Session{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+	})
+}
+
+func (r *sessionRepository) GetDeletedBefore(before time.Time) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at, id").
 		Find(&sessions).Error
 	if err != nil {
 		return nil, err
 	}
 	return sessions, nil
 }
+
+// Purge стирает связанные записи явно: в SQLite внешние ключи могут быть выключены.
+// Отчеты и лидерборд сессии в PostgreSQL удаляются каскадом
+func (r *sessionRepository) Purge(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var deleted int64
+		err := tx.Unscoped().Model(&entity.Session{}).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&deleted).Error
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		for _, model := range []interface{}{&entity.Task{}, &entity.Message{}, &entity.Participant{}, &entity.SessionTag{}} {
+			if err := tx.Unscoped().Where("session_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id = ?", id).Delete(&entity.Session{}).Error
+	})
+}
@@ -0,0 +1,56 @@
+package gorm
+
+import (
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+)
+
+type sessionTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionTemplateRepository(db *gorm.DB) interfaces.SessionTemplateRepository {
+	return &sessionTemplateRepository{db: db}
+}
+
+func (r *sessionTemplateRepository) Create(template *entity.SessionTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *sessionTemplateRepository) GetByID(id string) (*entity.SessionTemplate, error) {
+	return r.first("id = ?", id)
+}
+
+func (r *sessionTemplateRepository) GetByShareLink(shareLink string) (*entity.SessionTemplate, error) {
+	return r.first("share_link = ?", shareLink)
+}
+
+func (r *sessionTemplateRepository) first(query string, args ...interface{}) (*entity.SessionTemplate, error) {
+	var template entity.SessionTemplate
+	err := r.db.Where(query, args...).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *sessionTemplateRepository) ListByOwnerID(ownerID string) ([]*entity.SessionTemplate, error) {
+	var templates []*entity.SessionTemplate
+	err := r.db.Where("owner_id = ?", ownerID).Order("created_at DESC, id DESC").Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *sessionTemplateRepository) Update(template *entity.SessionTemplate) error {
+	return r.db.Save(template).Error
+}
+
+func (r *sessionTemplateRepository) Delete(id string) error {
+	return r.db.Delete(&entity.SessionTemplate{}, "id = ?", id).Error
+}
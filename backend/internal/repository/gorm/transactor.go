@@ -0,0 +1,25 @@
+package gorm
+
+import (
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+)
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) interfaces.Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithinTransaction(fn func(repos interfaces.TxRepositories) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(interfaces.TxRepositories{
+			Sessions: NewSessionRepository(tx),
+			Tasks:    NewTaskRepository(tx),
+			Outbox:   NewOutboxRepository(tx),
+			Series:   NewSessionSeriesRepository(tx),
+		})
+	})
+}
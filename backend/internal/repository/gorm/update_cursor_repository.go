@@ -0,0 +1,42 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type updateCursorRepository struct {
+	db *gorm.DB
+}
+
+func NewUpdateCursorRepository(db *gorm.DB) interfaces.UpdateCursorRepository {
+	return &updateCursorRepository{db: db}
+}
+
+func (r *updateCursorRepository) GetMarker(source string) (*int64, error) {
+	var cursor entity.UpdateCursor
+	err := r.db.Where("source = ?", source).First(&cursor).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cursor.Marker, nil
+}
+
+func (r *updateCursorRepository) SaveMarker(source string, marker int64) error {
+	cursor := entity.UpdateCursor{
+		Source:    source,
+		Marker:    marker,
+		UpdatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}},
+		DoUpdates: clause.AssignmentColumns([]string{"marker", "updated_at"}),
+	}).Create(&cursor).Error
+}
@@ -0,0 +1,66 @@
+package memory
+
+import "github.com/rnegic/synchronous/internal/entity"
+
+// Репозитории хранят и отдают копии: как и при работе с БД, изменения
+// возвращенной сущности не видны остальным, пока не вызван Update
+
+func cloneSession(session *entity.Session) *entity.Session {
+	clone := *session
+	clone.Participants = append([]entity.Participant(nil), session.Participants...)
+	clone.Tasks = append([]entity.Task(nil), session.Tasks...)
+	clone.Tags = append([]entity.SessionTag(nil), session.Tags...)
+	clone.CyclePlan = cloneCyclePlan(session.CyclePlan)
+	return &clone
+}
+
+func cloneSeries(series *entity.SessionSeries) *entity.SessionSeries {
+	clone := *series
+	clone.Tasks = append([]string(nil), series.Tasks...)
+	clone.Members = append([]entity.SeriesMember(nil), series.Members...)
+	return &clone
+}
+
+func cloneTemplate(template *entity.SessionTemplate) *entity.SessionTemplate {
+	clone := *template
+	clone.Tags = append([]string(nil), template.Tags...)
+	clone.Tasks = append([]string(nil), template.Tasks...)
+	clone.Roster = append([]entity.TemplateMember(nil), template.Roster...)
+	clone.CyclePlan = cloneCyclePlan(template.CyclePlan)
+	return &clone
+}
+
+func cloneCyclePlan(plan *entity.CyclePlan) *entity.CyclePlan {
+	if plan == nil {
+		return nil
+	}
+	clone := *plan
+	clone.Sequence = append([]entity.CycleStep(nil), plan.Sequence...)
+	return &clone
+}
+
+func cloneTask(task *entity.Task) *entity.Task {
+	clone := *task
+	return &clone
+}
+
+func cloneUser(user *entity.User) *entity.User {
+	clone := *user
+	clone.Stats = nil
+	return &clone
+}
+
+func cloneStats(stats *entity.UserStats) *entity.UserStats {
+	clone := *stats
+	return &clone
+}
+
+func cloneMessage(message *entity.Message) *entity.Message {
+	clone := *message
+	return &clone
+}
+
+func cloneOutboxMessage(message *entity.OutboxMessage) *entity.OutboxMessage {
+	clone := *message
+	return &clone
+}
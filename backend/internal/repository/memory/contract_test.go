@@ -0,0 +1,30 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/rnegic/synchronous/internal/repository/memory"
+	"github.com/rnegic/synchronous/internal/repository/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		users := memory.NewUserRepository()
+		tasks := memory.NewTaskRepository()
+		messages := memory.NewMessageRepository()
+		sessions := memory.NewSessionRepository(tasks, messages)
+
+		return repotest.Repositories{
+			Users:       users,
+			Sessions:    sessions,
+			Tasks:       tasks,
+			Messages:    messages,
+			Leaderboard: memory.NewLeaderboardRepository(sessions, tasks, users),
+			RateLimits:  memory.NewRateLimitStore(),
+			Idempotency: memory.NewIdempotencyRepository(),
+			Series:      memory.NewSessionSeriesRepository(),
+			Templates:   memory.NewSessionTemplateRepository(),
+			Outbox:      memory.NewOutboxRepository(),
+		}
+	})
+}
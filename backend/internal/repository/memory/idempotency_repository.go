@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type IdempotencyRepository struct {
+	records   map[string]*entity.IdempotencyRecord
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+func NewIdempotencyRepository() interfaces.IdempotencyRepository {
+	return &IdempotencyRepository{
+		records: make(map[string]*entity.IdempotencyRecord),
+	}
+}
+
+func (r *IdempotencyRepository) Begin(record *entity.IdempotencyRecord, now time.Time) (*entity.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(now)
+
+	if existing, exists := r.records[record.Key]; exists && existing.ExpiresAt.After(now) {
+		clone := *existing
+		return &clone, nil
+	}
+
+	clone := *record
+	r.records[record.Key] = &clone
+	return nil, nil
+}
+
+func (r *IdempotencyRepository) Complete(key string, responseStatus int, contentType, responseBody string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, exists := r.records[key]
+	if !exists {
+		return nil
+	}
+	record.Status = entity.IdempotencyStatusCompleted
+	record.ResponseStatus = responseStatus
+	record.ContentType = contentType
+	record.ResponseBody = responseBody
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
+
+// prune раз в час удаляет истекшие записи
+func (r *IdempotencyRepository) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < time.Hour {
+		return
+	}
+	r.lastPrune = now
+
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, key)
+		}
+	}
+}
@@ -1,112 +1,175 @@
 package memory
 
 import (
+	"fmt"
 	"sort"
-	"sync"
+	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
 )
 
+// LeaderboardRepository считает лидерборды по данным сессий, задач и статистики
+// пользователей — так же, как запросы gorm-репозитория
 type LeaderboardRepository struct {
-	sessionScores map[string]map[string]int // sessionID -> userID -> score
-	globalScores  map[string]int            // userID -> totalScore
-	mu            sync.RWMutex
+	sessionRepo interfaces.SessionRepository
+	taskRepo    interfaces.TaskRepository
+	userRepo    interfaces.UserRepository
 }
 
-func NewLeaderboardRepository() interfaces.LeaderboardRepository {
+// statsSource заменяет users LEFT JOIN user_stats и FirstOrCreate; реализован UserRepository этого пакета
+type statsSource interface {
+	statsSnapshot() ([]*entity.User, map[string]*entity.UserStats)
+	firstOrCreateStats(initial *entity.UserStats) (*entity.UserStats, error)
+}
+
+func NewLeaderboardRepository(
+	sessionRepo interfaces.SessionRepository,
+	taskRepo interfaces.TaskRepository,
+	userRepo interfaces.UserRepository,
+) interfaces.LeaderboardRepository {
 	return &LeaderboardRepository{
-		sessionScores: make(map[string]map[string]int),
-		globalScores:  make(map[string]int),
+		sessionRepo: sessionRepo,
+		taskRepo:    taskRepo,
+		userRepo:    userRepo,
 	}
 }
 
 func (r *LeaderboardRepository) GetSessionLeaderboard(sessionID string) ([]*entity.LeaderboardEntry, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	scores, exists := r.sessionScores[sessionID]
-	if !exists {
+	session, err := r.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
 		return []*entity.LeaderboardEntry{}, nil
 	}
 
-	type userScore struct {
-		userID string
-		score  int
+	tasks, err := r.taskRepo.GetBySessionID(sessionID)
+	if err != nil {
+		return nil, err
 	}
 
-	var userScores []userScore
-	for userID, score := range scores {
-		userScores = append(userScores, userScore{userID: userID, score: score})
+	// Как LEFT JOIN tasks ON tasks.session_id = sessions.id: каждому участнику
+	// достаются все выполненные задачи сессии, а focus_duration суммируется по строкам join
+	completed := 0
+	for _, task := range tasks {
+		if task.Completed {
+			completed++
+		}
 	}
+	rows := max(len(tasks), 1)
 
-	// Сортируем по убыванию score
-	sort.Slice(userScores, func(i, j int) bool {
-		return userScores[i].score > userScores[j].score
-	})
-
-	// Формируем результат
-	var entries []*entity.LeaderboardEntry
-	for rank, us := range userScores {
+	entries := make([]*entity.LeaderboardEntry, 0, len(session.Participants))
+	for _, participant := range session.Participants {
 		entries = append(entries, &entity.LeaderboardEntry{
-			Rank:   rank + 1,
-			UserID: us.userID,
-			Score:  us.score,
+			UserID:         participant.UserID,
+			UserName:       participant.UserName,
+			AvatarURL:      participant.AvatarURL,
+			TasksCompleted: completed,
+			FocusTime:      session.FocusDuration * rows,
 		})
 	}
 
-	return entries, nil
+	return rankEntries(entries, 0), nil
 }
 
 func (r *LeaderboardRepository) GetGlobalLeaderboard(period entity.LeaderboardPeriod, limit int) ([]*entity.LeaderboardEntry, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	type userScore struct {
-		userID string
-		score  int
+	source, ok := r.userRepo.(statsSource)
+	if !ok {
+		return nil, fmt.Errorf("global leaderboard requires memory user repository")
+	}
+	users, stats := source.statsSnapshot()
+
+	// Фильтр по периоду
+	var since time.Time
+	now := time.Now()
+	switch period {
+	case entity.LeaderboardPeriodDay:
+		since = now.AddDate(0, 0, -1)
+	case entity.LeaderboardPeriodWeek:
+		since = now.AddDate(0, 0, -7)
+	case entity.LeaderboardPeriodMonth:
+		since = now.AddDate(0, -1, 0)
+		// LeaderboardPeriodAll - без фильтра
 	}
 
-	var userScores []userScore
-	for userID, score := range r.globalScores {
-		userScores = append(userScores, userScore{userID: userID, score: score})
+	entries := make([]*entity.LeaderboardEntry, 0, len(users))
+	for _, user := range users {
+		userStats := stats[user.ID]
+		if !since.IsZero() && (userStats == nil || userStats.UpdatedAt.Before(since)) {
+			continue
+		}
+
+		entry := &entity.LeaderboardEntry{
+			UserID:    user.ID,
+			UserName:  user.Name,
+			AvatarURL: user.AvatarURL,
+		}
+		if userStats != nil {
+			entry.TasksCompleted = userStats.TotalSessions
+			entry.FocusTime = userStats.TotalFocusTime
+		}
+		entries = append(entries, entry)
 	}
 
-	// Сортируем по убыванию score
-	sort.Slice(userScores, func(i, j int) bool {
-		return userScores[i].score > userScores[j].score
-	})
+	return rankEntries(entries, limit), nil
+}
 
-	// Ограничиваем количество
-	if limit > 0 && len(userScores) > limit {
-		userScores = userScores[:limit]
+func (r *LeaderboardRepository) UpdateUserScore(userID string, sessionID string, score int) error {
+	// Обновляем статистику пользователя на основе завершенной сессии
+	session, err := r.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return fmt.Errorf("session with ID %s not found", sessionID)
+	}
+	// Отмененные сессии в статистику не идут
+	if session.Status == entity.SessionStatusCancelled {
+		return nil
 	}
 
-	// Формируем результат
-	var entries []*entity.LeaderboardEntry
-	for rank, us := range userScores {
-		entries = append(entries, &entity.LeaderboardEntry{
-			Rank:   rank + 1,
-			UserID: us.userID,
-			Score:  us.score,
-		})
+	source, ok := r.userRepo.(statsSource)
+	if !ok {
+		return fmt.Errorf("user score requires memory user repository")
+	}
+
+	// Обновляем или создаем статистику
+	stats, err := source.firstOrCreateStats(&entity.UserStats{
+		UserID:         userID,
+		TotalSessions:  1,
+		TotalFocusTime: session.FocusDuration,
+	})
+	if err != nil {
+		return err
 	}
 
-	return entries, nil
+	stats.TotalSessions++
+	stats.TotalFocusTime += session.FocusDuration
+	return r.userRepo.UpdateStats(userID, stats)
 }
 
-func (r *LeaderboardRepository) UpdateUserScore(userID string, sessionID string, score int) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// rankEntries сортирует записи как ORDER BY tasks_completed DESC, focus_time DESC, user_id,
+// ограничивает количество и проставляет rank и score
+func rankEntries(entries []*entity.LeaderboardEntry, limit int) []*entity.LeaderboardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TasksCompleted != entries[j].TasksCompleted {
+			return entries[i].TasksCompleted > entries[j].TasksCompleted
+		}
+		if entries[i].FocusTime != entries[j].FocusTime {
+			return entries[i].FocusTime > entries[j].FocusTime
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
 
-	// Обновляем счет для сессии
-	if r.sessionScores[sessionID] == nil {
-		r.sessionScores[sessionID] = make(map[string]int)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
 	}
-	r.sessionScores[sessionID][userID] = score
 
-	// Обновляем глобальный счет
-	r.globalScores[userID] += score
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].Score = entries[i].TasksCompleted*10 + entries[i].FocusTime
+	}
 
-	return nil
+	return entries
 }
@@ -29,25 +29,42 @@ func (r *MessageRepository) Create(message *entity.Message) error {
 		return fmt.Errorf("message with ID %s already exists", message.ID)
 	}
 
-	r.messages[message.ID] = message
+	// Как уникальный индекс idx_messages_max_message_id
+	if message.MaxMessageID != nil {
+		for _, existing := range r.messages {
+			if existing.MaxMessageID != nil && *existing.MaxMessageID == *message.MaxMessageID {
+				return fmt.Errorf("message with maxMessageID %s already exists", *message.MaxMessageID)
+			}
+		}
+	}
+
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+
+	r.messages[message.ID] = cloneMessage(message)
 	return nil
 }
 
+// GetBySessionID возвращает последние limit сообщений до before в хронологическом порядке
 func (r *MessageRepository) GetBySessionID(sessionID string, before *time.Time, limit int) ([]*entity.Message, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	var messages []*entity.Message
+	messages := make([]*entity.Message, 0)
 	for _, message := range r.messages {
 		if message.SessionID == sessionID {
 			if before == nil || message.CreatedAt.Before(*before) {
-				messages = append(messages, message)
+				messages = append(messages, cloneMessage(message))
 			}
 		}
 	}
 
 	// Сортируем по времени создания (от новых к старым)
 	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID > messages[j].ID
+		}
 		return messages[i].CreatedAt.After(messages[j].CreatedAt)
 	})
 
@@ -56,6 +73,11 @@ func (r *MessageRepository) GetBySessionID(sessionID string, before *time.Time,
 		messages = messages[:limit]
 	}
 
+	// Разворачиваем порядок для правильной последовательности
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
 	return messages, nil
 }
 
@@ -65,8 +87,45 @@ func (r *MessageRepository) GetByID(id string) (*entity.Message, error) {
 
 	message, exists := r.messages[id]
 	if !exists {
-		return nil, fmt.Errorf("message with ID %s not found", id)
+		return nil, nil
+	}
+
+	return cloneMessage(message), nil
+}
+
+func (r *MessageRepository) GetByMaxMessageID(maxMessageID string) (*entity.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, message := range r.messages {
+		if message.MaxMessageID != nil && *message.MaxMessageID == maxMessageID {
+			return cloneMessage(message), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// detach убирает сообщения сессии и возвращает их для attach
+func (r *MessageRepository) detach(sessionID string) []*entity.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var detached []*entity.Message
+	for id, message := range r.messages {
+		if message.SessionID == sessionID {
+			detached = append(detached, message)
+			delete(r.messages, id)
+		}
 	}
+	return detached
+}
 
-	return message, nil
+func (r *MessageRepository) attach(messages []*entity.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, message := range messages {
+		r.messages[message.ID] = message
+	}
 }
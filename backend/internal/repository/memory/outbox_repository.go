@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type OutboxRepository struct {
+	messages map[string]*entity.OutboxMessage
+	mu       sync.RWMutex
+}
+
+func NewOutboxRepository() interfaces.OutboxRepository {
+	return &OutboxRepository{
+		messages: make(map[string]*entity.OutboxMessage),
+	}
+}
+
+func (r *OutboxRepository) Create(message *entity.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.messages[message.ID]; exists {
+		return fmt.Errorf("outbox message with ID %s already exists", message.ID)
+	}
+
+	r.messages[message.ID] = cloneOutboxMessage(message)
+	return nil
+}
+
+func (r *OutboxRepository) GetByID(id string) (*entity.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	message, exists := r.messages[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneOutboxMessage(message), nil
+}
+
+func (r *OutboxRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*entity.OutboxMessage
+	for _, message := range r.messages {
+		if message.Status == entity.OutboxStatusPending && !message.NextAttemptAt.After(now) {
+			due = append(due, message)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*entity.OutboxMessage, 0, len(due))
+	for _, message := range due {
+		message.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, cloneOutboxMessage(message))
+	}
+
+	return claimed, nil
+}
+
+func (r *OutboxRepository) Update(message *entity.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.messages[message.ID]; !exists {
+		return fmt.Errorf("outbox message with ID %s not found", message.ID)
+	}
+
+	r.messages[message.ID] = cloneOutboxMessage(message)
+	return nil
+}
+
+func (r *OutboxRepository) List(status entity.OutboxStatus, page, limit int) ([]*entity.OutboxMessage, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var messages []*entity.OutboxMessage
+	for _, message := range r.messages {
+		if status == "" || message.Status == status {
+			messages = append(messages, message)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+
+	total := len(messages)
+	start := (page - 1) * limit
+	if start >= total {
+		return []*entity.OutboxMessage{}, total, nil
+	}
+
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	result := make([]*entity.OutboxMessage, 0, end-start)
+	for _, message := range messages[start:end] {
+		result = append(result, cloneOutboxMessage(message))
+	}
+	return result, total, nil
+}
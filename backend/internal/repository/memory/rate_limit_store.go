@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type RateLimitStore struct {
+	buckets   map[string]*entity.RateLimitBucket
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+func NewRateLimitStore() interfaces.RateLimitStore {
+	return &RateLimitStore{
+		buckets: make(map[string]*entity.RateLimitBucket),
+	}
+}
+
+func (s *RateLimitStore) Take(key string, limit entity.RateLimit, now time.Time) (entity.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	bucket, exists := s.buckets[key]
+	if !exists {
+		bucket = &entity.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		s.buckets[key] = bucket
+	}
+	return bucket.Take(limit, now), nil
+}
+
+// prune раз в RateLimitMaxPeriod удаляет заполнившиеся bucket'ы, чтобы ключи по IP не копились
+func (s *RateLimitStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < entity.RateLimitMaxPeriod {
+		return
+	}
+	s.lastPrune = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.UpdatedAt) > entity.RateLimitMaxPeriod {
+			delete(s.buckets, key)
+		}
+	}
+}
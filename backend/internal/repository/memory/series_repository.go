@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type SessionSeriesRepository struct {
+	series     map[string]*entity.SessionSeries
+	exceptions map[string][]*entity.SeriesException // seriesID -> изменения по порядку повторений
+	mu         sync.RWMutex
+}
+
+func NewSessionSeriesRepository() interfaces.SessionSeriesRepository {
+	return &SessionSeriesRepository{
+		series:     make(map[string]*entity.SessionSeries),
+		exceptions: make(map[string][]*entity.SeriesException),
+	}
+}
+
+func (r *SessionSeriesRepository) Create(series *entity.SessionSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.series[series.ID]; exists {
+		return fmt.Errorf("series with ID %s already exists", series.ID)
+	}
+	for _, existing := range r.series {
+		if existing.InviteLink == series.InviteLink {
+			return fmt.Errorf("series with invite link %s already exists", series.InviteLink)
+		}
+	}
+
+	now := time.Now()
+	if series.CreatedAt.IsZero() {
+		series.CreatedAt = now
+	}
+	series.UpdatedAt = now
+	for i := range series.Members {
+		series.Members[i].SeriesID = series.ID
+	}
+
+	r.series[series.ID] = cloneSeries(series)
+	return nil
+}
+
+func (r *SessionSeriesRepository) GetByID(id string) (*entity.SessionSeries, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	series, exists := r.series[id]
+	if !exists {
+		return nil, nil
+	}
+	return cloneSeries(series), nil
+}
+
+func (r *SessionSeriesRepository) GetByInviteLink(inviteLink string) (*entity.SessionSeries, error) {
+	found := r.filter(func(series *entity.SessionSeries) bool {
+		return series.InviteLink == inviteLink
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return found[0], nil
+}
+
+func (r *SessionSeriesRepository) ListByUserID(userID string) ([]*entity.SessionSeries, error) {
+	found := r.filter(func(series *entity.SessionSeries) bool {
+		return series.CreatorID == userID || series.HasMember(userID)
+	})
+	slices.SortFunc(found, func(a, b *entity.SessionSeries) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return found, nil
+}
+
+func (r *SessionSeriesRepository) ListActive() ([]*entity.SessionSeries, error) {
+	found := r.filter(func(series *entity.SessionSeries) bool {
+		return series.EndedAt == nil
+	})
+	slices.SortFunc(found, func(a, b *entity.SessionSeries) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return found, nil
+}
+
+func (r *SessionSeriesRepository) Update(series *entity.SessionSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.series[series.ID]
+	if !exists {
+		return fmt.Errorf("series with ID %s not found", series.ID)
+	}
+
+	series.UpdatedAt = time.Now()
+	stored := cloneSeries(series)
+	stored.Members = existing.Members
+	r.series[series.ID] = stored
+	return nil
+}
+
+func (r *SessionSeriesRepository) AddMember(member *entity.SeriesMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series, exists := r.series[member.SeriesID]
+	if !exists {
+		return fmt.Errorf("series with ID %s not found", member.SeriesID)
+	}
+	if series.HasMember(member.UserID) {
+		return nil
+	}
+	if member.JoinedAt.IsZero() {
+		member.JoinedAt = time.Now()
+	}
+
+	series.Members = append(slices.Clip(series.Members), *member)
+	return nil
+}
+
+func (r *SessionSeriesRepository) SaveException(exception *entity.SeriesException) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := *exception
+	exceptions := slices.DeleteFunc(slices.Clone(r.exceptions[exception.SeriesID]), func(e *entity.SeriesException) bool {
+		return e.OccurrenceAt.Equal(exception.OccurrenceAt)
+	})
+	exceptions = append(exceptions, &clone)
+	slices.SortFunc(exceptions, func(a, b *entity.SeriesException) int {
+		return a.OccurrenceAt.Compare(b.OccurrenceAt)
+	})
+	r.exceptions[exception.SeriesID] = exceptions
+	return nil
+}
+
+func (r *SessionSeriesRepository) GetExceptions(seriesID string) ([]*entity.SeriesException, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exceptions := make([]*entity.SeriesException, 0, len(r.exceptions[seriesID]))
+	for _, exception := range r.exceptions[seriesID] {
+		clone := *exception
+		exceptions = append(exceptions, &clone)
+	}
+	return exceptions, nil
+}
+
+// filter возвращает копии подходящих серий
+func (r *SessionSeriesRepository) filter(match func(series *entity.SessionSeries) bool) []*entity.SessionSeries {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found := make([]*entity.SessionSeries, 0)
+	for _, series := range r.series {
+		if match(series) {
+			found = append(found, cloneSeries(series))
+		}
+	}
+	return found
+}
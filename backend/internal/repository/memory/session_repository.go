@@ -1,25 +1,53 @@
 package memory
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"gorm.io/gorm"
 )
 
 type SessionRepository struct {
-	sessions     map[string]*entity.Session
-	inviteLinks  map[string]string   // inviteLink -> sessionID
-	userSessions map[string][]string // userID -> []sessionID
-	mu           sync.RWMutex
+	sessions    map[string]*entity.Session
+	inviteLinks map[string]string          // inviteLink -> sessionID; ссылка занята до Purge
+	deleted     map[string]*deletedSession // мягко удаленные, невидимые остальным методам
+	// taskRepo заменяет Preload("Tasks"): задачи хранятся отдельно, как в таблице tasks
+	taskRepo    interfaces.TaskRepository
+	messageRepo interfaces.MessageRepository
+	mu          sync.RWMutex
 }
 
-func NewSessionRepository() interfaces.SessionRepository {
+// deletedSession удаленная сессия с задачами и сообщениями, удаленными вместе с ней
+type deletedSession struct {
+	session  *entity.Session
+	tasks    []*entity.Task
+	messages []*entity.Message
+}
+
+// sessionChildren заменяет мягкое удаление по session_id; реализован TaskRepository
+// и MessageRepository этого пакета
+type sessionChildren[T any] interface {
+	detach(sessionID string) []T
+	attach(items []T)
+}
+
+func NewSessionRepository(
+	taskRepo interfaces.TaskRepository,
+	messageRepo interfaces.MessageRepository,
+) interfaces.SessionRepository {
 	return &SessionRepository{
-		sessions:     make(map[string]*entity.Session),
-		inviteLinks:  make(map[string]string),
-		userSessions: make(map[string][]string),
+		sessions:    make(map[string]*entity.Session),
+		inviteLinks: make(map[string]string),
+		deleted:     make(map[string]*deletedSession),
+		taskRepo:    taskRepo,
+		messageRepo: messageRepo,
 	}
 }
 
@@ -27,110 +55,212 @@ func (r *SessionRepository) Create(session *entity.Session) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.sessions[session.ID]; exists {
+	if _, exists := r.sessions[session.ID]; exists || r.deleted[session.ID] != nil {
 		return fmt.Errorf("session with ID %s already exists", session.ID)
 	}
+	if _, exists := r.inviteLinks[session.InviteLink]; exists {
+		return fmt.Errorf("session with invite link %s already exists", session.InviteLink)
+	}
 
-	r.sessions[session.ID] = session
-	r.inviteLinks[session.InviteLink] = session.ID
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+	for i := range session.Participants {
+		session.Participants[i].SessionID = session.ID
+	}
+	for i := range session.Tags {
+		session.Tags[i].SessionID = session.ID
+	}
 
-	// Добавляем в список сессий пользователя
-	r.userSessions[session.CreatorID] = append(r.userSessions[session.CreatorID], session.ID)
+	stored := cloneSession(session)
+	stored.Tasks = nil
+	r.sessions[session.ID] = stored
+	r.inviteLinks[session.InviteLink] = session.ID
 
 	return nil
 }
 
 func (r *SessionRepository) GetByID(id string) (*entity.Session, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	session, exists := r.sessions[id]
+	if exists {
+		session = cloneSession(session)
+	}
+	r.mu.RUnlock()
+
 	if !exists {
-		return nil, fmt.Errorf("session with ID %s not found", id)
+		return nil, nil
 	}
 
-	return session, nil
+	return r.withTasks(session)
 }
 
 func (r *SessionRepository) GetByInviteLink(inviteLink string) (*entity.Session, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	sessionID, exists := r.inviteLinks[inviteLink]
-	if !exists {
-		return nil, fmt.Errorf("session with invite link %s not found", inviteLink)
-	}
+	r.mu.RUnlock()
 
-	session, exists := r.sessions[sessionID]
 	if !exists {
-		return nil, fmt.Errorf("session with ID %s not found", sessionID)
+		return nil, nil
 	}
 
-	return session, nil
+	return r.GetByID(sessionID)
 }
 
-func (r *SessionRepository) GetActiveByUserID(userID string) (*entity.Session, error) {
+func (r *SessionRepository) GetByMaxChatID(chatID int64) (*entity.Session, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	sessionIDs, exists := r.userSessions[userID]
-	if !exists {
-		return nil, nil
-	}
-
-	for _, sessionID := range sessionIDs {
-		session, exists := r.sessions[sessionID]
-		if exists && session.Status == entity.SessionStatusActive {
-			return session, nil
+	for _, session := range r.sessions {
+		if session.MaxChatID != nil && *session.MaxChatID == chatID {
+			return cloneSession(session), nil
 		}
 	}
 
 	return nil, nil
 }
 
-func (r *SessionRepository) GetHistory(userID string, page, limit int) ([]*entity.Session, int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	sessionIDs, exists := r.userSessions[userID]
-	if !exists {
-		return []*entity.Session{}, 0, nil
+// GetActiveByUserID ищет активную или приостановленную сессию, где пользователь — участник
+func (r *SessionRepository) GetActiveByUserID(userID string) (*entity.Session, error) {
+	sessions := r.filter(func(session *entity.Session) bool {
+		return (session.Status == entity.SessionStatusActive || session.Status == entity.SessionStatusPaused) &&
+			hasParticipant(session, userID)
+	})
+	if len(sessions) == 0 {
+		return nil, nil
 	}
 
-	var completedSessions []*entity.Session
-	for _, sessionID := range sessionIDs {
-		session, exists := r.sessions[sessionID]
-		if exists && session.Status == entity.SessionStatusCompleted {
-			completedSessions = append(completedSessions, session)
-		}
-	}
+	// Как First() в gorm — сессия с наименьшим ID
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
 
-	// Простая пагинация
-	start := (page - 1) * limit
-	end := start + limit
-	total := len(completedSessions)
+	return r.withTasks(sessions[0])
+}
 
-	if start > total {
-		return []*entity.Session{}, total, nil
+// List отбирает сессии по фильтру, сортирует как gorm (ключ сортировки, затем
+// created_at DESC, id DESC) и считает задачи только для сессий страницы
+func (r *SessionRepository) List(filter entity.SessionFilter) ([]*entity.SessionSummary, error) {
+	sessions := r.filter(func(session *entity.Session) bool {
+		return matchesFilter(session, filter) &&
+			(filter.After == nil || compareListPosition(filter.Sort, listPosition(session), *filter.After) > 0)
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return compareListPosition(filter.Sort, listPosition(sessions[i]), listPosition(sessions[j])) < 0
+	})
+	if filter.Limit > 0 && len(sessions) > filter.Limit {
+		sessions = sessions[:filter.Limit]
 	}
 
-	if end > total {
-		end = total
+	summaries := make([]*entity.SessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		summary := &entity.SessionSummary{
+			ID:                session.ID,
+			Mode:              session.Mode,
+			Status:            session.Status,
+			FocusDuration:     session.FocusDuration,
+			BreakDuration:     session.BreakDuration,
+			GroupName:         session.GroupName,
+			Topic:             session.Topic,
+			Language:          session.Language,
+			ScheduledAt:       session.ScheduledAt,
+			IsPrivate:         session.IsPrivate,
+			CreatorID:         session.CreatorID,
+			MaxChatID:         session.MaxChatID,
+			MaxChatLink:       session.MaxChatLink,
+			StartedAt:         session.StartedAt,
+			CompletedAt:       session.CompletedAt,
+			CreatedAt:         session.CreatedAt,
+			ParticipantsCount: len(session.Participants),
+			Participants:      make([]entity.ParticipantSummary, 0, len(session.Participants)),
+			Tags:              session.TagNames(),
+		}
+		sort.Strings(summary.Tags)
+		participants := append([]entity.Participant(nil), session.Participants...)
+		sort.SliceStable(participants, func(i, j int) bool {
+			if participants[i].JoinedAt.Equal(participants[j].JoinedAt) {
+				return participants[i].UserID < participants[j].UserID
+			}
+			return participants[i].JoinedAt.Before(participants[j].JoinedAt)
+		})
+		for _, p := range participants {
+			summary.Participants = append(summary.Participants, entity.ParticipantSummary{
+				SessionID: session.ID,
+				UserID:    p.UserID,
+				UserName:  p.UserName,
+				AvatarURL: p.AvatarURL,
+			})
+		}
+
+		if r.taskRepo != nil {
+			tasks, err := r.taskRepo.GetBySessionID(session.ID)
+			if err != nil {
+				return nil, err
+			}
+			summary.TasksTotal = len(tasks)
+			for _, task := range tasks {
+				if task.Completed {
+					summary.TasksCompleted++
+				}
+			}
+		}
+
+		summaries = append(summaries, summary)
 	}
 
-	return completedSessions[start:end], total, nil
+	return summaries, nil
 }
 
+// Update сохраняет сессию целиком; как и gorm Save, создает ее, если записи нет.
+// Участники, как и ассоциации в gorm, только добавляются: изменения существующих
+// выполняются через UpdateParticipantReady/RemoveParticipant
 func (r *SessionRepository) Update(session *entity.Session) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.sessions[session.ID]; !exists {
-		return fmt.Errorf("session with ID %s not found", session.ID)
+	r.updateLocked(session)
+	return nil
+}
+
+func (r *SessionRepository) UpdateIfStatus(session *entity.Session, status entity.SessionStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.sessions[session.ID]; !exists || existing.Status != status {
+		return false, nil
 	}
+	r.updateLocked(session)
+	return true, nil
+}
 
-	r.sessions[session.ID] = session
-	return nil
+// updateLocked сохраняет сессию без задач, добавляя новых участников к сохраненным
+func (r *SessionRepository) updateLocked(session *entity.Session) {
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+
+	stored := cloneSession(session)
+	stored.Tasks = nil
+
+	if existing, exists := r.sessions[session.ID]; exists {
+		stored.Participants = append([]entity.Participant(nil), existing.Participants...)
+		for _, participant := range session.Participants {
+			if !hasParticipant(stored, participant.UserID) {
+				participant.SessionID = session.ID
+				stored.Participants = append(stored.Participants, participant)
+			}
+		}
+		if existing.InviteLink != session.InviteLink {
+			delete(r.inviteLinks, existing.InviteLink)
+		}
+	}
+
+	r.sessions[session.ID] = stored
+	r.inviteLinks[session.InviteLink] = session.ID
 }
 
 func (r *SessionRepository) AddParticipant(sessionID string, participant *entity.Participant) error {
@@ -142,19 +272,14 @@ func (r *SessionRepository) AddParticipant(sessionID string, participant *entity
 		return fmt.Errorf("session with ID %s not found", sessionID)
 	}
 
-	// Проверяем, не является ли участник уже в сессии
-	for i, p := range session.Participants {
-		if p.UserID == participant.UserID {
-			session.Participants[i] = *participant
-			return nil
-		}
+	// Как и первичный ключ (session_id, user_id) в БД
+	if hasParticipant(session, participant.UserID) {
+		return fmt.Errorf("participant %s already exists in session %s", participant.UserID, sessionID)
 	}
 
+	participant.SessionID = sessionID
 	session.Participants = append(session.Participants, *participant)
 
-	// Добавляем в список сессий пользователя
-	r.userSessions[participant.UserID] = append(r.userSessions[participant.UserID], sessionID)
-
 	return nil
 }
 
@@ -164,12 +289,12 @@ func (r *SessionRepository) RemoveParticipant(sessionID string, userID string) e
 
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return fmt.Errorf("session with ID %s not found", sessionID)
+		return nil
 	}
 
 	for i, p := range session.Participants {
 		if p.UserID == userID {
-			session.Participants = append(session.Participants[:i], session.Participants[i+1:]...)
+			session.Participants = append(session.Participants[:i:i], session.Participants[i+1:]...)
 			break
 		}
 	}
@@ -177,13 +302,14 @@ func (r *SessionRepository) RemoveParticipant(sessionID string, userID string) e
 	return nil
 }
 
+// UpdateParticipantReady, как и UPDATE в gorm, не считает отсутствие участника ошибкой
 func (r *SessionRepository) UpdateParticipantReady(sessionID string, userID string, isReady bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	session, exists := r.sessions[sessionID]
 	if !exists {
-		return fmt.Errorf("session with ID %s not found", sessionID)
+		return nil
 	}
 
 	for i, p := range session.Participants {
@@ -193,31 +319,291 @@ func (r *SessionRepository) UpdateParticipantReady(sessionID string, userID stri
 		}
 	}
 
-	return fmt.Errorf("participant with userID %s not found in session %s", userID, sessionID)
+	return nil
 }
 
 func (r *SessionRepository) GetSessionsByStatus(status entity.SessionStatus) ([]*entity.Session, error) {
+	sessions := r.filter(func(session *entity.Session) bool {
+		return session.Status == status
+	})
+	sortByCreatedAtDesc(sessions)
+
+	return r.withTasksAll(sessions)
+}
+
+func (r *SessionRepository) GetScheduledBefore(until time.Time) ([]*entity.Session, error) {
+	sessions := r.filter(func(session *entity.Session) bool {
+		return session.Status == entity.SessionStatusPending && session.ScheduledAt != nil && !session.ScheduledAt.After(until)
+	})
+	slices.SortFunc(sessions, func(a, b *entity.Session) int {
+		return cmp.Or(a.ScheduledAt.Compare(*b.ScheduledAt), cmp.Compare(a.ID, b.ID))
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepository) GetBySeriesID(seriesID string) ([]*entity.Session, error) {
+	sessions := r.filter(func(session *entity.Session) bool {
+		return session.SeriesID != nil && *session.SeriesID == seriesID
+	})
+	slices.SortFunc(sessions, func(a, b *entity.Session) int {
+		return cmp.Or(a.OccurrenceAt.Compare(*b.OccurrenceAt), cmp.Compare(a.ID, b.ID))
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepository) CountByStatus() (map[entity.SessionStatus]int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	var sessions []*entity.Session
+	counts := make(map[entity.SessionStatus]int)
 	for _, session := range r.sessions {
-		if session.Status == status {
-			sessions = append(sessions, session)
+		counts[session.Status]++
+	}
+	return counts, nil
+}
+
+// filter возвращает копии подходящих сессий без задач
+func (r *SessionRepository) filter(match func(session *entity.Session) bool) []*entity.Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := make([]*entity.Session, 0)
+	for _, session := range r.sessions {
+		if match(session) {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+
+	return sessions
+}
+
+// withTasks подгружает задачи сессии из репозитория задач
+func (r *SessionRepository) withTasks(session *entity.Session) (*entity.Session, error) {
+	session.Tasks = []entity.Task{}
+	if r.taskRepo == nil {
+		return session, nil
+	}
+
+	tasks, err := r.taskRepo.GetBySessionID(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		session.Tasks = append(session.Tasks, *task)
+	}
+
+	return session, nil
+}
+
+func (r *SessionRepository) withTasksAll(sessions []*entity.Session) ([]*entity.Session, error) {
+	for i, session := range sessions {
+		withTasks, err := r.withTasks(session)
+		if err != nil {
+			return nil, err
 		}
+		sessions[i] = withTasks
 	}
 
 	return sessions, nil
 }
 
-func (r *SessionRepository) GetAll() ([]*entity.Session, error) {
+// matchesFilter повторяет условия WHERE из gorm-реализации List
+func matchesFilter(session *entity.Session, filter entity.SessionFilter) bool {
+	if filter.UserID != "" {
+		isCreator := session.CreatorID == filter.UserID
+		switch filter.Role {
+		case entity.SessionRoleCreator:
+			if !isCreator {
+				return false
+			}
+		case entity.SessionRoleParticipant:
+			if isCreator || !hasParticipant(session, filter.UserID) {
+				return false
+			}
+		default:
+			if !isCreator && !hasParticipant(session, filter.UserID) {
+				return false
+			}
+		}
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, session.Status) {
+		return false
+	}
+	if filter.Mode != "" && session.Mode != filter.Mode {
+		return false
+	}
+	if filter.CreatedFrom != nil && session.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !session.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	if filter.HasChat != nil && (session.MaxChatID != nil) != *filter.HasChat {
+		return false
+	}
+	if filter.PublicOnly && session.IsPrivate {
+		return false
+	}
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		if !containsFold(session.GroupName, search) && !containsFold(session.Topic, search) {
+			return false
+		}
+	}
+	for _, tag := range filter.Tags {
+		if !slices.Contains(session.TagNames(), tag) {
+			return false
+		}
+	}
+	if filter.Language != "" && (session.Language == nil || *session.Language != filter.Language) {
+		return false
+	}
+	if filter.JoinableAt != nil {
+		if session.Status != entity.SessionStatusPending ||
+			(session.ScheduledAt != nil && session.ScheduledAt.After(filter.JoinableAt.Add(entity.JoinableWindow))) ||
+			(filter.Capacity > 0 && len(session.Participants) >= filter.Capacity) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(value *string, lowerSubstr string) bool {
+	return value != nil && strings.Contains(strings.ToLower(*value), lowerSubstr)
+}
+
+// listPosition — позиция сессии в списке в виде курсора
+func listPosition(session *entity.Session) entity.SessionCursor {
+	position := entity.SessionCursor{
+		StartAt:   session.CreatedAt,
+		Count:     len(session.Participants),
+		CreatedAt: session.CreatedAt,
+		ID:        session.ID,
+	}
+	if session.ScheduledAt != nil {
+		position.StartAt = *session.ScheduledAt
+	}
+	return position
+}
+
+// compareListPosition сравнивает позиции в списке с сортировкой sortBy:
+// отрицательный результат — a идет раньше b
+func compareListPosition(sortBy entity.SessionSort, a, b entity.SessionCursor) int {
+	switch sortBy {
+	case entity.SessionSortStart:
+		if c := a.StartAt.Compare(b.StartAt); c != 0 {
+			return c
+		}
+	case entity.SessionSortSeats:
+		if c := cmp.Compare(a.Count, b.Count); c != 0 {
+			return c
+		}
+	case entity.SessionSortPopular:
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+	}
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(b.ID, a.ID)
+}
+
+func hasParticipant(session *entity.Session, userID string) bool {
+	for _, p := range session.Participants {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByCreatedAtDesc(sessions []*entity.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+}
+
+// Delete прячет сессию и забирает ее задачи и сообщения из их репозиториев
+func (r *SessionRepository) Delete(id string) error {
+	tasks, tasksOK := r.taskRepo.(sessionChildren[*entity.Task])
+	messages, messagesOK := r.messageRepo.(sessionChildren[*entity.Message])
+	if !tasksOK || !messagesOK {
+		return fmt.Errorf("session deletion requires memory task and message repositories")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil
+	}
+	session.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	delete(r.sessions, id)
+	r.deleted[id] = &deletedSession{
+		session:  session,
+		tasks:    tasks.detach(id),
+		messages: messages.detach(id),
+	}
+	return nil
+}
+
+func (r *SessionRepository) GetDeletedByID(id string) (*entity.Session, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	sessions := make([]*entity.Session, 0, len(r.sessions))
-	for _, session := range r.sessions {
-		sessions = append(sessions, session)
+	deleted, exists := r.deleted[id]
+	if !exists {
+		return nil, nil
+	}
+	session := cloneSession(deleted.session)
+	session.Tasks = []entity.Task{}
+	return session, nil
+}
+
+func (r *SessionRepository) Restore(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted, exists := r.deleted[id]
+	if !exists {
+		return nil
 	}
+	deleted.session.DeletedAt = gorm.DeletedAt{}
+	r.sessions[id] = deleted.session
+	delete(r.deleted, id)
+	r.taskRepo.(sessionChildren[*entity.Task]).attach(deleted.tasks)
+	r.messageRepo.(sessionChildren[*entity.Message]).attach(deleted.messages)
+	return nil
+}
 
+func (r *SessionRepository) GetDeletedBefore(before time.Time) ([]*entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := make([]*entity.Session, 0)
+	for _, deleted := range r.deleted {
+		if deleted.session.DeletedAt.Time.Before(before) {
+			sessions = append(sessions, cloneSession(deleted.session))
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *entity.Session) int {
+		return cmp.Or(a.DeletedAt.Time.Compare(b.DeletedAt.Time), cmp.Compare(a.ID, b.ID))
+	})
 	return sessions, nil
 }
+
+func (r *SessionRepository) Purge(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if deleted, exists := r.deleted[id]; exists {
+		delete(r.inviteLinks, deleted.session.InviteLink)
+		delete(r.deleted, id)
+	}
+	return nil
+}
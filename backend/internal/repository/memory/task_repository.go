@@ -2,7 +2,9 @@ package memory
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
@@ -27,7 +29,13 @@ func (r *TaskRepository) Create(task *entity.Task) error {
 		return fmt.Errorf("task with ID %s already exists", task.ID)
 	}
 
-	r.tasks[task.ID] = task
+	now := time.Now()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	task.UpdatedAt = now
+
+	r.tasks[task.ID] = cloneTask(task)
 	return nil
 }
 
@@ -37,38 +45,22 @@ func (r *TaskRepository) GetByID(id string) (*entity.Task, error) {
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("task with ID %s not found", id)
+		return nil, nil
 	}
 
-	return task, nil
+	return cloneTask(task), nil
 }
 
 func (r *TaskRepository) GetBySessionID(sessionID string) ([]*entity.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var tasks []*entity.Task
-	for _, task := range r.tasks {
-		if task.SessionID == sessionID {
-			tasks = append(tasks, task)
-		}
-	}
-
-	return tasks, nil
+	return r.filter(func(task *entity.Task) bool {
+		return task.SessionID == sessionID
+	}), nil
 }
 
 func (r *TaskRepository) GetBySessionIDAndUserID(sessionID string, userID string) ([]*entity.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var tasks []*entity.Task
-	for _, task := range r.tasks {
-		if task.SessionID == sessionID && task.UserID != nil && *task.UserID == userID {
-			tasks = append(tasks, task)
-		}
-	}
-
-	return tasks, nil
+	return r.filter(func(task *entity.Task) bool {
+		return task.SessionID == sessionID && task.UserID != nil && *task.UserID == userID
+	}), nil
 }
 
 func (r *TaskRepository) CountBySessionIDAndUserID(sessionID string, userID string) (int, int, error) {
@@ -89,15 +81,17 @@ func (r *TaskRepository) CountBySessionIDAndUserID(sessionID string, userID stri
 	return total, completed, nil
 }
 
+// Update сохраняет задачу целиком; как и gorm Save, создает ее, если записи нет
 func (r *TaskRepository) Update(task *entity.Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[task.ID]; !exists {
-		return fmt.Errorf("task with ID %s not found", task.ID)
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
 	}
+	task.UpdatedAt = time.Now()
 
-	r.tasks[task.ID] = task
+	r.tasks[task.ID] = cloneTask(task)
 	return nil
 }
 
@@ -105,10 +99,52 @@ func (r *TaskRepository) Delete(id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[id]; !exists {
-		return fmt.Errorf("task with ID %s not found", id)
-	}
-
 	delete(r.tasks, id)
 	return nil
 }
+
+// filter возвращает копии подходящих задач в порядке создания
+func (r *TaskRepository) filter(match func(task *entity.Task) bool) []*entity.Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]*entity.Task, 0)
+	for _, task := range r.tasks {
+		if match(task) {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].ID < tasks[j].ID
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+
+	return tasks
+}
+
+// detach убирает задачи сессии и возвращает их для attach
+func (r *TaskRepository) detach(sessionID string) []*entity.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var detached []*entity.Task
+	for id, task := range r.tasks {
+		if task.SessionID == sessionID {
+			detached = append(detached, task)
+			delete(r.tasks, id)
+		}
+	}
+	return detached
+}
+
+func (r *TaskRepository) attach(tasks []*entity.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range tasks {
+		r.tasks[task.ID] = task
+	}
+}
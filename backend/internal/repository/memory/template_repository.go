@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type SessionTemplateRepository struct {
+	templates map[string]*entity.SessionTemplate
+	mu        sync.RWMutex
+}
+
+func NewSessionTemplateRepository() interfaces.SessionTemplateRepository {
+	return &SessionTemplateRepository{
+		templates: make(map[string]*entity.SessionTemplate),
+	}
+}
+
+func (r *SessionTemplateRepository) Create(template *entity.SessionTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[template.ID]; exists {
+		return fmt.Errorf("template with ID %s already exists", template.ID)
+	}
+	for _, existing := range r.templates {
+		if existing.ShareLink == template.ShareLink {
+			return fmt.Errorf("template with share link %s already exists", template.ShareLink)
+		}
+	}
+
+	now := time.Now()
+	if template.CreatedAt.IsZero() {
+		template.CreatedAt = now
+	}
+	template.UpdatedAt = now
+
+	r.templates[template.ID] = cloneTemplate(template)
+	return nil
+}
+
+func (r *SessionTemplateRepository) GetByID(id string) (*entity.SessionTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, exists := r.templates[id]
+	if !exists {
+		return nil, nil
+	}
+	return cloneTemplate(template), nil
+}
+
+func (r *SessionTemplateRepository) GetByShareLink(shareLink string) (*entity.SessionTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, template := range r.templates {
+		if template.ShareLink == shareLink {
+			return cloneTemplate(template), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *SessionTemplateRepository) ListByOwnerID(ownerID string) ([]*entity.SessionTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]*entity.SessionTemplate, 0)
+	for _, template := range r.templates {
+		if template.OwnerID == ownerID {
+			templates = append(templates, cloneTemplate(template))
+		}
+	}
+	slices.SortFunc(templates, func(a, b *entity.SessionTemplate) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return templates, nil
+}
+
+func (r *SessionTemplateRepository) Update(template *entity.SessionTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[template.ID]; !exists {
+		return fmt.Errorf("template with ID %s not found", template.ID)
+	}
+
+	template.UpdatedAt = time.Now()
+	r.templates[template.ID] = cloneTemplate(template)
+	return nil
+}
+
+func (r *SessionTemplateRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.templates, id)
+	return nil
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+// Transactor для in-memory хранилища: изменения применяются сразу и не откатываются
+type Transactor struct {
+	repos interfaces.TxRepositories
+}
+
+func NewTransactor(
+	sessionRepo interfaces.SessionRepository,
+	taskRepo interfaces.TaskRepository,
+	outboxRepo interfaces.OutboxRepository,
+	seriesRepo interfaces.SessionSeriesRepository,
+) interfaces.Transactor {
+	return &Transactor{
+		repos: interfaces.TxRepositories{
+			Sessions: sessionRepo,
+			Tasks:    taskRepo,
+			Outbox:   outboxRepo,
+			Series:   seriesRepo,
+		},
+	}
+}
+
+func (t *Transactor) WithinTransaction(fn func(repos interfaces.TxRepositories) error) error {
+	return fn(t.repos)
+}
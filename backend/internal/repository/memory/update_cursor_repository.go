@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type UpdateCursorRepository struct {
+	markers map[string]int64
+	mu      sync.RWMutex
+}
+
+func NewUpdateCursorRepository() interfaces.UpdateCursorRepository {
+	return &UpdateCursorRepository{
+		markers: make(map[string]int64),
+	}
+}
+
+func (r *UpdateCursorRepository) GetMarker(source string) (*int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	marker, exists := r.markers[source]
+	if !exists {
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+func (r *UpdateCursorRepository) SaveMarker(source string, marker int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.markers[source] = marker
+	return nil
+}
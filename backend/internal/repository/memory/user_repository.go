@@ -3,6 +3,7 @@ package memory
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
@@ -30,10 +31,18 @@ func (r *UserRepository) Create(user *entity.User) error {
 	if _, exists := r.users[user.ID]; exists {
 		return fmt.Errorf("user with ID %s already exists", user.ID)
 	}
+	if _, exists := r.maxID[user.MaxUserID]; exists {
+		return fmt.Errorf("user with maxUserID %d already exists", user.MaxUserID)
+	}
+
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
 
-	r.users[user.ID] = user
+	r.users[user.ID] = cloneUser(user)
 	r.maxID[user.MaxUserID] = user.ID
-	r.stats[user.ID] = &entity.UserStats{}
 
 	return nil
 }
@@ -44,10 +53,10 @@ func (r *UserRepository) GetByID(id string) (*entity.User, error) {
 
 	user, exists := r.users[id]
 	if !exists {
-		return nil, fmt.Errorf("user with ID %s not found", id)
+		return nil, nil
 	}
 
-	return user, nil
+	return cloneUser(user), nil
 }
 
 func (r *UserRepository) GetByMaxUserID(maxUserID int64) (*entity.User, error) {
@@ -56,26 +65,37 @@ func (r *UserRepository) GetByMaxUserID(maxUserID int64) (*entity.User, error) {
 
 	userID, exists := r.maxID[maxUserID]
 	if !exists {
-		return nil, fmt.Errorf("user with maxUserID %d not found", maxUserID)
+		return nil, nil
 	}
 
 	user, exists := r.users[userID]
 	if !exists {
-		return nil, fmt.Errorf("user with ID %s not found", userID)
+		return nil, nil
 	}
 
-	return user, nil
+	return cloneUser(user), nil
 }
 
+// Update сохраняет пользователя целиком; как и gorm Save, создает его, если записи нет
 func (r *UserRepository) Update(user *entity.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.users[user.ID]; !exists {
-		return fmt.Errorf("user with ID %s not found", user.ID)
+	if ownerID, exists := r.maxID[user.MaxUserID]; exists && ownerID != user.ID {
+		return fmt.Errorf("user with maxUserID %d already exists", user.MaxUserID)
+	}
+
+	if existing, exists := r.users[user.ID]; exists && existing.MaxUserID != user.MaxUserID {
+		delete(r.maxID, existing.MaxUserID)
+	}
+
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
 	}
+	user.UpdatedAt = time.Now()
 
-	r.users[user.ID] = user
+	r.users[user.ID] = cloneUser(user)
+	r.maxID[user.MaxUserID] = user.ID
 	return nil
 }
 
@@ -83,22 +103,67 @@ func (r *UserRepository) UpdateStats(userID string, stats *entity.UserStats) err
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// Как внешний ключ user_stats.user_id -> users.id
 	if _, exists := r.users[userID]; !exists {
 		return fmt.Errorf("user with ID %s not found", userID)
 	}
 
-	r.stats[userID] = stats
+	stats.UserID = userID
+	stats.UpdatedAt = time.Now()
+	r.stats[userID] = cloneStats(stats)
 	return nil
 }
 
+// GetStats возвращает статистику пользователя, создавая пустую при первом обращении
 func (r *UserRepository) GetStats(userID string) (*entity.UserStats, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	stats, exists := r.stats[userID]
 	if !exists {
-		return &entity.UserStats{}, nil
+		stats = &entity.UserStats{
+			UserID:    userID,
+			UpdatedAt: time.Now(),
+		}
+		r.stats[userID] = stats
+	}
+
+	return cloneStats(stats), nil
+}
+
+// firstOrCreateStats возвращает статистику пользователя, а если ее нет — сохраняет initial.
+// Повторяет FirstOrCreate из gorm-лидерборда
+func (r *UserRepository) firstOrCreateStats(initial *entity.UserStats) (*entity.UserStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stats, exists := r.stats[initial.UserID]; exists {
+		return cloneStats(stats), nil
+	}
+	if _, exists := r.users[initial.UserID]; !exists {
+		return nil, fmt.Errorf("user with ID %s not found", initial.UserID)
+	}
+
+	initial.UpdatedAt = time.Now()
+	r.stats[initial.UserID] = cloneStats(initial)
+	return cloneStats(initial), nil
+}
+
+// statsSnapshot возвращает копии пользователей и их статистики (nil, если ее еще нет).
+// Нужен лидерборду вместо users LEFT JOIN user_stats
+func (r *UserRepository) statsSnapshot() ([]*entity.User, map[string]*entity.UserStats) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, cloneUser(user))
+	}
+
+	stats := make(map[string]*entity.UserStats, len(r.stats))
+	for userID, userStats := range r.stats {
+		stats[userID] = cloneStats(userStats)
 	}
 
-	return stats, nil
+	return users, stats
 }
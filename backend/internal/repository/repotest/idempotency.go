@@ -0,0 +1,116 @@
+package repotest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testIdempotency(t *testing.T, newRepos Factory) {
+	newRecord := func(key, fingerprint string, now time.Time) *entity.IdempotencyRecord {
+		return &entity.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      entity.IdempotencyStatusInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Hour),
+		}
+	}
+
+	t.Run("BeginCompleteReplay", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "user:" + uuid.New().String()
+
+		existing, err := repos.Idempotency.Begin(newRecord(key, "first", baseTime), baseTime)
+		if err != nil || existing != nil {
+			t.Fatalf("Begin new key: got %+v, %v; want nil", existing, err)
+		}
+
+		existing, err = repos.Idempotency.Begin(newRecord(key, "second", baseTime), baseTime)
+		if err != nil || existing == nil {
+			t.Fatalf("Begin taken key: got %+v, %v; want existing record", existing, err)
+		}
+		if existing.Fingerprint != "first" || existing.Status != entity.IdempotencyStatusInProgress {
+			t.Fatalf("existing record: %+v", existing)
+		}
+
+		if err := repos.Idempotency.Complete(key, 201, "application/json", `{"id":"1"}`); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		existing, err = repos.Idempotency.Begin(newRecord(key, "first", baseTime), baseTime.Add(time.Minute))
+		if err != nil || existing == nil {
+			t.Fatalf("Begin completed key: got %+v, %v", existing, err)
+		}
+		if existing.Status != entity.IdempotencyStatusCompleted || existing.ResponseStatus != 201 ||
+			existing.ContentType != "application/json" || existing.ResponseBody != `{"id":"1"}` {
+			t.Fatalf("completed record: %+v", existing)
+		}
+	})
+
+	t.Run("ExpiredRecordReplaced", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "user:" + uuid.New().String()
+
+		if _, err := repos.Idempotency.Begin(newRecord(key, "old", baseTime), baseTime); err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		later := baseTime.Add(2 * time.Hour)
+		existing, err := repos.Idempotency.Begin(newRecord(key, "new", later), later)
+		if err != nil || existing != nil {
+			t.Fatalf("Begin after expiry: got %+v, %v; want nil", existing, err)
+		}
+		existing, err = repos.Idempotency.Begin(newRecord(key, "other", later), later)
+		if err != nil || existing == nil || existing.Fingerprint != "new" {
+			t.Fatalf("Begin replaced key: got %+v, %v; want fingerprint new", existing, err)
+		}
+	})
+
+	t.Run("DeleteReleasesKey", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "user:" + uuid.New().String()
+
+		if _, err := repos.Idempotency.Begin(newRecord(key, "first", baseTime), baseTime); err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		if err := repos.Idempotency.Delete(key); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		existing, err := repos.Idempotency.Begin(newRecord(key, "second", baseTime), baseTime)
+		if err != nil || existing != nil {
+			t.Fatalf("Begin after Delete: got %+v, %v; want nil", existing, err)
+		}
+	})
+
+	t.Run("ConcurrentBegin", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "user:" + uuid.New().String()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		claimed := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				existing, err := repos.Idempotency.Begin(newRecord(key, "same", baseTime), baseTime)
+				if err != nil {
+					t.Errorf("Begin: %v", err)
+					return
+				}
+				if existing == nil {
+					mu.Lock()
+					claimed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if claimed != 1 {
+			t.Fatalf("key claimed %d times by concurrent requests, want 1", claimed)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package repotest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testLeaderboard(t *testing.T, newRepos Factory) {
+	t.Run("Session", func(t *testing.T) {
+		repos := newRepos(t)
+		leader := newUser(t, repos)
+		runnerUp := newUser(t, repos)
+		idle := newUser(t, repos)
+		session := newSession(t, repos, idle, nil)
+		otherSession := newSession(t, repos, runnerUp, nil)
+		for _, user := range []*entity.User{leader, runnerUp} {
+			participant := participantOf(user)
+			if err := repos.Sessions.AddParticipant(session.ID, &participant); err != nil {
+				t.Fatalf("AddParticipant: %v", err)
+			}
+		}
+
+		completed := func(task *entity.Task) { task.Completed = true }
+		newTask(t, repos, session.ID, leader, completed)
+		newTask(t, repos, session.ID, leader, completed)
+		newTask(t, repos, session.ID, leader, nil)
+		newTask(t, repos, session.ID, runnerUp, completed)
+		// Не считается: задача из другой сессии
+		newTask(t, repos, otherSession.ID, runnerUp, completed)
+
+		entries, err := repos.Leaderboard.GetSessionLeaderboard(session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionLeaderboard: %v", err)
+		}
+		if len(entries) != 3 {
+			t.Fatalf("GetSessionLeaderboard: got %d entries, want 3", len(entries))
+		}
+
+		// Каждый участник получает все выполненные задачи сессии (3), а focus_duration
+		// суммируется по строкам join с задачами (4); при равенстве порядок по user_id
+		users := []*entity.User{leader, runnerUp, idle}
+		sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+		focusTime := 4 * session.FocusDuration
+		for i, user := range users {
+			entry := entries[i]
+			if entry.UserID != user.ID || entry.UserName != user.Name || entry.Rank != i+1 ||
+				entry.TasksCompleted != 3 || entry.FocusTime != focusTime || entry.Score != 3*10+focusTime {
+				t.Fatalf("GetSessionLeaderboard[%d]: got %+v, want user %s", i, entry, user.ID)
+			}
+		}
+
+		empty, err := repos.Leaderboard.GetSessionLeaderboard(uuid.New().String())
+		if err != nil || len(empty) != 0 {
+			t.Fatalf("GetSessionLeaderboard for a missing session: got %v, %v", empty, err)
+		}
+	})
+
+	t.Run("UpdateUserScore", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		session := newSession(t, repos, user, nil)
+
+		// Первая запись создает статистику с одной сессией и сразу добавляет еще одну
+		for i := 0; i < 2; i++ {
+			if err := repos.Leaderboard.UpdateUserScore(user.ID, session.ID, 0); err != nil {
+				t.Fatalf("UpdateUserScore: %v", err)
+			}
+		}
+
+		stats, err := repos.Users.GetStats(user.ID)
+		if err != nil || stats == nil {
+			t.Fatalf("GetStats: got %v, %v", stats, err)
+		}
+		if stats.TotalSessions != 3 || stats.TotalFocusTime != 3*session.FocusDuration {
+			t.Fatalf("stats after two UpdateUserScore: got %+v", stats)
+		}
+
+		if err := repos.Leaderboard.UpdateUserScore(user.ID, uuid.New().String(), 0); err == nil {
+			t.Fatal("UpdateUserScore for a missing session: want error")
+		}
+
+		cancelled := newSession(t, repos, user, func(session *entity.Session) {
+			session.Status = entity.SessionStatusCancelled
+		})
+		if err := repos.Leaderboard.UpdateUserScore(user.ID, cancelled.ID, 0); err != nil {
+			t.Fatalf("UpdateUserScore for a cancelled session: %v", err)
+		}
+		if stats, err := repos.Users.GetStats(user.ID); err != nil || stats.TotalSessions != 3 {
+			t.Fatalf("cancelled session counted in stats: got %+v, %v", stats, err)
+		}
+	})
+
+	t.Run("Global", func(t *testing.T) {
+		repos := newRepos(t)
+		leader := newUser(t, repos)
+		runnerUp := newUser(t, repos)
+		withoutStats := newUser(t, repos)
+		shortSession := newSession(t, repos, leader, nil)
+		longSession := newSession(t, repos, runnerUp, func(session *entity.Session) {
+			session.FocusDuration = 50
+		})
+
+		// leader: две сессии по 25 минут (3 и 75), runnerUp: одна на 50 (2 и 100)
+		for _, score := range []struct {
+			user    *entity.User
+			session *entity.Session
+		}{{leader, shortSession}, {leader, shortSession}, {runnerUp, longSession}} {
+			if err := repos.Leaderboard.UpdateUserScore(score.user.ID, score.session.ID, 0); err != nil {
+				t.Fatalf("UpdateUserScore: %v", err)
+			}
+		}
+		ours := []string{leader.ID, runnerUp.ID, withoutStats.ID}
+
+		all, err := repos.Leaderboard.GetGlobalLeaderboard(entity.LeaderboardPeriodAll, 1_000_000)
+		if err != nil {
+			t.Fatalf("GetGlobalLeaderboard: %v", err)
+		}
+		assertIDs(t, "GetGlobalLeaderboard(all)", idsOf(all, entryUserID, ours...), leader.ID, runnerUp.ID, withoutStats.ID)
+		for i, entry := range all {
+			if entry.Rank != i+1 || entry.Score != entry.TasksCompleted*10+entry.FocusTime {
+				t.Fatalf("GetGlobalLeaderboard(all)[%d]: rank/score mismatch %+v", i, entry)
+			}
+			if entry.UserID == leader.ID && (entry.TasksCompleted != 3 || entry.FocusTime != 75) {
+				t.Fatalf("GetGlobalLeaderboard(all): leader entry %+v", entry)
+			}
+		}
+
+		// Пользователи без статистики не попадают в лидерборд за период
+		day, err := repos.Leaderboard.GetGlobalLeaderboard(entity.LeaderboardPeriodDay, 1_000_000)
+		if err != nil {
+			t.Fatalf("GetGlobalLeaderboard(day): %v", err)
+		}
+		assertIDs(t, "GetGlobalLeaderboard(day)", idsOf(day, entryUserID, ours...), leader.ID, runnerUp.ID)
+
+		limited, err := repos.Leaderboard.GetGlobalLeaderboard(entity.LeaderboardPeriodAll, 1)
+		if err != nil || len(limited) != 1 || limited[0].Rank != 1 {
+			t.Fatalf("GetGlobalLeaderboard with limit 1: got %v, %v", limited, err)
+		}
+	})
+}
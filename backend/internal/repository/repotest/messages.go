@@ -0,0 +1,113 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testMessages(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		author := newUser(t, repos)
+		session := newSession(t, repos, author, nil)
+		maxMessageID := uuid.New().String()
+		message := newMessage(t, repos, session.ID, author, func(message *entity.Message) {
+			message.MaxMessageID = &maxMessageID
+		})
+
+		got, err := repos.Messages.GetByID(message.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.SessionID != session.ID || got.UserID != author.ID || got.Text != message.Text || got.CreatedAt.IsZero() {
+			t.Fatalf("GetByID: got %+v", got)
+		}
+
+		byMax, err := repos.Messages.GetByMaxMessageID(maxMessageID)
+		if err != nil || byMax == nil || byMax.ID != message.ID {
+			t.Fatalf("GetByMaxMessageID: got %v, %v", byMax, err)
+		}
+	})
+
+	t.Run("NotFoundIsNil", func(t *testing.T) {
+		repos := newRepos(t)
+
+		got, err := repos.Messages.GetByID(uuid.New().String())
+		if err != nil || got != nil {
+			t.Fatalf("GetByID: got %v, %v; want nil, nil", got, err)
+		}
+		byMax, err := repos.Messages.GetByMaxMessageID(uuid.New().String())
+		if err != nil || byMax != nil {
+			t.Fatalf("GetByMaxMessageID: got %v, %v; want nil, nil", byMax, err)
+		}
+	})
+
+	t.Run("DuplicateMaxMessageIDRejected", func(t *testing.T) {
+		repos := newRepos(t)
+		author := newUser(t, repos)
+		session := newSession(t, repos, author, nil)
+		maxMessageID := uuid.New().String()
+		newMessage(t, repos, session.ID, author, func(message *entity.Message) {
+			message.MaxMessageID = &maxMessageID
+		})
+
+		duplicate := &entity.Message{
+			ID:           uuid.New().String(),
+			SessionID:    session.ID,
+			UserID:       author.ID,
+			UserName:     author.Name,
+			Text:         "duplicate",
+			MaxMessageID: &maxMessageID,
+		}
+		if err := repos.Messages.Create(duplicate); err == nil {
+			t.Fatal("Create with duplicate MaxMessageID: want error")
+		}
+	})
+
+	t.Run("GetBySessionID", func(t *testing.T) {
+		repos := newRepos(t)
+		author := newUser(t, repos)
+		session := newSession(t, repos, author, nil)
+		otherSession := newSession(t, repos, author, nil)
+
+		var ids []string
+		var times []time.Time
+		for i := 0; i < 5; i++ {
+			createdAt := baseTime.Add(time.Duration(i) * time.Minute)
+			message := newMessage(t, repos, session.ID, author, func(message *entity.Message) {
+				message.CreatedAt = createdAt
+			})
+			ids = append(ids, message.ID)
+			times = append(times, createdAt)
+		}
+		newMessage(t, repos, otherSession.ID, author, nil)
+
+		// Последние limit сообщений в хронологическом порядке
+		latest, err := repos.Messages.GetBySessionID(session.ID, nil, 3)
+		if err != nil {
+			t.Fatalf("GetBySessionID: %v", err)
+		}
+		if len(latest) != 3 {
+			t.Fatalf("GetBySessionID: got %d messages, want 3", len(latest))
+		}
+		assertIDs(t, "GetBySessionID", idsOf(latest, messageID, ids...), ids[2], ids[3], ids[4])
+
+		// Страница до курсора
+		earlier, err := repos.Messages.GetBySessionID(session.ID, &times[2], 10)
+		if err != nil {
+			t.Fatalf("GetBySessionID with before: %v", err)
+		}
+		if len(earlier) != 2 {
+			t.Fatalf("GetBySessionID with before: got %d messages, want 2", len(earlier))
+		}
+		assertIDs(t, "GetBySessionID with before", idsOf(earlier, messageID, ids...), ids[0], ids[1])
+
+		all, err := repos.Messages.GetBySessionID(session.ID, nil, 0)
+		if err != nil || len(all) != 5 {
+			t.Fatalf("GetBySessionID without limit: got %d messages, %v; want 5", len(all), err)
+		}
+	})
+}
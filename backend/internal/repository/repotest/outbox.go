@@ -0,0 +1,147 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func newOutboxMessage(t *testing.T, repos Repositories, modify func(message *entity.OutboxMessage)) *entity.OutboxMessage {
+	t.Helper()
+
+	message := &entity.OutboxMessage{
+		ID:            uuid.New().String(),
+		Action:        entity.OutboxActionSendMessageToUser,
+		Payload:       `{"maxUserId":1}`,
+		Status:        entity.OutboxStatusPending,
+		NextAttemptAt: baseTime,
+		CreatedAt:     baseTime,
+		UpdatedAt:     baseTime,
+	}
+	if modify != nil {
+		modify(message)
+	}
+	if err := repos.Outbox.Create(message); err != nil {
+		t.Fatalf("create outbox message: %v", err)
+	}
+	return message
+}
+
+func outboxID(message *entity.OutboxMessage) string { return message.ID }
+
+func testOutbox(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		sessionID := uuid.New().String()
+		message := newOutboxMessage(t, repos, func(message *entity.OutboxMessage) {
+			message.SessionID = &sessionID
+		})
+
+		got, err := repos.Outbox.GetByID(message.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.Action != message.Action || got.Payload != message.Payload || got.Status != entity.OutboxStatusPending ||
+			got.SessionID == nil || *got.SessionID != sessionID {
+			t.Fatalf("GetByID: got %+v", got)
+		}
+
+		missing, err := repos.Outbox.GetByID(uuid.New().String())
+		if err != nil || missing != nil {
+			t.Fatalf("GetByID missing: got %v, %v; want nil, nil", missing, err)
+		}
+	})
+
+	t.Run("ClaimDueHidesForLease", func(t *testing.T) {
+		repos := newRepos(t)
+		now := baseTime.Add(time.Hour)
+		due := newOutboxMessage(t, repos, nil)
+		later := newOutboxMessage(t, repos, func(message *entity.OutboxMessage) {
+			message.NextAttemptAt = now.Add(time.Minute)
+		})
+		dead := newOutboxMessage(t, repos, func(message *entity.OutboxMessage) {
+			message.Status = entity.OutboxStatusDead
+		})
+		ids := []string{due.ID, later.ID, dead.ID}
+
+		claimed, err := repos.Outbox.ClaimDue(now, time.Hour, 100)
+		if err != nil {
+			t.Fatalf("ClaimDue: %v", err)
+		}
+		assertIDs(t, "ClaimDue", idsOf(claimed, outboxID, ids...), due.ID)
+
+		// До конца аренды сообщение не выдается повторно, после — снова
+		claimed, err = repos.Outbox.ClaimDue(now.Add(30*time.Minute), time.Hour, 100)
+		if err != nil {
+			t.Fatalf("ClaimDue within lease: %v", err)
+		}
+		assertIDs(t, "ClaimDue within lease", idsOf(claimed, outboxID, ids...), later.ID)
+
+		claimed, err = repos.Outbox.ClaimDue(now.Add(2*time.Hour), time.Hour, 100)
+		if err != nil {
+			t.Fatalf("ClaimDue after lease: %v", err)
+		}
+		assertIDs(t, "ClaimDue after lease", idsOf(claimed, outboxID, ids...), due.ID, later.ID)
+	})
+
+	t.Run("ChangesNeedUpdate", func(t *testing.T) {
+		repos := newRepos(t)
+		message := newOutboxMessage(t, repos, nil)
+
+		claimed, err := repos.Outbox.ClaimDue(baseTime.Add(time.Hour), time.Hour, 100)
+		if err != nil {
+			t.Fatalf("ClaimDue: %v", err)
+		}
+		var delivered *entity.OutboxMessage
+		for _, c := range claimed {
+			if c.ID == message.ID {
+				delivered = c
+			}
+		}
+		if delivered == nil {
+			t.Fatalf("ClaimDue: %s not claimed", message.ID)
+		}
+
+		// Изменения выданного сообщения не видны, пока не вызван Update
+		delivered.Status = entity.OutboxStatusDelivered
+		delivered.Attempts = 1
+		if got, err := repos.Outbox.GetByID(message.ID); err != nil || got.Status != entity.OutboxStatusPending {
+			t.Fatalf("GetByID before Update: got %+v, %v", got, err)
+		}
+
+		if err := repos.Outbox.Update(delivered); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repos.Outbox.GetByID(message.ID)
+		if err != nil || got.Status != entity.OutboxStatusDelivered || got.Attempts != 1 {
+			t.Fatalf("GetByID after Update: got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("ListByStatus", func(t *testing.T) {
+		repos := newRepos(t)
+		older := newOutboxMessage(t, repos, nil)
+		newer := newOutboxMessage(t, repos, func(message *entity.OutboxMessage) {
+			message.CreatedAt = baseTime.Add(time.Minute)
+		})
+		dead := newOutboxMessage(t, repos, func(message *entity.OutboxMessage) {
+			message.Status = entity.OutboxStatusDead
+			message.CreatedAt = baseTime.Add(-time.Minute)
+		})
+		ids := []string{older.ID, newer.ID, dead.ID}
+
+		all, _, err := repos.Outbox.List("", 1, 1000)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		assertIDs(t, "List", idsOf(all, outboxID, ids...), newer.ID, older.ID, dead.ID)
+
+		deadOnly, total, err := repos.Outbox.List(entity.OutboxStatusDead, 1, 1000)
+		if err != nil || total < 1 {
+			t.Fatalf("List dead: total %d, %v", total, err)
+		}
+		assertIDs(t, "List dead", idsOf(deadOnly, outboxID, ids...), dead.ID)
+	})
+}
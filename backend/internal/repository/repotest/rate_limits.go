@@ -0,0 +1,77 @@
+package repotest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testRateLimits(t *testing.T, newRepos Factory) {
+	limit := entity.RateLimit{Requests: 3, Period: time.Minute, Burst: 3}
+
+	t.Run("TakeAndRefill", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "test:" + uuid.New().String()
+
+		for want := 2; want >= 0; want-- {
+			result, err := repos.RateLimits.Take(key, limit, baseTime)
+			if err != nil || !result.Allowed || result.Remaining != want {
+				t.Fatalf("Take: got %+v, %v; want allowed with %d remaining", result, err, want)
+			}
+		}
+
+		denied, err := repos.RateLimits.Take(key, limit, baseTime)
+		if err != nil || denied.Allowed {
+			t.Fatalf("Take over the limit: got %+v, %v", denied, err)
+		}
+		if denied.RetryAfter <= 19*time.Second || denied.RetryAfter > 20*time.Second {
+			t.Fatalf("RetryAfter = %s, want ~20s", denied.RetryAfter)
+		}
+		if denied.ResetAfter <= 59*time.Second || denied.ResetAfter > time.Minute {
+			t.Fatalf("ResetAfter = %s, want ~1m", denied.ResetAfter)
+		}
+
+		refilled, err := repos.RateLimits.Take(key, limit, baseTime.Add(20*time.Second))
+		if err != nil || !refilled.Allowed || refilled.Remaining != 0 {
+			t.Fatalf("Take after refill: got %+v, %v", refilled, err)
+		}
+
+		other, err := repos.RateLimits.Take("test:"+uuid.New().String(), limit, baseTime)
+		if err != nil || !other.Allowed || other.Remaining != 2 {
+			t.Fatalf("Take for another key: got %+v, %v", other, err)
+		}
+	})
+
+	t.Run("ConcurrentTakes", func(t *testing.T) {
+		repos := newRepos(t)
+		key := "test:" + uuid.New().String()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := repos.RateLimits.Take(key, limit, baseTime)
+				if err != nil {
+					t.Errorf("Take: %v", err)
+					return
+				}
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != limit.Burst {
+			t.Fatalf("allowed %d of 10 concurrent requests, want %d", allowed, limit.Burst)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+// Package repotest содержит общий контракт репозиториев из interfaces.
+// Каждая реализация хранилища (memory, gorm на SQLite и Postgres) прогоняется
+// через один и тот же набор проверок:
+//
+//	repotest.Run(t, func(t *testing.T) repotest.Repositories { ... })
+//
+// Проверки создают данные с уникальными ID и сравнивают только свои записи,
+// поэтому их можно запускать и на общей БД, где уже есть чужие данные.
+package repotest
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+// Repositories — набор репозиториев одной реализации хранилища
+type Repositories struct {
+	Users       interfaces.UserRepository
+	Sessions    interfaces.SessionRepository
+	Tasks       interfaces.TaskRepository
+	Messages    interfaces.MessageRepository
+	Leaderboard interfaces.LeaderboardRepository
+	RateLimits  interfaces.RateLimitStore
+	Idempotency interfaces.IdempotencyRepository
+	Series      interfaces.SessionSeriesRepository
+	Templates   interfaces.SessionTemplateRepository
+	Outbox      interfaces.OutboxRepository
+}
+
+// Factory создает репозитории для одного подтеста; освобождение ресурсов —
+// через t.Cleanup
+type Factory func(t *testing.T) Repositories
+
+// Run прогоняет весь контракт против реализации
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepos) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, newRepos) })
+	t.Run("Tasks", func(t *testing.T) { testTasks(t, newRepos) })
+	t.Run("Messages", func(t *testing.T) { testMessages(t, newRepos) })
+	t.Run("Leaderboard", func(t *testing.T) { testLeaderboard(t, newRepos) })
+	t.Run("RateLimits", func(t *testing.T) { testRateLimits(t, newRepos) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, newRepos) })
+	t.Run("Series", func(t *testing.T) { testSeries(t, newRepos) })
+	t.Run("Templates", func(t *testing.T) { testTemplates(t, newRepos) })
+	t.Run("Outbox", func(t *testing.T) { testOutbox(t, newRepos) })
+}
+
+// maxUserIDSeq выдает уникальные MaxUserID в пределах процесса и между запусками
+var maxUserIDSeq = time.Now().UnixNano()
+
+// baseTime — точка отсчета для явных CreatedAt; округлена до секунды, чтобы
+// сравнение не зависело от точности хранения времени в БД
+var baseTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+func newUser(t *testing.T, repos Repositories) *entity.User {
+	t.Helper()
+
+	user := &entity.User{
+		ID:        uuid.New().String(),
+		Name:      "user-" + uuid.New().String()[:8],
+		MaxUserID: atomic.AddInt64(&maxUserIDSeq, 1),
+	}
+	if err := repos.Users.Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func participantOf(user *entity.User) entity.Participant {
+	return entity.Participant{
+		UserID:   user.ID,
+		UserName: user.Name,
+		JoinedAt: time.Now(),
+	}
+}
+
+// newSession создает групповую сессию, где creator — единственный участник;
+// modify позволяет поменять поля до сохранения
+func newSession(t *testing.T, repos Repositories, creator *entity.User, modify func(session *entity.Session)) *entity.Session {
+	t.Helper()
+
+	session := &entity.Session{
+		ID:            uuid.New().String(),
+		Mode:          entity.SessionModeGroup,
+		Status:        entity.SessionStatusPending,
+		FocusDuration: 25,
+		BreakDuration: 5,
+		CreatorID:     creator.ID,
+		InviteLink:    uuid.New().String(),
+		Participants:  []entity.Participant{participantOf(creator)},
+	}
+	if modify != nil {
+		modify(session)
+	}
+	if err := repos.Sessions.Create(session); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session
+}
+
+func newTask(t *testing.T, repos Repositories, sessionID string, owner *entity.User, modify func(task *entity.Task)) *entity.Task {
+	t.Helper()
+
+	task := &entity.Task{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		UserID:    &owner.ID,
+		Title:     "task-" + uuid.New().String()[:8],
+	}
+	if modify != nil {
+		modify(task)
+	}
+	if err := repos.Tasks.Create(task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	return task
+}
+
+func newMessage(t *testing.T, repos Repositories, sessionID string, author *entity.User, modify func(message *entity.Message)) *entity.Message {
+	t.Helper()
+
+	message := &entity.Message{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		UserID:    author.ID,
+		UserName:  author.Name,
+		Text:      "message-" + uuid.New().String()[:8],
+	}
+	if modify != nil {
+		modify(message)
+	}
+	if err := repos.Messages.Create(message); err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+	return message
+}
+
+// idsOf возвращает ID из want в порядке их появления в got (чужие записи пропускаются)
+func idsOf[T any](got []T, id func(T) string, want ...string) []string {
+	wanted := make(map[string]bool, len(want))
+	for _, w := range want {
+		wanted[w] = true
+	}
+
+	ids := make([]string, 0, len(want))
+	for _, item := range got {
+		if wanted[id(item)] {
+			ids = append(ids, id(item))
+		}
+	}
+	return ids
+}
+
+func sessionID(session *entity.Session) string        { return session.ID }
+func summaryID(summary *entity.SessionSummary) string { return summary.ID }
+func taskID(task *entity.Task) string                 { return task.ID }
+func messageID(message *entity.Message) string        { return message.ID }
+func entryUserID(entry *entity.LeaderboardEntry) string {
+	return entry.UserID
+}
+
+func assertIDs(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %v, want %v", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got %v, want %v", what, got, want)
+		}
+	}
+}
+
+func participantIDs(session *entity.Session) map[string]bool {
+	ids := make(map[string]bool, len(session.Participants))
+	for _, p := range session.Participants {
+		ids[p.UserID] = true
+	}
+	return ids
+}
+
+func findParticipant(session *entity.Session, userID string) *entity.Participant {
+	for i := range session.Participants {
+		if session.Participants[i].UserID == userID {
+			return &session.Participants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func newSeries(t *testing.T, repos Repositories, creator *entity.User, members ...*entity.User) *entity.SessionSeries {
+	t.Helper()
+
+	series := &entity.SessionSeries{
+		ID:            uuid.New().String(),
+		CreatorID:     creator.ID,
+		Mode:          entity.SessionModeGroup,
+		FocusDuration: 25,
+		BreakDuration: 5,
+		Tasks:         []string{"plan", "review"},
+		Recurrence:    "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		StartsAt:      baseTime,
+		Timezone:      "Europe/Moscow",
+		InviteLink:    uuid.New().String(),
+	}
+	for i, user := range append([]*entity.User{creator}, members...) {
+		series.Members = append(series.Members, entity.SeriesMember{
+			UserID:   user.ID,
+			UserName: user.Name,
+			JoinedAt: baseTime.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	if err := repos.Series.Create(series); err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	return series
+}
+
+func seriesID(series *entity.SessionSeries) string { return series.ID }
+
+func testSeries(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		member := newUser(t, repos)
+		series := newSeries(t, repos, creator, member)
+
+		for name, get := range map[string]func() (*entity.SessionSeries, error){
+			"GetByID":         func() (*entity.SessionSeries, error) { return repos.Series.GetByID(series.ID) },
+			"GetByInviteLink": func() (*entity.SessionSeries, error) { return repos.Series.GetByInviteLink(series.InviteLink) },
+		} {
+			got, err := get()
+			if err != nil || got == nil {
+				t.Fatalf("%s: got %+v, %v", name, got, err)
+			}
+			if got.Recurrence != series.Recurrence || got.Timezone != "Europe/Moscow" || !got.StartsAt.Equal(baseTime) ||
+				len(got.Tasks) != 2 || got.Tasks[1] != "review" {
+				t.Fatalf("%s: got %+v", name, got)
+			}
+			if len(got.Members) != 2 || got.Members[0].UserID != creator.ID || !got.HasMember(member.ID) {
+				t.Fatalf("%s members: got %+v", name, got.Members)
+			}
+		}
+
+		if got, err := repos.Series.GetByID(uuid.New().String()); err != nil || got != nil {
+			t.Fatalf("GetByID missing: got %+v, %v", got, err)
+		}
+		if got, err := repos.Series.GetByInviteLink(uuid.New().String()); err != nil || got != nil {
+			t.Fatalf("GetByInviteLink missing: got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("MembersAndLists", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		joiner := newUser(t, repos)
+		first := newSeries(t, repos, creator)
+		second := newSeries(t, repos, creator)
+		ids := []string{first.ID, second.ID}
+
+		member := &entity.SeriesMember{SeriesID: first.ID, UserID: joiner.ID, UserName: joiner.Name, JoinedAt: baseTime.Add(time.Hour)}
+		for i := 0; i < 2; i++ {
+			if err := repos.Series.AddMember(member); err != nil {
+				t.Fatalf("AddMember #%d: %v", i+1, err)
+			}
+		}
+		got, err := repos.Series.GetByID(first.ID)
+		if err != nil || len(got.Members) != 2 || got.Members[1].UserID != joiner.ID {
+			t.Fatalf("members after AddMember: got %+v, %v", got, err)
+		}
+
+		list, err := repos.Series.ListByUserID(joiner.ID)
+		if err != nil {
+			t.Fatalf("ListByUserID: %v", err)
+		}
+		assertIDs(t, "ListByUserID member", idsOf(list, seriesID, ids...), first.ID)
+
+		// Остановка сохраняется и убирает серию из активных, состав не теряется
+		endedAt := baseTime.Add(time.Hour)
+		spawnedUntil := baseTime.Add(48 * time.Hour)
+		got.EndedAt = &endedAt
+		got.SpawnedUntil = &spawnedUntil
+		got.Members = nil
+		if err := repos.Series.Update(got); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		reloaded, err := repos.Series.GetByID(first.ID)
+		if err != nil || reloaded.EndedAt == nil || !reloaded.EndedAt.Equal(endedAt) ||
+			reloaded.SpawnedUntil == nil || !reloaded.SpawnedUntil.Equal(spawnedUntil) || len(reloaded.Members) != 2 {
+			t.Fatalf("after Update: got %+v, %v", reloaded, err)
+		}
+
+		active, err := repos.Series.ListActive()
+		if err != nil {
+			t.Fatalf("ListActive: %v", err)
+		}
+		assertIDs(t, "ListActive", idsOf(active, seriesID, ids...), second.ID)
+
+		list, err = repos.Series.ListByUserID(creator.ID)
+		if err != nil {
+			t.Fatalf("ListByUserID: %v", err)
+		}
+		if got := idsOf(list, seriesID, ids...); len(got) != 2 {
+			t.Fatalf("ListByUserID creator: got %v", got)
+		}
+	})
+
+	t.Run("Exceptions", func(t *testing.T) {
+		repos := newRepos(t)
+		series := newSeries(t, repos, newUser(t, repos))
+		first := baseTime.Add(24 * time.Hour)
+		second := baseTime.Add(48 * time.Hour)
+		moved := second.Add(time.Hour)
+		focus := 50
+
+		for _, exception := range []*entity.SeriesException{
+			{SeriesID: series.ID, OccurrenceAt: second, Skipped: true},
+			{SeriesID: series.ID, OccurrenceAt: first, FocusDuration: &focus},
+			// Повтор по тому же повторению заменяет изменение
+			{SeriesID: series.ID, OccurrenceAt: second, ScheduledAt: &moved},
+		} {
+			if err := repos.Series.SaveException(exception); err != nil {
+				t.Fatalf("SaveException: %v", err)
+			}
+		}
+
+		got, err := repos.Series.GetExceptions(series.ID)
+		if err != nil || len(got) != 2 {
+			t.Fatalf("GetExceptions: got %+v, %v", got, err)
+		}
+		if !got[0].OccurrenceAt.Equal(first) || got[0].FocusDuration == nil || *got[0].FocusDuration != 50 {
+			t.Fatalf("first exception: got %+v", got[0])
+		}
+		if !got[1].OccurrenceAt.Equal(second) || got[1].Skipped || got[1].ScheduledAt == nil || !got[1].ScheduledAt.Equal(moved) {
+			t.Fatalf("second exception: got %+v", got[1])
+		}
+	})
+
+	t.Run("SessionsBySeries", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		series := newSeries(t, repos, user)
+
+		instance := func(at time.Time) *entity.Session {
+			return newSession(t, repos, user, func(session *entity.Session) {
+				session.SeriesID = &series.ID
+				session.OccurrenceAt = &at
+				session.ScheduledAt = &at
+			})
+		}
+		later := instance(baseTime.Add(48 * time.Hour))
+		earlier := instance(baseTime.Add(24 * time.Hour))
+		other := newSession(t, repos, user, nil)
+
+		got, err := repos.Sessions.GetBySeriesID(series.ID)
+		if err != nil {
+			t.Fatalf("GetBySeriesID: %v", err)
+		}
+		assertIDs(t, "GetBySeriesID", idsOf(got, sessionID, earlier.ID, later.ID, other.ID), earlier.ID, later.ID)
+		if len(got) != 2 || len(got[0].Participants) != 1 || !got[0].OccurrenceAt.Equal(baseTime.Add(24*time.Hour)) {
+			t.Fatalf("GetBySeriesID: got %+v", got)
+		}
+	})
+}
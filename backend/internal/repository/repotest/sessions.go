@@ -0,0 +1,681 @@
+package repotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testSessions(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		groupName := "focus group"
+		session := newSession(t, repos, creator, func(session *entity.Session) {
+			session.GroupName = &groupName
+			session.IsPrivate = true
+		})
+
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.CreatorID != creator.ID || got.Mode != entity.SessionModeGroup ||
+			got.Status != entity.SessionStatusPending || !got.IsPrivate ||
+			got.FocusDuration != 25 || got.BreakDuration != 5 {
+			t.Fatalf("GetByID: got %+v", got)
+		}
+		if got.GroupName == nil || *got.GroupName != groupName {
+			t.Fatalf("GetByID: group name %v, want %q", got.GroupName, groupName)
+		}
+		if len(got.Participants) != 1 || got.Participants[0].UserID != creator.ID ||
+			got.Participants[0].SessionID != session.ID {
+			t.Fatalf("GetByID: participants %+v", got.Participants)
+		}
+		if len(got.Tasks) != 0 {
+			t.Fatalf("GetByID: want no tasks, got %+v", got.Tasks)
+		}
+
+		byLink, err := repos.Sessions.GetByInviteLink(session.InviteLink)
+		if err != nil || byLink == nil || byLink.ID != session.ID {
+			t.Fatalf("GetByInviteLink: got %v, %v", byLink, err)
+		}
+	})
+
+	t.Run("NotFoundIsNil", func(t *testing.T) {
+		repos := newRepos(t)
+
+		got, err := repos.Sessions.GetByID(uuid.New().String())
+		if err != nil || got != nil {
+			t.Fatalf("GetByID: got %v, %v; want nil, nil", got, err)
+		}
+		byLink, err := repos.Sessions.GetByInviteLink(uuid.New().String())
+		if err != nil || byLink != nil {
+			t.Fatalf("GetByInviteLink: got %v, %v; want nil, nil", byLink, err)
+		}
+		byChat, err := repos.Sessions.GetByMaxChatID(-1)
+		if err != nil || byChat != nil {
+			t.Fatalf("GetByMaxChatID: got %v, %v; want nil, nil", byChat, err)
+		}
+	})
+
+	t.Run("DuplicateInviteLinkRejected", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+
+		duplicate := &entity.Session{
+			ID:            uuid.New().String(),
+			Mode:          entity.SessionModeSolo,
+			Status:        entity.SessionStatusPending,
+			FocusDuration: 25,
+			BreakDuration: 5,
+			CreatorID:     creator.ID,
+			InviteLink:    session.InviteLink,
+		}
+		if err := repos.Sessions.Create(duplicate); err == nil {
+			t.Fatal("Create with duplicate invite link: want error")
+		}
+	})
+
+	t.Run("GetByMaxChatID", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		chatID := time.Now().UnixNano()
+		session := newSession(t, repos, creator, nil)
+
+		session.MaxChatID = &chatID
+		if err := repos.Sessions.Update(session); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repos.Sessions.GetByMaxChatID(chatID)
+		if err != nil || got == nil || got.ID != session.ID {
+			t.Fatalf("GetByMaxChatID: got %v, %v", got, err)
+		}
+		if len(got.Participants) != 1 {
+			t.Fatalf("GetByMaxChatID: participants %+v", got.Participants)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+
+		startedAt := time.Now().Truncate(time.Second)
+		session.Status = entity.SessionStatusActive
+		session.StartedAt = &startedAt
+		session.CurrentCycle = 2
+		session.CyclePlan = &entity.CyclePlan{Cycles: 3, Sequence: []entity.CycleStep{{Focus: 50, Break: 10}}}
+		if err := repos.Sessions.Update(session); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.Status != entity.SessionStatusActive || got.CurrentCycle != 2 {
+			t.Fatalf("GetByID after Update: got %+v", got)
+		}
+		if got.StartedAt == nil || !got.StartedAt.Equal(startedAt) {
+			t.Fatalf("GetByID after Update: startedAt %v, want %v", got.StartedAt, startedAt)
+		}
+		if got.CyclePlan == nil || got.CyclePlan.Cycles != 3 || len(got.CyclePlan.Sequence) != 1 ||
+			got.CyclePlan.Sequence[0].Focus != 50 {
+			t.Fatalf("GetByID after Update: cyclePlan %+v", got.CyclePlan)
+		}
+		if len(got.Participants) != 1 {
+			t.Fatalf("Update must keep participants, got %+v", got.Participants)
+		}
+	})
+
+	t.Run("UpdateIfStatus", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+
+		// Копия, прочитанная до того, как сессию запустили
+		stale, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || stale == nil {
+			t.Fatalf("GetByID: got %v, %v", stale, err)
+		}
+
+		startedAt := time.Now().Truncate(time.Second)
+		session.Status = entity.SessionStatusActive
+		session.StartedAt = &startedAt
+		updated, err := repos.Sessions.UpdateIfStatus(session, entity.SessionStatusPending)
+		if err != nil || !updated {
+			t.Fatalf("UpdateIfStatus(pending): got %v, %v", updated, err)
+		}
+
+		stale.Status = entity.SessionStatusCancelled
+		updated, err = repos.Sessions.UpdateIfStatus(stale, entity.SessionStatusPending)
+		if err != nil || updated {
+			t.Fatalf("UpdateIfStatus with a stale status: got %v, %v", updated, err)
+		}
+
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.Status != entity.SessionStatusActive || got.StartedAt == nil || !got.StartedAt.Equal(startedAt) {
+			t.Fatalf("GetByID after UpdateIfStatus: got %+v", got)
+		}
+		if len(got.Participants) != 1 {
+			t.Fatalf("UpdateIfStatus must keep participants, got %+v", got.Participants)
+		}
+
+		missing := *session
+		missing.ID = uuid.New().String()
+		if updated, err := repos.Sessions.UpdateIfStatus(&missing, entity.SessionStatusActive); err != nil || updated {
+			t.Fatalf("UpdateIfStatus for a missing session: got %v, %v", updated, err)
+		}
+	})
+
+	t.Run("Participants", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		member := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+
+		participant := participantOf(member)
+		if err := repos.Sessions.AddParticipant(session.ID, &participant); err != nil {
+			t.Fatalf("AddParticipant: %v", err)
+		}
+		duplicate := participantOf(member)
+		if err := repos.Sessions.AddParticipant(session.ID, &duplicate); err == nil {
+			t.Fatal("AddParticipant twice: want error")
+		}
+
+		if err := repos.Sessions.UpdateParticipantReady(session.ID, member.ID, true); err != nil {
+			t.Fatalf("UpdateParticipantReady: %v", err)
+		}
+		if err := repos.Sessions.UpdateParticipantReady(session.ID, uuid.New().String(), true); err != nil {
+			t.Fatalf("UpdateParticipantReady for a stranger: %v", err)
+		}
+
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		ids := participantIDs(got)
+		if len(ids) != 2 || !ids[creator.ID] || !ids[member.ID] {
+			t.Fatalf("participants after AddParticipant: %+v", got.Participants)
+		}
+		if p := findParticipant(got, member.ID); p == nil || !p.IsReady || p.SessionID != session.ID {
+			t.Fatalf("participant after UpdateParticipantReady: %+v", p)
+		}
+		if p := findParticipant(got, creator.ID); p == nil || p.IsReady {
+			t.Fatalf("creator must stay not ready: %+v", p)
+		}
+
+		if err := repos.Sessions.RemoveParticipant(session.ID, member.ID); err != nil {
+			t.Fatalf("RemoveParticipant: %v", err)
+		}
+		if err := repos.Sessions.RemoveParticipant(session.ID, member.ID); err != nil {
+			t.Fatalf("RemoveParticipant twice: %v", err)
+		}
+
+		got, err = repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		ids = participantIDs(got)
+		if len(ids) != 1 || !ids[creator.ID] {
+			t.Fatalf("participants after RemoveParticipant: %+v", got.Participants)
+		}
+	})
+
+	t.Run("TasksPreloaded", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+		first := newTask(t, repos, session.ID, creator, nil)
+		second := newTask(t, repos, session.ID, creator, nil)
+
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		tasks := make(map[string]bool)
+		for _, task := range got.Tasks {
+			tasks[task.ID] = true
+		}
+		if len(tasks) != 2 || !tasks[first.ID] || !tasks[second.ID] {
+			t.Fatalf("GetByID: tasks %+v", got.Tasks)
+		}
+	})
+
+	t.Run("GetActiveByUserID", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		stranger := newUser(t, repos)
+
+		newSession(t, repos, user, nil)
+		newSession(t, repos, user, func(session *entity.Session) {
+			session.Status = entity.SessionStatusCompleted
+		})
+
+		got, err := repos.Sessions.GetActiveByUserID(user.ID)
+		if err != nil || got != nil {
+			t.Fatalf("GetActiveByUserID without active sessions: got %v, %v", got, err)
+		}
+
+		for _, status := range []entity.SessionStatus{entity.SessionStatusActive, entity.SessionStatusPaused} {
+			owner := newUser(t, repos)
+			session := newSession(t, repos, owner, func(session *entity.Session) {
+				session.Status = status
+			})
+
+			got, err := repos.Sessions.GetActiveByUserID(owner.ID)
+			if err != nil || got == nil || got.ID != session.ID {
+				t.Fatalf("GetActiveByUserID for %s session: got %v, %v", status, got, err)
+			}
+			if len(got.Participants) != 1 {
+				t.Fatalf("GetActiveByUserID: participants %+v", got.Participants)
+			}
+		}
+
+		got, err = repos.Sessions.GetActiveByUserID(stranger.ID)
+		if err != nil || got != nil {
+			t.Fatalf("GetActiveByUserID for a non-participant: got %v, %v", got, err)
+		}
+	})
+
+	t.Run("ListPagesByCursor", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		other := newUser(t, repos)
+
+		// Две сессии с одинаковым created_at: порядок между ними задает id
+		var ids []string
+		for _, offset := range []time.Duration{0, time.Minute, time.Minute} {
+			session := newSession(t, repos, user, func(session *entity.Session) {
+				session.CreatedAt = baseTime.Add(offset)
+			})
+			ids = append(ids, session.ID)
+		}
+		if ids[1] < ids[2] {
+			ids[1], ids[2] = ids[2], ids[1]
+		}
+		newSession(t, repos, other, nil)
+		newTask(t, repos, ids[0], user, nil)
+		newTask(t, repos, ids[0], user, func(task *entity.Task) {
+			task.Completed = true
+		})
+
+		page, err := repos.Sessions.List(entity.SessionFilter{UserID: user.ID, Limit: 2})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		assertIDs(t, "List page 1", idsOf(page, summaryID, ids...), ids[1], ids[2])
+
+		// Курсор переживает кодирование в строку: сравнение идет со значением из БД
+		cursor, err := entity.ParseSessionCursor(entity.NewSessionCursor(page[1], entity.SessionSortCreated).Encode(), entity.SessionSortCreated)
+		if err != nil {
+			t.Fatalf("ParseSessionCursor: %v", err)
+		}
+		page, err = repos.Sessions.List(entity.SessionFilter{UserID: user.ID, Limit: 2, After: cursor})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		assertIDs(t, "List page 2", idsOf(page, summaryID, ids...), ids[0])
+		last := page[0]
+		if last.TasksTotal != 2 || last.TasksCompleted != 1 || last.ParticipantsCount != 1 ||
+			len(last.Participants) != 1 || last.Participants[0].UserID != user.ID {
+			t.Fatalf("List summary: %+v", last)
+		}
+
+		page, err = repos.Sessions.List(entity.SessionFilter{
+			UserID: user.ID,
+			Limit:  2,
+			After:  entity.NewSessionCursor(last, entity.SessionSortCreated),
+		})
+		if err != nil || len(page) != 0 {
+			t.Fatalf("List past the end: got %d sessions, %v", len(page), err)
+		}
+	})
+
+	t.Run("ListFilters", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		owner := newUser(t, repos)
+		chatID := int64(time.Now().UnixNano())
+
+		own := newSession(t, repos, user, func(session *entity.Session) {
+			session.Mode = entity.SessionModeSolo
+			session.Status = entity.SessionStatusCompleted
+			session.CreatedAt = baseTime
+		})
+		joined := newSession(t, repos, owner, func(session *entity.Session) {
+			session.MaxChatID = &chatID
+			session.CreatedAt = baseTime.Add(time.Hour)
+			session.Participants = append(session.Participants, participantOf(user))
+		})
+		private := newSession(t, repos, user, func(session *entity.Session) {
+			session.IsPrivate = true
+			session.Status = entity.SessionStatusCancelled
+			session.CreatedAt = baseTime.Add(2 * time.Hour)
+		})
+		newSession(t, repos, owner, nil)
+		ids := []string{own.ID, joined.ID, private.ID}
+
+		yes, no := true, false
+		from, to := baseTime.Add(time.Hour), baseTime.Add(2*time.Hour)
+		for name, tc := range map[string]struct {
+			filter entity.SessionFilter
+			want   []string
+		}{
+			"Any":         {entity.SessionFilter{}, []string{private.ID, joined.ID, own.ID}},
+			"Creator":     {entity.SessionFilter{Role: entity.SessionRoleCreator}, []string{private.ID, own.ID}},
+			"Participant": {entity.SessionFilter{Role: entity.SessionRoleParticipant}, []string{joined.ID}},
+			"Statuses": {entity.SessionFilter{Statuses: []entity.SessionStatus{
+				entity.SessionStatusCompleted, entity.SessionStatusCancelled,
+			}}, []string{private.ID, own.ID}},
+			"Mode":       {entity.SessionFilter{Mode: entity.SessionModeGroup}, []string{private.ID, joined.ID}},
+			"DateRange":  {entity.SessionFilter{CreatedFrom: &from, CreatedTo: &to}, []string{joined.ID}},
+			"HasChat":    {entity.SessionFilter{HasChat: &yes}, []string{joined.ID}},
+			"NoChat":     {entity.SessionFilter{HasChat: &no}, []string{private.ID, own.ID}},
+			"PublicOnly": {entity.SessionFilter{PublicOnly: true}, []string{joined.ID, own.ID}},
+		} {
+			tc.filter.UserID = user.ID
+			tc.filter.Limit = 10
+			got, err := repos.Sessions.List(tc.filter)
+			if err != nil {
+				t.Fatalf("List %s: %v", name, err)
+			}
+			assertIDs(t, "List "+name, idsOf(got, summaryID, ids...), tc.want...)
+		}
+	})
+
+	t.Run("ListDiscovery", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		guest := newUser(t, repos)
+		now := time.Now()
+
+		text := func(value string) *string { return &value }
+		soon, later := now.Add(5*time.Minute), now.Add(24*time.Hour)
+		english := newSession(t, repos, user, func(session *entity.Session) {
+			session.GroupName = text("Morning Writers")
+			session.Language = text("en")
+			session.Tags = []entity.SessionTag{{Tag: "writing"}, {Tag: "english"}}
+			session.ScheduledAt = &later
+			session.CreatedAt = baseTime
+		})
+		golang := newSession(t, repos, user, func(session *entity.Session) {
+			session.Topic = text("Go 100%_done")
+			session.Language = text("ru")
+			session.Tags = []entity.SessionTag{{Tag: "go"}, {Tag: "writing"}}
+			session.ScheduledAt = &soon
+			session.CreatedAt = baseTime.Add(time.Minute)
+			session.Participants = append(session.Participants, participantOf(guest))
+		})
+		open := newSession(t, repos, user, func(session *entity.Session) {
+			session.CreatedAt = baseTime.Add(2 * time.Minute)
+		})
+		ids := []string{english.ID, golang.ID, open.ID}
+
+		got, err := repos.Sessions.GetByID(golang.ID)
+		if err != nil || len(got.Tags) != 2 {
+			t.Fatalf("GetByID tags: got %+v, %v", got, err)
+		}
+
+		for name, tc := range map[string]struct {
+			filter entity.SessionFilter
+			want   []string
+		}{
+			"SearchName":    {entity.SessionFilter{Search: "WRITERS"}, []string{english.ID}},
+			"SearchTopic":   {entity.SessionFilter{Search: "100%_"}, []string{golang.ID}},
+			"SearchLiteral": {entity.SessionFilter{Search: "1_0"}, nil},
+			"Tags":          {entity.SessionFilter{Tags: []string{"writing"}}, []string{golang.ID, english.ID}},
+			"AllTags":       {entity.SessionFilter{Tags: []string{"writing", "go"}}, []string{golang.ID}},
+			"Language":      {entity.SessionFilter{Language: "en"}, []string{english.ID}},
+			"Joinable":      {entity.SessionFilter{JoinableAt: &now, Capacity: 5}, []string{open.ID, golang.ID}},
+			"JoinableFull":  {entity.SessionFilter{JoinableAt: &now, Capacity: 2}, []string{open.ID}},
+		} {
+			tc.filter.UserID = user.ID
+			tc.filter.Limit = 10
+			got, err := repos.Sessions.List(tc.filter)
+			if err != nil {
+				t.Fatalf("List %s: %v", name, err)
+			}
+			assertIDs(t, "List "+name, idsOf(got, summaryID, ids...), tc.want...)
+			if name == "Tags" && strings.Join(got[1].Tags, ",") != "english,writing" {
+				t.Fatalf("List summary tags: %v", got[1].Tags)
+			}
+		}
+
+		// Сортировки листаются по одной сессии, курсор проходит через строку
+		for sortBy, want := range map[entity.SessionSort][]string{
+			entity.SessionSortStart:   {open.ID, golang.ID, english.ID},
+			entity.SessionSortSeats:   {open.ID, english.ID, golang.ID},
+			entity.SessionSortPopular: {golang.ID, open.ID, english.ID},
+		} {
+			var order []string
+			filter := entity.SessionFilter{UserID: user.ID, Sort: sortBy, Limit: 1}
+			for len(order) <= len(want) {
+				page, err := repos.Sessions.List(filter)
+				if err != nil {
+					t.Fatalf("List sorted by %s: %v", sortBy, err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				order = append(order, page[0].ID)
+				filter.After, err = entity.ParseSessionCursor(entity.NewSessionCursor(page[0], sortBy).Encode(), sortBy)
+				if err != nil {
+					t.Fatalf("ParseSessionCursor: %v", err)
+				}
+			}
+			assertIDs(t, "List sorted by "+string(sortBy), order, want...)
+		}
+	})
+
+	t.Run("GetScheduledBefore", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		now := time.Now()
+
+		scheduled := func(at time.Time, status entity.SessionStatus) *entity.Session {
+			return newSession(t, repos, user, func(session *entity.Session) {
+				session.ScheduledAt = &at
+				session.Status = status
+				session.Quorum = 2
+			})
+		}
+		overdue := scheduled(now.Add(-time.Hour), entity.SessionStatusPending)
+		soon := scheduled(now.Add(10*time.Minute), entity.SessionStatusPending)
+		later := scheduled(now.Add(2*time.Hour), entity.SessionStatusPending)
+		started := scheduled(now.Add(-time.Hour), entity.SessionStatusActive)
+		unscheduled := newSession(t, repos, user, nil)
+		ids := []string{overdue.ID, soon.ID, later.ID, started.ID, unscheduled.ID}
+
+		got, err := repos.Sessions.GetScheduledBefore(now.Add(15 * time.Minute))
+		if err != nil {
+			t.Fatalf("GetScheduledBefore: %v", err)
+		}
+		assertIDs(t, "GetScheduledBefore", idsOf(got, sessionID, ids...), overdue.ID, soon.ID)
+		for _, session := range got {
+			if session.ID == soon.ID && (session.Quorum != 2 || len(session.Participants) != 1) {
+				t.Fatalf("GetScheduledBefore: got %+v", session)
+			}
+		}
+
+		// Отметка о напоминании переживает сохранение
+		remindedAt := now.Truncate(time.Second)
+		soon.RemindedAt = &remindedAt
+		if err := repos.Sessions.Update(soon); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		reloaded, err := repos.Sessions.GetByID(soon.ID)
+		if err != nil || reloaded.RemindedAt == nil || !reloaded.RemindedAt.Equal(remindedAt) {
+			t.Fatalf("RemindedAt after Update: got %+v, %v", reloaded, err)
+		}
+	})
+
+	t.Run("ListsNewestFirst", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+
+		var ids []string
+		for i := 0; i < 3; i++ {
+			session := newSession(t, repos, user, func(session *entity.Session) {
+				session.Status = entity.SessionStatusCompleted
+				session.CreatedAt = baseTime.Add(time.Duration(i) * time.Minute)
+			})
+			ids = append(ids, session.ID)
+		}
+		pending := newSession(t, repos, user, func(session *entity.Session) {
+			session.CreatedAt = baseTime.Add(time.Hour)
+		})
+
+		byStatus, err := repos.Sessions.GetSessionsByStatus(entity.SessionStatusCompleted)
+		if err != nil {
+			t.Fatalf("GetSessionsByStatus: %v", err)
+		}
+		assertIDs(t, "GetSessionsByStatus",
+			idsOf(byStatus, sessionID, append(ids, pending.ID)...), ids[2], ids[1], ids[0])
+
+		all, err := repos.Sessions.List(entity.SessionFilter{UserID: user.ID, Limit: 10})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		assertIDs(t, "List", idsOf(all, summaryID, append(ids, pending.ID)...), pending.ID, ids[2], ids[1], ids[0])
+	})
+
+	t.Run("CountByStatus", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+
+		// Хранилище может быть общим с другими тестами — сравниваем приращение
+		before, err := repos.Sessions.CountByStatus()
+		if err != nil {
+			t.Fatalf("CountByStatus: %v", err)
+		}
+		for _, status := range []entity.SessionStatus{
+			entity.SessionStatusActive, entity.SessionStatusActive, entity.SessionStatusPaused,
+		} {
+			newSession(t, repos, user, func(session *entity.Session) {
+				session.Status = status
+			})
+		}
+
+		after, err := repos.Sessions.CountByStatus()
+		if err != nil {
+			t.Fatalf("CountByStatus: %v", err)
+		}
+		want := map[entity.SessionStatus]int{
+			entity.SessionStatusActive:    2,
+			entity.SessionStatusPaused:    1,
+			entity.SessionStatusCompleted: 0,
+		}
+		for status, delta := range want {
+			if got := after[status] - before[status]; got != delta {
+				t.Fatalf("CountByStatus[%s]: grew by %d, want %d", status, got, delta)
+			}
+		}
+	})
+
+	t.Run("DeleteRestorePurge", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := newUser(t, repos)
+		session := newSession(t, repos, creator, nil)
+		kept := newTask(t, repos, session.ID, creator, nil)
+		removed := newTask(t, repos, session.ID, creator, nil)
+		message := newMessage(t, repos, session.ID, creator, nil)
+		if err := repos.Tasks.Delete(removed.ID); err != nil {
+			t.Fatalf("Tasks.Delete: %v", err)
+		}
+
+		before := time.Now().Add(-time.Second)
+		if err := repos.Sessions.Delete(session.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if got, err := repos.Sessions.GetByID(session.ID); err != nil || got != nil {
+			t.Fatalf("GetByID after Delete: got %v, %v; want nil, nil", got, err)
+		}
+		if got, err := repos.Sessions.GetByInviteLink(session.InviteLink); err != nil || got != nil {
+			t.Fatalf("GetByInviteLink after Delete: got %v, %v; want nil, nil", got, err)
+		}
+		if got, err := repos.Tasks.GetByID(kept.ID); err != nil || got != nil {
+			t.Fatalf("Tasks.GetByID after Delete: got %v, %v; want nil, nil", got, err)
+		}
+		if got, err := repos.Messages.GetBySessionID(session.ID, nil, 10); err != nil || len(got) != 0 {
+			t.Fatalf("Messages.GetBySessionID after Delete: got %v, %v", got, err)
+		}
+
+		deleted, err := repos.Sessions.GetDeletedByID(session.ID)
+		if err != nil || deleted == nil || deleted.CreatorID != creator.ID || !deleted.DeletedAt.Valid ||
+			deleted.DeletedAt.Time.Before(before) {
+			t.Fatalf("GetDeletedByID: got %+v, %v", deleted, err)
+		}
+		expired, err := repos.Sessions.GetDeletedBefore(time.Now().Add(time.Second))
+		if err != nil || !containsSession(expired, session.ID) {
+			t.Fatalf("GetDeletedBefore(now): got %v, %v; want the deleted session", expired, err)
+		}
+		expired, err = repos.Sessions.GetDeletedBefore(before)
+		if err != nil || containsSession(expired, session.ID) {
+			t.Fatalf("GetDeletedBefore(past): got %v, %v; want no deleted session", expired, err)
+		}
+
+		// Восстанавливаются только задачи, удаленные вместе с сессией
+		if err := repos.Sessions.Restore(session.ID); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		got, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || got == nil || got.DeletedAt.Valid {
+			t.Fatalf("GetByID after Restore: got %+v, %v", got, err)
+		}
+		if len(got.Tasks) != 1 || got.Tasks[0].ID != kept.ID {
+			t.Fatalf("GetByID after Restore: tasks %+v, want only %s", got.Tasks, kept.ID)
+		}
+		if messages, err := repos.Messages.GetBySessionID(session.ID, nil, 10); err != nil ||
+			len(messages) != 1 || messages[0].ID != message.ID {
+			t.Fatalf("Messages.GetBySessionID after Restore: got %v, %v", messages, err)
+		}
+		if deleted, err := repos.Sessions.GetDeletedByID(session.ID); err != nil || deleted != nil {
+			t.Fatalf("GetDeletedByID after Restore: got %v, %v; want nil, nil", deleted, err)
+		}
+
+		// Purge не трогает живые сессии и стирает удаленные
+		if err := repos.Sessions.Purge(session.ID); err != nil {
+			t.Fatalf("Purge of live session: %v", err)
+		}
+		if got, err := repos.Sessions.GetByID(session.ID); err != nil || got == nil {
+			t.Fatalf("GetByID after Purge of live session: got %v, %v", got, err)
+		}
+		if err := repos.Sessions.Delete(session.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repos.Sessions.Purge(session.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if deleted, err := repos.Sessions.GetDeletedByID(session.ID); err != nil || deleted != nil {
+			t.Fatalf("GetDeletedByID after Purge: got %v, %v; want nil, nil", deleted, err)
+		}
+		if err := repos.Sessions.Restore(session.ID); err != nil {
+			t.Fatalf("Restore after Purge: %v", err)
+		}
+		if got, err := repos.Sessions.GetByID(session.ID); err != nil || got != nil {
+			t.Fatalf("GetByID after Purge: got %v, %v; want nil, nil", got, err)
+		}
+		if got, err := repos.Tasks.GetByID(kept.ID); err != nil || got != nil {
+			t.Fatalf("Tasks.GetByID after Purge: got %v, %v; want nil, nil", got, err)
+		}
+	})
+}
+
+func containsSession(sessions []*entity.Session, id string) bool {
+	for _, session := range sessions {
+		if session.ID == id {
+			return true
+		}
+	}
+	return false
+}
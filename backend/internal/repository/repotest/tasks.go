@@ -0,0 +1,141 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testTasks(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		session := newSession(t, repos, owner, nil)
+		task := newTask(t, repos, session.ID, owner, nil)
+
+		got, err := repos.Tasks.GetByID(task.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.SessionID != session.ID || got.Title != task.Title || got.Completed ||
+			got.UserID == nil || *got.UserID != owner.ID {
+			t.Fatalf("GetByID: got %+v", got)
+		}
+
+		missing, err := repos.Tasks.GetByID(uuid.New().String())
+		if err != nil || missing != nil {
+			t.Fatalf("GetByID for a missing task: got %v, %v; want nil, nil", missing, err)
+		}
+	})
+
+	t.Run("ListsInCreationOrder", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		member := newUser(t, repos)
+		session := newSession(t, repos, owner, nil)
+		otherSession := newSession(t, repos, owner, nil)
+
+		var ids []string
+		for i, user := range []*entity.User{member, owner, member} {
+			task := newTask(t, repos, session.ID, user, func(task *entity.Task) {
+				task.CreatedAt = baseTime.Add(time.Duration(2-i) * time.Minute)
+			})
+			ids = append(ids, task.ID)
+		}
+		newTask(t, repos, otherSession.ID, member, nil)
+
+		bySession, err := repos.Tasks.GetBySessionID(session.ID)
+		if err != nil {
+			t.Fatalf("GetBySessionID: %v", err)
+		}
+		if len(bySession) != 3 {
+			t.Fatalf("GetBySessionID: got %d tasks, want 3", len(bySession))
+		}
+		assertIDs(t, "GetBySessionID", idsOf(bySession, taskID, ids...), ids[2], ids[1], ids[0])
+
+		byUser, err := repos.Tasks.GetBySessionIDAndUserID(session.ID, member.ID)
+		if err != nil {
+			t.Fatalf("GetBySessionIDAndUserID: %v", err)
+		}
+		if len(byUser) != 2 {
+			t.Fatalf("GetBySessionIDAndUserID: got %d tasks, want 2", len(byUser))
+		}
+		assertIDs(t, "GetBySessionIDAndUserID", idsOf(byUser, taskID, ids...), ids[2], ids[0])
+
+		empty, err := repos.Tasks.GetBySessionID(uuid.New().String())
+		if err != nil || len(empty) != 0 {
+			t.Fatalf("GetBySessionID for a missing session: got %v, %v", empty, err)
+		}
+	})
+
+	t.Run("UpdateAndCount", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		member := newUser(t, repos)
+		session := newSession(t, repos, owner, nil)
+
+		first := newTask(t, repos, session.ID, owner, nil)
+		newTask(t, repos, session.ID, owner, nil)
+		newTask(t, repos, session.ID, member, nil)
+
+		completedAt := time.Now().Truncate(time.Second)
+		first.Completed = true
+		first.CompletedAt = &completedAt
+		if err := repos.Tasks.Update(first); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repos.Tasks.GetByID(first.ID)
+		if err != nil || got == nil || !got.Completed || got.CompletedAt == nil || !got.CompletedAt.Equal(completedAt) {
+			t.Fatalf("GetByID after Update: got %+v, %v", got, err)
+		}
+
+		total, completed, err := repos.Tasks.CountBySessionIDAndUserID(session.ID, owner.ID)
+		if err != nil || total != 2 || completed != 1 {
+			t.Fatalf("CountBySessionIDAndUserID: got %d/%d, %v; want 2/1", total, completed, err)
+		}
+		total, completed, err = repos.Tasks.CountBySessionIDAndUserID(session.ID, uuid.New().String())
+		if err != nil || total != 0 || completed != 0 {
+			t.Fatalf("CountBySessionIDAndUserID for a stranger: got %d/%d, %v", total, completed, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		session := newSession(t, repos, owner, nil)
+		task := newTask(t, repos, session.ID, owner, func(task *entity.Task) {
+			task.Completed = true
+		})
+		kept := newTask(t, repos, session.ID, owner, nil)
+
+		if err := repos.Tasks.Delete(task.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repos.Tasks.Delete(uuid.New().String()); err != nil {
+			t.Fatalf("Delete for a missing task: %v", err)
+		}
+
+		got, err := repos.Tasks.GetByID(task.ID)
+		if err != nil || got != nil {
+			t.Fatalf("GetByID after Delete: got %v, %v; want nil, nil", got, err)
+		}
+
+		tasks, err := repos.Tasks.GetBySessionID(session.ID)
+		if err != nil || len(tasks) != 1 || tasks[0].ID != kept.ID {
+			t.Fatalf("GetBySessionID after Delete: got %v, %v", tasks, err)
+		}
+
+		total, completed, err := repos.Tasks.CountBySessionIDAndUserID(session.ID, owner.ID)
+		if err != nil || total != 1 || completed != 0 {
+			t.Fatalf("CountBySessionIDAndUserID after Delete: got %d/%d, %v; want 1/0", total, completed, err)
+		}
+
+		withTasks, err := repos.Sessions.GetByID(session.ID)
+		if err != nil || withTasks == nil || len(withTasks.Tasks) != 1 {
+			t.Fatalf("session tasks after Delete: got %v, %v", withTasks, err)
+		}
+	})
+}
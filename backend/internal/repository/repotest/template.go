@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func newTemplate(t *testing.T, repos Repositories, owner *entity.User, createdAt time.Time) *entity.SessionTemplate {
+	t.Helper()
+
+	template := &entity.SessionTemplate{
+		ID:            uuid.New().String(),
+		OwnerID:       owner.ID,
+		Name:          "morning",
+		Mode:          entity.SessionModeGroup,
+		FocusDuration: 50,
+		BreakDuration: 10,
+		Tags:          []string{"go"},
+		Tasks:         []string{"plan", "review"},
+		Roster:        []entity.TemplateMember{},
+		ShareLink:     uuid.New().String(),
+		CreatedAt:     createdAt,
+	}
+	if err := repos.Templates.Create(template); err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	return template
+}
+
+func testTemplates(t *testing.T, newRepos Factory) {
+	t.Run("CreateGetUpdateDelete", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		member := newUser(t, repos)
+		template := newTemplate(t, repos, owner, baseTime)
+
+		for name, get := range map[string]func() (*entity.SessionTemplate, error){
+			"GetByID":        func() (*entity.SessionTemplate, error) { return repos.Templates.GetByID(template.ID) },
+			"GetByShareLink": func() (*entity.SessionTemplate, error) { return repos.Templates.GetByShareLink(template.ShareLink) },
+		} {
+			got, err := get()
+			if err != nil || got == nil {
+				t.Fatalf("%s: got %+v, %v", name, got, err)
+			}
+			if got.Name != "morning" || got.OwnerID != owner.ID || got.FocusDuration != 50 ||
+				len(got.Tasks) != 2 || got.Tasks[1] != "review" || len(got.Tags) != 1 || len(got.Roster) != 0 {
+				t.Fatalf("%s: got %+v", name, got)
+			}
+		}
+
+		template.Name = "evening"
+		template.Tasks = []string{"read"}
+		template.Roster = []entity.TemplateMember{{UserID: member.ID, UserName: member.Name}}
+		if err := repos.Templates.Update(template); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := repos.Templates.GetByID(template.ID)
+		if err != nil || got == nil {
+			t.Fatalf("get after update: got %+v, %v", got, err)
+		}
+		if got.Name != "evening" || len(got.Tasks) != 1 || len(got.Roster) != 1 || got.Roster[0].UserID != member.ID ||
+			got.ShareLink != template.ShareLink {
+			t.Fatalf("after update: got %+v", got)
+		}
+
+		if err := repos.Templates.Delete(template.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if got, err := repos.Templates.GetByID(template.ID); err != nil || got != nil {
+			t.Fatalf("GetByID deleted: got %+v, %v", got, err)
+		}
+		if got, err := repos.Templates.GetByShareLink(template.ShareLink); err != nil || got != nil {
+			t.Fatalf("GetByShareLink deleted: got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("ListByOwnerID", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := newUser(t, repos)
+		other := newUser(t, repos)
+		older := newTemplate(t, repos, owner, baseTime)
+		newer := newTemplate(t, repos, owner, baseTime.Add(time.Minute))
+		newTemplate(t, repos, other, baseTime)
+
+		got, err := repos.Templates.ListByOwnerID(owner.ID)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != newer.ID || got[1].ID != older.ID {
+			t.Fatalf("list: got %d templates, want newer then older", len(got))
+		}
+
+		if got, err := repos.Templates.ListByOwnerID(uuid.New().String()); err != nil || len(got) != 0 {
+			t.Fatalf("list empty: got %+v, %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+func testUsers(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		avatar := "https://example.com/avatar.png"
+		user := newUser(t, repos)
+		user.AvatarURL = &avatar
+		if err := repos.Users.Update(user); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+
+		got, err := repos.Users.GetByID(user.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.Name != user.Name || got.MaxUserID != user.MaxUserID {
+			t.Fatalf("GetByID: got %+v, want %+v", got, user)
+		}
+		if got.AvatarURL == nil || *got.AvatarURL != avatar {
+			t.Fatalf("GetByID: avatar %v, want %q", got.AvatarURL, avatar)
+		}
+		if got.CreatedAt.IsZero() {
+			t.Fatal("GetByID: CreatedAt is not set")
+		}
+
+		byMax, err := repos.Users.GetByMaxUserID(user.MaxUserID)
+		if err != nil || byMax == nil || byMax.ID != user.ID {
+			t.Fatalf("GetByMaxUserID: got %v, %v", byMax, err)
+		}
+	})
+
+	t.Run("NotFoundIsNil", func(t *testing.T) {
+		repos := newRepos(t)
+
+		got, err := repos.Users.GetByID(uuid.New().String())
+		if err != nil || got != nil {
+			t.Fatalf("GetByID: got %v, %v; want nil, nil", got, err)
+		}
+		byMax, err := repos.Users.GetByMaxUserID(-1)
+		if err != nil || byMax != nil {
+			t.Fatalf("GetByMaxUserID: got %v, %v; want nil, nil", byMax, err)
+		}
+	})
+
+	t.Run("DuplicatesRejected", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+
+		sameID := &entity.User{ID: user.ID, Name: "dup", MaxUserID: user.MaxUserID + 1_000_000}
+		if err := repos.Users.Create(sameID); err == nil {
+			t.Fatal("Create with duplicate ID: want error")
+		}
+		sameMax := &entity.User{ID: uuid.New().String(), Name: "dup", MaxUserID: user.MaxUserID}
+		if err := repos.Users.Create(sameMax); err == nil {
+			t.Fatal("Create with duplicate MaxUserID: want error")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+
+		user.Name = "renamed"
+		if err := repos.Users.Update(user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repos.Users.GetByID(user.ID)
+		if err != nil || got == nil || got.Name != "renamed" {
+			t.Fatalf("GetByID after Update: got %v, %v", got, err)
+		}
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+		name := user.Name
+
+		user.Name = "changed without Update"
+		got, err := repos.Users.GetByID(user.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByID: got %v, %v", got, err)
+		}
+		if got.Name != name {
+			t.Fatalf("stored user changed through the Create argument: %q", got.Name)
+		}
+
+		got.Name = "changed result"
+		again, _ := repos.Users.GetByID(user.ID)
+		if again.Name != name {
+			t.Fatalf("stored user changed through a returned value: %q", again.Name)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		repos := newRepos(t)
+		user := newUser(t, repos)
+
+		stats, err := repos.Users.GetStats(user.ID)
+		if err != nil || stats == nil {
+			t.Fatalf("GetStats: got %v, %v", stats, err)
+		}
+		if stats.UserID != user.ID || stats.TotalSessions != 0 || stats.TotalFocusTime != 0 {
+			t.Fatalf("GetStats: want empty stats, got %+v", stats)
+		}
+
+		stats.TotalSessions = 3
+		stats.TotalFocusTime = 75
+		stats.CurrentStreak = 2
+		if err := repos.Users.UpdateStats(user.ID, stats); err != nil {
+			t.Fatalf("UpdateStats: %v", err)
+		}
+
+		got, err := repos.Users.GetStats(user.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetStats after UpdateStats: got %v, %v", got, err)
+		}
+		if got.TotalSessions != 3 || got.TotalFocusTime != 75 || got.CurrentStreak != 2 {
+			t.Fatalf("GetStats after UpdateStats: got %+v", got)
+		}
+	})
+}
@@ -1,45 +1,54 @@
 package router
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/metrics"
+	"github.com/rnegic/synchronous/internal/transport/http/middleware"
 )
 
-func New() *gin.Engine {
+// New создает gin с общими middleware. trustedProxies — прокси, чьему X-Forwarded-For
+// верим при определении IP клиента (c.ClientIP)
+func New(log *slog.Logger, m *metrics.Metrics, origins *middleware.AllowedOrigins, trustedProxies []string) *gin.Engine {
 	// Устанавливаем режим gin (release для продакшена)
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Error("invalid trusted proxies, X-Forwarded-For is ignored", logger.Err(err))
+		router.SetTrustedProxies(nil)
+	}
 
 	// CORS configuration for HTTP-only cookies
+	// Список origin задается в конфиге (SERVER.CORS_ORIGINS) и перезагружается на лету
 	config := cors.Config{
-		AllowOrigins: []string{
-			"http://localhost:3000",  // Local development
-			"http://focus-sync.ru",   // Production HTTP
-			"https://focus-sync.ru",  // Production HTTPS
-			"https://st.max.ru",      // MAX CDN (loads max-web-app.js)
-			"https://webappcdn.max.ru", // MAX WebApp CDN
-			"https://max.ru",         // MAX main domain
-		},
+		AllowOriginFunc: origins.Allowed,
 		AllowMethods: []string{
 			"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS",
 		},
 		AllowHeaders: []string{
-			"Origin", "Content-Type", "Accept", "Authorization",
+			"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader,
+			middleware.IdempotencyKeyHeader,
 		},
 		ExposeHeaders: []string{
-			"Content-Length",
+			"Content-Length", middleware.RequestIDHeader, "Retry-After",
+			middleware.RateLimitLimitHeader, middleware.RateLimitRemainingHeader,
+			middleware.RateLimitResetHeader, middleware.RateLimitPolicyHeader,
+			middleware.IdempotencyReplayedHeader,
 		},
 		AllowCredentials: true, // Critical for cookies
 		MaxAge:           12 * time.Hour,
 	}
 
 	// Добавляем middleware
+	router.Use(middleware.RequestLogger(log))
+	router.Use(middleware.Recovery(log))
+	router.Use(middleware.Metrics(m))
 	router.Use(cors.New(config))
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
 
 	return router
 }
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"sort"
 	"strings"
@@ -14,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
 	"github.com/rnegic/synchronous/pkg/jwt"
 )
 
@@ -21,43 +24,38 @@ type AuthService struct {
 	userRepo     interfaces.UserRepository
 	tokenManager *jwt.TokenManager
 	botToken     string
+	log          *slog.Logger
 }
 
 func NewAuthService(
 	userRepo interfaces.UserRepository,
 	tokenManager *jwt.TokenManager,
 	botToken string,
+	log *slog.Logger,
 ) interfaces.AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
 		tokenManager: tokenManager,
 		botToken:     botToken,
+		log:          logger.Component(log, "auth"),
 	}
 }
 
-func (s *AuthService) Login(initData, deviceID string) (*entity.AuthTokens, *entity.User, error) {
+func (s *AuthService) Login(ctx context.Context, initData, deviceID string) (*entity.AuthTokens, *entity.User, error) {
 	_ = deviceID
 
-	fmt.Printf("[Auth Service] 📥 Login attempt\n")
-	fmt.Printf("[Auth Service]   initData length: %d\n", len(initData))
-	fmt.Printf("[Auth Service]   initData preview: %.100s...\n", initData)
-
+	// initData, hash и ключи подписи в лог не пишем — только причину отказа
 	payload, err := validateInitData(initData, s.botToken)
 	if err != nil {
-		fmt.Printf("[Auth Service] ❌ Validation failed: %v\n", err)
 		return nil, nil, fmt.Errorf("failed to validate init data: %w", err)
 	}
 
-	fmt.Printf("[Auth Service] ✅ Validation successful\n")
-
-	startParam := payload["start_param"]
-	if startParam != "" {
-		fmt.Printf("[Auth Service] 📎 Found start_param: %s\n", startParam)
+	if startParam := payload["start_param"]; startParam != "" {
+		s.log.DebugContext(ctx, "login with start_param", "start_param", startParam)
 	}
 
 	userJSON, ok := payload["user"]
 	if !ok || strings.TrimSpace(userJSON) == "" {
-		fmt.Printf("[Auth Service] ❌ Missing user payload\n")
 		return nil, nil, fmt.Errorf("init data missing user payload")
 	}
 
@@ -156,15 +154,11 @@ type maxInitDataUser struct {
 }
 
 func validateInitData(initData, botToken string) (map[string]string, error) {
-	fmt.Printf("[Validation] 🔍 Starting validation\n")
-
 	if strings.TrimSpace(initData) == "" {
-		fmt.Printf("[Validation] ❌ initData is empty\n")
 		return nil, fmt.Errorf("init data is empty")
 	}
 
 	if strings.TrimSpace(botToken) == "" {
-		fmt.Printf("[Validation] ❌ botToken is not configured\n")
 		return nil, fmt.Errorf("bot token is not configured")
 	}
 
@@ -173,20 +167,16 @@ func validateInitData(initData, botToken string) (map[string]string, error) {
 	if err != nil {
 		decodedInitData = initData
 	}
-	fmt.Printf("[Validation] 📝 Decoded initData: %.100s...\n", decodedInitData)
 
 	values, err := url.ParseQuery(decodedInitData)
 	if err != nil {
-		fmt.Printf("[Validation] ❌ Failed to parse query: %v\n", err)
 		return nil, fmt.Errorf("unable to parse init data: %w", err)
 	}
 
 	hash := values.Get("hash")
 	if strings.TrimSpace(hash) == "" {
-		fmt.Printf("[Validation] ❌ hash is missing\n")
 		return nil, fmt.Errorf("init data missing hash")
 	}
-	fmt.Printf("[Validation] 🔑 Extracted hash: %s\n", hash)
 	values.Del("hash")
 
 	keys := make([]string, 0, len(values))
@@ -194,7 +184,6 @@ func validateInitData(initData, botToken string) (map[string]string, error) {
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
-	fmt.Printf("[Validation] 📋 Sorted keys: %v\n", keys)
 
 	var sb strings.Builder
 	for i, key := range keys {
@@ -206,34 +195,26 @@ func validateInitData(initData, botToken string) (map[string]string, error) {
 		}
 	}
 	dataCheckString := sb.String()
-	fmt.Printf("[Validation] 📄 data_check_string (first 200 chars): %.200s\n", dataCheckString)
 
 	// 1. Создаем secret_key = HMAC_SHA256("WebAppData", botToken)
 	secretKeyMac := hmac.New(sha256.New, []byte("WebAppData"))
 	secretKeyMac.Write([]byte(botToken))
 	secretKey := secretKeyMac.Sum(nil)
-	fmt.Printf("[Validation] 🔐 secret_key: %x\n", secretKey)
 
 	// 2. Вычисляем hash = HMAC_SHA256(secret_key, data_check_string)
 	mac := hmac.New(sha256.New, secretKey)
 	mac.Write([]byte(dataCheckString))
 	expectedHash := mac.Sum(nil)
-	fmt.Printf("[Validation] 🎯 expected_hash: %x\n", expectedHash)
 
 	providedHash, err := hex.DecodeString(hash)
 	if err != nil {
-		fmt.Printf("[Validation] ❌ Invalid hash format: %v\n", err)
 		return nil, fmt.Errorf("invalid hash format: %w", err)
 	}
-	fmt.Printf("[Validation] 📨 provided_hash: %x\n", providedHash)
 
 	if !hmac.Equal(expectedHash, providedHash) {
-		fmt.Printf("[Validation] ❌ Hash mismatch!\n")
 		return nil, fmt.Errorf("init data hash mismatch")
 	}
 
-	fmt.Printf("[Validation] ✅ Validation successful!\n")
-
 	result := make(map[string]string, len(keys))
 	for _, key := range keys {
 		result[key] = values.Get(key)
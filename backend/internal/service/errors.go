@@ -0,0 +1,33 @@
+package service
+
+import "github.com/rnegic/synchronous/internal/entity"
+
+// Общие доменные ошибки сервисов; транспорт отображает их по категории и коду
+var (
+	errSessionNotFound     = entity.NewNotFoundError("session_not_found", "session not found")
+	errSessionAccessDenied = entity.NewForbiddenError("session_access_denied", "access denied")
+	errSessionStarted      = entity.NewInvalidStateError("session_already_started", "session already started")
+	errSessionFull         = entity.NewConflictError("session_full", "session is full")
+	errSessionChanged      = entity.NewConflictError("session_changed", "session was changed concurrently, retry")
+	errTaskNotFound        = entity.NewNotFoundError("task_not_found", "task not found")
+	errTaskNotOwned        = entity.NewForbiddenError("task_not_owned", "task does not belong to user")
+	errUserNotFound        = entity.NewNotFoundError("user_not_found", "user not found")
+	errChatNotCreated      = entity.NewNotFoundError("chat_not_created", "chat not created for this session")
+	errSeriesNotFound      = entity.NewNotFoundError("series_not_found", "series not found")
+	errSeriesAccessDenied  = entity.NewForbiddenError("series_access_denied", "access denied")
+	errSeriesEnded         = entity.NewInvalidStateError("series_ended", "series is stopped")
+	errSeriesFull          = entity.NewConflictError("series_full", "series is full")
+	errNotSeriesCreator    = entity.NewForbiddenError("not_series_creator", "only the series creator can change it")
+	errTemplateNotFound    = entity.NewNotFoundError("template_not_found", "template not found")
+	errTemplateNotOwned    = entity.NewForbiddenError("template_not_owned", "template belongs to another user")
+)
+
+// errNotCreator ошибка действия, доступного только создателю сессии
+func errNotCreator(message string) error {
+	return entity.NewForbiddenError("not_session_creator", message)
+}
+
+// errNotParticipant ошибка действия, доступного только участникам сессии
+func errNotParticipant(message string) error {
+	return entity.NewForbiddenError("not_session_participant", message)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+// loadInvitees загружает приглашаемых пользователей без повторов и без самого
+// приглашающего; вместе с ним их не больше maxSize
+func loadInvitees(userRepo interfaces.UserRepository, inviterID string, userIDs []string, maxSize int) ([]*entity.User, error) {
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id != inviterID && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids)+1 > maxSize {
+		return nil, entity.NewValidationError("invalid_invitees",
+			fmt.Sprintf("at most %d users can be invited", maxSize-1),
+			map[string]string{"inviteUserIds": "max"})
+	}
+
+	invitees := make([]*entity.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := userRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user: %w", err)
+		}
+		if user == nil {
+			return nil, errUserNotFound
+		}
+		invitees = append(invitees, user)
+	}
+	return invitees, nil
+}
+
+// enqueueInvites ставит в outbox приглашение бота каждому из invitees. Участниками
+// они становятся, только когда сами вступят по ссылке-приглашению
+func enqueueInvites(outbox interfaces.OutboxRepository, sessionID string, invitees []*entity.User, text string) error {
+	for _, user := range invitees {
+		if err := enqueueOutbox(outbox, entity.OutboxActionSendMessageToUser, sessionID, sendMessageToUserPayload{
+			MaxUserID: user.MaxUserID,
+			Message:   &maxapi.SendMessageRequest{Text: text},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -28,9 +28,16 @@ func NewLeaderboardService(
 // GetSessionLeaderboard возвращает лидерборд для сессии
 func (s *LeaderboardService) GetSessionLeaderboard(sessionID string, userID string) ([]*entity.LeaderboardEntry, error) {
 	// Проверяем доступ к сессии
-	_, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.sessionRepo.GetByID(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return nil, errSessionNotFound
+	}
+	// У отмененной сессии нет результатов
+	if session.Status == entity.SessionStatusCancelled {
+		return []*entity.LeaderboardEntry{}, nil
 	}
 
 	// Получаем записи лидерборда из репозитория
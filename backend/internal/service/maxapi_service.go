@@ -1,61 +1,102 @@
 package service
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/rnegic/synchronous/internal/interfaces"
 	"github.com/rnegic/synchronous/pkg/maxapi"
 )
 
+// maxAPICallTimeout ограничивает один вызов сервиса вместе со всеми повторами
+const maxAPICallTimeout = 60 * time.Second
+
 type MaxAPIService struct {
 	client  *maxapi.Client
 	baseURL string
+	opts    []maxapi.Option
+
+	// ctx родительский для всех вызовов; отменяется в Close
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewMaxAPIService(baseURL, accessToken string) interfaces.MaxAPIService {
-	client, err := maxapi.NewClient(baseURL, accessToken)
+func NewMaxAPIService(baseURL, accessToken string, opts ...maxapi.Option) (interfaces.MaxAPIService, error) {
+	client, err := maxapi.NewClient(baseURL, accessToken, opts...)
 	if err != nil {
-		log.Fatalf("failed to initialize Max API client: %v", err)
+		return nil, fmt.Errorf("failed to initialize Max API client: %w", err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &MaxAPIService{
 		client:  client,
 		baseURL: baseURL,
-	}
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+func (s *MaxAPIService) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, maxAPICallTimeout)
+}
+
+// SetRateLimit меняет лимит запросов в секунду к Max API
+func (s *MaxAPIService) SetRateLimit(rps float64) {
+	s.client.SetRateLimit(rps, int(rps))
+}
+
+// Close прерывает текущие вызовы и закрывает соединения с Max API
+func (s *MaxAPIService) Close() {
+	s.cancel()
+	s.client.Close()
 }
 
 func (s *MaxAPIService) GetBotInfo() (*maxapi.BotInfo, error) {
-	return s.client.GetMyInfo()
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.GetMyInfo(ctx)
 }
 
 func (s *MaxAPIService) GetProfileByToken(accessToken string) (*maxapi.BotInfo, error) {
-	dynamicClient, err := maxapi.NewClient(s.baseURL, accessToken)
+	dynamicClient, err := maxapi.NewClient(s.baseURL, accessToken, s.opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Max API client: %w", err)
 	}
 
-	return dynamicClient.GetMyInfo()
+	ctx, cancel := s.context()
+	defer cancel()
+	return dynamicClient.GetMyInfo(ctx)
 }
 
 func (s *MaxAPIService) SendMessage(chatID int64, text string) error {
 	req := &maxapi.SendMessageRequest{
 		Text: text,
 	}
-	_, err := s.client.SendMessage(chatID, req)
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.SendMessage(ctx, chatID, req)
 	return err
 }
 
 func (s *MaxAPIService) SendMessageToUser(userID int64, message *maxapi.SendMessageRequest) (*maxapi.SendMessageResponse, error) {
-	return s.client.SendMessageToUser(userID, message)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.SendMessageToUser(ctx, userID, message)
 }
 
 func (s *MaxAPIService) GetChat(chatID int64) (*maxapi.Chat, error) {
-	return s.client.GetChat(chatID)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.GetChat(ctx, chatID)
 }
 
 func (s *MaxAPIService) GetChatByLink(chatLink string) (*maxapi.Chat, error) {
-	return s.client.GetChatByLink(chatLink)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.GetChatByLink(ctx, chatLink)
 }
 
 func (s *MaxAPIService) GetUserInfo(userID int64) (*maxapi.MaxUser, error) {
@@ -64,25 +105,41 @@ func (s *MaxAPIService) GetUserInfo(userID int64) (*maxapi.MaxUser, error) {
 }
 
 func (s *MaxAPIService) GetMessages(chatID int64, from, to, count *int64, messageIDs []string) ([]maxapi.Message, error) {
-	return s.client.GetMessages(chatID, from, to, count, messageIDs)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.GetMessages(ctx, chatID, from, to, count, messageIDs)
 }
 
 func (s *MaxAPIService) AddMembers(chatID int64, userIDs []int64) error {
-	return s.client.AddMembers(chatID, userIDs)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.AddMembers(ctx, chatID, userIDs)
 }
 
 func (s *MaxAPIService) GetChatMembers(chatID int64, marker *int64, count *int, userIDs []int64) (*maxapi.ChatMembersResponse, error) {
-	return s.client.GetChatMembers(chatID, marker, count, userIDs)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.GetChatMembers(ctx, chatID, marker, count, userIDs)
 }
 
 func (s *MaxAPIService) EditChat(chatID int64, title *string, icon interface{}) (*maxapi.Chat, error) {
-	return s.client.EditChat(chatID, title, icon)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.EditChat(ctx, chatID, title, icon)
 }
 
 func (s *MaxAPIService) DeleteChat(chatID int64) error {
-	return s.client.DeleteChat(chatID)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.DeleteChat(ctx, chatID)
 }
 
 func (s *MaxAPIService) RemoveMember(chatID int64, userID int64) error {
-	return s.client.RemoveMember(chatID, userID)
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.RemoveMember(ctx, chatID, userID)
+}
+
+func (s *MaxAPIService) GetUpdates(ctx context.Context, marker *int64, limit, timeout int) (*maxapi.UpdatesResponse, error) {
+	return s.client.GetUpdates(ctx, marker, limit, timeout, nil)
 }
@@ -2,15 +2,19 @@ package service
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/pkg/maxapi"
 )
 
 type MessageService struct {
 	sessionService interfaces.SessionService
 	maxAPIService  interfaces.MaxAPIService
+	sessionRepo    interfaces.SessionRepository
 	userRepo       interfaces.UserRepository
 	messageRepo    interfaces.MessageRepository
 }
@@ -18,12 +22,14 @@ type MessageService struct {
 func NewMessageService(
 	sessionService interfaces.SessionService,
 	maxAPIService interfaces.MaxAPIService,
+	sessionRepo interfaces.SessionRepository,
 	userRepo interfaces.UserRepository,
 	messageRepo interfaces.MessageRepository,
 ) interfaces.MessageService {
 	return &MessageService{
 		sessionService: sessionService,
 		maxAPIService:  maxAPIService,
+		sessionRepo:    sessionRepo,
 		userRepo:       userRepo,
 		messageRepo:    messageRepo,
 	}
@@ -34,12 +40,12 @@ func (s *MessageService) GetMessages(sessionID string, userID string, before *ti
 	// Проверяем доступ к сессии
 	session, err := s.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	// Проверяем, что чат создан
 	if session.MaxChatID == nil {
-		return nil, fmt.Errorf("chat not created for this session")
+		return nil, errChatNotCreated
 	}
 
 	// Преобразуем before в Unix timestamp в миллисекундах для Max API
@@ -54,7 +60,7 @@ func (s *MessageService) GetMessages(sessionID string, userID string, before *ti
 	// Получаем сообщения из Max API
 	maxMessages, err := s.maxAPIService.GetMessages(*session.MaxChatID, nil, to, &count, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get messages from Max API: %w", err)
+		return nil, entity.NewUpstreamError("max_api_error", "failed to get messages from Max API", err)
 	}
 
 	// Преобразуем сообщения Max API в entity.Message
@@ -87,18 +93,21 @@ func (s *MessageService) SendMessage(sessionID string, userID string, text strin
 	// Проверяем доступ к сессии
 	session, err := s.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	// Проверяем, что чат создан
 	if session.MaxChatID == nil {
-		return nil, fmt.Errorf("chat not created for this session")
+		return nil, errChatNotCreated
 	}
 
 	// Получаем информацию о пользователе
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, errUserNotFound
 	}
 
 	// Отправляем сообщение через Max API
@@ -106,7 +115,7 @@ func (s *MessageService) SendMessage(sessionID string, userID string, text strin
 	// Отправляем сообщение от имени бота в чат
 	err = s.maxAPIService.SendMessage(*session.MaxChatID, text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send message to Max API: %w", err)
+		return nil, entity.NewUpstreamError("max_api_error", "failed to send message to Max API", err)
 	}
 
 	// Создаем объект сообщения для ответа
@@ -130,18 +139,18 @@ func (s *MessageService) GetChatInfo(sessionID string, userID string) (*entity.M
 	// Проверяем доступ к сессии
 	session, err := s.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	// Проверяем, что чат создан
 	if session.MaxChatID == nil {
-		return nil, fmt.Errorf("chat not created for this session")
+		return nil, errChatNotCreated
 	}
 
 	// Получаем информацию о чате из Max API
 	chat, err := s.maxAPIService.GetChat(*session.MaxChatID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chat info from Max API: %w", err)
+		return nil, entity.NewUpstreamError("max_api_error", "failed to get chat info from Max API", err)
 	}
 
 	chatInfo := &entity.MaxChatInfo{
@@ -158,3 +167,63 @@ func (s *MessageService) GetChatInfo(sessionID string, userID string) (*entity.M
 
 	return chatInfo, nil
 }
+
+// SaveIncomingMessage сохраняет сообщение из чата Max в историю сессии
+func (s *MessageService) SaveIncomingMessage(maxMsg *maxapi.Message) (*entity.Message, error) {
+	if maxMsg == nil || maxMsg.Recipient.ChatID == 0 || maxMsg.Body.Mid == "" {
+		return nil, nil
+	}
+
+	text := strings.TrimSpace(maxMsg.Body.Text)
+	if text == "" {
+		// Вложения без текста пока не сохраняем
+		return nil, nil
+	}
+
+	// Сообщение относится к сессии, только если чат привязан к ней
+	session, err := s.sessionRepo.GetByMaxChatID(maxMsg.Recipient.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session by chat: %w", err)
+	}
+	if session == nil {
+		return nil, nil
+	}
+
+	// Max может доставить одно и то же обновление несколько раз
+	existing, err := s.messageRepo.GetByMaxMessageID(maxMsg.Body.Mid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check message: %w", err)
+	}
+	if existing != nil {
+		return nil, nil
+	}
+
+	// Сообщения от пользователей, которых нет в нашей БД (в том числе от бота), пропускаем
+	user, err := s.userRepo.GetByMaxUserID(maxMsg.Sender.UserID)
+	if err != nil || user == nil {
+		return nil, nil
+	}
+
+	createdAt := time.Now()
+	if maxMsg.Timestamp > 0 {
+		createdAt = time.Unix(maxMsg.Timestamp/1000, (maxMsg.Timestamp%1000)*1000000)
+	}
+
+	maxMessageID := maxMsg.Body.Mid
+	msg := &entity.Message{
+		ID:           uuid.New().String(),
+		SessionID:    session.ID,
+		UserID:       user.ID,
+		UserName:     user.Name,
+		AvatarURL:    user.AvatarURL,
+		Text:         text,
+		MaxMessageID: &maxMessageID,
+		CreatedAt:    createdAt,
+	}
+
+	if err := s.messageRepo.Create(msg); err != nil {
+		return nil, fmt.Errorf("failed to save message: %w", err)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/health"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+const (
+	outboxBatchSize = 10
+	// outboxLease время, на которое сообщение скрывается от других воркеров во время доставки.
+	// Пачка доставляется последовательно, а каждый вызов Max API ограничен maxAPICallTimeout,
+	// поэтому аренды хватает на всю пачку с запасом
+	outboxLease       = outboxBatchSize*maxAPICallTimeout + time.Minute
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 30 * time.Minute
+)
+
+// Параметры действий outbox, сериализуются в OutboxMessage.Payload
+
+type sendMessageToUserPayload struct {
+	MaxUserID int64                      `json:"maxUserId"`
+	Message   *maxapi.SendMessageRequest `json:"message"`
+}
+
+type addChatMembersPayload struct {
+	ChatID     int64   `json:"chatId"`
+	MaxUserIDs []int64 `json:"maxUserIds"`
+}
+
+type deleteChatPayload struct {
+	ChatID int64 `json:"chatId"`
+}
+
+// errPermanent помечает ошибки, которые не исправятся повтором
+var errPermanent = errors.New("permanent outbox failure")
+
+// enqueueOutbox записывает действие в outbox. Вызывается внутри транзакции вместе с изменением состояния
+func enqueueOutbox(repo interfaces.OutboxRepository, action entity.OutboxAction, sessionID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	now := time.Now()
+	message := &entity.OutboxMessage{
+		ID:            uuid.New().String(),
+		Action:        action,
+		Payload:       string(data),
+		Status:        entity.OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if sessionID != "" {
+		message.SessionID = &sessionID
+	}
+
+	if err := repo.Create(message); err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", action, err)
+	}
+
+	return nil
+}
+
+// OutboxService доставляет действия из outbox в Max API с повторами и dead-lettering
+type OutboxService struct {
+	outboxRepo    interfaces.OutboxRepository
+	maxAPIService interfaces.MaxAPIService
+	interval      time.Duration
+	maxAttempts   int
+	log           *slog.Logger
+	heartbeat     *health.Heartbeat
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewOutboxService создает воркер outbox
+func NewOutboxService(
+	outboxRepo interfaces.OutboxRepository,
+	maxAPIService interfaces.MaxAPIService,
+	interval time.Duration,
+	maxAttempts int,
+	log *slog.Logger,
+) *OutboxService {
+	return &OutboxService{
+		outboxRepo:    outboxRepo,
+		maxAPIService: maxAPIService,
+		interval:      interval,
+		maxAttempts:   maxAttempts,
+		log:           logger.Component(log, "outbox"),
+		heartbeat:     health.NewHeartbeat(3*interval + outboxLease),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start запускает периодическую доставку
+func (s *OutboxService) Start() {
+	s.log.Info("starting outbox worker", "interval", s.interval, "max_attempts", s.maxAttempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.heartbeat.Beat()
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.processDue(ctx)
+				s.heartbeat.Beat()
+			}
+		}
+	}()
+}
+
+// Heartbeat отмечает каждый проход доставки — для проверки готовности. Допуск
+// учитывает, что доставка пачки может занять до аренды сообщения
+func (s *OutboxService) Heartbeat() *health.Heartbeat {
+	return s.heartbeat
+}
+
+// Stop останавливает доставку и ждет, пока текущая пачка будет обработана
+func (s *OutboxService) Stop() {
+	s.once.Do(func() {
+		if s.cancel == nil {
+			return
+		}
+		s.cancel()
+		<-s.done
+		s.log.Info("stopped")
+	})
+}
+
+// processDue забирает пачку готовых сообщений и доставляет их. При остановке
+// оставшиеся сообщения пачки не трогаются — их заберут снова после истечения аренды.
+// Сообщение, вызов для которого может не успеть до конца аренды, тоже оставляем:
+// иначе другая реплика заберет его и не идемпотентный вызов уйдет дважды
+func (s *OutboxService) processDue(ctx context.Context) {
+	claimedAt := time.Now()
+	messages, err := s.outboxRepo.ClaimDue(claimedAt, outboxLease, outboxBatchSize)
+	if err != nil {
+		s.log.Error("failed to claim due messages", logger.Err(err))
+		return
+	}
+
+	leaseEnd := claimedAt.Add(outboxLease)
+	for i, message := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Until(leaseEnd) < maxAPICallTimeout {
+			s.log.Warn("outbox lease is running out, leaving the rest of the batch", "left", len(messages)-i)
+			return
+		}
+		s.deliver(message)
+	}
+}
+
+func (s *OutboxService) deliver(message *entity.OutboxMessage) {
+	err := s.dispatch(message)
+	now := time.Now()
+	message.Attempts++
+	message.UpdatedAt = now
+
+	switch {
+	case err == nil:
+		message.Status = entity.OutboxStatusDelivered
+		message.DeliveredAt = &now
+		message.LastError = nil
+	case errors.Is(err, errPermanent) || message.Attempts >= s.maxAttempts:
+		errText := err.Error()
+		message.Status = entity.OutboxStatusDead
+		message.LastError = &errText
+		s.log.Error("message moved to dead letters",
+			"message_id", message.ID, "action", message.Action, "attempts", message.Attempts, logger.Err(err))
+	default:
+		errText := err.Error()
+		message.LastError = &errText
+		message.NextAttemptAt = now.Add(outboxBackoff(message.Attempts))
+		s.log.Warn("delivery failed, will retry",
+			"message_id", message.ID, "action", message.Action, "attempts", message.Attempts,
+			"next_attempt_at", message.NextAttemptAt, logger.Err(err))
+	}
+
+	if err := s.outboxRepo.Update(message); err != nil {
+		s.log.Error("failed to update message", "message_id", message.ID, logger.Err(err))
+	}
+}
+
+// dispatch выполняет вызов Max API, соответствующий действию
+func (s *OutboxService) dispatch(message *entity.OutboxMessage) error {
+	var err error
+
+	switch message.Action {
+	case entity.OutboxActionSendMessageToUser:
+		var payload sendMessageToUserPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", errPermanent, err)
+		}
+		_, err = s.maxAPIService.SendMessageToUser(payload.MaxUserID, payload.Message)
+
+	case entity.OutboxActionAddChatMembers:
+		var payload addChatMembersPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", errPermanent, err)
+		}
+		err = s.maxAPIService.AddMembers(payload.ChatID, payload.MaxUserIDs)
+
+	case entity.OutboxActionDeleteChat:
+		var payload deleteChatPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", errPermanent, err)
+		}
+		err = s.maxAPIService.DeleteChat(payload.ChatID)
+		// Чат уже удалён — цель достигнута
+		if maxapi.IsNotFound(err) {
+			err = nil
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown action %q", errPermanent, message.Action)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	// 4xx кроме 429 означает, что запрос некорректен или запрещён — повтор не поможет
+	var apiErr *maxapi.APIError
+	if errors.As(err, &apiErr) && !apiErr.Temporary() {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	return err
+}
+
+// outboxBackoff экспоненциальная задержка перед следующей попыткой
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff << uint(attempts-1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+// SendMessageToUser ставит в outbox сообщение бота пользователю Max. Для ответов
+// на обновления: ошибка Max API не должна мешать их обработке
+func (s *OutboxService) SendMessageToUser(maxUserID int64, message *maxapi.SendMessageRequest) error {
+	return enqueueOutbox(s.outboxRepo, entity.OutboxActionSendMessageToUser, "", sendMessageToUserPayload{
+		MaxUserID: maxUserID,
+		Message:   message,
+	})
+}
+
+// List возвращает сообщения outbox для админки
+func (s *OutboxService) List(status entity.OutboxStatus, page, limit int) ([]*entity.OutboxMessage, int, error) {
+	return s.outboxRepo.List(status, page, limit)
+}
+
+// Retry возвращает сообщение из dead letters в очередь
+func (s *OutboxService) Retry(id string) (*entity.OutboxMessage, error) {
+	message, err := s.outboxRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox message: %w", err)
+	}
+	if message == nil {
+		return nil, entity.NewNotFoundError("outbox_message_not_found", "outbox message not found")
+	}
+
+	if message.Status == entity.OutboxStatusDelivered {
+		return nil, entity.NewConflictError("outbox_message_delivered", "outbox message already delivered")
+	}
+
+	now := time.Now()
+	message.Status = entity.OutboxStatusPending
+	message.Attempts = 0
+	message.NextAttemptAt = now
+	message.UpdatedAt = now
+
+	if err := s.outboxRepo.Update(message); err != nil {
+		return nil, fmt.Errorf("failed to update outbox message: %w", err)
+	}
+
+	return message, nil
+}
@@ -0,0 +1,710 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+const (
+	seriesUpcomingLimit  = 10                  // повторений в расписании серии
+	seriesUpcomingWindow = 90 * 24 * time.Hour // насколько вперед строится расписание
+	seriesHistoryLimit   = 100                 // повторений в истории; счетчики — по всем
+	// seriesFirstOccurrence — правило должно дать хотя бы одно повторение за этот срок от начала
+	seriesFirstOccurrence = 366 * 24 * time.Hour
+)
+
+// SeriesService ведет повторяющиеся сессии: создает экземпляры на horizon вперед,
+// применяет изменения отдельных повторений и считает историю серии
+type SeriesService struct {
+	seriesRepo  interfaces.SessionSeriesRepository
+	sessionRepo interfaces.SessionRepository
+	userRepo    interfaces.UserRepository
+	transactor  interfaces.Transactor
+	// maxSessionSize — предел участников серии и ее экземпляров, APP.MAX_SESSION_SIZE
+	maxSessionSize int
+	horizon        time.Duration
+}
+
+func NewSeriesService(
+	seriesRepo interfaces.SessionSeriesRepository,
+	sessionRepo interfaces.SessionRepository,
+	userRepo interfaces.UserRepository,
+	transactor interfaces.Transactor,
+	maxSessionSize int,
+	horizon time.Duration,
+) interfaces.SeriesService {
+	return &SeriesService{
+		seriesRepo:     seriesRepo,
+		sessionRepo:    sessionRepo,
+		userRepo:       userRepo,
+		transactor:     transactor,
+		maxSessionSize: maxSessionSize,
+		horizon:        horizon,
+	}
+}
+
+// CreateSeries создает серию и сразу экземпляры повторений в пределах горизонта
+func (s *SeriesService) CreateSeries(userID string, params entity.NewSeriesParams) (*entity.SessionSeries, error) {
+	now := time.Now()
+
+	sessionParams := params.Session
+	sessionParams.ScheduledAt = nil
+	topic, tags, language, _, err := normalizeSessionMeta(sessionParams, now)
+	if err != nil {
+		return nil, err
+	}
+	if sessionParams.Quorum < 0 || sessionParams.Quorum > s.maxSessionSize {
+		return nil, entity.NewValidationError("invalid_quorum",
+			fmt.Sprintf("quorum must be between 0 and %d", s.maxSessionSize),
+			map[string]string{"quorum": "invalid"})
+	}
+
+	rule, err := entity.ParseRecurrence(params.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	timezone := params.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return nil, entity.NewValidationError("invalid_timezone", "timezone must be an IANA name like \"Europe/Moscow\"",
+			map[string]string{"timezone": "invalid"})
+	}
+	if params.StartsAt.Before(now) {
+		return nil, entity.NewValidationError("invalid_starts_at", "startsAt must not be in the past",
+			map[string]string{"startsAt": "min"})
+	}
+	// Повторения и их адреса в API — с точностью до секунды
+	start := params.StartsAt.In(location).Truncate(time.Second)
+	if len(rule.Occurrences(start, start.Add(-time.Nanosecond), start.Add(seriesFirstOccurrence))) == 0 {
+		return nil, entity.NewValidationError("invalid_recurrence", "recurrence has no occurrences within a year of startsAt",
+			map[string]string{"recurrence": "invalid"})
+	}
+
+	if sessionParams.Mode == entity.SessionModeSolo && len(params.InviteUserIDs) > 0 {
+		return nil, entity.NewValidationError("invalid_invitees", "solo series cannot have members",
+			map[string]string{"inviteUserIds": "invalid"})
+	}
+	creator, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if creator == nil {
+		return nil, errUserNotFound
+	}
+	invitees, err := loadInvitees(s.userRepo, userID, params.InviteUserIDs, s.maxSessionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Tag)
+	}
+	tasks := slices.Clone(sessionParams.Tasks)
+	if tasks == nil {
+		tasks = []string{}
+	}
+
+	series := &entity.SessionSeries{
+		ID:            uuid.New().String(),
+		CreatorID:     userID,
+		Mode:          sessionParams.Mode,
+		FocusDuration: sessionParams.FocusDuration,
+		BreakDuration: sessionParams.BreakDuration,
+		GroupName:     sessionParams.GroupName,
+		Topic:         topic,
+		Language:      language,
+		Tags:          tagNames,
+		IsPrivate:     sessionParams.IsPrivate,
+		Quorum:        sessionParams.Quorum,
+		Tasks:         tasks,
+		Recurrence:    rule.String(),
+		StartsAt:      start.Local(),
+		Timezone:      timezone,
+		InviteLink:    uuid.New().String()[:8],
+		Members: []entity.SeriesMember{{
+			UserID:    creator.ID,
+			UserName:  creator.Name,
+			AvatarURL: creator.AvatarURL,
+			JoinedAt:  now,
+		}},
+		CreatedAt: now,
+	}
+
+	// Приглашенные не вступают сами собой: бот присылает им код приглашения серии
+	text := fmt.Sprintf("%s приглашает вас в повторяющуюся сессию «%s». Чтобы вступить, откройте Синхрон и введите код приглашения %s.",
+		creator.Name, seriesTitle(series), series.InviteLink)
+	err = s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Series.Create(series); err != nil {
+			return fmt.Errorf("failed to create series: %w", err)
+		}
+		if err := s.spawn(tx, series, now); err != nil {
+			return err
+		}
+		return enqueueInvites(tx.Outbox, "", invitees, text)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// seriesTitle название серии для сообщений бота
+func seriesTitle(series *entity.SessionSeries) string {
+	switch {
+	case series.GroupName != nil && *series.GroupName != "":
+		return *series.GroupName
+	case series.Topic != nil:
+		return *series.Topic
+	default:
+		return "фокус-сессия"
+	}
+}
+
+// GetSeries возвращает серию и до seriesUpcomingLimit ближайших повторений
+func (s *SeriesService) GetSeries(seriesID string, userID string) (*entity.SessionSeries, []entity.SeriesOccurrence, error) {
+	series, err := s.loadSeries(seriesID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	upcoming, err := s.occurrences(series, now, now.Add(seriesUpcomingWindow), now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(upcoming) > seriesUpcomingLimit {
+		upcoming = upcoming[:seriesUpcomingLimit]
+	}
+	return series, upcoming, nil
+}
+
+func (s *SeriesService) ListSeries(userID string) ([]*entity.SessionSeries, error) {
+	series, err := s.seriesRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	return series, nil
+}
+
+// JoinSeries добавляет пользователя в серию по постоянной ссылке и во все
+// ожидающие экземпляры, где есть место
+func (s *SeriesService) JoinSeries(inviteLink string, userID string) (*entity.SessionSeries, error) {
+	series, err := s.seriesRepo.GetByInviteLink(inviteLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series by invite link: %w", err)
+	}
+	if series == nil {
+		return nil, errSeriesNotFound
+	}
+	if series.HasMember(userID) {
+		return series, nil
+	}
+	if series.EndedAt != nil {
+		return nil, errSeriesEnded
+	}
+	if series.Mode == entity.SessionModeSolo || len(series.Members) >= s.maxSessionSize {
+		return nil, errSeriesFull
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user == nil {
+		return nil, errUserNotFound
+	}
+	instances, err := s.sessionRepo.GetBySeriesID(series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series sessions: %w", err)
+	}
+
+	now := time.Now()
+	err = s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		err := tx.Series.AddMember(&entity.SeriesMember{
+			SeriesID:  series.ID,
+			UserID:    user.ID,
+			UserName:  user.Name,
+			AvatarURL: user.AvatarURL,
+			JoinedAt:  now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add series member: %w", err)
+		}
+
+		for _, instance := range instances {
+			if instance.Status != entity.SessionStatusPending || len(instance.Participants) >= s.maxSessionSize ||
+				isParticipant(instance, userID) {
+				continue
+			}
+			err := tx.Sessions.AddParticipant(instance.ID, &entity.Participant{
+				UserID:    user.ID,
+				UserName:  user.Name,
+				AvatarURL: user.AvatarURL,
+				JoinedAt:  now,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add participant: %w", err)
+			}
+			if _, err := createUserTasks(tx.Tasks, instance.ID, userID, series.Tasks, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.seriesRepo.GetByID(series.ID)
+}
+
+// StopSeries останавливает серию: новые экземпляры не создаются, ожидающие отменяются
+func (s *SeriesService) StopSeries(seriesID string, userID string) error {
+	series, err := s.loadSeries(seriesID, userID)
+	if err != nil {
+		return err
+	}
+	if series.CreatorID != userID {
+		return errNotSeriesCreator
+	}
+	if series.EndedAt != nil {
+		return nil
+	}
+
+	instances, err := s.sessionRepo.GetBySeriesID(series.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get series sessions: %w", err)
+	}
+
+	now := time.Now()
+	series.EndedAt = &now
+	return s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Series.Update(series); err != nil {
+			return fmt.Errorf("failed to update series: %w", err)
+		}
+		for _, instance := range instances {
+			if instance.Status != entity.SessionStatusPending {
+				continue
+			}
+			cancelSession(instance, cancelReasonSeriesEnd, now)
+			// Экземпляр, который планировщик успел начать, не отменяем
+			if _, err := tx.Sessions.UpdateIfStatus(instance, entity.SessionStatusPending); err != nil {
+				return fmt.Errorf("failed to cancel series session: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateOccurrence пропускает одно повторение или меняет его время и длительности.
+// Уже созданный экземпляр меняется вместе с ним, пока он не начался; отмена
+// пропуска возвращает экземпляр или создает его заново
+func (s *SeriesService) UpdateOccurrence(
+	seriesID string, userID string, occurrenceAt time.Time, change entity.OccurrenceChange,
+) (*entity.SeriesOccurrence, error) {
+	series, err := s.loadSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if series.CreatorID != userID {
+		return nil, errNotSeriesCreator
+	}
+	if series.EndedAt != nil {
+		return nil, errSeriesEnded
+	}
+
+	rule, err := entity.ParseRecurrence(series.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	at := occurrenceAt.In(series.Location())
+	if !rule.IsOccurrence(series.StartsAt.In(series.Location()), at) {
+		return nil, entity.NewNotFoundError("occurrence_not_found", "series has no occurrence at this time")
+	}
+	now := time.Now()
+	if change.ScheduledAt != nil && !change.ScheduledAt.After(now) {
+		return nil, entity.NewValidationError("invalid_scheduled_at", "scheduledAt must be in the future",
+			map[string]string{"scheduledAt": "min"})
+	}
+
+	exceptions, err := s.seriesRepo.GetExceptions(series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series exceptions: %w", err)
+	}
+	instances, err := s.sessionRepo.GetBySeriesID(series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series sessions: %w", err)
+	}
+
+	exception := findException(exceptions, at)
+	if exception == nil {
+		exception = &entity.SeriesException{SeriesID: series.ID, OccurrenceAt: at.Local()}
+	}
+	wasSkipped := exception.Skipped
+	instance := findInstance(instances, at)
+	switch {
+	case instance != nil:
+		if instance.Status != entity.SessionStatusPending && (!wasSkipped || instance.Status != entity.SessionStatusCancelled) {
+			return nil, errSessionStarted
+		}
+	case !occurrenceStart(at, exception).After(now):
+		return nil, entity.NewInvalidStateError("occurrence_passed", "occurrence has already passed")
+	}
+
+	if change.Skipped != nil {
+		exception.Skipped = *change.Skipped
+	}
+	if change.ScheduledAt != nil {
+		scheduledAt := change.ScheduledAt.Local()
+		exception.ScheduledAt = &scheduledAt
+	}
+	if change.FocusDuration != nil {
+		exception.FocusDuration = change.FocusDuration
+	}
+	if change.BreakDuration != nil {
+		exception.BreakDuration = change.BreakDuration
+	}
+
+	err = s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Series.SaveException(exception); err != nil {
+			return fmt.Errorf("failed to save series exception: %w", err)
+		}
+
+		switch {
+		case instance != nil:
+			from := instance.Status
+			applyOccurrence(instance, series, exception)
+			// Планировщик мог начать экземпляр после его загрузки
+			updated, err := tx.Sessions.UpdateIfStatus(instance, from)
+			if err != nil {
+				return fmt.Errorf("failed to update series session: %w", err)
+			}
+			if !updated {
+				return errSessionStarted
+			}
+		case !exception.Skipped && series.SpawnedUntil != nil && !at.After(*series.SpawnedUntil) &&
+			occurrenceStart(at, exception).After(now):
+			// Повторение уже пройдено при создании экземпляров и само не появится
+			if _, err := s.createInstance(tx, series, at, exception, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.occurrences(series, at.Add(-time.Nanosecond), at, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(occurrences) == 0 {
+		return nil, fmt.Errorf("occurrence %s disappeared", at.Format(time.RFC3339))
+	}
+	return &occurrences[0], nil
+}
+
+// applyOccurrence переносит изменение повторения на ожидающий экземпляр
+func applyOccurrence(instance *entity.Session, series *entity.SessionSeries, exception *entity.SeriesException) {
+	instance.Status = entity.SessionStatusPending
+	instance.CancelledAt = nil
+	instance.CancelReason = nil
+	if exception.Skipped {
+		cancelSession(instance, cancelReasonSkipped, time.Now())
+	}
+
+	scheduledAt := occurrenceStart(exception.OccurrenceAt, exception).Local()
+	if instance.ScheduledAt == nil || !instance.ScheduledAt.Equal(scheduledAt) {
+		// Напоминания считаются заново от нового начала
+		instance.ScheduledAt = &scheduledAt
+		instance.RemindedAt = nil
+	}
+	instance.FocusDuration, instance.BreakDuration = occurrenceDurations(series, exception)
+}
+
+// GetSeriesHistory возвращает прошедшие повторения и серии завершенных подряд
+func (s *SeriesService) GetSeriesHistory(seriesID string, userID string) (*entity.SeriesHistory, error) {
+	series, err := s.loadSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	occurrences, err := s.occurrences(series, series.StartsAt.Add(-time.Nanosecond), now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &entity.SeriesHistory{Occurrences: make([]entity.SeriesOccurrence, 0, min(len(occurrences), seriesHistoryLimit))}
+	streak := 0
+	for _, occurrence := range occurrences {
+		switch occurrence.Status {
+		case string(entity.SessionStatusCompleted):
+			history.Completed++
+			streak++
+			history.LongestStreak = max(history.LongestStreak, streak)
+		case entity.OccurrenceStatusSkipped:
+			history.Skipped++
+		case string(entity.SessionStatusCancelled), entity.OccurrenceStatusMissed:
+			history.Missed++
+			streak = 0
+		}
+		// Идущие и перенесенные на потом повторения серию пока не меняют
+	}
+	history.CurrentStreak = streak
+
+	for i := len(occurrences) - 1; i >= 0 && len(history.Occurrences) < seriesHistoryLimit; i-- {
+		history.Occurrences = append(history.Occurrences, occurrences[i])
+	}
+	return history, nil
+}
+
+// SpawnUpcoming создает экземпляры всех активных серий. Ошибка одной серии не
+// мешает остальным; возвращается последняя
+func (s *SeriesService) SpawnUpcoming(now time.Time) (err error) {
+	active, err := s.seriesRepo.ListActive()
+	if err != nil {
+		return fmt.Errorf("failed to list active series: %w", err)
+	}
+
+	for _, series := range active {
+		spawnErr := s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+			// Серию могли остановить после выборки
+			current, err := tx.Series.GetByID(series.ID)
+			if err != nil || current == nil || current.EndedAt != nil {
+				return err
+			}
+			return s.spawn(tx, current, now)
+		})
+		if spawnErr != nil {
+			err = fmt.Errorf("series %s: %w", series.ID, spawnErr)
+		}
+	}
+	return err
+}
+
+// spawn создает экземпляры повторений до now+horizon, которые еще не созданы.
+// Пропущенные и уже прошедшие повторения не создаются
+func (s *SeriesService) spawn(tx interfaces.TxRepositories, series *entity.SessionSeries, now time.Time) error {
+	rule, err := entity.ParseRecurrence(series.Recurrence)
+	if err != nil {
+		return err
+	}
+	until := now.Add(s.horizon)
+	after := series.StartsAt.Add(-time.Nanosecond)
+	if series.SpawnedUntil != nil {
+		after = *series.SpawnedUntil
+	}
+	if !until.After(after) {
+		return nil
+	}
+
+	exceptions, err := tx.Series.GetExceptions(series.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get series exceptions: %w", err)
+	}
+	for _, at := range rule.Occurrences(series.StartsAt.In(series.Location()), after, until) {
+		exception := findException(exceptions, at)
+		if exception != nil && exception.Skipped || !occurrenceStart(at, exception).After(now) {
+			continue
+		}
+		if _, err := s.createInstance(tx, series, at, exception, now); err != nil {
+			return err
+		}
+	}
+
+	spawnedUntil := until.Local()
+	series.SpawnedUntil = &spawnedUntil
+	if err := tx.Series.Update(series); err != nil {
+		return fmt.Errorf("failed to update series: %w", err)
+	}
+	return nil
+}
+
+// createInstance создает сессию повторения at со всеми участниками серии и их задачами
+func (s *SeriesService) createInstance(
+	tx interfaces.TxRepositories, series *entity.SessionSeries, at time.Time, exception *entity.SeriesException, now time.Time,
+) (*entity.Session, error) {
+	seriesID := series.ID
+	occurrenceAt := at.Local()
+	scheduledAt := occurrenceStart(at, exception).Local()
+	focusDuration, breakDuration := occurrenceDurations(series, exception)
+
+	session := &entity.Session{
+		ID:            uuid.New().String(),
+		Mode:          series.Mode,
+		Status:        entity.SessionStatusPending,
+		FocusDuration: focusDuration,
+		BreakDuration: breakDuration,
+		GroupName:     series.GroupName,
+		IsPrivate:     series.IsPrivate,
+		Topic:         series.Topic,
+		Language:      series.Language,
+		ScheduledAt:   &scheduledAt,
+		Quorum:        series.Quorum,
+		SeriesID:      &seriesID,
+		OccurrenceAt:  &occurrenceAt,
+		CreatorID:     series.CreatorID,
+		InviteLink:    uuid.New().String()[:8],
+		CreatedAt:     now,
+	}
+	for _, tag := range series.Tags {
+		session.Tags = append(session.Tags, entity.SessionTag{Tag: tag})
+	}
+	for _, member := range series.Members {
+		session.Participants = append(session.Participants, entity.Participant{
+			UserID:    member.UserID,
+			UserName:  member.UserName,
+			AvatarURL: member.AvatarURL,
+			JoinedAt:  now,
+		})
+	}
+
+	if err := tx.Sessions.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create series session: %w", err)
+	}
+	for _, member := range series.Members {
+		if _, err := createUserTasks(tx.Tasks, session.ID, member.UserID, series.Tasks, now); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// occurrences собирает повторения серии в (after, until] с изменениями и
+// созданными экземплярами; now отличает несостоявшиеся от предстоящих
+func (s *SeriesService) occurrences(series *entity.SessionSeries, after, until, now time.Time) ([]entity.SeriesOccurrence, error) {
+	rule, err := entity.ParseRecurrence(series.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if series.EndedAt != nil && series.EndedAt.Before(until) {
+		until = *series.EndedAt
+	}
+	exceptions, err := s.seriesRepo.GetExceptions(series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series exceptions: %w", err)
+	}
+	instances, err := s.sessionRepo.GetBySeriesID(series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series sessions: %w", err)
+	}
+
+	location := series.Location()
+	occurrences := make([]entity.SeriesOccurrence, 0)
+	for _, at := range rule.Occurrences(series.StartsAt.In(location), after, until) {
+		exception := findException(exceptions, at)
+		occurrence := entity.SeriesOccurrence{
+			OccurrenceAt: at,
+			ScheduledAt:  occurrenceStart(at, exception).In(location),
+			Status:       entity.OccurrenceStatusUpcoming,
+		}
+		occurrence.FocusDuration, occurrence.BreakDuration = occurrenceDurations(series, exception)
+
+		if instance := findInstance(instances, at); instance != nil {
+			occurrence.Status = string(instance.Status)
+			occurrence.SessionID = &instance.ID
+			occurrence.FocusDuration, occurrence.BreakDuration = instance.FocusDuration, instance.BreakDuration
+			if instance.ScheduledAt != nil {
+				occurrence.ScheduledAt = instance.ScheduledAt.In(location)
+			}
+		} else if !occurrence.ScheduledAt.After(now) {
+			occurrence.Status = entity.OccurrenceStatusMissed
+		}
+		if exception != nil && exception.Skipped {
+			occurrence.Status = entity.OccurrenceStatusSkipped
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+// loadSeries загружает серию, доступную создателю и участникам
+func (s *SeriesService) loadSeries(seriesID string, userID string) (*entity.SessionSeries, error) {
+	series, err := s.seriesRepo.GetByID(seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+	if series == nil {
+		return nil, errSeriesNotFound
+	}
+	if series.CreatorID != userID && !series.HasMember(userID) {
+		return nil, errSeriesAccessDenied
+	}
+	return series, nil
+}
+
+// createUserTasks создает участнику сессии задачи с заголовками titles
+func createUserTasks(taskRepo interfaces.TaskRepository, sessionID, userID string, titles []string, now time.Time) ([]entity.Task, error) {
+	tasks := make([]entity.Task, 0, len(titles))
+	for _, title := range titles {
+		task := entity.Task{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			UserID:    &userID,
+			Title:     title,
+			CreatedAt: now,
+		}
+		if err := taskRepo.Create(&task); err != nil {
+			return nil, fmt.Errorf("failed to create task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func findException(exceptions []*entity.SeriesException, at time.Time) *entity.SeriesException {
+	for _, exception := range exceptions {
+		if exception.OccurrenceAt.Equal(at) {
+			return exception
+		}
+	}
+	return nil
+}
+
+func findInstance(instances []*entity.Session, at time.Time) *entity.Session {
+	for _, instance := range instances {
+		if instance.OccurrenceAt != nil && instance.OccurrenceAt.Equal(at) {
+			return instance
+		}
+	}
+	return nil
+}
+
+// occurrenceStart начало повторения с учетом переноса
+func occurrenceStart(at time.Time, exception *entity.SeriesException) time.Time {
+	if exception != nil && exception.ScheduledAt != nil {
+		return *exception.ScheduledAt
+	}
+	return at
+}
+
+func occurrenceDurations(series *entity.SessionSeries, exception *entity.SeriesException) (focus int, rest int) {
+	focus, rest = series.FocusDuration, series.BreakDuration
+	if exception != nil && exception.FocusDuration != nil {
+		focus = *exception.FocusDuration
+	}
+	if exception != nil && exception.BreakDuration != nil {
+		rest = *exception.BreakDuration
+	}
+	return focus, rest
+}
+
+func isParticipant(session *entity.Session, userID string) bool {
+	for _, participant := range session.Participants {
+		if participant.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -1,75 +1,334 @@
 package service
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/health"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/metrics"
 )
 
-// SessionCleanupService handles automatic cleanup of stale sessions
+// SessionCleanupService cancels abandoned sessions: pending ones that were never started
+// and active or paused ones without activity and WebSocket connections. Participants get
+// a bot message and the session_cancelled event. An abandoned session whose cycle plan is
+// finished did its work and is completed instead, with the session_completed event. It also
+// purges sessions deleted longer than the restore window ago, together with their tasks,
+// messages and Max chat
 type SessionCleanupService struct {
-	sessionRepo interfaces.SessionRepository
+	sessionRepo    interfaces.SessionRepository
+	sessionService interfaces.SessionService
+	messageRepo    interfaces.MessageRepository
+	userRepo       interfaces.UserRepository
+	transactor     interfaces.Transactor
+	notifier       interfaces.SessionNotifier
+	presence       interfaces.SessionPresence
+	log            *slog.Logger
+	metrics        *metrics.Metrics
+	heartbeat      *health.Heartbeat
+
+	mu          sync.Mutex
 	interval    time.Duration
 	maxAge      time.Duration
+	idleTimeout time.Duration
+	rescheduled chan struct{}
+
+	restoreWindow time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
 }
 
 // NewSessionCleanupService creates a new cleanup service
 func NewSessionCleanupService(
 	sessionRepo interfaces.SessionRepository,
+	sessionService interfaces.SessionService,
+	messageRepo interfaces.MessageRepository,
+	userRepo interfaces.UserRepository,
+	transactor interfaces.Transactor,
+	notifier interfaces.SessionNotifier,
+	presence interfaces.SessionPresence,
 	interval time.Duration,
 	maxAge time.Duration,
+	idleTimeout time.Duration,
+	restoreWindow time.Duration,
+	log *slog.Logger,
+	metrics *metrics.Metrics,
 ) *SessionCleanupService {
 	return &SessionCleanupService{
-		sessionRepo: sessionRepo,
-		interval:    interval,
-		maxAge:      maxAge,
+		sessionRepo:    sessionRepo,
+		sessionService: sessionService,
+		messageRepo:    messageRepo,
+		userRepo:       userRepo,
+		transactor:     transactor,
+		notifier:       notifier,
+		presence:       presence,
+		interval:       interval,
+		maxAge:         maxAge,
+		idleTimeout:    idleTimeout,
+		rescheduled:    make(chan struct{}, 1),
+		restoreWindow:  restoreWindow,
+		log:            logger.Component(log, "session_cleanup"),
+		metrics:        metrics,
+		heartbeat:      health.NewHeartbeat(2 * interval),
+		done:           make(chan struct{}),
 	}
 }
 
 // Start begins the cleanup routine
 func (s *SessionCleanupService) Start() {
-	log.Printf("[SessionCleanup] 🧹 Starting cleanup service (interval: %v, maxAge: %v)\n", s.interval, s.maxAge)
+	interval, maxAge, idleTimeout := s.schedule()
+	s.log.Info("starting cleanup service", "interval", interval, "max_age", maxAge, "idle_timeout", idleTimeout)
 
-	ticker := time.NewTicker(s.interval)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.heartbeat.Beat()
 	go func() {
-		for range ticker.C {
-			s.cleanup()
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.rescheduled:
+				interval, _, _ := s.schedule()
+				ticker.Reset(interval)
+			case <-ticker.C:
+				start := time.Now()
+				cleaned, err := s.cleanup()
+				s.metrics.ObserveCleanup(cleaned, time.Since(start), err)
+				s.heartbeat.Beat()
+			}
 		}
 	}()
 }
 
-// cleanup removes stale pending sessions
-func (s *SessionCleanupService) cleanup() {
-	sessions, err := s.sessionRepo.GetSessionsByStatus(entity.SessionStatusPending)
-	if err != nil {
-		log.Printf("[SessionCleanup] ❌ Failed to get pending sessions: %v\n", err)
-		return
+// SetSchedule changes the interval, the max age of pending sessions and the idle timeout
+// of started ones on the fly, e.g. on config reload. The next pass happens one new interval from now
+func (s *SessionCleanupService) SetSchedule(interval, maxAge, idleTimeout time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.maxAge = maxAge
+	s.idleTimeout = idleTimeout
+	s.mu.Unlock()
+
+	s.heartbeat.SetMaxAge(2 * interval)
+	select {
+	case s.rescheduled <- struct{}{}:
+	default:
 	}
+	s.log.Info("schedule changed", "interval", interval, "max_age", maxAge, "idle_timeout", idleTimeout)
+}
+
+func (s *SessionCleanupService) schedule() (interval, maxAge, idleTimeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval, s.maxAge, s.idleTimeout
+}
+
+// Heartbeat is beaten on start and after every cleanup pass, for readiness checks
+func (s *SessionCleanupService) Heartbeat() *health.Heartbeat {
+	return s.heartbeat
+}
+
+// Stop stops the cleanup routine and waits for a running pass to finish
+func (s *SessionCleanupService) Stop() {
+	s.once.Do(func() {
+		if s.cancel == nil {
+			return
+		}
+		s.cancel()
+		<-s.done
+		s.log.Info("stopped")
+	})
+}
 
+// cleanup cancels or completes abandoned sessions and purges expired deleted ones. It returns
+// the number of such sessions and the last error, if any session could not be checked or updated
+func (s *SessionCleanupService) cleanup() (cleaned int, err error) {
 	now := time.Now()
-	cleaned := 0
+	_, maxAge, idleTimeout := s.schedule()
 
-	for _, session := range sessions {
-		age := now.Sub(session.CreatedAt)
-		if age > s.maxAge {
-			// Mark session as completed (or you could delete it)
-			completedAt := now
-			session.Status = entity.SessionStatusCompleted
-			session.CompletedAt = &completedAt
-
-			if err := s.sessionRepo.Update(session); err != nil {
-				log.Printf("[SessionCleanup] ❌ Failed to cleanup session %s: %v\n", session.ID, err)
+	for _, status := range []entity.SessionStatus{
+		entity.SessionStatusPending, entity.SessionStatusActive, entity.SessionStatusPaused,
+	} {
+		sessions, listErr := s.sessionRepo.GetSessionsByStatus(status)
+		if listErr != nil {
+			s.log.Error("failed to get sessions", "status", status, logger.Err(listErr))
+			return cleaned, listErr
+		}
+
+		for _, session := range sessions {
+			text, checkErr := s.abandoned(session, now, maxAge, idleTimeout)
+			if checkErr == nil && text != "" {
+				checkErr = s.endAbandoned(session, text, now)
+			}
+			if checkErr != nil {
+				s.log.Error("failed to cleanup session", "session_id", session.ID, logger.Err(checkErr))
+				err = checkErr
 				continue
 			}
-
-			cleaned++
-			log.Printf("[SessionCleanup] 🧹 Cleaned up stale session: %s (age: %v)\n", session.ID, age)
+			if text != "" {
+				cleaned++
+			}
 		}
 	}
 
 	if cleaned > 0 {
-		log.Printf("[SessionCleanup] ✅ Cleaned up %d stale sessions\n", cleaned)
+		s.log.Info("cleaned up abandoned sessions", "count", cleaned)
+	}
+	if purgeErr := s.purge(now); purgeErr != nil {
+		err = purgeErr
 	}
+	return cleaned, err
+}
+
+// purge permanently removes sessions deleted before the restore window. The Max chat
+// deletion is enqueued to the outbox in the same transaction
+func (s *SessionCleanupService) purge(now time.Time) (err error) {
+	sessions, err := s.sessionRepo.GetDeletedBefore(now.Add(-s.restoreWindow))
+	if err != nil {
+		s.log.Error("failed to get deleted sessions", logger.Err(err))
+		return err
+	}
+
+	purged := 0
+	for _, session := range sessions {
+		purgeErr := s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+			if session.MaxChatID != nil {
+				if err := enqueueOutbox(tx.Outbox, entity.OutboxActionDeleteChat, session.ID,
+					deleteChatPayload{ChatID: *session.MaxChatID}); err != nil {
+					return err
+				}
+			}
+			return tx.Sessions.Purge(session.ID)
+		})
+		if purgeErr != nil {
+			s.log.Error("failed to purge session", "session_id", session.ID, logger.Err(purgeErr))
+			err = purgeErr
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		s.log.Info("purged deleted sessions", "count", purged)
+	}
+	return err
+}
+
+// abandoned returns the bot message for participants if the session is abandoned, or "".
+// A pending session is abandoned maxAge after its creation or scheduled start; a started one
+// after idleTimeout without changes, tasks, messages and plan progress while nobody is connected
+func (s *SessionCleanupService) abandoned(
+	session *entity.Session, now time.Time, maxAge, idleTimeout time.Duration,
+) (string, error) {
+	if session.Status == entity.SessionStatusPending {
+		// Запланированные сессии считаются от начала; после него их отменяет SessionSchedulerService
+		startAt := session.CreatedAt
+		if session.ScheduledAt != nil {
+			startAt = *session.ScheduledAt
+		}
+		if now.Sub(startAt) <= maxAge {
+			return "", nil
+		}
+		return fmt.Sprintf("Сессия «%s» отменена: ее так и не начали.", sessionTitle(session)), nil
+	}
+
+	lastActivity := session.UpdatedAt
+	for _, task := range session.Tasks {
+		if task.UpdatedAt.After(lastActivity) {
+			lastActivity = task.UpdatedAt
+		}
+	}
+	if progress := planActivity(session, lastActivity, now); progress.After(lastActivity) {
+		lastActivity = progress
+	}
+	if now.Sub(lastActivity) <= idleTimeout {
+		return "", nil
+	}
+	messages, err := s.messageRepo.GetBySessionID(session.ID, nil, 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to get last message: %w", err)
+	}
+	if len(messages) > 0 && now.Sub(messages[0].CreatedAt) <= idleTimeout {
+		return "", nil
+	}
+
+	userIDs := make([]string, 0, len(session.Participants))
+	for _, participant := range session.Participants {
+		userIDs = append(userIDs, participant.UserID)
+	}
+	if s.presence.HasConnections(session.ID, userIDs) {
+		return "", nil
+	}
+	return fmt.Sprintf("Сессия «%s» отменена: в ней давно нет активности.", sessionTitle(session)), nil
+}
+
+// planActivity returns the last moment the cycle plan counts as activity. A running plan of
+// Cycles cycles is activity until it is finished. Without the end of the plan only the focus
+// block going on at lastActivity counts, otherwise such a session would never be abandoned
+func planActivity(session *entity.Session, lastActivity, now time.Time) time.Time {
+	if session.StartedAt == nil {
+		return lastActivity
+	}
+	if finishedAt, finished := session.PlanFinishedAt(now); finished {
+		return finishedAt
+	}
+	if session.PausedAt != nil {
+		return lastActivity
+	}
+	if session.PlanDuration() > 0 {
+		return now
+	}
+	progress := session.Progress(session.ActiveTime(lastActivity))
+	if progress.Phase == entity.CyclePhaseFocus {
+		return lastActivity.Add(progress.PhaseLeft)
+	}
+	return lastActivity
+}
+
+// endAbandoned completes the session if its cycle plan is finished and cancels it otherwise
+func (s *SessionCleanupService) endAbandoned(session *entity.Session, text string, now time.Time) error {
+	if _, finished := session.PlanFinishedAt(now); !finished {
+		return s.cancelAbandoned(session, text, now)
+	}
+
+	completed, err := s.sessionService.CompleteFinishedPlan(session, now)
+	if err != nil || !completed {
+		return err
+	}
+	notifyParticipants(s.notifier, session, "session_completed", map[string]interface{}{
+		"sessionId":     session.ID,
+		"autoCompleted": true,
+	})
+	s.log.Debug("completed abandoned session with finished plan", "session_id", session.ID)
+	return nil
+}
+
+// cancelAbandoned cancels the session and notifies participants
+func (s *SessionCleanupService) cancelAbandoned(session *entity.Session, text string, now time.Time) error {
+	from := session.Status
+	cancelSession(session, cancelReasonAbandoned, now)
+	updated, err := updateAndMessage(s.transactor, s.userRepo, session, from, text)
+	if err != nil || !updated {
+		return err
+	}
+
+	notifyParticipants(s.notifier, session, "session_cancelled", map[string]interface{}{
+		"sessionId": session.ID,
+		"reason":    cancelReasonAbandoned,
+	})
+	s.log.Debug("cancelled abandoned session", "session_id", session.ID)
+	return nil
 }
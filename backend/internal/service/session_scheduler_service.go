@@ -0,0 +1,368 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/health"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+// SessionSchedulerService ведет сессии по времени: создает экземпляры повторяющихся
+// серий, напоминает участникам о начале через бота MAX и WebSocket, запускает сессию,
+// когда к началу готов кворум, отменяет ее, если за NoShowTimeout после начала никто
+// не отметил готовность, и завершает сессии, план циклов которых пройден
+type SessionSchedulerService struct {
+	sessionRepo    interfaces.SessionRepository
+	userRepo       interfaces.UserRepository
+	sessionService interfaces.SessionService
+	seriesService  interfaces.SeriesService
+	transactor     interfaces.Transactor
+	notifier       interfaces.SessionNotifier
+	log            *slog.Logger
+	heartbeat      *health.Heartbeat
+
+	interval      time.Duration
+	reminders     []time.Duration // по убыванию
+	noShowTimeout time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewSessionSchedulerService(
+	sessionRepo interfaces.SessionRepository,
+	userRepo interfaces.UserRepository,
+	sessionService interfaces.SessionService,
+	seriesService interfaces.SeriesService,
+	transactor interfaces.Transactor,
+	notifier interfaces.SessionNotifier,
+	interval time.Duration,
+	reminders []time.Duration,
+	noShowTimeout time.Duration,
+	log *slog.Logger,
+) *SessionSchedulerService {
+	reminders = slices.Clone(reminders)
+	slices.SortFunc(reminders, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+
+	return &SessionSchedulerService{
+		sessionRepo:    sessionRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		seriesService:  seriesService,
+		transactor:     transactor,
+		notifier:       notifier,
+		log:            logger.Component(log, "session_scheduler"),
+		heartbeat:      health.NewHeartbeat(2 * interval),
+		interval:       interval,
+		reminders:      reminders,
+		noShowTimeout:  noShowTimeout,
+		done:           make(chan struct{}),
+	}
+}
+
+// Start запускает проверку запланированных сессий каждые interval
+func (s *SessionSchedulerService) Start() {
+	s.log.Info("starting session scheduler", "interval", s.interval, "reminders", s.reminders,
+		"no_show_timeout", s.noShowTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.heartbeat.Beat()
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.run(time.Now())
+				s.heartbeat.Beat()
+			}
+		}
+	}()
+}
+
+// Heartbeat отмечается при запуске и после каждого прохода, для проверки готовности
+func (s *SessionSchedulerService) Heartbeat() *health.Heartbeat {
+	return s.heartbeat
+}
+
+// Stop останавливает планировщик и ждет завершения текущего прохода
+func (s *SessionSchedulerService) Stop() {
+	s.once.Do(func() {
+		if s.cancel == nil {
+			return
+		}
+		s.cancel()
+		<-s.done
+		s.log.Info("stopped")
+	})
+}
+
+// run создает экземпляры серий, завершает пройденные планы и обрабатывает сессии, которым
+// пора напомнить, начаться или отмениться. Ошибка одной сессии не мешает остальным;
+// возвращается последняя
+func (s *SessionSchedulerService) run(now time.Time) (err error) {
+	if spawnErr := s.seriesService.SpawnUpcoming(now); spawnErr != nil {
+		s.log.Error("failed to spawn series sessions", logger.Err(spawnErr))
+		err = spawnErr
+	}
+
+	completed, completeErr := s.sessionService.CompleteFinishedPlans(now)
+	if completeErr != nil {
+		s.log.Error("failed to complete finished sessions", logger.Err(completeErr))
+		err = completeErr
+	}
+	for _, session := range completed {
+		s.notifyParticipants(session, "session_completed", map[string]interface{}{
+			"sessionId":     session.ID,
+			"autoCompleted": true,
+		})
+		s.log.Info("session completed by cycle plan", "session_id", session.ID, "cycles", session.CyclePlan.Cycles)
+	}
+
+	horizon := time.Duration(0)
+	if len(s.reminders) > 0 {
+		horizon = s.reminders[0]
+	}
+	sessions, listErr := s.sessionRepo.GetScheduledBefore(now.Add(horizon))
+	if listErr != nil {
+		s.log.Error("failed to get scheduled sessions", logger.Err(listErr))
+		return listErr
+	}
+
+	for _, session := range sessions {
+		ready := readyParticipants(session)
+
+		var actionErr error
+		switch {
+		case now.Before(*session.ScheduledAt):
+			actionErr = s.remind(session, now)
+		case session.Quorum > 0 && ready >= session.Quorum:
+			actionErr = s.autoStart(session, now)
+		case ready == 0 && !now.Before(session.ScheduledAt.Add(s.noShowTimeout)):
+			actionErr = s.cancelNoShow(session, now)
+		}
+		if actionErr != nil {
+			s.log.Error("failed to process scheduled session", "session_id", session.ID, logger.Err(actionErr))
+			err = actionErr
+		}
+	}
+	return err
+}
+
+// remind отправляет напоминание, если наступил срок очередного из reminders.
+// Пропущенные сроки не догоняются: уходит одно напоминание за последний из них.
+// Сроки раньше создания сессии не учитываются
+func (s *SessionSchedulerService) remind(session *entity.Session, now time.Time) error {
+	session, err := s.updatePending(session.ID, func(session *entity.Session) (string, bool) {
+		if session.ScheduledAt == nil || !now.Before(*session.ScheduledAt) {
+			return "", false
+		}
+		var due *time.Time
+		for _, offset := range s.reminders {
+			at := session.ScheduledAt.Add(-offset)
+			if !at.After(now) && !at.Before(session.CreatedAt) {
+				due = &at
+			}
+		}
+		if due == nil || (session.RemindedAt != nil && !session.RemindedAt.Before(*due)) {
+			return "", false
+		}
+
+		session.RemindedAt = &now
+		return fmt.Sprintf("Напоминание: сессия «%s» начнется через %d мин. Откройте Синхрон и отметьте готовность.",
+			sessionTitle(session), int(math.Ceil(session.ScheduledAt.Sub(now).Minutes()))), true
+	})
+	if err != nil || session == nil {
+		return err
+	}
+
+	s.notifyParticipants(session, "session_reminder", map[string]interface{}{
+		"sessionId":   session.ID,
+		"scheduledAt": session.ScheduledAt.Format(time.RFC3339),
+		"startsIn":    int(session.ScheduledAt.Sub(now).Seconds()),
+	})
+	s.log.Debug("reminded participants", "session_id", session.ID, "participants", len(session.Participants))
+	return nil
+}
+
+// autoStart запускает сессию, в которой к началу готов кворум участников
+func (s *SessionSchedulerService) autoStart(session *entity.Session, now time.Time) error {
+	session, err := s.updatePending(session.ID, func(session *entity.Session) (string, bool) {
+		if session.ScheduledAt == nil || now.Before(*session.ScheduledAt) ||
+			session.Quorum == 0 || readyParticipants(session) < session.Quorum {
+			return "", false
+		}
+		session.Status = entity.SessionStatusActive
+		session.StartedAt = &now
+		return "", true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	if session == nil {
+		return nil
+	}
+
+	s.notifyParticipants(session, "session_started", map[string]interface{}{
+		"sessionId":   session.ID,
+		"autoStarted": true,
+	})
+	s.log.Info("session started by quorum", "session_id", session.ID, "quorum", session.Quorum)
+	return nil
+}
+
+// cancelNoShow отменяет сессию, к которой никто не пришел
+func (s *SessionSchedulerService) cancelNoShow(session *entity.Session, now time.Time) error {
+	session, err := s.updatePending(session.ID, func(session *entity.Session) (string, bool) {
+		if session.ScheduledAt == nil || now.Before(session.ScheduledAt.Add(s.noShowTimeout)) ||
+			readyParticipants(session) > 0 {
+			return "", false
+		}
+		cancelSession(session, cancelReasonNoShow, now)
+		return fmt.Sprintf("Сессия «%s» отменена: к началу никто не отметил готовность.", sessionTitle(session)), true
+	})
+	if err != nil || session == nil {
+		return err
+	}
+
+	s.notifyParticipants(session, "session_cancelled", map[string]interface{}{
+		"sessionId": session.ID,
+		"reason":    cancelReasonNoShow,
+	})
+	s.log.Info("session cancelled, nobody showed up", "session_id", session.ID, "scheduled_at", session.ScheduledAt)
+	return nil
+}
+
+// updatePending перечитывает сессию в транзакции и, если она все еще ожидает начала,
+// отдает ее change. change заново решает по свежей копии, нужно ли действие, меняет ее
+// и возвращает сообщение бота участникам ("" — без сообщения). Сессия сохраняется, только
+// если ее статус не сменили за это время; nil — действие уже не нужно
+func (s *SessionSchedulerService) updatePending(
+	sessionID string, change func(session *entity.Session) (string, bool),
+) (*entity.Session, error) {
+	var updated *entity.Session
+	err := s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		session, err := tx.Sessions.GetByID(sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to reload session: %w", err)
+		}
+		if session == nil || session.Status != entity.SessionStatusPending {
+			return nil
+		}
+		text, ok := change(session)
+		if !ok {
+			return nil
+		}
+
+		saved, err := tx.Sessions.UpdateIfStatus(session, entity.SessionStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		if !saved {
+			return nil
+		}
+		if text != "" {
+			if err := enqueueMessages(tx.Outbox, s.userRepo, session, text); err != nil {
+				return err
+			}
+		}
+		updated = session
+		return nil
+	})
+	return updated, err
+}
+
+// updateAndMessage сохраняет сессию, если ее статус в хранилище все еще from, и одной
+// транзакцией ставит в outbox сообщение бота каждому участнику. false — статус
+// успели сменить, ничего не записано
+func updateAndMessage(
+	transactor interfaces.Transactor, userRepo interfaces.UserRepository,
+	session *entity.Session, from entity.SessionStatus, text string,
+) (bool, error) {
+	updated := false
+	err := transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		var err error
+		updated, err = tx.Sessions.UpdateIfStatus(session, from)
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		if !updated {
+			return nil
+		}
+		return enqueueMessages(tx.Outbox, userRepo, session, text)
+	})
+	return updated, err
+}
+
+// enqueueMessages ставит в outbox сообщение бота каждому участнику сессии
+func enqueueMessages(
+	outbox interfaces.OutboxRepository, userRepo interfaces.UserRepository, session *entity.Session, text string,
+) error {
+	for _, participant := range session.Participants {
+		user, err := userRepo.GetByID(participant.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load participant: %w", err)
+		}
+		if user == nil {
+			continue
+		}
+		if err := enqueueOutbox(outbox, entity.OutboxActionSendMessageToUser, session.ID, sendMessageToUserPayload{
+			MaxUserID: user.MaxUserID,
+			Message:   &maxapi.SendMessageRequest{Text: text},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SessionSchedulerService) notifyParticipants(session *entity.Session, event string, data map[string]interface{}) {
+	notifyParticipants(s.notifier, session, event, data)
+}
+
+// notifyParticipants отправляет событие по WebSocket всем участникам сессии
+func notifyParticipants(notifier interfaces.SessionNotifier, session *entity.Session, event string, data map[string]interface{}) {
+	for _, participant := range session.Participants {
+		notifier.SendToUser(participant.UserID, event, data)
+	}
+}
+
+func readyParticipants(session *entity.Session) int {
+	ready := 0
+	for _, participant := range session.Participants {
+		if participant.IsReady {
+			ready++
+		}
+	}
+	return ready
+}
+
+// sessionTitle название сессии для сообщений бота
+func sessionTitle(session *entity.Session) string {
+	switch {
+	case session.GroupName != nil && *session.GroupName != "":
+		return *session.GroupName
+	case session.Topic != nil:
+		return *session.Topic
+	default:
+		return "фокус-сессия"
+	}
+}
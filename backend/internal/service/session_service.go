@@ -1,10 +1,12 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/rnegic/synchronous/internal/entity"
@@ -17,6 +19,11 @@ type SessionService struct {
 	taskRepo      interfaces.TaskRepository
 	userRepo      interfaces.UserRepository
 	maxAPIService interfaces.MaxAPIService
+	transactor    interfaces.Transactor
+	// maxSessionSize — предел участников сессии, APP.MAX_SESSION_SIZE
+	maxSessionSize int
+	// restoreWindow — сколько удаленную сессию можно восстановить, APP.SESSION_RESTORE_WINDOW
+	restoreWindow time.Duration
 }
 
 func NewSessionService(
@@ -24,30 +31,47 @@ func NewSessionService(
 	taskRepo interfaces.TaskRepository,
 	userRepo interfaces.UserRepository,
 	maxAPIService interfaces.MaxAPIService,
+	transactor interfaces.Transactor,
+	maxSessionSize int,
+	restoreWindow time.Duration,
 ) interfaces.SessionService {
 	return &SessionService{
-		sessionRepo:   sessionRepo,
-		taskRepo:      taskRepo,
-		userRepo:      userRepo,
-		maxAPIService: maxAPIService,
+		sessionRepo:    sessionRepo,
+		taskRepo:       taskRepo,
+		userRepo:       userRepo,
+		maxAPIService:  maxAPIService,
+		transactor:     transactor,
+		maxSessionSize: maxSessionSize,
+		restoreWindow:  restoreWindow,
 	}
 }
 
-func (s *SessionService) CreateSession(
-	userID string,
-	mode entity.SessionMode,
-	tasks []string,
-	focusDuration, breakDuration int,
-	groupName *string,
-	isPrivate bool,
-) (*entity.Session, error) {
+func (s *SessionService) CreateSession(userID string, params entity.NewSessionParams) (*entity.Session, error) {
+	topic, tags, language, scheduledAt, err := normalizeSessionMeta(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if params.Quorum < 0 || params.Quorum > s.maxSessionSize || (params.Quorum > 0 && scheduledAt == nil) {
+		return nil, entity.NewValidationError("invalid_quorum",
+			fmt.Sprintf("quorum must be between 1 and %d and requires scheduledAt", s.maxSessionSize),
+			map[string]string{"quorum": "invalid"})
+	}
+	if params.CyclePlan != nil {
+		if err := params.CyclePlan.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	sessionID := uuid.New().String()
 	inviteLink := uuid.New().String()[:8] // Короткая ссылка
 	// Получаем реальные данные пользователя для корректного отображения имени и аватара
 	creator, err := s.userRepo.GetByID(userID)
-	if err != nil || creator == nil {
+	if err != nil {
 		return nil, fmt.Errorf("failed to load creator: %w", err)
 	}
+	if creator == nil {
+		return nil, errUserNotFound
+	}
 
 	participants := []entity.Participant{
 		{
@@ -62,14 +86,20 @@ func (s *SessionService) CreateSession(
 	// Сначала создаем сессию, чтобы она существовала в БД для внешних ключей
 	session := &entity.Session{
 		ID:            sessionID,
-		Mode:          mode,
+		Mode:          params.Mode,
 		Status:        entity.SessionStatusPending,
-		FocusDuration: focusDuration,
-		BreakDuration: breakDuration,
-		GroupName:     groupName,
-		IsPrivate:     isPrivate,
+		FocusDuration: params.FocusDuration,
+		BreakDuration: params.BreakDuration,
+		GroupName:     params.GroupName,
+		IsPrivate:     params.IsPrivate,
+		Topic:         topic,
+		Language:      language,
+		ScheduledAt:   scheduledAt,
+		Quorum:        params.Quorum,
+		CyclePlan:     params.CyclePlan,
 		CreatorID:     userID,
 		Participants:  participants,
+		Tags:          tags,
 		InviteLink:    inviteLink,
 		CreatedAt:     time.Now(),
 		CurrentCycle:  0,
@@ -81,8 +111,8 @@ func (s *SessionService) CreateSession(
 
 	// Теперь создаем задачи после создания сессии
 	// Привязываем задачи к пользователю (creator) для индивидуального отслеживания
-	tasksList := make([]entity.Task, 0, len(tasks))
-	for _, title := range tasks {
+	tasksList := make([]entity.Task, 0, len(params.Tasks))
+	for _, title := range params.Tasks {
 		task := entity.Task{
 			ID:        uuid.New().String(),
 			Title:     title,
@@ -105,10 +135,52 @@ func (s *SessionService) CreateSession(
 	return session, nil
 }
 
+// normalizeSessionMeta проверяет тему, теги, язык и время начала новой сессии
+func normalizeSessionMeta(params entity.NewSessionParams, now time.Time) (
+	topic *string, tags []entity.SessionTag, language *string, scheduledAt *time.Time, err error,
+) {
+	if params.Topic != nil {
+		if value := strings.TrimSpace(*params.Topic); value != "" {
+			if utf8.RuneCountInString(value) > entity.MaxSessionTopic {
+				return nil, nil, nil, nil, entity.NewValidationError("invalid_topic", "topic is too long",
+					map[string]string{"topic": "max"})
+			}
+			topic = &value
+		}
+	}
+
+	names, err := entity.NormalizeSessionTags(params.Tags)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	for _, name := range names {
+		tags = append(tags, entity.SessionTag{Tag: name})
+	}
+
+	if params.Language != nil && strings.TrimSpace(*params.Language) != "" {
+		value, err := entity.NormalizeLanguage(*params.Language)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		language = &value
+	}
+
+	if params.ScheduledAt != nil {
+		if params.ScheduledAt.Before(now) {
+			return nil, nil, nil, nil, entity.NewValidationError("invalid_scheduled_at", "scheduledAt must not be in the past",
+				map[string]string{"scheduledAt": "min"})
+		}
+		// В локальном поясе, как created_at: сортировка по началу сравнивает их между собой
+		value := params.ScheduledAt.Local()
+		scheduledAt = &value
+	}
+	return topic, tags, language, scheduledAt, nil
+}
+
 func (s *SessionService) GetSession(sessionID string, userID string) (*entity.Session, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	// Проверяем доступ
@@ -129,7 +201,7 @@ func (s *SessionService) GetSession(sessionID string, userID string) (*entity.Se
 	}
 
 	if !hasAccess {
-		return nil, fmt.Errorf("access denied")
+		return nil, errSessionAccessDenied
 	}
 
 	// Загружаем только задачи текущего пользователя (индивидуальные задачи)
@@ -153,7 +225,7 @@ func (s *SessionService) GetActiveSession(userID string) (*entity.Session, error
 		return nil, fmt.Errorf("failed to get active session: %w", err)
 	}
 	if session == nil {
-		return nil, fmt.Errorf("active session not found")
+		return nil, entity.NewNotFoundError("active_session_not_found", "active session not found")
 	}
 
 	// Load only tasks that belong to the current user to avoid leaking private notes
@@ -170,58 +242,71 @@ func (s *SessionService) GetActiveSession(userID string) (*entity.Session, error
 	return session, nil
 }
 
-func (s *SessionService) GetHistory(userID string, page, limit int) ([]*entity.Session, int, error) {
-	return s.sessionRepo.GetHistory(userID, page, limit)
+// GetHistory возвращает страницу сессий пользователя с фильтрами
+func (s *SessionService) GetHistory(userID string, filter entity.SessionFilter) (*entity.SessionPage, error) {
+	filter.UserID = userID
+	return s.listSessions(filter)
 }
 
-func (s *SessionService) GetPublicSessions(page, limit int) ([]*entity.Session, int, error) {
-	// Get all public sessions that are pending (waiting for participants)
-	sessions, err := s.sessionRepo.GetAll()
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get sessions: %w", err)
-	}
-
-	// Filter for public, pending, and GROUP sessions only (no solo sessions)
-	publicSessions := make([]*entity.Session, 0)
-	for _, session := range sessions {
-		if !session.IsPrivate &&
-			session.Status == entity.SessionStatusPending &&
-			session.Mode == entity.SessionModeGroup {
-			publicSessions = append(publicSessions, session)
-		}
-	}
+// GetPublicSessions возвращает страницу публичных групповых сессий, ожидающих участников
+func (s *SessionService) GetPublicSessions(filter entity.SessionFilter) (*entity.SessionPage, error) {
+	filter.UserID = ""
+	filter.Role = entity.SessionRoleAny
+	filter.PublicOnly = true
+	filter.Statuses = []entity.SessionStatus{entity.SessionStatusPending}
+	filter.Mode = entity.SessionModeGroup
+	if filter.JoinableAt != nil {
+		filter.Capacity = s.maxSessionSize
+	}
+	return s.listSessions(filter)
+}
 
-	total := len(publicSessions)
+// listSessions запрашивает на одну сессию больше лимита, чтобы узнать, есть ли следующая страница
+func (s *SessionService) listSessions(filter entity.SessionFilter) (*entity.SessionPage, error) {
+	limit := filter.Limit
+	filter.Limit = limit + 1
 
-	// Apply pagination
-	start := (page - 1) * limit
-	if start >= total {
-		return []*entity.Session{}, total, nil
+	sessions, err := s.sessionRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	end := start + limit
-	if end > total {
-		end = total
+	page := &entity.SessionPage{Sessions: sessions}
+	if len(sessions) > limit {
+		page.Sessions = sessions[:limit]
+		page.NextCursor = entity.NewSessionCursor(page.Sessions[limit-1], filter.Sort)
 	}
 
-	return publicSessions[start:end], total, nil
+	joinableBy := time.Now().Add(entity.JoinableWindow)
+	for _, summary := range page.Sessions {
+		summary.OpenSeats = max(s.maxSessionSize-summary.ParticipantsCount, 0)
+		summary.Joinable = summary.Status == entity.SessionStatusPending && summary.OpenSeats > 0 &&
+			(summary.ScheduledAt == nil || !summary.ScheduledAt.After(joinableBy))
+	}
+	return page, nil
 }
 
 func (s *SessionService) JoinSession(sessionID string, userID string) (*entity.Session, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	if session.Status != entity.SessionStatusPending {
-		return nil, fmt.Errorf("session already started")
+		return nil, errSessionStarted
+	}
+	if len(session.Participants) >= s.maxSessionSize {
+		return nil, errSessionFull
 	}
 
 	// Подтягиваем реальные имя и аватар участника
 	user, uerr := s.userRepo.GetByID(userID)
-	if uerr != nil || user == nil {
+	if uerr != nil {
 		return nil, fmt.Errorf("failed to load user: %w", uerr)
 	}
+	if user == nil {
+		return nil, errUserNotFound
+	}
 
 	participant := &entity.Participant{
 		UserID:    userID,
@@ -246,11 +331,11 @@ func (s *SessionService) JoinByInviteLink(inviteLink string, userID string) (*en
 
 	session, err := s.sessionRepo.GetByInviteLink(cleanInviteLink)
 	if err != nil {
-		return nil, fmt.Errorf("session not found by invite link: %w", err)
+		return nil, fmt.Errorf("failed to get session by invite link: %w", err)
 	}
 
 	if session == nil {
-		return nil, fmt.Errorf("session not found by invite link")
+		return nil, entity.NewNotFoundError("session_not_found", "session not found by invite link")
 	}
 
 	// Проверяем, не присоединен ли уже пользователь
@@ -270,17 +355,17 @@ func (s *SessionService) SetReady(sessionID string, userID string, isReady bool)
 }
 
 func (s *SessionService) StartSession(sessionID string, userID string) error {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
 	}
 
 	if session.CreatorID != userID {
-		return fmt.Errorf("only creator can start session")
+		return errNotCreator("only creator can start session")
 	}
 
 	if session.Status != entity.SessionStatusPending {
-		return fmt.Errorf("session already started")
+		return errSessionStarted
 	}
 
 	now := time.Now()
@@ -291,28 +376,13 @@ func (s *SessionService) StartSession(sessionID string, userID string) error {
 }
 
 func (s *SessionService) PauseSession(sessionID string, userID string) error {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
 	}
 
-	// Verify user is participant or creator
-	if session.CreatorID != userID {
-		// For group sessions, check if user is participant
-		if session.Mode == entity.SessionModeGroup {
-			isParticipant := false
-			for _, p := range session.Participants {
-				if p.UserID == userID {
-					isParticipant = true
-					break
-				}
-			}
-			if !isParticipant {
-				return fmt.Errorf("user not authorized to pause session")
-			}
-		} else {
-			return fmt.Errorf("only creator can pause solo session")
-		}
+	if err := checkControl(session, userID, "pause"); err != nil {
+		return err
 	}
 
 	if session.Status != entity.SessionStatusActive {
@@ -320,36 +390,25 @@ func (s *SessionService) PauseSession(sessionID string, userID string) error {
 		if session.Status == entity.SessionStatusPaused {
 			return nil
 		}
-		return fmt.Errorf("session is not active")
+		return entity.NewInvalidStateError("session_not_active", "session is not active")
 	}
 
+	// Пауза не входит в выполненный план: ее длительность учитывается при возобновлении
+	now := time.Now()
 	session.Status = entity.SessionStatusPaused
+	session.PausedAt = &now
+	session.CurrentCycle = session.Progress(session.ActiveTime(now)).CurrentCycle
 	return s.sessionRepo.Update(session)
 }
 
 func (s *SessionService) ResumeSession(sessionID string, userID string) error {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
 	}
 
-	// Verify user is participant or creator
-	if session.CreatorID != userID {
-		// For group sessions, check if user is participant
-		if session.Mode == entity.SessionModeGroup {
-			isParticipant := false
-			for _, p := range session.Participants {
-				if p.UserID == userID {
-					isParticipant = true
-					break
-				}
-			}
-			if !isParticipant {
-				return fmt.Errorf("user not authorized to resume session")
-			}
-		} else {
-			return fmt.Errorf("only creator can resume solo session")
-		}
+	if err := checkControl(session, userID, "resume"); err != nil {
+		return err
 	}
 
 	// Idempotent: if already active, do nothing
@@ -358,25 +417,113 @@ func (s *SessionService) ResumeSession(sessionID string, userID string) error {
 	}
 
 	if session.Status != entity.SessionStatusPaused {
-		return fmt.Errorf("session is not paused")
+		return entity.NewInvalidStateError("session_not_paused", "session is not paused")
 	}
 
 	session.Status = entity.SessionStatusActive
+	endPause(session, time.Now())
 	return s.sessionRepo.Update(session)
 }
 
+// Причины отмены сессии без участия создателя
+const (
+	cancelReasonNoShow    = "no_show"            // к запланированному началу никто не готов
+	cancelReasonAbandoned = "abandoned"          // сессию так и не начали или бросили
+	cancelReasonSkipped   = "occurrence_skipped" // повторение серии пропущено
+	cancelReasonSeriesEnd = "series_ended"       // серия остановлена
+)
+
+// cancelSession переводит сессию в cancelled с причиной reason
+func cancelSession(session *entity.Session, reason string, at time.Time) {
+	endPause(session, at)
+	session.Status = entity.SessionStatusCancelled
+	session.CancelledAt = &at
+	session.CancelReason = &reason
+}
+
+// CancelSession отменяет сессию по решению создателя; участники получают сообщение бота
+// с причиной
+func (s *SessionService) CancelSession(sessionID string, userID string, reason string) (*entity.Session, error) {
+	session, err := s.loadSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.CreatorID != userID {
+		return nil, errNotCreator("only creator can cancel session")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > entity.MaxCancelReason {
+		return nil, entity.NewValidationError("invalid_reason",
+			fmt.Sprintf("reason must be 1-%d characters", entity.MaxCancelReason), map[string]string{"reason": "invalid"})
+	}
+
+	switch session.Status {
+	case entity.SessionStatusCancelled:
+		return nil, entity.NewInvalidStateError("session_already_cancelled", "session is already cancelled")
+	case entity.SessionStatusCompleted:
+		return nil, entity.NewInvalidStateError("session_already_completed", "session is already completed")
+	}
+
+	from := session.Status
+	cancelSession(session, reason, time.Now())
+	text := fmt.Sprintf("Сессия «%s» отменена создателем: %s", sessionTitle(session), reason)
+	updated, err := updateAndMessage(s.transactor, s.userRepo, session, from, text)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errSessionChanged
+	}
+	return session, nil
+}
+
+// endPause добавляет текущую паузу к TotalPauseTime
+func endPause(session *entity.Session, now time.Time) {
+	if session.PausedAt == nil {
+		return
+	}
+	session.TotalPauseTime += now.Sub(*session.PausedAt).Milliseconds()
+	session.PausedAt = nil
+}
+
+// checkControl проверяет, что пользователь может управлять ходом сессии: групповой —
+// любой участник, одиночной — только создатель. action попадает в текст ошибки
+func checkControl(session *entity.Session, userID string, action string) error {
+	if session.CreatorID == userID {
+		return nil
+	}
+	if session.Mode != entity.SessionModeGroup {
+		return errNotCreator(fmt.Sprintf("only creator can %s solo session", action))
+	}
+	for _, p := range session.Participants {
+		if p.UserID == userID {
+			return nil
+		}
+	}
+	return errNotParticipant(fmt.Sprintf("user not authorized to %s session", action))
+}
+
 func (s *SessionService) CompleteSession(sessionID string, userID string) (*entity.SessionReport, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
+	}
+	if err := checkControl(session, userID, "complete"); err != nil {
+		return nil, err
 	}
 
-	now := time.Now()
-	session.Status = entity.SessionStatusCompleted
-	session.CompletedAt = &now
+	switch session.Status {
+	case entity.SessionStatusCancelled:
+		return nil, entity.NewInvalidStateError("session_cancelled", "session is cancelled")
+	case entity.SessionStatusCompleted:
+		return nil, entity.NewInvalidStateError("session_already_completed", "session is already completed")
+	}
 
-	if err := s.sessionRepo.Update(session); err != nil {
-		return nil, fmt.Errorf("failed to update session: %w", err)
+	now := time.Now()
+	if err := s.complete(session, now); err != nil {
+		return nil, err
 	}
 
 	tasks, err := s.taskRepo.GetBySessionID(sessionID)
@@ -386,28 +533,82 @@ func (s *SessionService) CompleteSession(sessionID string, userID string) (*enti
 
 	report := s.buildSessionReport(session, tasks, now)
 
-	// Создаем чат для обсуждения после завершения сессии
-	// Отправляем сообщение создателю с кнопкой для создания чата
-	if err := s.createDiscussionChat(session); err != nil {
-		// Логируем ошибку, но не прерываем завершение сессии
-		// В реальности здесь должно быть логирование
-		_ = err
+	return report, nil
+}
+
+// complete завершает сессию в момент at. Сообщение с кнопкой создания чата ставится
+// в outbox в той же транзакции, что и завершение сессии, и доставляется воркером
+// Сессия сохраняется, только если ее статус не изменился с загрузки, иначе errSessionChanged
+func (s *SessionService) complete(session *entity.Session, at time.Time) error {
+	from := session.Status
+	endPause(session, at)
+	session.Status = entity.SessionStatusCompleted
+	session.CompletedAt = &at
+	session.CurrentCycle = session.Progress(session.ActiveTime(at)).CurrentCycle
+
+	return s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		updated, err := tx.Sessions.UpdateIfStatus(session, from)
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		if !updated {
+			return errSessionChanged
+		}
+		return s.createDiscussionChat(tx.Outbox, session)
+	})
+}
+
+// CompleteFinishedPlans завершает активные сессии с AutoComplete, план которых пройден
+// к now. Сессия завершается в момент окончания плана, а не проверки
+func (s *SessionService) CompleteFinishedPlans(now time.Time) ([]*entity.Session, error) {
+	sessions, err := s.sessionRepo.GetSessionsByStatus(entity.SessionStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active sessions: %w", err)
 	}
 
-	return report, nil
+	var completed []*entity.Session
+	for _, session := range sessions {
+		if session.CyclePlan == nil || !session.CyclePlan.AutoComplete {
+			continue
+		}
+		ok, completeErr := s.CompleteFinishedPlan(session, now)
+		if completeErr != nil {
+			err = completeErr
+			continue
+		}
+		if ok {
+			completed = append(completed, session)
+		}
+	}
+	return completed, err
+}
+
+// CompleteFinishedPlan завершает начатую сессию, если ее план из Cycles циклов пройден
+// к now, в момент окончания плана. false — план не пройден или сессию успели изменить
+func (s *SessionService) CompleteFinishedPlan(session *entity.Session, now time.Time) (bool, error) {
+	finishedAt, finished := session.PlanFinishedAt(now)
+	if !finished {
+		return false, nil
+	}
+	// Пауза началась уже после конца плана и в паузы сессии не входит
+	session.PausedAt = nil
+	if err := s.complete(session, finishedAt); err != nil {
+		if errors.Is(err, errSessionChanged) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 func (s *SessionService) GetSessionReport(sessionID string, userID string) (*entity.SessionReport, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
-	}
-	if session == nil {
-		return nil, fmt.Errorf("session not found")
+		return nil, err
 	}
 
 	if !s.hasAccessToSession(session, userID) {
-		return nil, fmt.Errorf("access denied")
+		return nil, errSessionAccessDenied
 	}
 
 	tasks, err := s.taskRepo.GetBySessionID(sessionID)
@@ -425,21 +626,13 @@ func (s *SessionService) GetSessionReport(sessionID string, userID string) (*ent
 	return s.buildSessionReport(session, tasks, completedAt), nil
 }
 
+// buildSessionReport считает фокус и перерывы по выполненной части плана циклов:
+// по активному времени до завершения, а для незавершенной сессии — до текущего момента
 func (s *SessionService) buildSessionReport(session *entity.Session, tasks []*entity.Task, completedAt time.Time) *entity.SessionReport {
-	cycles := session.CurrentCycle
-	if cycles <= 0 {
-		cycles = 1
-	}
-
-	focusMinutes := session.FocusDuration * cycles
-	if focusMinutes < 0 {
-		focusMinutes = 0
-	}
-
-	breakMinutes := session.BreakDuration * cycles
-	if breakMinutes < 0 {
-		breakMinutes = 0
-	}
+	progress := session.Progress(session.ActiveTime(time.Now()))
+	cycles := progress.CyclesCompleted
+	focusMinutes := int(progress.FocusTime.Round(time.Minute) / time.Minute)
+	breakMinutes := int(progress.BreakTime.Round(time.Minute) / time.Minute)
 
 	statsByUser := make(map[string]*entity.ParticipantReport, len(session.Participants))
 	for _, participant := range session.Participants {
@@ -497,6 +690,30 @@ func (s *SessionService) buildSessionReport(session *entity.Session, tasks []*en
 	}
 }
 
+// loadSession загружает сессию; отсутствие сессии — errSessionNotFound
+func (s *SessionService) loadSession(sessionID string) (*entity.Session, error) {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return nil, errSessionNotFound
+	}
+	return session, nil
+}
+
+// loadTask загружает задачу сессии; задача другой сессии считается ненайденной
+func (s *SessionService) loadTask(sessionID string, taskID string) (*entity.Task, error) {
+	task, err := s.taskRepo.GetByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil || task.SessionID != sessionID {
+		return nil, errTaskNotFound
+	}
+	return task, nil
+}
+
 func (s *SessionService) hasAccessToSession(session *entity.Session, userID string) bool {
 	if session.CreatorID == userID {
 		return true
@@ -512,8 +729,8 @@ func (s *SessionService) hasAccessToSession(session *entity.Session, userID stri
 }
 
 // createDiscussionChat создает чат для обсуждения после завершения сессии
-// Отправляет сообщение создателю с кнопкой для создания чата в Max
-func (s *SessionService) createDiscussionChat(session *entity.Session) error {
+// Ставит в outbox сообщение создателю с кнопкой для создания чата в Max
+func (s *SessionService) createDiscussionChat(outbox interfaces.OutboxRepository, session *entity.Session) error {
 	// Получаем создателя сессии для получения его MaxUserID
 	creator, err := s.userRepo.GetByID(session.CreatorID)
 	if err != nil {
@@ -553,12 +770,10 @@ func (s *SessionService) createDiscussionChat(session *entity.Session) error {
 	}
 
 	// Отправляем сообщение создателю в личный чат
-	_, err = s.maxAPIService.SendMessageToUser(creator.MaxUserID, message)
-	if err != nil {
-		return fmt.Errorf("failed to send chat creation message: %w", err)
-	}
-
-	return nil
+	return enqueueOutbox(outbox, entity.OutboxActionSendMessageToUser, session.ID, sendMessageToUserPayload{
+		MaxUserID: creator.MaxUserID,
+		Message:   message,
+	})
 }
 
 // HandleChatCreated обрабатывает webhook о создании чата через кнопку
@@ -567,20 +782,21 @@ func (s *SessionService) HandleChatCreated(update interface{}) error {
 	// Проверяем тип обновления
 	chatUpdate, ok := update.(*maxapi.MessageChatCreatedUpdate)
 	if !ok {
-		return fmt.Errorf("invalid update type: expected *maxapi.MessageChatCreatedUpdate")
+		return entity.NewValidationError("invalid_update", "expected message_chat_created update", nil)
 	}
 
 	// Извлекаем session_id из start_payload
 	// Формат: "session_id:abc123:discussion" или "session_id:abc123"
 	sessionID := s.extractSessionIDFromPayload(chatUpdate.StartPayload)
 	if sessionID == "" {
-		return fmt.Errorf("invalid start_payload: %s", chatUpdate.StartPayload)
+		return entity.NewValidationError("invalid_start_payload",
+			fmt.Sprintf("invalid start_payload: %s", chatUpdate.StartPayload), nil)
 	}
 
 	// Находим сессию
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
 	}
 
 	// Сохраняем информацию о чате в сессии
@@ -590,19 +806,13 @@ func (s *SessionService) HandleChatCreated(update interface{}) error {
 	// Формат ссылки зависит от Max API: например, https://max.ru/chat/{chatID}
 	// Пока сохраняем только chatID, ссылку можно сформировать при необходимости
 
-	// Обновляем сессию
-	if err := s.sessionRepo.Update(session); err != nil {
-		return fmt.Errorf("failed to update session: %w", err)
-	}
-
-	// Добавляем участников сессии в чат
-	if err := s.addParticipantsToChat(session, chatID); err != nil {
-		// Логируем ошибку, но не прерываем обработку
-		// В реальности здесь должно быть логирование
-		_ = err
-	}
-
-	return nil
+	// Обновляем сессию и ставим добавление участников в outbox одной транзакцией
+	return s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Sessions.Update(session); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		return s.addParticipantsToChat(tx.Outbox, session, chatID)
+	})
 }
 
 // extractSessionIDFromPayload извлекает session_id из start_payload
@@ -628,12 +838,12 @@ func (s *SessionService) extractSessionIDFromPayload(payload string) string {
 }
 
 // addParticipantsToChat добавляет участников сессии в чат Max
-func (s *SessionService) addParticipantsToChat(session *entity.Session, chatID int64) error {
+func (s *SessionService) addParticipantsToChat(outbox interfaces.OutboxRepository, session *entity.Session, chatID int64) error {
 	// Собираем MaxUserID всех участников
 	maxUserIDs := make([]int64, 0, len(session.Participants))
 	for _, participant := range session.Participants {
 		user, err := s.userRepo.GetByID(participant.UserID)
-		if err != nil {
+		if err != nil || user == nil {
 			// Пропускаем участника, если не удалось получить его данные
 			continue
 		}
@@ -641,94 +851,113 @@ func (s *SessionService) addParticipantsToChat(session *entity.Session, chatID i
 	}
 
 	// Добавляем участников в чат
-	if len(maxUserIDs) > 0 {
-		if err := s.maxAPIService.AddMembers(chatID, maxUserIDs); err != nil {
-			return fmt.Errorf("failed to add participants to chat: %w", err)
-		}
+	if len(maxUserIDs) == 0 {
+		return nil
 	}
 
-	return nil
+	return enqueueOutbox(outbox, entity.OutboxActionAddChatMembers, session.ID, addChatMembersPayload{
+		ChatID:     chatID,
+		MaxUserIDs: maxUserIDs,
+	})
 }
 
 // DeleteChatAfterDiscussion удаляет чат для обсуждения после окончания обсуждения
 func (s *SessionService) DeleteChatAfterDiscussion(sessionID string, userID string) error {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
 	}
 
 	// Проверяем права доступа - только создатель может удалить чат
 	if session.CreatorID != userID {
-		return fmt.Errorf("only creator can delete chat")
+		return errNotCreator("only creator can delete chat")
 	}
 
 	// Проверяем, что сессия завершена
 	if session.Status != entity.SessionStatusCompleted {
-		return fmt.Errorf("can only delete chat after session completion")
+		return entity.NewInvalidStateError("session_not_completed", "can only delete chat after session completion")
 	}
 
-	// Удаляем чат в Max API, если он существует
-	if session.MaxChatID != nil {
-		err := s.maxAPIService.DeleteChat(*session.MaxChatID)
-		if err != nil {
-			return fmt.Errorf("failed to delete chat: %w", err)
-		}
+	if session.MaxChatID == nil {
+		return errChatNotCreated
+	}
 
-		// Очищаем информацию о чате в сессии
-		session.MaxChatID = nil
-		session.MaxChatLink = nil
-		if err := s.sessionRepo.Update(session); err != nil {
+	// Очищаем информацию о чате в сессии, а удаление чата в Max API
+	// ставим в outbox — воркер повторит его при временных ошибках
+	chatID := *session.MaxChatID
+	session.MaxChatID = nil
+	session.MaxChatLink = nil
+
+	return s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Sessions.Update(session); err != nil {
 			return fmt.Errorf("failed to update session: %w", err)
 		}
-	} else {
-		return fmt.Errorf("chat does not exist for this session")
-	}
-
-	return nil
+		return enqueueOutbox(tx.Outbox, entity.OutboxActionDeleteChat, session.ID, deleteChatPayload{ChatID: chatID})
+	})
 }
 
-// DeleteSession удаляет сессию и связанный чат Max (если есть)
-func (s *SessionService) DeleteSession(sessionID string, userID string) error {
-	session, err := s.sessionRepo.GetByID(sessionID)
+// DeleteSession мягко удаляет сессию вместе с задачами и сообщениями и возвращает
+// срок, до которого ее можно восстановить. Чат Max удаляется при окончательной очистке
+func (s *SessionService) DeleteSession(sessionID string, userID string) (*entity.Session, time.Time, error) {
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return nil, time.Time{}, err
 	}
 
 	// Проверяем права доступа - только создатель может удалить сессию
 	if session.CreatorID != userID {
-		return fmt.Errorf("only creator can delete session")
+		return nil, time.Time{}, errNotCreator("only creator can delete session")
 	}
 
-	// Удаляем чат в Max API, если он существует
-	if session.MaxChatID != nil {
-		err := s.maxAPIService.DeleteChat(*session.MaxChatID)
-		if err != nil {
-			// Логируем ошибку, но не прерываем удаление сессии
-			// Чат может быть уже удален пользователем вручную или иметь другие проблемы
-			// В реальности здесь должно быть логирование
-			_ = err // Игнорируем ошибку удаления чата
-		}
+	if session.Status == entity.SessionStatusActive || session.Status == entity.SessionStatusPaused {
+		return nil, time.Time{}, entity.NewInvalidStateError("session_in_progress",
+			"complete or cancel the session before deleting it")
+	}
+	if session.SeriesID != nil {
+		return nil, time.Time{}, entity.NewInvalidStateError("session_in_series",
+			"skip the occurrence or stop the series instead of deleting the session")
 	}
 
-	// Удаляем сессию из репозитория
-	// Примечание: в реальности нужно проверить, есть ли метод Delete в репозитории
-	// Если нет, можно обновить статус на "cancelled" или "deleted"
-	return fmt.Errorf("delete method not implemented in repository")
+	// Срок считаем от момента до удаления: он не позже того, что увидит очистка
+	restoreUntil := time.Now().Add(s.restoreWindow)
+	if err := s.sessionRepo.Delete(sessionID); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to delete session: %w", err)
+	}
+	return session, restoreUntil, nil
 }
 
-func (s *SessionService) UpdateTask(sessionID string, taskID string, userID string, completed bool) (*entity.Task, error) {
-	task, err := s.taskRepo.GetByID(taskID)
+// RestoreSession возвращает удаленную сессию, пока не истекло окно восстановления
+func (s *SessionService) RestoreSession(sessionID string, userID string) (*entity.Session, error) {
+	deleted, err := s.sessionRepo.GetDeletedByID(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+		return nil, fmt.Errorf("failed to get deleted session: %w", err)
+	}
+	if deleted == nil {
+		return nil, errSessionNotFound
 	}
 
-	if task.SessionID != sessionID {
-		return nil, fmt.Errorf("task does not belong to session")
+	if deleted.CreatorID != userID {
+		return nil, errNotCreator("only creator can restore session")
+	}
+	if time.Since(deleted.DeletedAt.Time) > s.restoreWindow {
+		return nil, entity.NewInvalidStateError("restore_window_expired", "session can no longer be restored")
+	}
+
+	if err := s.sessionRepo.Restore(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to restore session: %w", err)
+	}
+	return s.loadSession(sessionID)
+}
+
+func (s *SessionService) UpdateTask(sessionID string, taskID string, userID string, completed bool) (*entity.Task, error) {
+	task, err := s.loadTask(sessionID, taskID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Проверяем что задача принадлежит текущему пользователю
 	if task.UserID == nil || *task.UserID != userID {
-		return nil, fmt.Errorf("task does not belong to user")
+		return nil, errTaskNotOwned
 	}
 
 	task.Completed = completed
@@ -764,27 +993,23 @@ func (s *SessionService) AddTask(sessionID string, userID string, title string)
 }
 
 func (s *SessionService) DeleteTask(sessionID string, taskID string, userID string) error {
-	task, err := s.taskRepo.GetByID(taskID)
+	task, err := s.loadTask(sessionID, taskID)
 	if err != nil {
-		return fmt.Errorf("task not found: %w", err)
-	}
-
-	if task.SessionID != sessionID {
-		return fmt.Errorf("task does not belong to session")
+		return err
 	}
 
 	// Проверяем что задача принадлежит текущему пользователю
 	if task.UserID == nil || *task.UserID != userID {
-		return fmt.Errorf("task does not belong to user")
+		return errTaskNotOwned
 	}
 
 	return s.taskRepo.Delete(taskID)
 }
 
 func (s *SessionService) GetParticipantsProgress(sessionID string, userID string) ([]entity.ParticipantProgress, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
 	// Verify user is participant
@@ -796,7 +1021,7 @@ func (s *SessionService) GetParticipantsProgress(sessionID string, userID string
 		}
 	}
 	if !isParticipant {
-		return nil, fmt.Errorf("user is not a participant")
+		return nil, errNotParticipant("user is not a participant")
 	}
 
 	// Get progress for all participants
@@ -827,13 +1052,13 @@ func (s *SessionService) GetParticipantsProgress(sessionID string, userID string
 }
 
 func (s *SessionService) InviteUsers(sessionID string, userID string, userIDs []string) (int, string, error) {
-	session, err := s.sessionRepo.GetByID(sessionID)
+	session, err := s.loadSession(sessionID)
 	if err != nil {
-		return 0, "", fmt.Errorf("session not found: %w", err)
+		return 0, "", err
 	}
 
 	if session.CreatorID != userID {
-		return 0, "", fmt.Errorf("only creator can invite users")
+		return 0, "", errNotCreator("only creator can invite users")
 	}
 
 	// В реальности отправляем приглашения через Max API
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+// TemplateService ведет шаблоны сессий и создает сессии из них одним вызовом
+type TemplateService struct {
+	templateRepo interfaces.SessionTemplateRepository
+	sessionRepo  interfaces.SessionRepository
+	taskRepo     interfaces.TaskRepository
+	userRepo     interfaces.UserRepository
+	transactor   interfaces.Transactor
+	// maxSessionSize — предел участников сессии вместе с составом шаблона, APP.MAX_SESSION_SIZE
+	maxSessionSize int
+}
+
+func NewTemplateService(
+	templateRepo interfaces.SessionTemplateRepository,
+	sessionRepo interfaces.SessionRepository,
+	taskRepo interfaces.TaskRepository,
+	userRepo interfaces.UserRepository,
+	transactor interfaces.Transactor,
+	maxSessionSize int,
+) interfaces.TemplateService {
+	return &TemplateService{
+		templateRepo:   templateRepo,
+		sessionRepo:    sessionRepo,
+		taskRepo:       taskRepo,
+		userRepo:       userRepo,
+		transactor:     transactor,
+		maxSessionSize: maxSessionSize,
+	}
+}
+
+func (s *TemplateService) CreateTemplate(userID string, params entity.NewTemplateParams) (*entity.SessionTemplate, error) {
+	roster, err := s.loadRoster(userID, params)
+	if err != nil {
+		return nil, err
+	}
+	template, err := buildTemplate(userID, params, roster)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return template, nil
+}
+
+// CreateTemplateFromSession сохраняет настройки завершенной сессии, задачи пользователя
+// в ней и остальных участников как состав. Без name шаблон называется как сессия
+func (s *TemplateService) CreateTemplateFromSession(userID string, sessionID string, name string) (*entity.SessionTemplate, error) {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return nil, errSessionNotFound
+	}
+	if !isParticipant(session, userID) {
+		return nil, errSessionAccessDenied
+	}
+	if session.Status != entity.SessionStatusCompleted {
+		return nil, entity.NewInvalidStateError("session_not_completed", "template can only be saved from a completed session")
+	}
+
+	tasks, err := s.taskRepo.GetBySessionIDAndUserID(sessionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+
+	// Состав берется из участников как есть: они уже прошли проверки при вступлении
+	roster := make([]entity.TemplateMember, 0, len(session.Participants))
+	for _, participant := range session.Participants {
+		if participant.UserID != userID {
+			roster = append(roster, entity.TemplateMember{
+				UserID:    participant.UserID,
+				UserName:  participant.UserName,
+				AvatarURL: participant.AvatarURL,
+			})
+		}
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = sessionTitle(session)
+	}
+	template, err := buildTemplate(userID, entity.NewTemplateParams{
+		Name: name,
+		Session: entity.NewSessionParams{
+			Mode:          session.Mode,
+			Tasks:         titles,
+			FocusDuration: session.FocusDuration,
+			BreakDuration: session.BreakDuration,
+			GroupName:     session.GroupName,
+			IsPrivate:     session.IsPrivate,
+			Topic:         session.Topic,
+			Tags:          session.TagNames(),
+			Language:      session.Language,
+			CyclePlan:     session.CyclePlan,
+		},
+	}, roster)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return template, nil
+}
+
+func (s *TemplateService) GetTemplate(templateID string, userID string) (*entity.SessionTemplate, error) {
+	return s.loadOwnTemplate(templateID, userID)
+}
+
+func (s *TemplateService) ListTemplates(userID string) ([]*entity.SessionTemplate, error) {
+	templates, err := s.templateRepo.ListByOwnerID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+// UpdateTemplate заменяет настройки, задачи и состав шаблона; ссылка не меняется
+func (s *TemplateService) UpdateTemplate(
+	templateID string, userID string, params entity.NewTemplateParams,
+) (*entity.SessionTemplate, error) {
+	template, err := s.loadOwnTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+	roster, err := s.loadRoster(userID, params)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := buildTemplate(userID, params, roster)
+	if err != nil {
+		return nil, err
+	}
+	updated.ID = template.ID
+	updated.ShareLink = template.ShareLink
+	updated.CreatedAt = template.CreatedAt
+
+	if err := s.templateRepo.Update(updated); err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *TemplateService) DeleteTemplate(templateID string, userID string) error {
+	if _, err := s.loadOwnTemplate(templateID, userID); err != nil {
+		return err
+	}
+	if err := s.templateRepo.Delete(templateID); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func (s *TemplateService) GetSharedTemplate(shareLink string) (*entity.SessionTemplate, error) {
+	template, err := s.templateRepo.GetByShareLink(shareLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template by share link: %w", err)
+	}
+	if template == nil {
+		return nil, errTemplateNotFound
+	}
+	return template, nil
+}
+
+// CopySharedTemplate сохраняет шаблон по ссылке себе; состав владельца не копируется
+func (s *TemplateService) CopySharedTemplate(shareLink string, userID string) (*entity.SessionTemplate, error) {
+	shared, err := s.GetSharedTemplate(shareLink)
+	if err != nil {
+		return nil, err
+	}
+
+	template := *shared
+	template.ID = uuid.New().String()
+	template.OwnerID = userID
+	template.Tags = slices.Clone(shared.Tags)
+	template.Tasks = slices.Clone(shared.Tasks)
+	template.Roster = []entity.TemplateMember{}
+	template.ShareLink = uuid.New().String()[:8]
+	template.CreatedAt = time.Now()
+	if err := s.templateRepo.Create(&template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return &template, nil
+}
+
+// CreateSessionFromTemplate создает сессию по шаблону: своему по TemplateID или любому
+// по ShareLink. Создатель получает задачи шаблона, состав своего шаблона — приглашение
+// бота с кодом сессии; участниками они становятся, только вступив сами
+func (s *TemplateService) CreateSessionFromTemplate(userID string, params entity.TemplateSessionParams) (*entity.Session, error) {
+	var template *entity.SessionTemplate
+	var err error
+	switch {
+	case params.TemplateID != "":
+		template, err = s.loadOwnTemplate(params.TemplateID, userID)
+	case params.ShareLink != "":
+		template, err = s.GetSharedTemplate(params.ShareLink)
+	default:
+		err = entity.NewValidationError("invalid_template", "templateId or shareLink is required",
+			map[string]string{"templateId": "required"})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, _, _, scheduledAt, err := normalizeSessionMeta(entity.NewSessionParams{ScheduledAt: params.ScheduledAt}, now)
+	if err != nil {
+		return nil, err
+	}
+	if params.Quorum < 0 || params.Quorum > s.maxSessionSize || (params.Quorum > 0 && scheduledAt == nil) {
+		return nil, entity.NewValidationError("invalid_quorum",
+			fmt.Sprintf("quorum must be between 1 and %d and requires scheduledAt", s.maxSessionSize),
+			map[string]string{"quorum": "invalid"})
+	}
+
+	creator, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if creator == nil {
+		return nil, errUserNotFound
+	}
+	invitees, err := s.templateInvitees(template, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &entity.Session{
+		ID:            uuid.New().String(),
+		Mode:          template.Mode,
+		Status:        entity.SessionStatusPending,
+		FocusDuration: template.FocusDuration,
+		BreakDuration: template.BreakDuration,
+		GroupName:     template.GroupName,
+		IsPrivate:     template.IsPrivate,
+		Topic:         template.Topic,
+		Language:      template.Language,
+		ScheduledAt:   scheduledAt,
+		Quorum:        params.Quorum,
+		CyclePlan:     template.CyclePlan,
+		CreatorID:     userID,
+		Participants: []entity.Participant{{
+			UserID:    creator.ID,
+			UserName:  creator.Name,
+			AvatarURL: creator.AvatarURL,
+			JoinedAt:  now,
+		}},
+		InviteLink: uuid.New().String()[:8],
+		CreatedAt:  now,
+	}
+	for _, tag := range template.Tags {
+		session.Tags = append(session.Tags, entity.SessionTag{Tag: tag})
+	}
+
+	err = s.transactor.WithinTransaction(func(tx interfaces.TxRepositories) error {
+		if err := tx.Sessions.Create(session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		// Как и CreateSession, возвращаем задачи создателя
+		tasks, err := createUserTasks(tx.Tasks, session.ID, userID, template.Tasks, now)
+		if err != nil {
+			return err
+		}
+		session.Tasks = tasks
+
+		text := fmt.Sprintf("%s приглашает вас в сессию «%s». Чтобы присоединиться, откройте Синхрон и введите код приглашения %s.",
+			creator.Name, sessionTitle(session), session.InviteLink)
+		return enqueueInvites(tx.Outbox, session.ID, invitees, text)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// templateInvitees состав шаблона, которому бот пришлет приглашение, если сессию
+// создает владелец шаблона. Удаленные с момента сохранения пользователи пропускаются
+func (s *TemplateService) templateInvitees(template *entity.SessionTemplate, userID string) ([]*entity.User, error) {
+	if template.OwnerID != userID {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(template.Roster))
+	for _, member := range template.Roster {
+		if member.UserID != userID && !slices.Contains(ids, member.UserID) {
+			ids = append(ids, member.UserID)
+		}
+	}
+	if len(ids)+1 > s.maxSessionSize {
+		return nil, entity.NewValidationError("invalid_roster",
+			fmt.Sprintf("template roster exceeds the session limit of %d participants", s.maxSessionSize),
+			map[string]string{"roster": "max"})
+	}
+
+	invitees := make([]*entity.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := s.userRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user: %w", err)
+		}
+		if user != nil {
+			invitees = append(invitees, user)
+		}
+	}
+	return invitees, nil
+}
+
+// loadRoster проверяет состав шаблона: без владельца и повторов, не больше
+// maxSessionSize вместе с ним, только существующие пользователи
+func (s *TemplateService) loadRoster(ownerID string, params entity.NewTemplateParams) ([]entity.TemplateMember, error) {
+	if params.Session.Mode == entity.SessionModeSolo && len(params.RosterIDs) > 0 {
+		return nil, entity.NewValidationError("invalid_roster", "solo template cannot have a roster",
+			map[string]string{"roster": "invalid"})
+	}
+
+	ids := make([]string, 0, len(params.RosterIDs))
+	for _, id := range params.RosterIDs {
+		if id != ownerID && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids)+1 > s.maxSessionSize {
+		return nil, entity.NewValidationError("invalid_roster",
+			fmt.Sprintf("template can have at most %d participants", s.maxSessionSize),
+			map[string]string{"roster": "max"})
+	}
+
+	roster := make([]entity.TemplateMember, 0, len(ids))
+	for _, id := range ids {
+		user, err := s.userRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user: %w", err)
+		}
+		if user == nil {
+			return nil, errUserNotFound
+		}
+		roster = append(roster, entity.TemplateMember{
+			UserID:    user.ID,
+			UserName:  user.Name,
+			AvatarURL: user.AvatarURL,
+		})
+	}
+	return roster, nil
+}
+
+// loadOwnTemplate загружает шаблон, доступный только владельцу
+func (s *TemplateService) loadOwnTemplate(templateID string, userID string) (*entity.SessionTemplate, error) {
+	template, err := s.templateRepo.GetByID(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	if template == nil {
+		return nil, errTemplateNotFound
+	}
+	if template.OwnerID != userID {
+		return nil, errTemplateNotOwned
+	}
+	return template, nil
+}
+
+// buildTemplate проверяет название и метаданные и собирает новый шаблон
+func buildTemplate(ownerID string, params entity.NewTemplateParams, roster []entity.TemplateMember) (*entity.SessionTemplate, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" || utf8.RuneCountInString(name) > entity.MaxTemplateName {
+		return nil, entity.NewValidationError("invalid_name", "name must be 1-255 characters",
+			map[string]string{"name": "invalid"})
+	}
+
+	sessionParams := params.Session
+	sessionParams.ScheduledAt = nil
+	topic, tags, language, _, err := normalizeSessionMeta(sessionParams, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if sessionParams.CyclePlan != nil {
+		if err := sessionParams.CyclePlan.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Tag)
+	}
+	tasks := slices.Clone(sessionParams.Tasks)
+	if tasks == nil {
+		tasks = []string{}
+	}
+
+	return &entity.SessionTemplate{
+		ID:            uuid.New().String(),
+		OwnerID:       ownerID,
+		Name:          name,
+		Mode:          sessionParams.Mode,
+		FocusDuration: sessionParams.FocusDuration,
+		BreakDuration: sessionParams.BreakDuration,
+		GroupName:     sessionParams.GroupName,
+		Topic:         topic,
+		Language:      language,
+		Tags:          tagNames,
+		IsPrivate:     sessionParams.IsPrivate,
+		Tasks:         tasks,
+		CyclePlan:     sessionParams.CyclePlan,
+		Roster:        roster,
+		ShareLink:     uuid.New().String()[:8],
+		CreatedAt:     time.Now(),
+	}, nil
+}
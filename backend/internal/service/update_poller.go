@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/health"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+const (
+	// updatesCursorSource ключ маркера в update_cursors
+	updatesCursorSource = "maxapi_updates"
+	updatesBatchLimit   = 100
+	// updatesPollTimeout держит запрос открытым на сервере; меньше таймаута http клиента
+	updatesPollTimeout = 25
+	updatesMaxBackoff  = 30 * time.Second
+)
+
+// UpdatePoller получает обновления Max API через long polling /updates
+// и передает их в тот же диспетчер, что и webhook
+type UpdatePoller struct {
+	maxAPIService interfaces.MaxAPIService
+	cursorRepo    interfaces.UpdateCursorRepository
+	dispatcher    interfaces.UpdateDispatcher
+	log           *slog.Logger
+	heartbeat     *health.Heartbeat
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewUpdatePoller создает поллер обновлений
+func NewUpdatePoller(
+	maxAPIService interfaces.MaxAPIService,
+	cursorRepo interfaces.UpdateCursorRepository,
+	dispatcher interfaces.UpdateDispatcher,
+	log *slog.Logger,
+) *UpdatePoller {
+	return &UpdatePoller{
+		maxAPIService: maxAPIService,
+		cursorRepo:    cursorRepo,
+		dispatcher:    dispatcher,
+		log:           logger.Component(log, "update_poller"),
+		heartbeat:     health.NewHeartbeat(2 * (updatesPollTimeout*time.Second + updatesMaxBackoff)),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start запускает цикл опроса в отдельной горутине
+func (p *UpdatePoller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.log.Info("starting long polling", "timeout_seconds", updatesPollTimeout)
+
+	go func() {
+		defer close(p.done)
+		p.run(ctx)
+	}()
+}
+
+// Heartbeat отмечает каждую итерацию опроса, в том числе неудачную. Итерация —
+// это long poll или пауза после ошибки, допуск — две такие итерации
+func (p *UpdatePoller) Heartbeat() *health.Heartbeat {
+	return p.heartbeat
+}
+
+// Stop прерывает текущий запрос и ждет завершения обработки последней пачки
+func (p *UpdatePoller) Stop() {
+	p.once.Do(func() {
+		if p.cancel == nil {
+			return
+		}
+		p.cancel()
+		<-p.done
+		p.log.Info("stopped")
+	})
+}
+
+// dispatch обрабатывает обновление, повторяя его при временных ошибках (например,
+// недоступна БД), пока не получится: в отличие от webhook, MAX не пришлет его снова.
+// Доменные ошибки и постоянные ошибки Max API (4xx, например бот заблокирован)
+// повтором не исправить — такое обновление пропускаем. Пока идут
+// повторы, heartbeat не обновляется и проверка готовности показывает застрявший поллер.
+// Возвращает false, если поллер остановили до успешной обработки
+func (p *UpdatePoller) dispatch(ctx context.Context, update interface{}) bool {
+	// Начатую обработку не прерываем остановкой
+	dispatchCtx := context.WithoutCancel(ctx)
+	for failures := 1; ; failures++ {
+		_, err := p.dispatcher.Dispatch(dispatchCtx, update)
+		if err == nil {
+			return true
+		}
+
+		var domainErr *entity.DomainError
+		var apiErr *maxapi.APIError
+		if errors.As(err, &domainErr) || errors.As(err, &apiErr) && !maxapi.IsRetryable(err) {
+			p.log.Warn("skipping update that cannot be processed", logger.Err(err))
+			return true
+		}
+
+		backoff := pollBackoff(failures)
+		p.log.Error("failed to dispatch update", "retry_in", backoff, "attempts", failures, logger.Err(err))
+		if !sleepContext(ctx, backoff) {
+			return false
+		}
+	}
+}
+
+// pollBackoff экспоненциальная пауза после failures неудач подряд
+func pollBackoff(failures int) time.Duration {
+	backoff := time.Second << uint(failures-1)
+	if backoff <= 0 || backoff > updatesMaxBackoff {
+		backoff = updatesMaxBackoff
+	}
+	return backoff
+}
+
+// sleepContext ждет d; false — если контекст отменили раньше
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (p *UpdatePoller) run(ctx context.Context) {
+	marker, err := p.cursorRepo.GetMarker(updatesCursorSource)
+	if err != nil {
+		p.log.Warn("failed to load marker, starting from the latest updates", logger.Err(err))
+	}
+
+	failures := 0
+	for ctx.Err() == nil {
+		p.heartbeat.Beat()
+		resp, err := p.maxAPIService.GetUpdates(ctx, marker, updatesBatchLimit, updatesPollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+			backoff := pollBackoff(failures)
+			p.log.Error("failed to get updates", "retry_in", backoff, logger.Err(err))
+
+			if !sleepContext(ctx, backoff) {
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		// Маркер сохраняем только после того, как обработана вся пачка. При остановке
+		// посреди пачки маркер остается прежним и пачка придет снова; повторная
+		// доставка безопасна — сообщения дедуплицируются
+		for _, raw := range resp.Updates {
+			update, err := maxapi.ParseUpdate(raw)
+			if err != nil {
+				p.log.Warn("failed to parse update", logger.Err(err))
+				continue
+			}
+			if !p.dispatch(ctx, update) {
+				return
+			}
+		}
+
+		if resp.Marker != nil && (marker == nil || *resp.Marker != *marker) {
+			marker = resp.Marker
+			if err := p.cursorRepo.SaveMarker(updatesCursorSource, *marker); err != nil {
+				p.log.Warn("failed to save marker", "marker", *marker, logger.Err(err))
+			}
+		}
+	}
+}
@@ -20,7 +20,10 @@ func NewUserService(userRepo interfaces.UserRepository) interfaces.UserService {
 func (s *UserService) GetProfile(userID string) (*entity.User, *entity.UserStats, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("user not found: %w", err)
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil, errUserNotFound
 	}
 
 	stats, err := s.userRepo.GetStats(userID)
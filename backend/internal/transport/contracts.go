@@ -1,5 +1,12 @@
 package transport
 
 type ErrorsResponse struct {
-	Errors map[string]string `json:"errors"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ErrorResponse единый формат ошибки API
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	ErrorsResponse
 }
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware пропускает запросы со статическим токеном в заголовке X-Admin-Token.
+// Пустой adminToken закрывает доступ полностью
+func AdminMiddleware(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("X-Admin-Token")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+				"code":  "forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -14,6 +14,7 @@ func AuthMiddleware(authService interfaces.AuthService) gin.HandlerFunc {
 		if err != nil || accessToken == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "unauthorized",
+				"code":  "unauthorized",
 			})
 			c.Abort()
 			return
@@ -23,6 +24,7 @@ func AuthMiddleware(authService interfaces.AuthService) gin.HandlerFunc {
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid token",
+				"code":  "invalid_token",
 			})
 			c.Abort()
 			return
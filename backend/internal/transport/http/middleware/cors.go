@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// AllowedOrigins — общая политика origin для CORS и WebSocket. Список можно
+// заменить на лету при перезагрузке конфигурации
+type AllowedOrigins struct {
+	policy atomic.Pointer[originPolicy]
+}
+
+type originPolicy struct {
+	exact     map[string]struct{}
+	wildcards []wildcardOrigin
+}
+
+// wildcardOrigin — шаблон https://*.example.com: любой поддомен example.com
+// (на любую глубину), но не сам example.com; схема и порт должны совпасть
+type wildcardOrigin struct {
+	scheme string
+	suffix string // ".example.com"
+	port   string
+}
+
+// NewAllowedOrigins создает политику из origin вида https://example.com и
+// шаблонов https://*.example.com
+func NewAllowedOrigins(origins []string) *AllowedOrigins {
+	a := &AllowedOrigins{}
+	a.Set(origins)
+	return a
+}
+
+// Set заменяет список целиком. Некорректные записи пропускаются — их отсекает
+// проверка конфигурации
+func (a *AllowedOrigins) Set(origins []string) {
+	policy := &originPolicy{exact: make(map[string]struct{}, len(origins))}
+	for _, origin := range origins {
+		origin = normalizeOrigin(origin)
+		if !strings.Contains(origin, "*") {
+			policy.exact[origin] = struct{}{}
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+		if err != nil || !strings.HasPrefix(u.Host, "wildcard.") {
+			continue
+		}
+		policy.wildcards = append(policy.wildcards, wildcardOrigin{
+			scheme: u.Scheme,
+			suffix: strings.TrimPrefix(u.Hostname(), "wildcard"),
+			port:   u.Port(),
+		})
+	}
+	a.policy.Store(policy)
+}
+
+// Allowed сообщает, разрешен ли origin
+func (a *AllowedOrigins) Allowed(origin string) bool {
+	origin = normalizeOrigin(origin)
+	policy := a.policy.Load()
+	if _, ok := policy.exact[origin]; ok {
+		return true
+	}
+	if len(policy.wildcards) == 0 {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Hostname()
+	for _, wildcard := range policy.wildcards {
+		if u.Scheme == wildcard.scheme && u.Port() == wildcard.port &&
+			len(host) > len(wildcard.suffix) && strings.HasSuffix(host, wildcard.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWebSocketOrigin — CheckOrigin для websocket.Upgrader. Браузер всегда
+// присылает Origin при открытии WebSocket и прикладывает cookie пользователя,
+// поэтому чужая страница не должна получить соединение. Запросы без Origin
+// (не из браузера) и с того же хоста пропускаем, как это делает и CORS
+func (a *AllowedOrigins) CheckWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return a.Allowed(origin)
+}
+
+// normalizeOrigin приводит origin к виду для сравнения: схема и хост
+// регистронезависимы, завершающий слэш не важен
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedOrigins(t *testing.T) {
+	origins := NewAllowedOrigins([]string{
+		"https://focus-sync.ru",
+		"https://*.max.ru",
+		"http://*.example.com:8080",
+		"https://*.",
+	})
+
+	for origin, want := range map[string]bool{
+		"https://focus-sync.ru":          true,
+		"https://Focus-Sync.ru/":         true,
+		"http://focus-sync.ru":           false,
+		"https://st.max.ru":              true,
+		"https://a.b.max.ru":             true,
+		"https://max.ru":                 false,
+		"https://evilmax.ru":             false,
+		"https://max.ru.evil.com":        false,
+		"http://st.max.ru":               false,
+		"https://st.max.ru:8443":         false,
+		"http://app.example.com:8080":    true,
+		"http://app.example.com":         false,
+		"https://focus-sync.ru.evil.com": false,
+		"null":                           false,
+	} {
+		if got := origins.Allowed(origin); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+
+	origins.Set([]string{"https://new.example"})
+	if origins.Allowed("https://focus-sync.ru") || !origins.Allowed("https://new.example") {
+		t.Error("Set did not replace the list")
+	}
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	origins := NewAllowedOrigins([]string{"https://*.max.ru"})
+
+	for origin, want := range map[string]bool{
+		"":                       true, // не браузер
+		"https://api.local:8080": true, // тот же хост
+		"https://st.max.ru":      true,
+		"https://evil.example":   false,
+	} {
+		r := httptest.NewRequest("GET", "http://api.local:8080/api/v1/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := origins.CheckWebSocketOrigin(r); got != want {
+			t.Errorf("CheckWebSocketOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+)
+
+// Заголовки идемпотентных запросов
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed" // "true" в ответе, взятом из сохраненных
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentRequest — тело запроса читается в память для отпечатка, больше не принимаем
+	maxIdempotentRequest = 1 << 20
+	// maxIdempotentResponse — ответы больше не сохраняются: повтор выполнит запрос заново
+	maxIdempotentResponse = 1 << 20
+)
+
+// Idempotency сохраняет ответ на мутирующий запрос с заголовком Idempotency-Key
+// и на ttl отдает его же на повторы с тем же ключом, не выполняя обработчик.
+// Ключ действует в пределах пользователя; тот же ключ с другим запросом — 422,
+// повтор во время выполнения первого — 409. Ответы 5xx не сохраняются, чтобы
+// повтор мог пройти. Подключается после AuthMiddleware
+func Idempotency(repo interfaces.IdempotencyRepository, ttl time.Duration, log *slog.Logger) gin.HandlerFunc {
+	log = logger.Component(log, "idempotency")
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		userID := c.GetString("userID")
+		if key == "" || userID == "" || !mutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency-Key must be 1-255 printable ASCII characters",
+				"code":  "invalid_idempotency_key",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentRequest))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "request body is too large",
+				"code":  "request_too_large",
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "failed to read request body",
+				"code":  "invalid_request",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &entity.IdempotencyRecord{
+			Key:         userID + ":" + key,
+			Fingerprint: requestFingerprint(c.Request.Method, c.Request.URL.Path, body),
+			Status:      entity.IdempotencyStatusInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		existing, err := repo.Begin(record, now)
+		if err != nil {
+			// Без хранилища ключей запрос выполняется как обычно
+			log.ErrorContext(c.Request.Context(), "idempotency store failed, request executed without key",
+				logger.Err(err))
+			c.Next()
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, record.Fingerprint)
+			return
+		}
+
+		writer := &idempotentWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		completed := false
+		defer func() {
+			// Ошибка сервера, паника или слишком большой ответ — освобождаем ключ
+			if completed {
+				return
+			}
+			if err := repo.Delete(record.Key); err != nil {
+				log.ErrorContext(c.Request.Context(), "failed to release idempotency key", logger.Err(err))
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || writer.overflow {
+			return
+		}
+		if err := repo.Complete(record.Key, status, writer.Header().Get("Content-Type"), writer.body.String()); err != nil {
+			log.ErrorContext(c.Request.Context(), "failed to store idempotent response", logger.Err(err))
+			return
+		}
+		completed = true
+	}
+}
+
+// replayIdempotent отвечает на повтор по существующей записи ключа
+func replayIdempotent(c *gin.Context, existing *entity.IdempotencyRecord, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key was already used for a different request",
+			"code":  "idempotency_key_reused",
+		})
+	case existing.Status != entity.IdempotencyStatusCompleted:
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "a request with this Idempotency-Key is still in progress",
+			"code":  "idempotency_request_in_progress",
+		})
+	default:
+		c.Header(IdempotencyReplayedHeader, "true")
+		c.Data(existing.ResponseStatus, existing.ContentType, []byte(existing.ResponseBody))
+		c.Abort()
+	}
+}
+
+// idempotentWriter копирует тело ответа для сохранения
+type idempotentWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *idempotentWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotentWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *idempotentWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxIdempotentResponse {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+func mutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// validIdempotencyKey допускает видимые ASCII-символы: ключ попадает в хранилище
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint отличает повтор от другого запроса с тем же ключом
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rnegic/synchronous/internal/logger"
+)
+
+// RequestIDHeader — заголовок с ID запроса: принимаем от nginx, возвращаем клиенту
+const RequestIDHeader = "X-Request-ID"
+
+// RequestLogger присваивает запросу ID, кладет его в context запроса и пишет
+// access-лог. Query-строка не логируется: в ней бывают initData и токены
+func RequestLogger(log *slog.Logger) gin.HandlerFunc {
+	log = logger.Component(log, "http")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("size", c.Writer.Size()),
+		}
+		if userID := c.GetString("userID"); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		log.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery перехватывает панику в обработчике и отвечает 500. В отличие от
+// gin.Recovery не выводит заголовки запроса — в них cookies с токенами
+func Recovery(log *slog.Logger) gin.HandlerFunc {
+	log = logger.Component(log, "http")
+
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.ErrorContext(c.Request.Context(), "panic recovered",
+				slog.Any("panic", recovered),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "internal server error",
+					"code":  "internal_error",
+				})
+				return
+			}
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// validRequestID не дает записать в лог произвольную строку из заголовка
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 64 {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
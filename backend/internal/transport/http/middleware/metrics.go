@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/metrics"
+)
+
+// unmatchedRoute — метка для запросов без маршрута (404), чтобы сканеры не плодили серии
+const unmatchedRoute = "unmatched"
+
+// Metrics учитывает длительность и статус запросов по шаблону маршрута
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
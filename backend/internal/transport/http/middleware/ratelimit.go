@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/metrics"
+)
+
+// Заголовки ограничителя (draft-ietf-httpapi-ratelimit-headers)
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimiter ограничивает частоту запросов token bucket'ами по группам маршрутов.
+// Ключ — пользователь из AuthMiddleware, для публичных маршрутов — IP клиента.
+// Лимиты групп можно заменить на лету при перезагрузке конфигурации
+type RateLimiter struct {
+	store   interfaces.RateLimitStore
+	limits  atomic.Pointer[map[string]entity.RateLimit]
+	log     *slog.Logger
+	metrics *metrics.Metrics
+}
+
+// NewRateLimiter создает ограничитель; группа без лимита или с выключенным лимитом не ограничивается
+func NewRateLimiter(store interfaces.RateLimitStore, limits map[string]entity.RateLimit, log *slog.Logger, m *metrics.Metrics) *RateLimiter {
+	l := &RateLimiter{
+		store:   store,
+		log:     logger.Component(log, "ratelimit"),
+		metrics: m,
+	}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits заменяет лимиты всех групп. Накопленные bucket'ы сохраняются
+func (l *RateLimiter) SetLimits(limits map[string]entity.RateLimit) {
+	copied := make(map[string]entity.RateLimit, len(limits))
+	for group, limit := range limits {
+		copied[group] = limit
+	}
+	l.limits.Store(&copied)
+}
+
+// Limit ограничивает все маршруты, к которым подключено middleware
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.take(c, group)
+	}
+}
+
+// LimitRoute ограничивает только маршрут method + route (шаблон gin, как в c.FullPath()),
+// остальные маршруты группы пропускает
+func (l *RateLimiter) LimitRoute(group, method, route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != method || c.FullPath() != route {
+			c.Next()
+			return
+		}
+		l.take(c, group)
+	}
+}
+
+func (l *RateLimiter) take(c *gin.Context, group string) {
+	limit := (*l.limits.Load())[group]
+	if !limit.Enabled() {
+		c.Next()
+		return
+	}
+
+	key := group + ":ip:" + c.ClientIP()
+	if userID := c.GetString("userID"); userID != "" {
+		key = group + ":user:" + userID
+	}
+
+	result, err := l.store.Take(key, limit, time.Now())
+	if err != nil {
+		// Сбой хранилища не должен останавливать API — пропускаем запрос
+		l.log.ErrorContext(c.Request.Context(), "rate limit store failed, request allowed",
+			"group", group, logger.Err(err))
+		c.Next()
+		return
+	}
+
+	c.Header(RateLimitLimitHeader, strconv.Itoa(limit.Requests))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+	c.Header(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	c.Header(RateLimitPolicyHeader, strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+
+	if !result.Allowed {
+		l.metrics.RateLimited(group)
+		c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "too many requests",
+			"code":  "rate_limited",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
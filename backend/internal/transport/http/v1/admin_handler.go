@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+type AdminHandler struct {
+	*BaseHandler
+	outboxService interfaces.OutboxService
+}
+
+func NewAdminHandler(baseHandler *BaseHandler, outboxService interfaces.OutboxService) *AdminHandler {
+	return &AdminHandler{
+		BaseHandler:   baseHandler,
+		outboxService: outboxService,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup) {
+	outbox := router.Group("/outbox")
+	{
+		outbox.GET("", h.listOutbox)
+		outbox.POST("/:id/retry", h.retryOutbox)
+	}
+}
+
+// listOutbox возвращает сообщения outbox, по умолчанию — dead letters
+func (h *AdminHandler) listOutbox(c *gin.Context) {
+	status := entity.OutboxStatus(c.DefaultQuery("status", string(entity.OutboxStatusDead)))
+	switch status {
+	case entity.OutboxStatusPending, entity.OutboxStatusDelivered, entity.OutboxStatusDead:
+	case "all":
+		status = ""
+	default:
+		h.HandleError(c, entity.NewValidationError("invalid_status", "invalid status", map[string]string{
+			"status": "oneof",
+		}))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	messages, total, err := h.outboxService.List(status, page, limit)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	messagesList := make([]gin.H, 0, len(messages))
+	for _, message := range messages {
+		messagesList = append(messagesList, h.outboxMessageToMap(message))
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"messages": messagesList,
+		"pagination": gin.H{
+			"page":    page,
+			"limit":   limit,
+			"total":   total,
+			"hasNext": (page * limit) < total,
+		},
+	})
+}
+
+// retryOutbox возвращает сообщение в очередь доставки
+func (h *AdminHandler) retryOutbox(c *gin.Context) {
+	message, err := h.outboxService.Retry(c.Param("id"))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, h.outboxMessageToMap(message))
+}
+
+func (h *AdminHandler) outboxMessageToMap(message *entity.OutboxMessage) gin.H {
+	result := gin.H{
+		"id":            message.ID,
+		"action":        message.Action,
+		"sessionId":     message.SessionID,
+		"payload":       message.Payload,
+		"status":        message.Status,
+		"attempts":      message.Attempts,
+		"lastError":     message.LastError,
+		"nextAttemptAt": message.NextAttemptAt.Format(time.RFC3339),
+		"createdAt":     message.CreatedAt.Format(time.RFC3339),
+	}
+	if message.DeliveredAt != nil {
+		result["deliveredAt"] = message.DeliveredAt.Format(time.RFC3339)
+	}
+	return result
+}
@@ -1,12 +1,13 @@
 package v1
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rnegic/synchronous/internal/entity"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
 	"github.com/rnegic/synchronous/pkg/jwt"
 )
 
@@ -14,6 +15,7 @@ type AuthHandler struct {
 	*BaseHandler
 	authService  interfaces.AuthService
 	tokenManager *jwt.TokenManager
+	log          *slog.Logger
 }
 
 func NewAuthHandler(baseHandler *BaseHandler, authService interfaces.AuthService, tokenManager *jwt.TokenManager) *AuthHandler {
@@ -21,6 +23,7 @@ func NewAuthHandler(baseHandler *BaseHandler, authService interfaces.AuthService
 		BaseHandler:  baseHandler,
 		authService:  authService,
 		tokenManager: tokenManager,
+		log:          logger.Component(baseHandler.log, "auth"),
 	}
 }
 
@@ -36,29 +39,25 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 func (h *AuthHandler) login(c *gin.Context) {
 	var req entity.MaxAuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
-	fmt.Printf("[Auth Handler] 📥 Login request received\n")
-	fmt.Printf("[Auth Handler]   Request origin: %s\n", c.Request.Header.Get("Origin"))
-	fmt.Printf("[Auth Handler]   User-Agent: %s\n", c.Request.Header.Get("User-Agent"))
-	fmt.Printf("[Auth Handler]   X-Forwarded-Proto: %s\n", c.Request.Header.Get("X-Forwarded-Proto"))
-
-	tokens, user, err := h.authService.Login(req.InitData, req.DeviceID)
+	ctx := c.Request.Context()
+	tokens, user, err := h.authService.Login(ctx, req.InitData, req.DeviceID)
 	if err != nil {
-		fmt.Printf("[Auth Handler] ❌ Login failed: %v\n", err)
+		h.log.WarnContext(ctx, "login failed",
+			"origin", c.GetHeader("Origin"), "user_agent", c.GetHeader("User-Agent"), logger.Err(err))
 		h.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	fmt.Printf("[Auth Handler] ✅ Login successful for user: %s (ID: %s)\n", user.Name, user.ID)
+	h.log.InfoContext(ctx, "login succeeded", "user_id", user.ID)
 
 	// Set HTTP-only cookies instead of returning tokens in body
 	accessTTL := h.tokenManager.GetAccessTTL()
 	refreshTTL := h.tokenManager.GetRefreshTTL()
 
-	fmt.Printf("[Auth Handler] 🍪 Setting cookies (access TTL: %ds, refresh TTL: %ds)\n", accessTTL, refreshTTL)
 	h.setAccessTokenCookie(c, tokens.AccessToken, accessTTL)
 	h.setRefreshTokenCookie(c, tokens.RefreshToken, refreshTTL)
 
@@ -71,8 +70,6 @@ func (h *AuthHandler) login(c *gin.Context) {
 			"createdAt": user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		},
 	})
-
-	fmt.Printf("[Auth Handler] ✅ Response sent with cookies\n")
 }
 
 func (h *AuthHandler) refresh(c *gin.Context) {
@@ -113,7 +110,7 @@ func (h *AuthHandler) logout(c *gin.Context) {
 	}
 
 	if err := h.authService.Logout(userID); err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
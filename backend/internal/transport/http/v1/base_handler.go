@@ -1,16 +1,25 @@
 package v1
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/transport"
 )
 
 type BaseHandler struct {
+	log *slog.Logger
 }
 
-func NewBaseHandler() *BaseHandler {
-	return &BaseHandler{}
+func NewBaseHandler(log *slog.Logger) *BaseHandler {
+	return &BaseHandler{log: log}
 }
 
 // Helper методы для работы с gin.Context
@@ -24,11 +33,122 @@ func (h *BaseHandler) GetUserID(c *gin.Context) string {
 }
 
 func (h *BaseHandler) ErrorResponse(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, gin.H{
-		"error": message,
+	c.JSON(statusCode, transport.ErrorResponse{
+		Error: message,
+		Code:  defaultErrorCode(statusCode),
 	})
 }
 
+// HandleError передает ошибку сервиса в ErrorMiddleware, которое выберет статус по ее категории
+func (h *BaseHandler) HandleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+}
+
+// ErrorMiddleware рендерит ошибки, переданные через HandleError, в едином формате:
+// {"error": "...", "code": "...", "errors": {"field": "..."}}
+func (h *BaseHandler) ErrorMiddleware() gin.HandlerFunc {
+	log := logger.Component(h.log, "http")
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		statusCode, response := mapError(err)
+		if statusCode >= http.StatusInternalServerError {
+			log.ErrorContext(c.Request.Context(), "request failed",
+				"method", c.Request.Method, "path", c.Request.URL.Path, logger.Err(err))
+		}
+
+		c.JSON(statusCode, response)
+	}
+}
+
+// bindingError превращает ошибку ShouldBindJSON в ошибку валидации с полями запроса
+func (h *BaseHandler) bindingError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return entity.NewValidationError("invalid_request_body", "invalid request body", nil)
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fields[lowerFirst(fieldErr.Field())] = fieldErr.Tag()
+	}
+	return entity.NewValidationError("invalid_request_body", "invalid request body", fields)
+}
+
+// errorKinds сопоставляет категории доменных ошибок со статусами HTTP
+var errorKinds = []struct {
+	kind       error
+	statusCode int
+	code       string
+}{
+	{entity.ErrNotFound, http.StatusNotFound, "not_found"},
+	{entity.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{entity.ErrConflict, http.StatusConflict, "conflict"},
+	{entity.ErrInvalidState, http.StatusConflict, "invalid_state"},
+	{entity.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{entity.ErrUpstream, http.StatusBadGateway, "upstream_error"},
+}
+
+func mapError(err error) (int, transport.ErrorResponse) {
+	for _, kind := range errorKinds {
+		if !errors.Is(err, kind.kind) {
+			continue
+		}
+
+		response := transport.ErrorResponse{Error: err.Error(), Code: kind.code}
+		var domainErr *entity.DomainError
+		if errors.As(err, &domainErr) {
+			response.Error = domainErr.Message
+			if domainErr.Code != "" {
+				response.Code = domainErr.Code
+			}
+			response.Errors = domainErr.Fields
+		}
+		return kind.statusCode, response
+	}
+
+	// Неизвестные ошибки наружу не показываем — подробности только в логе
+	return http.StatusInternalServerError, transport.ErrorResponse{
+		Error: "internal server error",
+		Code:  "internal_error",
+	}
+}
+
+func defaultErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusBadGateway:
+		return "upstream_error"
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return "error"
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
 func (h *BaseHandler) SuccessResponse(c *gin.Context, statusCode int, data interface{}) {
 	c.JSON(statusCode, data)
 }
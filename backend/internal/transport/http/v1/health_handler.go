@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/health"
+)
+
+type HealthHandler struct {
+	*BaseHandler
+	checker   *health.Checker
+	startedAt time.Time
+}
+
+func NewHealthHandler(baseHandler *BaseHandler, checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		BaseHandler: baseHandler,
+		checker:     checker,
+		startedAt:   time.Now(),
+	}
+}
+
+func (h *HealthHandler) RegisterRoutes(router *gin.RouterGroup) {
+	health := router.Group("/health")
+	{
+		health.GET("/live", h.live)
+		health.GET("/ready", h.ready)
+	}
+}
+
+// live отвечает, пока процесс обслуживает HTTP; зависимости не проверяет,
+// чтобы сбой БД или MAX API не приводил к перезапуску контейнера
+func (h *HealthHandler) live(c *gin.Context) {
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"status":        health.StatusOK,
+		"startedAt":     h.startedAt,
+		"uptimeSeconds": int64(time.Since(h.startedAt).Seconds()),
+	})
+}
+
+// ready проверяет зависимости: 503, если упала критичная проверка (БД),
+// 200 со статусом degraded — если некритичная (MAX API, фоновые воркеры)
+func (h *HealthHandler) ready(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if report.Status == health.StatusDown {
+		statusCode = http.StatusServiceUnavailable
+	}
+	h.SuccessResponse(c, statusCode, report)
+}
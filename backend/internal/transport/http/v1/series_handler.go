@@ -0,0 +1,326 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+// SeriesHandler повторяющиеся сессии: серии, их расписание, изменения повторений и история
+type SeriesHandler struct {
+	*BaseHandler
+	seriesService interfaces.SeriesService
+}
+
+func NewSeriesHandler(baseHandler *BaseHandler, seriesService interfaces.SeriesService) *SeriesHandler {
+	return &SeriesHandler{
+		BaseHandler:   baseHandler,
+		seriesService: seriesService,
+	}
+}
+
+func (h *SeriesHandler) RegisterRoutes(router *gin.RouterGroup) {
+	series := router.Group("/series")
+	{
+		series.GET("", h.listSeries)
+		series.POST("", h.createSeries)
+		series.POST("/join-by-invite", h.joinByInviteLink)
+
+		one := series.Group("/:seriesId")
+		{
+			one.GET("", h.getSeries)
+			one.POST("/stop", h.stopSeries)
+			one.GET("/history", h.getHistory)
+			one.PATCH("/occurrences/:occurrenceAt", h.updateOccurrence)
+		}
+	}
+}
+
+// createSeries создает серию; экземпляры на ближайший горизонт создаются сразу
+func (h *SeriesHandler) createSeries(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Mode          string    `json:"mode" binding:"required"`
+		Tasks         []string  `json:"tasks"`
+		FocusDuration int       `json:"focusDuration" binding:"required,min=1"`
+		BreakDuration int       `json:"breakDuration" binding:"required,min=1"`
+		GroupName     *string   `json:"groupName"`
+		IsPrivate     bool      `json:"isPrivate"`
+		Topic         *string   `json:"topic"`
+		Tags          []string  `json:"tags"`
+		Language      *string   `json:"language"`
+		Quorum        int       `json:"quorum"`
+		Recurrence    string    `json:"recurrence" binding:"required"`
+		StartsAt      time.Time `json:"startsAt" binding:"required"`
+		Timezone      string    `json:"timezone"`
+		InviteUserIDs []string  `json:"inviteUserIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return
+	}
+
+	mode := entity.SessionMode(req.Mode)
+	if mode != entity.SessionModeSolo && mode != entity.SessionModeGroup {
+		h.HandleError(c, entity.NewValidationError("invalid_mode", "invalid mode: must be 'solo' or 'group'", map[string]string{
+			"mode": "oneof",
+		}))
+		return
+	}
+
+	series, err := h.seriesService.CreateSeries(userID, entity.NewSeriesParams{
+		Session: entity.NewSessionParams{
+			Mode:          mode,
+			Tasks:         req.Tasks,
+			FocusDuration: req.FocusDuration,
+			BreakDuration: req.BreakDuration,
+			GroupName:     req.GroupName,
+			IsPrivate:     req.IsPrivate,
+			Topic:         req.Topic,
+			Tags:          req.Tags,
+			Language:      req.Language,
+			Quorum:        req.Quorum,
+		},
+		Recurrence:    req.Recurrence,
+		StartsAt:      req.StartsAt,
+		Timezone:      req.Timezone,
+		InviteUserIDs: req.InviteUserIDs,
+	})
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusCreated, gin.H{
+		"series": seriesToMap(series),
+	})
+}
+
+// listSeries возвращает серии, где пользователь создатель или участник
+func (h *SeriesHandler) listSeries(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	list, err := h.seriesService.ListSeries(userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(list))
+	for _, series := range list {
+		items = append(items, seriesToMap(series))
+	}
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"series": items,
+	})
+}
+
+// getSeries возвращает серию с ближайшими повторениями
+func (h *SeriesHandler) getSeries(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	series, upcoming, err := h.seriesService.GetSeries(c.Param("seriesId"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"series":   seriesToMap(series),
+		"upcoming": occurrencesToMaps(upcoming),
+	})
+}
+
+// joinByInviteLink добавляет пользователя в серию по ее постоянной ссылке
+func (h *SeriesHandler) joinByInviteLink(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		InviteLink string `json:"inviteLink" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return
+	}
+
+	series, err := h.seriesService.JoinSeries(req.InviteLink, userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"series": seriesToMap(series),
+	})
+}
+
+// stopSeries останавливает серию и отменяет ожидающие экземпляры
+func (h *SeriesHandler) stopSeries(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.seriesService.StopSeries(c.Param("seriesId"), userID); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// updateOccurrence пропускает повторение или меняет его время и длительности.
+// Повторение задается началом по правилу в RFC 3339
+func (h *SeriesHandler) updateOccurrence(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	occurrenceAt, err := time.Parse(time.RFC3339, c.Param("occurrenceAt"))
+	if err != nil {
+		h.HandleError(c, entity.NewValidationError("invalid_occurrence", "occurrence must be an RFC 3339 time",
+			map[string]string{"occurrenceAt": "datetime"}))
+		return
+	}
+
+	var req struct {
+		Skipped       *bool      `json:"skipped"`
+		ScheduledAt   *time.Time `json:"scheduledAt"`
+		FocusDuration *int       `json:"focusDuration" binding:"omitempty,min=1"`
+		BreakDuration *int       `json:"breakDuration" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return
+	}
+
+	occurrence, err := h.seriesService.UpdateOccurrence(c.Param("seriesId"), userID, occurrenceAt, entity.OccurrenceChange{
+		Skipped:       req.Skipped,
+		ScheduledAt:   req.ScheduledAt,
+		FocusDuration: req.FocusDuration,
+		BreakDuration: req.BreakDuration,
+	})
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"occurrence": occurrenceToMap(*occurrence),
+	})
+}
+
+// getHistory возвращает прошедшие повторения серии и серии завершенных подряд
+func (h *SeriesHandler) getHistory(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	history, err := h.seriesService.GetSeriesHistory(c.Param("seriesId"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"occurrences":   occurrencesToMaps(history.Occurrences),
+		"completed":     history.Completed,
+		"missed":        history.Missed,
+		"skipped":       history.Skipped,
+		"currentStreak": history.CurrentStreak,
+		"longestStreak": history.LongestStreak,
+	})
+}
+
+func seriesToMap(series *entity.SessionSeries) gin.H {
+	members := make([]gin.H, 0, len(series.Members))
+	for _, member := range series.Members {
+		members = append(members, gin.H{
+			"userId":    member.UserID,
+			"userName":  member.UserName,
+			"avatarUrl": member.AvatarURL,
+			"joinedAt":  member.JoinedAt.Format(time.RFC3339),
+		})
+	}
+	tags := series.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	seriesMap := gin.H{
+		"id":            series.ID,
+		"creatorId":     series.CreatorID,
+		"mode":          series.Mode,
+		"focusDuration": series.FocusDuration,
+		"breakDuration": series.BreakDuration,
+		"isPrivate":     series.IsPrivate,
+		"quorum":        series.Quorum,
+		"tasks":         series.Tasks,
+		"tags":          tags,
+		"recurrence":    series.Recurrence,
+		"startsAt":      series.StartsAt.In(series.Location()).Format(time.RFC3339),
+		"timezone":      series.Timezone,
+		"inviteLink":    series.InviteLink,
+		"members":       members,
+		"createdAt":     series.CreatedAt.Format(time.RFC3339),
+	}
+	if series.GroupName != nil {
+		seriesMap["groupName"] = *series.GroupName
+	}
+	if series.Topic != nil {
+		seriesMap["topic"] = *series.Topic
+	}
+	if series.Language != nil {
+		seriesMap["language"] = *series.Language
+	}
+	if series.EndedAt != nil {
+		seriesMap["endedAt"] = series.EndedAt.Format(time.RFC3339)
+	}
+	return seriesMap
+}
+
+func occurrenceToMap(occurrence entity.SeriesOccurrence) gin.H {
+	return gin.H{
+		"occurrenceAt":  occurrence.OccurrenceAt.Format(time.RFC3339),
+		"scheduledAt":   occurrence.ScheduledAt.Format(time.RFC3339),
+		"focusDuration": occurrence.FocusDuration,
+		"breakDuration": occurrence.BreakDuration,
+		"status":        occurrence.Status,
+		"sessionId":     occurrence.SessionID,
+	}
+}
+
+func occurrencesToMaps(occurrences []entity.SeriesOccurrence) []gin.H {
+	items := make([]gin.H, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		items = append(items, occurrenceToMap(occurrence))
+	}
+	return items
+}
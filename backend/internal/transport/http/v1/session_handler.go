@@ -3,7 +3,6 @@ package v1
 import (
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +13,7 @@ import (
 type SessionHandler struct {
 	*BaseHandler
 	sessionService     interfaces.SessionService
+	templateService    interfaces.TemplateService
 	messageService     interfaces.MessageService
 	leaderboardService interfaces.LeaderboardService
 	wsHandler          *WebSocketHandler
@@ -22,6 +22,7 @@ type SessionHandler struct {
 func NewSessionHandler(
 	baseHandler *BaseHandler,
 	sessionService interfaces.SessionService,
+	templateService interfaces.TemplateService,
 	messageService interfaces.MessageService,
 	leaderboardService interfaces.LeaderboardService,
 	wsHandler *WebSocketHandler,
@@ -29,6 +30,7 @@ func NewSessionHandler(
 	return &SessionHandler{
 		BaseHandler:        baseHandler,
 		sessionService:     sessionService,
+		templateService:    templateService,
 		messageService:     messageService,
 		leaderboardService: leaderboardService,
 		wsHandler:          wsHandler,
@@ -42,6 +44,7 @@ func (h *SessionHandler) RegisterRoutes(router *gin.RouterGroup) {
 		sessions.GET("", h.getHistory)
 		sessions.GET("/public", h.getPublicSessions)
 		sessions.POST("", h.createSession)
+		sessions.POST("/from-template", h.createFromTemplate)
 		sessions.GET("/active", h.getActiveSession)
 		sessions.POST("/join-by-invite", h.joinByInviteLink)
 
@@ -49,12 +52,15 @@ func (h *SessionHandler) RegisterRoutes(router *gin.RouterGroup) {
 		session := sessions.Group("/:sessionId")
 		{
 			session.GET("", h.getSession)
+			session.DELETE("", h.deleteSession)
+			session.POST("/restore", h.restoreSession)
 			session.POST("/join", h.joinSession)
 			session.PATCH("/ready", h.setReady)
 			session.POST("/start", h.startSession)
 			session.POST("/pause", h.pauseSession)
 			session.POST("/resume", h.resumeSession)
 			session.POST("/complete", h.completeSession)
+			session.POST("/cancel", h.cancelSession)
 			session.GET("/report", h.getSessionReport)
 
 			// Чат
@@ -91,36 +97,49 @@ func (h *SessionHandler) createSession(c *gin.Context) {
 	}
 
 	var req struct {
-		Mode          string   `json:"mode" binding:"required"`
-		Tasks         []string `json:"tasks" binding:"required"`
-		FocusDuration int      `json:"focusDuration" binding:"required"`
-		BreakDuration int      `json:"breakDuration" binding:"required"`
-		GroupName     *string  `json:"groupName"`
-		IsPrivate     bool     `json:"isPrivate"`
+		Mode          string            `json:"mode" binding:"required"`
+		Tasks         []string          `json:"tasks" binding:"required"`
+		FocusDuration int               `json:"focusDuration" binding:"required"`
+		BreakDuration int               `json:"breakDuration" binding:"required"`
+		GroupName     *string           `json:"groupName"`
+		IsPrivate     bool              `json:"isPrivate"`
+		Topic         *string           `json:"topic"`
+		Tags          []string          `json:"tags"`
+		Language      *string           `json:"language"`
+		ScheduledAt   *time.Time        `json:"scheduledAt"`
+		Quorum        int               `json:"quorum"`
+		CyclePlan     *entity.CyclePlan `json:"cyclePlan"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	mode := entity.SessionMode(req.Mode)
 	if mode != entity.SessionModeSolo && mode != entity.SessionModeGroup {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid mode: must be 'solo' or 'group'")
+		h.HandleError(c, entity.NewValidationError("invalid_mode", "invalid mode: must be 'solo' or 'group'", map[string]string{
+			"mode": "oneof",
+		}))
 		return
 	}
 
-	session, err := h.sessionService.CreateSession(
-		userID,
-		mode,
-		req.Tasks,
-		req.FocusDuration,
-		req.BreakDuration,
-		req.GroupName,
-		req.IsPrivate,
-	)
+	session, err := h.sessionService.CreateSession(userID, entity.NewSessionParams{
+		Mode:          mode,
+		Tasks:         req.Tasks,
+		FocusDuration: req.FocusDuration,
+		BreakDuration: req.BreakDuration,
+		GroupName:     req.GroupName,
+		IsPrivate:     req.IsPrivate,
+		Topic:         req.Topic,
+		Tags:          req.Tags,
+		Language:      req.Language,
+		ScheduledAt:   req.ScheduledAt,
+		Quorum:        req.Quorum,
+		CyclePlan:     req.CyclePlan,
+	})
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -129,86 +148,80 @@ func (h *SessionHandler) createSession(c *gin.Context) {
 	})
 }
 
-// getHistory возвращает историю сессий
-func (h *SessionHandler) getHistory(c *gin.Context) {
+// createFromTemplate создает сессию по шаблону одним вызовом: своему по templateId
+// или любому по shareLink. Состав шаблона добавляется, только если он свой
+func (h *SessionHandler) createFromTemplate(c *gin.Context) {
 	userID := h.GetUserID(c)
 	if userID == "" {
 		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if limit < 1 {
-		limit = 10
+	var req struct {
+		TemplateID  string     `json:"templateId"`
+		ShareLink   string     `json:"shareLink"`
+		ScheduledAt *time.Time `json:"scheduledAt"`
+		Quorum      int        `json:"quorum"`
 	}
-	if limit > 50 {
-		limit = 50
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return
 	}
 
-	sessions, total, err := h.sessionService.GetHistory(userID, page, limit)
+	session, err := h.templateService.CreateSessionFromTemplate(userID, entity.TemplateSessionParams{
+		TemplateID:  req.TemplateID,
+		ShareLink:   req.ShareLink,
+		ScheduledAt: req.ScheduledAt,
+		Quorum:      req.Quorum,
+	})
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
-	sessionsList := make([]gin.H, 0, len(sessions))
-	for _, session := range sessions {
-		sessionsList = append(sessionsList, h.sessionToMap(session))
-	}
-
-	hasNext := (page * limit) < total
-
 	h.SuccessResponse(c, http.StatusOK, gin.H{
-		"sessions": sessionsList,
-		"pagination": gin.H{
-			"page":    page,
-			"limit":   limit,
-			"total":   total,
-			"hasNext": hasNext,
-		},
+		"session": h.sessionToMap(session),
 	})
 }
 
-// getPublicSessions возвращает список публичных сессий
-func (h *SessionHandler) getPublicSessions(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if limit < 1 {
-		limit = 10
+// getHistory возвращает историю сессий пользователя постранично по курсору
+func (h *SessionHandler) getHistory(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
 	}
-	if limit > 50 {
-		limit = 50
+
+	filter, err := parseSessionFilter(c, true)
+	if err != nil {
+		h.HandleError(c, err)
+		return
 	}
 
-	sessions, total, err := h.sessionService.GetPublicSessions(page, limit)
+	page, err := h.sessionService.GetHistory(userID, filter)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
-	sessionsList := make([]gin.H, 0, len(sessions))
-	for _, session := range sessions {
-		sessionsList = append(sessionsList, h.sessionToMap(session))
+	h.SuccessResponse(c, http.StatusOK, h.sessionPageToMap(page, filter.Limit))
+}
+
+// getPublicSessions возвращает публичные сессии, ожидающие участников
+func (h *SessionHandler) getPublicSessions(c *gin.Context) {
+	filter, err := parseSessionFilter(c, false)
+	if err != nil {
+		h.HandleError(c, err)
+		return
 	}
 
-	hasNext := (page * limit) < total
+	page, err := h.sessionService.GetPublicSessions(filter)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
 
-	h.SuccessResponse(c, http.StatusOK, gin.H{
-		"sessions": sessionsList,
-		"pagination": gin.H{
-			"page":    page,
-			"limit":   limit,
-			"total":   total,
-			"hasNext": hasNext,
-		},
-	})
+	h.SuccessResponse(c, http.StatusOK, h.sessionPageToMap(page, filter.Limit))
 }
 
 // getActiveSession возвращает активную сессию
@@ -221,7 +234,7 @@ func (h *SessionHandler) getActiveSession(c *gin.Context) {
 
 	session, err := h.sessionService.GetActiveSession(userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -244,13 +257,7 @@ func (h *SessionHandler) getSession(c *gin.Context) {
 
 	session, err := h.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else if strings.Contains(err.Error(), "access denied") {
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -270,11 +277,7 @@ func (h *SessionHandler) joinSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	session, err := h.sessionService.JoinSession(sessionID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "already started") {
-			h.ErrorResponse(c, http.StatusBadRequest, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -319,19 +322,13 @@ func (h *SessionHandler) joinByInviteLink(c *gin.Context) {
 		InviteLink string `json:"inviteLink" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "inviteLink is required")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	session, err := h.sessionService.JoinByInviteLink(req.InviteLink, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			h.ErrorResponse(c, http.StatusNotFound, "session not found by invite link")
-		} else if strings.Contains(err.Error(), "already started") {
-			h.ErrorResponse(c, http.StatusBadRequest, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -379,12 +376,12 @@ func (h *SessionHandler) setReady(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	if err := h.sessionService.SetReady(sessionID, userID, req.IsReady); err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -411,18 +408,14 @@ func (h *SessionHandler) startSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	if err := h.sessionService.StartSession(sessionID, userID); err != nil {
-		if strings.Contains(err.Error(), "only creator") {
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
 	// Получаем обновленную сессию
 	session, err := h.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -453,18 +446,14 @@ func (h *SessionHandler) pauseSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	if err := h.sessionService.PauseSession(sessionID, userID); err != nil {
-		if strings.Contains(err.Error(), "not authorized") || strings.Contains(err.Error(), "only creator") {
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusBadRequest, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
 	// Получаем обновленную сессию
 	session, err := h.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -487,18 +476,14 @@ func (h *SessionHandler) resumeSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	if err := h.sessionService.ResumeSession(sessionID, userID); err != nil {
-		if strings.Contains(err.Error(), "not authorized") || strings.Contains(err.Error(), "only creator") {
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusBadRequest, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
 	// Получаем обновленную сессию
 	session, err := h.sessionService.GetSession(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -522,7 +507,7 @@ func (h *SessionHandler) completeSession(c *gin.Context) {
 
 	report, err := h.sessionService.CompleteSession(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -531,6 +516,90 @@ func (h *SessionHandler) completeSession(c *gin.Context) {
 	})
 }
 
+// cancelSession отменяет сессию по решению создателя и сообщает участникам причину
+func (h *SessionHandler) cancelSession(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return
+	}
+
+	session, err := h.sessionService.CancelSession(c.Param("sessionId"), userID, req.Reason)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		for _, participant := range session.Participants {
+			h.wsHandler.SendToUser(participant.UserID, "session_cancelled", gin.H{
+				"sessionId":   session.ID,
+				"reason":      *session.CancelReason,
+				"cancelledBy": userID,
+			})
+		}
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"session": h.sessionToMap(session),
+	})
+}
+
+// deleteSession мягко удаляет сессию; до restoreUntil ее можно восстановить
+func (h *SessionHandler) deleteSession(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, restoreUntil, err := h.sessionService.DeleteSession(c.Param("sessionId"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		for _, participant := range session.Participants {
+			h.wsHandler.SendToUser(participant.UserID, "session_deleted", gin.H{
+				"sessionId": session.ID,
+				"deletedBy": userID,
+			})
+		}
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"restoreUntil": restoreUntil,
+	})
+}
+
+// restoreSession восстанавливает удаленную сессию
+func (h *SessionHandler) restoreSession(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	session, err := h.sessionService.RestoreSession(c.Param("sessionId"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"session": h.sessionToMap(session),
+	})
+}
+
 func (h *SessionHandler) getSessionReport(c *gin.Context) {
 	userID := h.GetUserID(c)
 	if userID == "" {
@@ -546,14 +615,7 @@ func (h *SessionHandler) getSessionReport(c *gin.Context) {
 
 	report, err := h.sessionService.GetSessionReport(sessionID, userID)
 	if err != nil {
-		switch {
-		case strings.Contains(err.Error(), "not found"):
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		case strings.Contains(err.Error(), "access denied"):
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		default:
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -602,20 +664,52 @@ func (h *SessionHandler) sessionToMap(session *entity.Session) gin.H {
 		"isPrivate":     session.IsPrivate,
 		"creatorId":     session.CreatorID,
 		"participants":  participantsList,
+		"tags":          session.TagNames(),
 		"inviteLink":    session.InviteLink,
 		"createdAt":     session.CreatedAt.Format(time.RFC3339),
 		"currentCycle":  session.CurrentCycle,
+		"quorum":        session.Quorum,
 	}
 
 	if session.GroupName != nil {
 		sessionMap["groupName"] = *session.GroupName
 	}
+	if session.Topic != nil {
+		sessionMap["topic"] = *session.Topic
+	}
+	if session.Language != nil {
+		sessionMap["language"] = *session.Language
+	}
+	if session.ScheduledAt != nil {
+		sessionMap["scheduledAt"] = session.ScheduledAt.Format(time.RFC3339)
+	}
+	if session.CyclePlan != nil {
+		sessionMap["cyclePlan"] = session.CyclePlan
+	}
+	// Текущие цикл и фаза считаются по активному времени; фаза идет, пока сессия активна
+	if session.StartedAt != nil {
+		now := time.Now()
+		progress := session.Progress(session.ActiveTime(now))
+		sessionMap["currentCycle"] = progress.CurrentCycle
+		sessionMap["phase"] = progress.Phase
+		if session.Status == entity.SessionStatusActive && progress.Phase != entity.CyclePhaseDone {
+			sessionMap["phaseEndsAt"] = now.Add(progress.PhaseLeft).Format(time.RFC3339)
+		}
+	}
+	if session.SeriesID != nil {
+		sessionMap["seriesId"] = *session.SeriesID
+		sessionMap["occurrenceAt"] = session.OccurrenceAt.Format(time.RFC3339)
+	}
 	if session.StartedAt != nil {
 		sessionMap["startedAt"] = session.StartedAt.Format(time.RFC3339)
 	}
 	if session.CompletedAt != nil {
 		sessionMap["completedAt"] = session.CompletedAt.Format(time.RFC3339)
 	}
+	if session.CancelledAt != nil {
+		sessionMap["cancelledAt"] = session.CancelledAt.Format(time.RFC3339)
+		sessionMap["cancelReason"] = *session.CancelReason
+	}
 	if session.MaxChatID != nil {
 		sessionMap["maxChatId"] = *session.MaxChatID
 	}
@@ -670,13 +764,13 @@ func (h *SessionHandler) addTask(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	task, err := h.sessionService.AddTask(sessionID, userID, req.Title)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -706,17 +800,13 @@ func (h *SessionHandler) updateTask(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	task, err := h.sessionService.UpdateTask(sessionID, taskID, userID, req.Completed)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -747,11 +837,7 @@ func (h *SessionHandler) deleteTask(c *gin.Context) {
 	taskID := c.Param("taskId")
 
 	if err := h.sessionService.DeleteTask(sessionID, taskID, userID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -774,7 +860,7 @@ func (h *SessionHandler) getParticipantsProgress(c *gin.Context) {
 
 	progress, err := h.sessionService.GetParticipantsProgress(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, "failed to get participants progress: "+err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -811,11 +897,7 @@ func (h *SessionHandler) getMessages(c *gin.Context) {
 
 	messages, err := h.messageService.GetMessages(sessionID, userID, before, limit)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "chat") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -854,17 +936,13 @@ func (h *SessionHandler) sendMessage(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		h.HandleError(c, h.bindingError(err))
 		return
 	}
 
 	message, err := h.messageService.SendMessage(sessionID, userID, req.Text)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "chat") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -896,11 +974,7 @@ func (h *SessionHandler) getChatInfo(c *gin.Context) {
 
 	chatInfo, err := h.messageService.GetChatInfo(sessionID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "chat") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -923,13 +997,7 @@ func (h *SessionHandler) deleteChat(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	if err := h.sessionService.DeleteChatAfterDiscussion(sessionID, userID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			h.ErrorResponse(c, http.StatusNotFound, err.Error())
-		} else if strings.Contains(err.Error(), "only creator") {
-			h.ErrorResponse(c, http.StatusForbidden, err.Error())
-		} else {
-			h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		}
+		h.HandleError(c, err)
 		return
 	}
 
@@ -948,7 +1016,7 @@ func (h *SessionHandler) getSessionLeaderboard(c *gin.Context) {
 
 	entries, err := h.leaderboardService.GetSessionLeaderboard(sessionID, userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -994,7 +1062,7 @@ func (h *SessionHandler) getGlobalLeaderboard(c *gin.Context) {
 
 	entries, err := h.leaderboardService.GetGlobalLeaderboard(userID, period, limit)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
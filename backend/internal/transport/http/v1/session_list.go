@@ -0,0 +1,235 @@
+package v1
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+)
+
+const (
+	defaultSessionPageSize = 10
+	maxSessionPageSize     = 50
+	maxSearchLength        = 100
+)
+
+// parseSessionFilter разбирает параметры списка сессий: sort, cursor, limit, q,
+// tags (через запятую), language, from, to, hasChat, для публичного списка еще
+// joinable, а для истории status (через запятую), mode и role
+func parseSessionFilter(c *gin.Context, history bool) (entity.SessionFilter, error) {
+	filter := entity.SessionFilter{Limit: defaultSessionPageSize}
+	invalid := func(field string) (entity.SessionFilter, error) {
+		return entity.SessionFilter{}, entity.NewValidationError("invalid_query", "invalid query parameter "+field,
+			map[string]string{field: "invalid"})
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return invalid("limit")
+		}
+		filter.Limit = min(limit, maxSessionPageSize)
+	}
+
+	switch sort := entity.SessionSort(c.Query("sort")); sort {
+	case entity.SessionSortCreated, entity.SessionSortStart, entity.SessionSortSeats, entity.SessionSortPopular:
+		filter.Sort = sort
+	default:
+		return invalid("sort")
+	}
+
+	// Курсор привязан к сортировке, поэтому разбирается после нее
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := entity.ParseSessionCursor(value, filter.Sort)
+		if err != nil {
+			return entity.SessionFilter{}, entity.NewValidationError("invalid_cursor", "invalid cursor",
+				map[string]string{"cursor": "invalid"})
+		}
+		filter.After = cursor
+	}
+
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(filter.Search) > maxSearchLength {
+		return invalid("q")
+	}
+	if value := c.Query("tags"); value != "" {
+		tags, err := entity.NormalizeSessionTags(strings.Split(value, ","))
+		if err != nil {
+			return invalid("tags")
+		}
+		filter.Tags = tags
+	}
+	if value := c.Query("language"); value != "" {
+		language, err := entity.NormalizeLanguage(value)
+		if err != nil {
+			return invalid("language")
+		}
+		filter.Language = language
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			return invalid("from")
+		}
+		filter.CreatedFrom = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			return invalid("to")
+		}
+		// Дата без времени включает весь день
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = &to
+	}
+
+	if value := c.Query("hasChat"); value != "" {
+		hasChat, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("hasChat")
+		}
+		filter.HasChat = &hasChat
+	}
+
+	if !history {
+		if value := c.Query("joinable"); value != "" {
+			joinable, err := strconv.ParseBool(value)
+			if err != nil {
+				return invalid("joinable")
+			}
+			if joinable {
+				now := time.Now()
+				filter.JoinableAt = &now
+			}
+		}
+		return filter, nil
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			switch status := entity.SessionStatus(strings.TrimSpace(status)); status {
+			case entity.SessionStatusPending, entity.SessionStatusActive, entity.SessionStatusPaused,
+				entity.SessionStatusCompleted, entity.SessionStatusCancelled:
+				filter.Statuses = append(filter.Statuses, status)
+			default:
+				return invalid("status")
+			}
+		}
+	}
+
+	switch mode := entity.SessionMode(c.Query("mode")); mode {
+	case "", entity.SessionModeSolo, entity.SessionModeGroup:
+		filter.Mode = mode
+	default:
+		return invalid("mode")
+	}
+
+	switch role := entity.SessionRole(c.Query("role")); role {
+	case entity.SessionRoleAny, entity.SessionRoleCreator, entity.SessionRoleParticipant:
+		filter.Role = role
+	default:
+		return invalid("role")
+	}
+
+	return filter, nil
+}
+
+// parseDateParam принимает RFC 3339 или дату YYYY-MM-DD (UTC)
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	return t, true, err
+}
+
+func (h *SessionHandler) sessionPageToMap(page *entity.SessionPage, limit int) gin.H {
+	sessionsList := make([]gin.H, 0, len(page.Sessions))
+	for _, summary := range page.Sessions {
+		sessionsList = append(sessionsList, h.sessionSummaryToMap(summary))
+	}
+
+	pagination := gin.H{
+		"limit":      limit,
+		"hasNext":    page.NextCursor != nil,
+		"nextCursor": nil,
+	}
+	if page.NextCursor != nil {
+		pagination["nextCursor"] = page.NextCursor.Encode()
+	}
+
+	return gin.H{
+		"sessions":   sessionsList,
+		"pagination": pagination,
+	}
+}
+
+// sessionSummaryToMap — сессия в списке: счетчики вместо задач, участники без статуса готовности
+func (h *SessionHandler) sessionSummaryToMap(summary *entity.SessionSummary) gin.H {
+	participantsList := make([]gin.H, 0, len(summary.Participants))
+	for _, p := range summary.Participants {
+		participantMap := gin.H{
+			"userId":   p.UserID,
+			"userName": p.UserName,
+		}
+		if p.AvatarURL != nil {
+			participantMap["avatarUrl"] = *p.AvatarURL
+		}
+		participantsList = append(participantsList, participantMap)
+	}
+	tags := summary.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	sessionMap := gin.H{
+		"id":                summary.ID,
+		"mode":              summary.Mode,
+		"status":            summary.Status,
+		"focusDuration":     summary.FocusDuration,
+		"breakDuration":     summary.BreakDuration,
+		"isPrivate":         summary.IsPrivate,
+		"creatorId":         summary.CreatorID,
+		"participants":      participantsList,
+		"participantsCount": summary.ParticipantsCount,
+		"openSeats":         summary.OpenSeats,
+		"joinable":          summary.Joinable,
+		"tags":              tags,
+		"tasksTotal":        summary.TasksTotal,
+		"tasksCompleted":    summary.TasksCompleted,
+		"createdAt":         summary.CreatedAt.Format(time.RFC3339),
+	}
+
+	if summary.GroupName != nil {
+		sessionMap["groupName"] = *summary.GroupName
+	}
+	if summary.Topic != nil {
+		sessionMap["topic"] = *summary.Topic
+	}
+	if summary.Language != nil {
+		sessionMap["language"] = *summary.Language
+	}
+	if summary.ScheduledAt != nil {
+		sessionMap["scheduledAt"] = summary.ScheduledAt.Format(time.RFC3339)
+	}
+	if summary.StartedAt != nil {
+		sessionMap["startedAt"] = summary.StartedAt.Format(time.RFC3339)
+	}
+	if summary.CompletedAt != nil {
+		sessionMap["completedAt"] = summary.CompletedAt.Format(time.RFC3339)
+	}
+	if summary.MaxChatID != nil {
+		sessionMap["maxChatId"] = *summary.MaxChatID
+	}
+	if summary.MaxChatLink != nil {
+		sessionMap["maxChatLink"] = *summary.MaxChatLink
+	}
+
+	return sessionMap
+}
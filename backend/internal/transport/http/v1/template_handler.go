@@ -0,0 +1,314 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/entity"
+	"github.com/rnegic/synchronous/internal/interfaces"
+)
+
+// TemplateHandler шаблоны сессий: свои и открытые по ссылке.
+// Сессия из шаблона создается через POST /sessions/from-template
+type TemplateHandler struct {
+	*BaseHandler
+	templateService interfaces.TemplateService
+}
+
+func NewTemplateHandler(baseHandler *BaseHandler, templateService interfaces.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		BaseHandler:     baseHandler,
+		templateService: templateService,
+	}
+}
+
+func (h *TemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	templates := router.Group("/templates")
+	{
+		templates.GET("", h.listTemplates)
+		templates.POST("", h.createTemplate)
+		templates.POST("/from-session/:sessionId", h.createFromSession)
+
+		// Чужие шаблоны по ссылке
+		templates.GET("/shared/:shareLink", h.getSharedTemplate)
+		templates.POST("/shared/:shareLink/copy", h.copySharedTemplate)
+
+		template := templates.Group("/:templateId")
+		{
+			template.GET("", h.getTemplate)
+			template.PUT("", h.updateTemplate)
+			template.DELETE("", h.deleteTemplate)
+		}
+	}
+}
+
+// templateRequest тело создания и изменения шаблона
+type templateRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	Mode          string            `json:"mode" binding:"required"`
+	Tasks         []string          `json:"tasks"`
+	FocusDuration int               `json:"focusDuration" binding:"required,min=1"`
+	BreakDuration int               `json:"breakDuration" binding:"required,min=1"`
+	GroupName     *string           `json:"groupName"`
+	IsPrivate     bool              `json:"isPrivate"`
+	Topic         *string           `json:"topic"`
+	Tags          []string          `json:"tags"`
+	Language      *string           `json:"language"`
+	CyclePlan     *entity.CyclePlan `json:"cyclePlan"`
+	RosterIDs     []string          `json:"rosterIds"`
+}
+
+// bindTemplate разбирает тело шаблона; при ошибке ответ уже отправлен
+func (h *TemplateHandler) bindTemplate(c *gin.Context) (entity.NewTemplateParams, bool) {
+	var req templateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, h.bindingError(err))
+		return entity.NewTemplateParams{}, false
+	}
+
+	mode := entity.SessionMode(req.Mode)
+	if mode != entity.SessionModeSolo && mode != entity.SessionModeGroup {
+		h.HandleError(c, entity.NewValidationError("invalid_mode", "invalid mode: must be 'solo' or 'group'", map[string]string{
+			"mode": "oneof",
+		}))
+		return entity.NewTemplateParams{}, false
+	}
+
+	return entity.NewTemplateParams{
+		Name: req.Name,
+		Session: entity.NewSessionParams{
+			Mode:          mode,
+			Tasks:         req.Tasks,
+			FocusDuration: req.FocusDuration,
+			BreakDuration: req.BreakDuration,
+			GroupName:     req.GroupName,
+			IsPrivate:     req.IsPrivate,
+			Topic:         req.Topic,
+			Tags:          req.Tags,
+			Language:      req.Language,
+			CyclePlan:     req.CyclePlan,
+		},
+		RosterIDs: req.RosterIDs,
+	}, true
+}
+
+// createTemplate сохраняет шаблон с нуля
+func (h *TemplateHandler) createTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params, ok := h.bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(userID, params)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusCreated, gin.H{
+		"template": templateToMap(template, true),
+	})
+}
+
+// createFromSession сохраняет шаблон по завершенной сессии
+func (h *TemplateHandler) createFromSession(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Тело необязательно: без названия шаблон называется как сессия
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.HandleError(c, h.bindingError(err))
+		return
+	}
+
+	template, err := h.templateService.CreateTemplateFromSession(userID, c.Param("sessionId"), req.Name)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusCreated, gin.H{
+		"template": templateToMap(template, true),
+	})
+}
+
+// listTemplates возвращает шаблоны пользователя, новые первыми
+func (h *TemplateHandler) listTemplates(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(templates))
+	for _, template := range templates {
+		items = append(items, templateToMap(template, true))
+	}
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"templates": items,
+	})
+}
+
+func (h *TemplateHandler) getTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Param("templateId"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"template": templateToMap(template, true),
+	})
+}
+
+// updateTemplate заменяет шаблон целиком; ссылка для шаринга сохраняется
+func (h *TemplateHandler) updateTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params, ok := h.bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(c.Param("templateId"), userID, params)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"template": templateToMap(template, true),
+	})
+}
+
+func (h *TemplateHandler) deleteTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Param("templateId"), userID); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// getSharedTemplate показывает шаблон по ссылке; состав видит только владелец
+func (h *TemplateHandler) getSharedTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	template, err := h.templateService.GetSharedTemplate(c.Param("shareLink"))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusOK, gin.H{
+		"template": templateToMap(template, template.OwnerID == userID),
+	})
+}
+
+// copySharedTemplate сохраняет шаблон по ссылке в свои шаблоны
+func (h *TemplateHandler) copySharedTemplate(c *gin.Context) {
+	userID := h.GetUserID(c)
+	if userID == "" {
+		h.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	template, err := h.templateService.CopySharedTemplate(c.Param("shareLink"), userID)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, http.StatusCreated, gin.H{
+		"template": templateToMap(template, true),
+	})
+}
+
+func templateToMap(template *entity.SessionTemplate, withRoster bool) gin.H {
+	tags := template.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	templateMap := gin.H{
+		"id":            template.ID,
+		"ownerId":       template.OwnerID,
+		"name":          template.Name,
+		"mode":          template.Mode,
+		"focusDuration": template.FocusDuration,
+		"breakDuration": template.BreakDuration,
+		"isPrivate":     template.IsPrivate,
+		"tasks":         template.Tasks,
+		"tags":          tags,
+		"shareLink":     template.ShareLink,
+		"createdAt":     template.CreatedAt.Format(time.RFC3339),
+		"updatedAt":     template.UpdatedAt.Format(time.RFC3339),
+	}
+	if template.GroupName != nil {
+		templateMap["groupName"] = *template.GroupName
+	}
+	if template.Topic != nil {
+		templateMap["topic"] = *template.Topic
+	}
+	if template.Language != nil {
+		templateMap["language"] = *template.Language
+	}
+	if template.CyclePlan != nil {
+		templateMap["cyclePlan"] = template.CyclePlan
+	}
+	if withRoster {
+		roster := make([]gin.H, 0, len(template.Roster))
+		for _, member := range template.Roster {
+			roster = append(roster, gin.H{
+				"userId":    member.UserID,
+				"userName":  member.UserName,
+				"avatarUrl": member.AvatarURL,
+			})
+		}
+		templateMap["roster"] = roster
+	}
+	return templateMap
+}
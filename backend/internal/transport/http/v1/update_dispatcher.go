@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+// chatTypeChat тип групповых чатов MAX; у личных диалогов с ботом тип "dialog"
+const chatTypeChat = "chat"
+
+const welcomeMessage = `Привет! Это бот Синхрон - я помогаю проводить фокус-сессии и синхронно работать с командой.
+
+Вот, что я умею:
+- запускать одиночные и групповые сессии по Помодоро с гибкими циклами
+- собирать задачи и отслеживать их выполнение в реальном времени
+- приглашать коллег по ссылке
+- сохранять отчёты по каждой сессии и делиться ими
+
+Чтобы стартовать, просто открой Mini App Синхрона и создай первую сессию - я подскажу каждый шаг 🚀`
+
+// UpdateDispatcher обрабатывает обновления Max API; общий для webhook и long polling
+type UpdateDispatcher struct {
+	sessionService interfaces.SessionService
+	messageService interfaces.MessageService
+	outboxService  interfaces.OutboxService
+	wsHandler      *WebSocketHandler
+	log            *slog.Logger
+}
+
+func NewUpdateDispatcher(
+	sessionService interfaces.SessionService,
+	messageService interfaces.MessageService,
+	outboxService interfaces.OutboxService,
+	wsHandler *WebSocketHandler,
+	log *slog.Logger,
+) *UpdateDispatcher {
+	return &UpdateDispatcher{
+		sessionService: sessionService,
+		messageService: messageService,
+		outboxService:  outboxService,
+		wsHandler:      wsHandler,
+		log:            logger.Component(log, "updates"),
+	}
+}
+
+func (d *UpdateDispatcher) Dispatch(ctx context.Context, update interface{}) (bool, error) {
+	// Обрабатываем обновление в зависимости от типа
+	switch u := update.(type) {
+	case *maxapi.MessageCreatedUpdate:
+		// Текст сообщения не логируем — это переписка пользователей
+		d.log.DebugContext(ctx, "received message",
+			"max_user_id", u.Message.Sender.UserID, "chat_id", u.Message.Recipient.ChatID)
+
+		if err := d.handleMessageCreated(u); err != nil {
+			return true, fmt.Errorf("failed to process message: %w", err)
+		}
+		return true, nil
+
+	case *maxapi.MessageChatCreatedUpdate:
+		// Обрабатываем создание чата
+		d.log.InfoContext(ctx, "received chat created update",
+			"chat_id", u.Chat.ChatID, "start_payload", u.StartPayload)
+
+		if err := d.sessionService.HandleChatCreated(update); err != nil {
+			return true, fmt.Errorf("failed to process chat creation: %w", err)
+		}
+
+		d.log.InfoContext(ctx, "chat created", "chat_id", u.Chat.ChatID)
+		return true, nil
+
+	default:
+		// Другие типы обновлений пока не обрабатываем
+		d.log.DebugContext(ctx, "unhandled update type", "type", fmt.Sprintf("%T", update))
+		return false, nil
+	}
+}
+
+func (d *UpdateDispatcher) handleMessageCreated(update *maxapi.MessageCreatedUpdate) error {
+	if update == nil {
+		return nil
+	}
+
+	// Сообщения из групповых чатов, привязанных к сессиям, сохраняем в историю.
+	// У личных диалогов с ботом тоже есть chat_id, их обрабатываем как команды
+	if update.Message.Recipient.ChatType == chatTypeChat && d.messageService != nil {
+		return d.handleChatMessage(update)
+	}
+
+	if d.outboxService == nil {
+		return nil
+	}
+
+	text := strings.TrimSpace(update.Message.Body.Text)
+	if text == "" {
+		return nil
+	}
+
+	lowered := strings.ToLower(text)
+	if lowered != "/start" && lowered != "start" && lowered != "привет" {
+		return nil
+	}
+
+	if update.Message.Sender.UserID == 0 {
+		return nil
+	}
+
+	// Приветствие уходит через outbox: пользователь мог заблокировать бота, и ошибка
+	// Max API не должна задерживать обработку следующих обновлений
+	return d.outboxService.SendMessageToUser(update.Message.Sender.UserID, &maxapi.SendMessageRequest{
+		Text: welcomeMessage,
+	})
+}
+
+// handleChatMessage сохраняет сообщение из чата сессии и рассылает его участникам через WebSocket
+func (d *UpdateDispatcher) handleChatMessage(update *maxapi.MessageCreatedUpdate) error {
+	message, err := d.messageService.SaveIncomingMessage(&update.Message)
+	if err != nil {
+		return err
+	}
+	if message == nil || d.wsHandler == nil {
+		return nil
+	}
+
+	msgMap := gin.H{
+		"id":        message.ID,
+		"sessionId": message.SessionID,
+		"userId":    message.UserID,
+		"userName":  message.UserName,
+		"text":      message.Text,
+		"createdAt": message.CreatedAt.Format(time.RFC3339),
+	}
+	if message.AvatarURL != nil {
+		msgMap["avatarUrl"] = *message.AvatarURL
+	}
+
+	d.wsHandler.SendToSession(message.SessionID, "chat_message", gin.H{
+		"sessionId": message.SessionID,
+		"message":   msgMap,
+	})
+
+	return nil
+}
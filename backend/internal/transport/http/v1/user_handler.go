@@ -36,7 +36,7 @@ func (h *UserHandler) getMe(c *gin.Context) {
 
 	user, stats, err := h.userService.GetProfile(userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
@@ -62,7 +62,7 @@ func (h *UserHandler) getContacts(c *gin.Context) {
 
 	contacts, err := h.userService.GetContacts(userID)
 	if err != nil {
-		h.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		h.HandleError(c, err)
 		return
 	}
 
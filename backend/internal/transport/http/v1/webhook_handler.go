@@ -1,36 +1,26 @@
 package v1
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
 	"github.com/rnegic/synchronous/pkg/maxapi"
 )
 
 type WebhookHandler struct {
 	*BaseHandler
-	sessionService interfaces.SessionService
-	maxAPIService  interfaces.MaxAPIService
+	dispatcher interfaces.UpdateDispatcher
+	log        *slog.Logger
 }
 
-const welcomeMessage = `Привет! Это бот Синхрон - я помогаю проводить фокус-сессии и синхронно работать с командой.
-
-Вот, что я умею:
-- запускать одиночные и групповые сессии по Помодоро с гибкими циклами
-- собирать задачи и отслеживать их выполнение в реальном времени
-- приглашать коллег по ссылке
-- сохранять отчёты по каждой сессии и делиться ими
-
-Чтобы стартовать, просто открой Mini App Синхрона и создай первую сессию - я подскажу каждый шаг 🚀`
-
-func NewWebhookHandler(baseHandler *BaseHandler, sessionService interfaces.SessionService, maxAPIService interfaces.MaxAPIService) *WebhookHandler {
+func NewWebhookHandler(baseHandler *BaseHandler, dispatcher interfaces.UpdateDispatcher) *WebhookHandler {
 	return &WebhookHandler{
-		BaseHandler:    baseHandler,
-		sessionService: sessionService,
-		maxAPIService:  maxAPIService,
+		BaseHandler: baseHandler,
+		dispatcher:  dispatcher,
+		log:         logger.Component(baseHandler.log, "webhook"),
 	}
 }
 
@@ -44,7 +34,7 @@ func (h *WebhookHandler) handleWebhook(c *gin.Context) {
 	// Читаем тело запроса
 	body, err := c.GetRawData()
 	if err != nil {
-		log.Printf("[Webhook] Failed to read request body: %v", err)
+		h.log.WarnContext(c.Request.Context(), "failed to read webhook body", logger.Err(err))
 		h.ErrorResponse(c, http.StatusBadRequest, "failed to read request body")
 		return
 	}
@@ -52,67 +42,22 @@ func (h *WebhookHandler) handleWebhook(c *gin.Context) {
 	// Парсим обновление
 	update, err := maxapi.ParseUpdate(body)
 	if err != nil {
-		log.Printf("[Webhook] Failed to parse update: %v", err)
+		h.log.WarnContext(c.Request.Context(), "failed to parse webhook update", logger.Err(err))
 		h.ErrorResponse(c, http.StatusBadRequest, "failed to parse update")
 		return
 	}
 
-	// Обрабатываем обновление в зависимости от типа
-	switch u := update.(type) {
-	case *maxapi.MessageCreatedUpdate:
-		log.Printf("[Webhook] Received message from user=%d chat=%d text=%q",
-			u.Message.Sender.UserID, u.Message.Recipient.ChatID, u.Message.Body.Text)
-
-		if err := h.handleMessageCreated(u); err != nil {
-			log.Printf("[Webhook] Failed to handle message_created: %v", err)
-			h.ErrorResponse(c, http.StatusInternalServerError, "failed to process message")
-			return
-		}
-
-		h.SuccessResponse(c, http.StatusOK, gin.H{"status": "processed"})
-
-	case *maxapi.MessageChatCreatedUpdate:
-		// Обрабатываем создание чата
-		log.Printf("[Webhook] Received chat created update: chatID=%d, startPayload=%s",
-			u.Chat.ChatID, u.StartPayload)
-
-		if err := h.sessionService.HandleChatCreated(update); err != nil {
-			log.Printf("[Webhook] Failed to handle chat created: %v", err)
-			h.ErrorResponse(c, http.StatusInternalServerError, "failed to process chat creation")
-			return
-		}
-
-		log.Printf("[Webhook] ✅ Chat created successfully: chatID=%d", u.Chat.ChatID)
-		h.SuccessResponse(c, http.StatusOK, gin.H{"status": "processed"})
-
-	default:
-		// Другие типы обновлений пока не обрабатываем
-		log.Printf("[Webhook] Received unhandled update type: %T", update)
-		h.SuccessResponse(c, http.StatusOK, gin.H{"status": "ignored"})
-	}
-}
-
-func (h *WebhookHandler) handleMessageCreated(update *maxapi.MessageCreatedUpdate) error {
-	if update == nil || h.maxAPIService == nil {
-		return nil
-	}
-
-	text := strings.TrimSpace(update.Message.Body.Text)
-	if text == "" {
-		return nil
-	}
-
-	lowered := strings.ToLower(text)
-	if lowered != "/start" && lowered != "start" && lowered != "привет" {
-		return nil
+	handled, err := h.dispatcher.Dispatch(c.Request.Context(), update)
+	if err != nil {
+		h.log.ErrorContext(c.Request.Context(), "failed to dispatch webhook update", logger.Err(err))
+		h.ErrorResponse(c, http.StatusInternalServerError, "failed to process update")
+		return
 	}
 
-	if update.Message.Sender.UserID == 0 {
-		return nil
+	if !handled {
+		h.SuccessResponse(c, http.StatusOK, gin.H{"status": "ignored"})
+		return
 	}
 
-	_, err := h.maxAPIService.SendMessageToUser(update.Message.Sender.UserID, &maxapi.SendMessageRequest{
-		Text: welcomeMessage,
-	})
-	return err
+	h.SuccessResponse(c, http.StatusOK, gin.H{"status": "processed"})
 }
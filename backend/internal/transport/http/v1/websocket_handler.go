@@ -1,38 +1,80 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/rnegic/synchronous/internal/interfaces"
+	"github.com/rnegic/synchronous/internal/logger"
+	"github.com/rnegic/synchronous/internal/metrics"
+	"github.com/rnegic/synchronous/internal/transport/http/middleware"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow all origins in development
-		// In production, check against allowed origins
-		return true
-	},
+// wsClient — соединение и его пользователь. gorilla/websocket допускает только
+// одного писателя на соединение, поэтому вся запись идет через write
+type wsClient struct {
+	conn    *websocket.Conn
+	userID  string
+	writeMu sync.Mutex
+}
+
+func (c *wsClient) write(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return c.conn.WriteMessage(messageType, data)
 }
 
 type WebSocketHandler struct {
 	*BaseHandler
-	clients   map[*websocket.Conn]string // conn -> userID
-	broadcast chan []byte
-	mu        sync.RWMutex
+	sessionService interfaces.SessionService
+	upgrader       websocket.Upgrader
+	clients        map[*websocket.Conn]*wsClient
+	rooms          map[string]map[*websocket.Conn]struct{} // sessionID -> conns
+	broadcast      chan []byte
+	mu             sync.RWMutex
+	log            *slog.Logger
+	metrics        *metrics.Metrics
+
+	// Остановка: stop завершает рассылку, conns считает открытые соединения
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	closing  bool
+	conns    sync.WaitGroup
 }
 
-func NewWebSocketHandler(baseHandler *BaseHandler) *WebSocketHandler {
+// NewWebSocketHandler создает обработчик WebSocket. Origin проверяется по той же
+// политике, что и CORS
+func NewWebSocketHandler(
+	baseHandler *BaseHandler,
+	sessionService interfaces.SessionService,
+	metrics *metrics.Metrics,
+	origins *middleware.AllowedOrigins,
+) *WebSocketHandler {
 	handler := &WebSocketHandler{
-		BaseHandler: baseHandler,
-		clients:     make(map[*websocket.Conn]string),
-		broadcast:   make(chan []byte, 256),
+		BaseHandler:    baseHandler,
+		sessionService: sessionService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     origins.CheckWebSocketOrigin,
+		},
+		clients:   make(map[*websocket.Conn]*wsClient),
+		rooms:     make(map[string]map[*websocket.Conn]struct{}),
+		broadcast: make(chan []byte, 256),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		log:       logger.Component(baseHandler.log, "websocket"),
+		metrics:   metrics,
 	}
 
 	// Start broadcast goroutine
@@ -49,34 +91,58 @@ func (h *WebSocketHandler) handleWebSocket(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID := h.GetUserID(c)
 	if userID == "" {
-		log.Println("[WebSocket] No userID - unauthorized connection attempt")
+		h.log.WarnContext(c.Request.Context(), "unauthorized connection attempt")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
 	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("[WebSocket] Failed to upgrade connection: %v\n", err)
+		h.log.WarnContext(c.Request.Context(), "failed to upgrade connection",
+			"origin", c.GetHeader("Origin"), logger.Err(err))
 		return
 	}
 
 	// Register client
+	client := &wsClient{conn: conn, userID: userID}
 	h.mu.Lock()
-	h.clients[conn] = userID
+	if h.closing {
+		h.mu.Unlock()
+		conn.WriteControl(websocket.CloseMessage, closeMessageRestart(), time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
+	h.clients[conn] = client
+	h.conns.Add(1)
+	clientCount := len(h.clients)
 	h.mu.Unlock()
 
-	log.Printf("[WebSocket] ✅ Client connected: userID=%s, total=%d\n", userID, len(h.clients))
+	ctx := c.Request.Context()
+	connLog := h.log.With("user_id", userID)
+	connLog.InfoContext(ctx, "client connected", "total", clientCount)
+
+	// Клиент может сразу подписаться на комнату сессии через query-параметр
+	if sessionID := c.Query("sessionId"); sessionID != "" {
+		h.joinRoom(conn, userID, sessionID)
+	}
 
 	// Handle client disconnect
 	defer func() {
 		h.mu.Lock()
 		delete(h.clients, conn)
-		clientCount := len(h.clients)
+		for sessionID, members := range h.rooms {
+			delete(members, conn)
+			if len(members) == 0 {
+				delete(h.rooms, sessionID)
+			}
+		}
+		clientCount = len(h.clients)
 		h.mu.Unlock()
 
 		conn.Close()
-		log.Printf("[WebSocket] 🔌 Client disconnected: userID=%s, total=%d\n", userID, clientCount)
+		h.conns.Done()
+		connLog.InfoContext(ctx, "client disconnected", "total", clientCount)
 	}()
 
 	// Configure connection
@@ -90,8 +156,8 @@ func (h *WebSocketHandler) handleWebSocket(c *gin.Context) {
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("[WebSocket] Error reading message: %v\n", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseServiceRestart) {
+				connLog.WarnContext(ctx, "failed to read message", logger.Err(err))
 			}
 			break
 		}
@@ -99,32 +165,49 @@ func (h *WebSocketHandler) handleWebSocket(c *gin.Context) {
 		// Parse message
 		var msg map[string]interface{}
 		if err := json.Unmarshal(message, &msg); err != nil {
-			log.Printf("[WebSocket] Failed to parse message: %v\n", err)
+			connLog.WarnContext(ctx, "failed to parse message", logger.Err(err))
 			continue
 		}
 
 		// Handle ping
-		if event, ok := msg["event"].(string); ok && event == "ping" {
-			h.sendToClient(conn, map[string]interface{}{
+		event, _ := msg["event"].(string)
+		if event == "ping" {
+			h.sendToClient(client, map[string]interface{}{
 				"event": "pong",
 				"data":  map[string]interface{}{},
 			})
 			continue
 		}
 
-		log.Printf("[WebSocket] 📨 Received from %s: %v\n", userID, msg)
+		// Подписка на события конкретной сессии
+		if event == "subscribe" || event == "unsubscribe" {
+			sessionID := ""
+			if data, ok := msg["data"].(map[string]interface{}); ok {
+				sessionID, _ = data["sessionId"].(string)
+			}
+			if sessionID == "" {
+				continue
+			}
+			if event == "subscribe" {
+				h.joinRoom(conn, userID, sessionID)
+			} else {
+				h.leaveRoom(conn, sessionID)
+			}
+			continue
+		}
+
+		connLog.DebugContext(ctx, "unhandled client event", "event", event)
 	}
 }
 
 // Send message to specific client
-func (h *WebSocketHandler) sendToClient(conn *websocket.Conn, message map[string]interface{}) error {
+func (h *WebSocketHandler) sendToClient(client *wsClient, message map[string]interface{}) error {
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return client.write(websocket.TextMessage, data)
 }
 
 // Broadcast message to all clients
@@ -136,26 +219,59 @@ func (h *WebSocketHandler) BroadcastMessage(event string, data interface{}) {
 
 	msgBytes, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("[WebSocket] Failed to marshal broadcast message: %v\n", err)
+		h.log.Error("failed to marshal broadcast message", "event", event, logger.Err(err))
 		return
 	}
 
-	h.broadcast <- msgBytes
+	select {
+	case h.broadcast <- msgBytes:
+		return
+	default:
+	}
+
+	// Очередь заполнена: отправитель ждет, пока handleBroadcasts ее разберет
+	h.metrics.BroadcastBlocked()
+	select {
+	case h.broadcast <- msgBytes:
+	case <-h.stop:
+	}
+}
+
+// ClientCount возвращает число подключенных клиентов
+func (h *WebSocketHandler) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients)
+}
+
+// BroadcastQueueDepth возвращает число сообщений, ожидающих рассылки
+func (h *WebSocketHandler) BroadcastQueueDepth() int {
+	return len(h.broadcast)
+}
+
+// BroadcastQueueCapacity возвращает размер очереди рассылки
+func (h *WebSocketHandler) BroadcastQueueCapacity() int {
+	return cap(h.broadcast)
 }
 
 // Handle broadcast messages
 func (h *WebSocketHandler) handleBroadcasts() {
+	defer close(h.done)
+
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-h.stop:
+			return
+
 		case message := <-h.broadcast:
 			h.mu.RLock()
-			for conn := range h.clients {
-				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-				if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
-					log.Printf("[WebSocket] Failed to send broadcast: %v\n", err)
+			for conn, client := range h.clients {
+				if err := client.write(websocket.TextMessage, message); err != nil {
+					h.log.Warn("failed to send broadcast", "user_id", client.userID, logger.Err(err))
 					conn.Close()
 				}
 			}
@@ -164,10 +280,9 @@ func (h *WebSocketHandler) handleBroadcasts() {
 		case <-ticker.C:
 			// Send ping to all clients
 			h.mu.RLock()
-			for conn := range h.clients {
-				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-					log.Printf("[WebSocket] Failed to send ping: %v\n", err)
+			for conn, client := range h.clients {
+				if err := client.write(websocket.PingMessage, nil); err != nil {
+					h.log.Warn("failed to send ping", "user_id", client.userID, logger.Err(err))
 					conn.Close()
 				}
 			}
@@ -176,6 +291,60 @@ func (h *WebSocketHandler) handleBroadcasts() {
 	}
 }
 
+// Close останавливает рассылку и закрывает соединения кадром 1012 (Service Restart) —
+// для клиента это сигнал переподключиться. Ждет ответного закрытия от клиентов
+// не дольше closeGracePeriod и отмены ctx, после чего обрывает оставшиеся соединения
+func (h *WebSocketHandler) Close(ctx context.Context) error {
+	h.stopOnce.Do(func() { close(h.stop) })
+	<-h.done
+
+	h.mu.Lock()
+	h.closing = true
+	clients := make([]*wsClient, 0, len(h.clients))
+	for _, client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for _, client := range clients {
+		client.conn.WriteControl(websocket.CloseMessage, closeMessageRestart(), deadline)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(finished)
+	}()
+
+	// Клиент, не ответивший на close frame, не должен съедать время остановки
+	// остальных компонентов — после closeGracePeriod закрываем соединения сами
+	grace := time.NewTimer(closeGracePeriod)
+	defer grace.Stop()
+
+	select {
+	case <-finished:
+		h.log.Info("closed connections", "count", len(clients))
+		return nil
+	case <-grace.C:
+	case <-ctx.Done():
+	}
+	for _, client := range clients {
+		client.conn.Close()
+	}
+	<-finished
+	h.log.Warn("closed connections, some without close handshake", "count", len(clients))
+	return ctx.Err()
+}
+
+// closeGracePeriod — сколько ждем ответного close frame от клиентов при остановке
+const closeGracePeriod = 2 * time.Second
+
+// closeMessageRestart кадр закрытия при остановке сервера
+func closeMessageRestart() []byte {
+	return websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting, reconnect")
+}
+
 // Send message to specific user
 func (h *WebSocketHandler) SendToUser(userID string, event string, data interface{}) {
 	message := map[string]interface{}{
@@ -185,27 +354,93 @@ func (h *WebSocketHandler) SendToUser(userID string, event string, data interfac
 
 	msgBytes, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("[WebSocket] Failed to marshal message: %v\n", err)
+		h.log.Error("failed to marshal message", "event", event, logger.Err(err))
 		return
 	}
 
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for conn, connUserID := range h.clients {
-		if connUserID == userID {
-			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
-				log.Printf("[WebSocket] Failed to send to user %s: %v\n", userID, err)
+	for _, client := range h.clients {
+		if client.userID == userID {
+			if err := client.write(websocket.TextMessage, msgBytes); err != nil {
+				h.log.Warn("failed to send to user", "user_id", userID, "event", event, logger.Err(err))
 			}
 			break
 		}
 	}
 }
 
-// Send message to session participants
+// HasConnections сообщает, подписан ли кто-то на события сессии или подключен ли
+// кто-то из userIDs
+func (h *WebSocketHandler) HasConnections(sessionID string, userIDs []string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.rooms[sessionID]) > 0 {
+		return true
+	}
+	for _, client := range h.clients {
+		if slices.Contains(userIDs, client.userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send message to clients subscribed to the session room
 func (h *WebSocketHandler) SendToSession(sessionID string, event string, data interface{}) {
-	// This would require session participant tracking
-	// For now, broadcast to all clients
-	h.BroadcastMessage(event, data)
+	message := map[string]interface{}{
+		"event": event,
+		"data":  data,
+	}
+
+	msgBytes, err := json.Marshal(message)
+	if err != nil {
+		h.log.Error("failed to marshal message", "event", event, logger.Err(err))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for conn := range h.rooms[sessionID] {
+		client, ok := h.clients[conn]
+		if !ok {
+			continue
+		}
+		if err := client.write(websocket.TextMessage, msgBytes); err != nil {
+			h.log.Warn("failed to send to session", "session_id", sessionID, "event", event, logger.Err(err))
+		}
+	}
+}
+
+// joinRoom подписывает соединение на события сессии, если у пользователя есть к ней доступ
+func (h *WebSocketHandler) joinRoom(conn *websocket.Conn, userID string, sessionID string) {
+	if h.sessionService != nil {
+		if _, err := h.sessionService.GetSession(sessionID, userID); err != nil {
+			h.log.Info("subscription denied", "user_id", userID, "session_id", sessionID, logger.Err(err))
+			return
+		}
+	}
+
+	h.mu.Lock()
+	if h.rooms[sessionID] == nil {
+		h.rooms[sessionID] = make(map[*websocket.Conn]struct{})
+	}
+	h.rooms[sessionID][conn] = struct{}{}
+	h.mu.Unlock()
+}
+
+// leaveRoom отписывает соединение от событий сессии
+func (h *WebSocketHandler) leaveRoom(conn *websocket.Conn, sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if members, ok := h.rooms[sessionID]; ok {
+		delete(members, conn)
+		if len(members) == 0 {
+			delete(h.rooms, sessionID)
+		}
+	}
 }
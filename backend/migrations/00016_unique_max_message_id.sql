@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Сообщения из чата Max приходят через webhook и могут доставляться повторно,
+-- поэтому max_message_id должен быть уникальным
+DROP INDEX IF EXISTS idx_max_message_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_max_message_id ON messages(max_message_id)
+    WHERE max_message_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_messages_max_message_id;
+CREATE INDEX IF NOT EXISTS idx_max_message_id ON messages(max_message_id);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Списки сессий листаются курсором по (created_at, id) от новых к старым
+CREATE INDEX IF NOT EXISTS idx_sessions_created_at_id ON sessions(created_at DESC, id DESC);
+DROP INDEX IF EXISTS idx_created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_created_at ON sessions(created_at);
+DROP INDEX IF EXISTS idx_sessions_created_at_id;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Метаданные для поиска публичных сессий: тема, язык, запланированное начало и теги
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS topic VARCHAR(255);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS language VARCHAR(8);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_language ON sessions(language);
+CREATE INDEX IF NOT EXISTS idx_sessions_scheduled_at ON sessions(scheduled_at);
+
+CREATE TABLE IF NOT EXISTS session_tags (
+    session_id VARCHAR(36) NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (session_id, tag),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_tags_tag ON session_tags(tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_tags;
+DROP INDEX IF EXISTS idx_sessions_scheduled_at;
+DROP INDEX IF EXISTS idx_sessions_language;
+ALTER TABLE sessions DROP COLUMN IF EXISTS scheduled_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS language;
+ALTER TABLE sessions DROP COLUMN IF EXISTS topic;
+-- +goose StatementEnd
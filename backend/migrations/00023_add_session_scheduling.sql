@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Планировщик: кворум готовых участников для автостарта и отметка о последнем напоминании
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS quorum INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS quorum;
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Повторяющиеся сессии: правило RRULE, состав, задачи по умолчанию и изменения отдельных повторений
+CREATE TABLE IF NOT EXISTS session_series (
+    id VARCHAR(36) PRIMARY KEY,
+    creator_id VARCHAR(36) NOT NULL,
+    mode session_mode NOT NULL,
+    focus_duration INTEGER NOT NULL,
+    break_duration INTEGER NOT NULL,
+    group_name VARCHAR(255),
+    topic VARCHAR(255),
+    language VARCHAR(8),
+    tags TEXT,
+    is_private BOOLEAN NOT NULL DEFAULT FALSE,
+    quorum INTEGER NOT NULL DEFAULT 0,
+    tasks TEXT NOT NULL,
+    recurrence VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    invite_link VARCHAR(50) NOT NULL,
+    spawned_until TIMESTAMP,
+    ended_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_series_creator_id ON session_series(creator_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_series_invite_link ON session_series(invite_link);
+
+CREATE TABLE IF NOT EXISTS session_series_members (
+    series_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    user_name VARCHAR(255) NOT NULL,
+    avatar_url TEXT,
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (series_id, user_id),
+    FOREIGN KEY (series_id) REFERENCES session_series(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_series_members_user_id ON session_series_members(user_id);
+
+CREATE TABLE IF NOT EXISTS session_series_exceptions (
+    series_id VARCHAR(36) NOT NULL,
+    occurrence_at TIMESTAMP NOT NULL,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    scheduled_at TIMESTAMP,
+    focus_duration INTEGER,
+    break_duration INTEGER,
+    PRIMARY KEY (series_id, occurrence_at),
+    FOREIGN KEY (series_id) REFERENCES session_series(id) ON DELETE CASCADE
+);
+
+-- Экземпляры серии: одно повторение создается не больше одного раза
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS series_id VARCHAR(36) REFERENCES session_series(id) ON DELETE SET NULL;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_series_occurrence ON sessions(series_id, occurrence_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_series_occurrence;
+ALTER TABLE sessions DROP COLUMN IF EXISTS occurrence_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS session_series_exceptions;
+DROP TABLE IF EXISTS session_series_members;
+DROP TABLE IF EXISTS session_series;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Шаблоны сессий: настройки, задачи по умолчанию и состав; делятся по share_link
+CREATE TABLE IF NOT EXISTS session_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    owner_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    mode session_mode NOT NULL,
+    focus_duration INTEGER NOT NULL,
+    break_duration INTEGER NOT NULL,
+    group_name VARCHAR(255),
+    topic VARCHAR(255),
+    language VARCHAR(8),
+    tags TEXT,
+    is_private BOOLEAN NOT NULL DEFAULT FALSE,
+    tasks TEXT NOT NULL,
+    roster TEXT NOT NULL,
+    share_link VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_templates_owner_id ON session_templates(owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_templates_share_link ON session_templates(share_link);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_templates;
+-- +goose StatementEnd
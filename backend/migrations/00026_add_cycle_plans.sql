@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- План циклов: число циклов, длинные перерывы, последовательность фаз и автозавершение (JSON)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS cycle_plan TEXT;
+ALTER TABLE session_templates ADD COLUMN IF NOT EXISTS cycle_plan TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE session_templates DROP COLUMN IF EXISTS cycle_plan;
+ALTER TABLE sessions DROP COLUMN IF EXISTS cycle_plan;
+-- +goose StatementEnd
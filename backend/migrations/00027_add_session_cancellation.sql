@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Отмена сессии: когда и почему — причина от создателя или код (no_show, abandoned, ...)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS cancel_reason VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN IF EXISTS cancel_reason;
+ALTER TABLE sessions DROP COLUMN IF EXISTS cancelled_at;
+-- +goose StatementEnd
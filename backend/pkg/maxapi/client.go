@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 	"unsafe"
@@ -52,6 +54,37 @@ type Client struct {
 	baseURL     string
 	accessToken string
 	httpClient  *http.Client
+	limiter     *tokenBucket
+	retry       RetryPolicy
+	observer    Observer
+}
+
+// Option настраивает Client
+type Option func(*Client)
+
+// WithRateLimit задаёт клиентский лимит запросов в секунду; rps <= 0 отключает лимит
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter = newTokenBucket(rps, burst)
+	}
+}
+
+// WithRetryPolicy задаёт политику повторов для временных ошибок
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// Observer получает итог каждого вызова: метод клиента, длительность с учётом
+// повторов и ожидания лимита, ошибку (nil при успехе)
+type Observer func(op string, duration time.Duration, err error)
+
+// WithObserver подключает наблюдателя вызовов, например для метрик
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
 }
 
 type BotInfo struct {
@@ -142,7 +175,7 @@ type chatButton struct {
 	UUID            string `json:"uuid,omitempty"`
 }
 
-func NewClient(baseURL, accessToken string) (*Client, error) {
+func NewClient(baseURL, accessToken string, opts ...Option) (*Client, error) {
 	cfg := &simpleConfig{
 		baseURL: baseURL,
 		timeout: 30,
@@ -154,19 +187,51 @@ func NewClient(baseURL, accessToken string) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
+	// Без своего транспорта пропадут повторы, Retry-After, лимит и разбор ошибок —
+	// лучше не стартовать, чем молча работать без них после обновления библиотеки
+	transport := &capturingTransport{base: http.DefaultTransport}
+	if !installTransport(api, transport) {
+		return nil, errTransportNotInstalled
+	}
+
+	client := &Client{
 		api:         api,
 		baseURL:     cfg.GetHttpBotAPIUrl(),
 		accessToken: accessToken,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
-	}, nil
+		limiter: newTokenBucket(DefaultRateLimit, DefaultRateLimit),
+		retry:   DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client, nil
+}
+
+// SetRateLimit меняет клиентский лимит на лету, например при перезагрузке
+// конфигурации; rps <= 0 отключает лимит
+func (c *Client) SetRateLimit(rps float64, burst int) {
+	c.limiter.SetRate(rps, burst)
+}
+
+// Close закрывает простаивающие соединения клиента. Текущие вызовы прерываются
+// отменой их контекста
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
 }
 
-func (c *Client) GetMyInfo() (*BotInfo, error) {
-	ctx := context.Background()
-	info, err := c.api.Bots.GetBot(ctx)
+func (c *Client) GetMyInfo(ctx context.Context) (*BotInfo, error) {
+	var info *schemes.BotInfo
+	err := c.call(ctx, "GetMyInfo", true, func(ctx context.Context) error {
+		var err error
+		info, err = c.api.Bots.GetBot(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -174,15 +239,18 @@ func (c *Client) GetMyInfo() (*BotInfo, error) {
 	return convertBotInfo(info), nil
 }
 
-func (c *Client) SendMessage(chatID int64, message *SendMessageRequest) (*SendMessageResponse, error) {
-	ctx := context.Background()
-
-	msg := maxbot.NewMessage().SetChat(chatID)
-	if message != nil {
-		msg.SetText(message.Text)
-	}
+func (c *Client) SendMessage(ctx context.Context, chatID int64, message *SendMessageRequest) (*SendMessageResponse, error) {
+	var sent schemes.Message
+	err := c.call(ctx, "SendMessage", false, func(ctx context.Context) error {
+		msg := maxbot.NewMessage().SetChat(chatID)
+		if message != nil {
+			msg.SetText(message.Text)
+		}
 
-	sent, err := c.api.Messages.SendMessageResult(ctx, msg)
+		var err error
+		sent, err = c.api.Messages.SendMessageResult(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -190,21 +258,25 @@ func (c *Client) SendMessage(chatID int64, message *SendMessageRequest) (*SendMe
 	return &SendMessageResponse{Message: convertMessage(sent)}, nil
 }
 
-func (c *Client) SendMessageToUser(userID int64, message *SendMessageRequest) (*SendMessageResponse, error) {
-	ctx := context.Background()
-	msg := maxbot.NewMessage().SetUser(userID)
-	if message != nil {
-		msg.SetText(message.Text)
-		if len(message.Attachments) > 0 {
-			for _, attachment := range message.Attachments {
-				if err := appendAttachment(msg, attachment); err != nil {
-					return nil, err
+func (c *Client) SendMessageToUser(ctx context.Context, userID int64, message *SendMessageRequest) (*SendMessageResponse, error) {
+	var sent schemes.Message
+	err := c.call(ctx, "SendMessageToUser", false, func(ctx context.Context) error {
+		msg := maxbot.NewMessage().SetUser(userID)
+		if message != nil {
+			msg.SetText(message.Text)
+			if len(message.Attachments) > 0 {
+				for _, attachment := range message.Attachments {
+					if err := appendAttachment(msg, attachment); err != nil {
+						return err
+					}
 				}
 			}
 		}
-	}
 
-	sent, err := c.api.Messages.SendMessageResult(ctx, msg)
+		var err error
+		sent, err = c.api.Messages.SendMessageResult(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -212,9 +284,13 @@ func (c *Client) SendMessageToUser(userID int64, message *SendMessageRequest) (*
 	return &SendMessageResponse{Message: convertMessage(sent)}, nil
 }
 
-func (c *Client) GetChat(chatID int64) (*Chat, error) {
-	ctx := context.Background()
-	chat, err := c.api.Chats.GetChat(ctx, chatID)
+func (c *Client) GetChat(ctx context.Context, chatID int64) (*Chat, error) {
+	var chat *schemes.Chat
+	err := c.call(ctx, "GetChat", true, func(ctx context.Context) error {
+		var err error
+		chat, err = c.api.Chats.GetChat(ctx, chatID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -222,19 +298,20 @@ func (c *Client) GetChat(chatID int64) (*Chat, error) {
 	return convertChat(chat), nil
 }
 
-func (c *Client) GetChatByLink(chatLink string) (*Chat, error) {
+func (c *Client) GetChatByLink(ctx context.Context, chatLink string) (*Chat, error) {
 	endpoint := fmt.Sprintf("/chats/%s", url.PathEscape(chatLink))
 	var chat Chat
-	if err := c.doRequest("GET", endpoint, nil, &chat); err != nil {
+	err := c.call(ctx, "GetChatByLink", true, func(ctx context.Context) error {
+		return c.doRequest(ctx, "GetChatByLink", "GET", endpoint, nil, &chat)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &chat, nil
 }
 
-func (c *Client) GetMessages(chatID int64, from, to, count *int64, messageIDs []string) ([]Message, error) {
-	ctx := context.Background()
-
+func (c *Client) GetMessages(ctx context.Context, chatID int64, from, to, count *int64, messageIDs []string) ([]Message, error) {
 	var fromInt, toInt, countInt int
 	if from != nil {
 		fromInt = int(*from)
@@ -246,7 +323,12 @@ func (c *Client) GetMessages(chatID int64, from, to, count *int64, messageIDs []
 		countInt = int(*count)
 	}
 
-	list, err := c.api.Messages.GetMessages(ctx, chatID, messageIDs, fromInt, toInt, countInt)
+	var list *schemes.MessageList
+	err := c.call(ctx, "GetMessages", true, func(ctx context.Context) error {
+		var err error
+		list, err = c.api.Messages.GetMessages(ctx, chatID, messageIDs, fromInt, toInt, countInt)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -259,20 +341,20 @@ func (c *Client) GetMessages(chatID int64, from, to, count *int64, messageIDs []
 	return result, nil
 }
 
-func (c *Client) AddMembers(chatID int64, userIDs []int64) error {
-	ctx := context.Background()
+func (c *Client) AddMembers(ctx context.Context, chatID int64, userIDs []int64) error {
 	intIDs := make([]int, 0, len(userIDs))
 	for _, id := range userIDs {
 		intIDs = append(intIDs, int(id))
 	}
 
-	_, err := c.api.Chats.AddMember(ctx, chatID, schemes.UserIdsList{UserIds: intIDs})
-	return err
+	// Повторное добавление уже добавленных участников безопасно
+	return c.call(ctx, "AddMembers", true, func(ctx context.Context) error {
+		_, err := c.api.Chats.AddMember(ctx, chatID, schemes.UserIdsList{UserIds: intIDs})
+		return err
+	})
 }
 
-func (c *Client) GetChatMembers(chatID int64, marker *int64, count *int, userIDs []int64) (*ChatMembersResponse, error) {
-	ctx := context.Background()
-
+func (c *Client) GetChatMembers(ctx context.Context, chatID int64, marker *int64, count *int, userIDs []int64) (*ChatMembersResponse, error) {
 	var markerVal, countVal int64
 	if marker != nil {
 		markerVal = *marker
@@ -281,7 +363,12 @@ func (c *Client) GetChatMembers(chatID int64, marker *int64, count *int, userIDs
 		countVal = int64(*count)
 	}
 
-	list, err := c.api.Chats.GetChatMembers(ctx, chatID, countVal, markerVal)
+	var list *schemes.ChatMembersList
+	err := c.call(ctx, "GetChatMembers", true, func(ctx context.Context) error {
+		var err error
+		list, err = c.api.Chats.GetChatMembers(ctx, chatID, countVal, markerVal)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -302,14 +389,18 @@ func (c *Client) GetChatMembers(chatID int64, marker *int64, count *int, userIDs
 	return resp, nil
 }
 
-func (c *Client) EditChat(chatID int64, title *string, icon interface{}) (*Chat, error) {
-	ctx := context.Background()
+func (c *Client) EditChat(ctx context.Context, chatID int64, title *string, icon interface{}) (*Chat, error) {
 	update := &schemes.ChatPatch{}
 	if title != nil {
 		update.Title = *title
 	}
 
-	chat, err := c.api.Chats.EditChat(ctx, chatID, update)
+	var chat *schemes.Chat
+	err := c.call(ctx, "EditChat", true, func(ctx context.Context) error {
+		var err error
+		chat, err = c.api.Chats.EditChat(ctx, chatID, update)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -317,15 +408,46 @@ func (c *Client) EditChat(chatID int64, title *string, icon interface{}) (*Chat,
 	return convertChat(chat), nil
 }
 
-func (c *Client) DeleteChat(chatID int64) error {
+func (c *Client) DeleteChat(ctx context.Context, chatID int64) error {
 	endpoint := fmt.Sprintf("/chats/%d", chatID)
-	return c.doRequest("DELETE", endpoint, nil, nil)
+	return c.call(ctx, "DeleteChat", true, func(ctx context.Context) error {
+		return c.doRequest(ctx, "DeleteChat", "DELETE", endpoint, nil, nil)
+	})
+}
+
+func (c *Client) RemoveMember(ctx context.Context, chatID int64, userID int64) error {
+	return c.call(ctx, "RemoveMember", true, func(ctx context.Context) error {
+		_, err := c.api.Chats.RemoveMember(ctx, chatID, userID)
+		return err
+	})
 }
 
-func (c *Client) RemoveMember(chatID int64, userID int64) error {
-	ctx := context.Background()
-	_, err := c.api.Chats.RemoveMember(ctx, chatID, userID)
-	return err
+// GetUpdates забирает обновления long polling'ом. timeout — сколько секунд сервер
+// держит запрос без событий, должен быть меньше таймаута http клиента (30 секунд)
+func (c *Client) GetUpdates(ctx context.Context, marker *int64, limit, timeout int, types []string) (*UpdatesResponse, error) {
+	query := url.Values{}
+	if marker != nil {
+		query.Set("marker", strconv.FormatInt(*marker, 10))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if timeout > 0 {
+		query.Set("timeout", strconv.Itoa(timeout))
+	}
+	if len(types) > 0 {
+		query.Set("types", strings.Join(types, ","))
+	}
+
+	var resp UpdatesResponse
+	err := c.call(ctx, "GetUpdates", true, func(ctx context.Context) error {
+		return c.doRequest(ctx, "GetUpdates", "GET", "/updates?"+query.Encode(), nil, &resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
 }
 
 // --- Helpers ----------------------------------------------------------------
@@ -513,7 +635,7 @@ func setMessageAttachment(msg *maxbot.Message, attachment interface{}) error {
 	return nil
 }
 
-func (c *Client) doRequest(method, endpoint string, body interface{}, out interface{}) error {
+func (c *Client) doRequest(ctx context.Context, op, method, endpoint string, body interface{}, out interface{}) error {
 	var reqBody *bytes.Buffer
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -525,7 +647,7 @@ func (c *Client) doRequest(method, endpoint string, body interface{}, out interf
 		reqBody = bytes.NewBuffer(nil)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -540,12 +662,13 @@ func (c *Client) doRequest(method, endpoint string, body interface{}, out interf
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return &NetworkError{Op: op, Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API error [%d]: %s", resp.StatusCode, resp.Status)
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return newAPIError(op, resp.StatusCode, resp.Header, data, nil)
 	}
 
 	if out != nil {
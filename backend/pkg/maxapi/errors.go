@@ -0,0 +1,235 @@
+package maxapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	maxbot "github.com/max-messenger/max-bot-api-client-go"
+)
+
+// Ошибки-классы для проверки через errors.Is
+var (
+	ErrBadRequest   = errors.New("max api: bad request")
+	ErrUnauthorized = errors.New("max api: unauthorized")
+	ErrForbidden    = errors.New("max api: forbidden")
+	ErrNotFound     = errors.New("max api: not found")
+	ErrRateLimited  = errors.New("max api: rate limited")
+	ErrUnavailable  = errors.New("max api: service unavailable")
+)
+
+// APIError ошибка, возвращённая MAX API, с разобранным телом ответа
+type APIError struct {
+	Op         string        // вызванный метод клиента, например "GetChat"
+	StatusCode int           // HTTP статус ответа
+	Code       string        // машинный код ошибки MAX, например "chat.not.found"
+	Message    string        // человекочитаемое описание
+	RetryAfter time.Duration // значение заголовка Retry-After, если он был
+	Err        error         // исходная ошибка библиотеки
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("max api %s: [%d] %s: %s", e.Op, e.StatusCode, e.Code, msg)
+	}
+	return fmt.Sprintf("max api %s: [%d] %s", e.Op, e.StatusCode, msg)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is сопоставляет статус ответа с ошибками-классами пакета
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Temporary сообщает, имеет ли смысл повторить запрос
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NetworkError ошибка транспорта: соединение, DNS, таймаут
+type NetworkError struct {
+	Op  string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("max api %s: network error: %v", e.Op, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound сообщает, что запрошенный объект (чат, сообщение, пользователь) не существует
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsForbidden сообщает, что у бота нет прав на операцию
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsRetryable сообщает, что ошибка временная: 429, 5xx или сетевая
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	var netErr *NetworkError
+	return errors.As(err, &netErr)
+}
+
+// ErrorKind возвращает короткое имя класса ошибки для логов и метрик:
+// "bad_request", "unauthorized", "forbidden", "not_found", "rate_limited",
+// "unavailable", "network", "canceled" или "other"; для nil — пустую строку
+func ErrorKind(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+
+	for _, kind := range []struct {
+		err  error
+		name string
+	}{
+		{ErrBadRequest, "bad_request"},
+		{ErrUnauthorized, "unauthorized"},
+		{ErrForbidden, "forbidden"},
+		{ErrNotFound, "not_found"},
+		{ErrRateLimited, "rate_limited"},
+		{ErrUnavailable, "unavailable"},
+	} {
+		if errors.Is(err, kind.err) {
+			return kind.name
+		}
+	}
+
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+	return "other"
+}
+
+// classifyError приводит ошибку библиотеки к типизированной ошибке пакета,
+// используя ответ, перехваченный транспортом
+func classifyError(op string, err error, info *callInfo) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	if info != nil && info.statusCode != 0 {
+		return newAPIError(op, info.statusCode, info.header, info.body, err)
+	}
+
+	var libAPIErr *maxbot.APIError
+	if errors.As(err, &libAPIErr) {
+		return &APIError{Op: op, StatusCode: libAPIErr.Code, Message: libAPIErr.Message, Err: err}
+	}
+
+	var libNetErr *maxbot.NetworkError
+	var libTimeoutErr *maxbot.TimeoutError
+	var netErr net.Error
+	if errors.As(err, &libNetErr) || errors.As(err, &libTimeoutErr) || errors.As(err, &netErr) {
+		return &NetworkError{Op: op, Err: err}
+	}
+
+	return fmt.Errorf("max api %s: %w", op, err)
+}
+
+// newAPIError разбирает тело ответа MAX вида {"code": "...", "message": "..."}
+func newAPIError(op string, statusCode int, header http.Header, body []byte, cause error) *APIError {
+	apiErr := &APIError{
+		Op:         op,
+		StatusCode: statusCode,
+		RetryAfter: parseRetryAfter(header),
+		Err:        cause,
+	}
+
+	var payload struct {
+		Code    string          `json:"code"`
+		Error   string          `json:"error"`
+		Message json.RawMessage `json:"message"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &payload) == nil {
+		apiErr.Code = payload.Code
+		var message string
+		if json.Unmarshal(payload.Message, &message) == nil {
+			apiErr.Message = message
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = payload.Error
+		}
+	} else if len(body) > 0 {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter поддерживает обе формы заголовка: секунды и HTTP-дату
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,393 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-messenger/max-bot-api-client-go/schemes"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+const (
+	defaultMessagesCount = 50
+	maxMessagesCount     = 100
+	defaultMembersCount  = 20
+	maxMembersCount      = 100
+	defaultUpdatesLimit  = 100
+	defaultUpdatesWait   = 30
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  query,
+		Body:   body,
+	})
+	f, failed := s.takeFaultLocked(r.Method, r.URL.Path)
+	s.mu.Unlock()
+
+	if failed {
+		if f.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter.Seconds()+0.5)))
+		}
+		writeError(w, f.status, f.code, http.StatusText(f.status))
+		return
+	}
+
+	if query.Get("access_token") != s.AccessToken {
+		writeError(w, http.StatusUnauthorized, "verify.token", "Invalid access_token")
+		return
+	}
+
+	// EscapedPath сохраняет %2F внутри ссылки на чат в /chats/{link}
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+
+	switch {
+	case len(segments) == 1 && segments[0] == "me" && r.Method == http.MethodGet:
+		s.handleGetMe(w)
+	case len(segments) == 1 && segments[0] == "messages" && r.Method == http.MethodPost:
+		s.handleSendMessage(w, query, body)
+	case len(segments) == 1 && segments[0] == "messages" && r.Method == http.MethodGet:
+		s.handleGetMessages(w, query)
+	case len(segments) == 1 && segments[0] == "updates" && r.Method == http.MethodGet:
+		s.handleGetUpdates(w, r)
+	case len(segments) == 2 && segments[0] == "chats":
+		s.handleChat(w, r.Method, segments[1], body)
+	case len(segments) == 3 && segments[0] == "chats" && segments[2] == "members":
+		s.handleMembers(w, r.Method, segments[1], query, body)
+	default:
+		writeError(w, http.StatusNotFound, "not.found", fmt.Sprintf("Path %s %s not found", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) takeFaultLocked(method, path string) (fault, bool) {
+	for i, f := range s.faults {
+		if f.method == method && f.path == path {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			return f, true
+		}
+	}
+	return fault{}, false
+}
+
+func (s *Server) handleGetMe(w http.ResponseWriter) {
+	s.mu.Lock()
+	bot := s.bot
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, bot)
+}
+
+func (s *Server) handleSendMessage(w http.ResponseWriter, query url.Values, body []byte) {
+	var req struct {
+		Text        string            `json:"text"`
+		Attachments []json.RawMessage `json:"attachments"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "proto.payload", "Invalid message body")
+		return
+	}
+	if req.Text == "" && len(req.Attachments) == 0 {
+		writeError(w, http.StatusBadRequest, "proto.payload", "Message text or attachments required")
+		return
+	}
+
+	chatID, _ := strconv.ParseInt(query.Get("chat_id"), 10, 64)
+	userID, _ := strconv.ParseInt(query.Get("user_id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state *chatState
+	recipient := schemes.Recipient{}
+
+	switch {
+	case userID != 0:
+		dialogID, ok := s.dialogs[userID]
+		if !ok {
+			state = s.newChatLocked(schemes.DIALOG, "", userID)
+			s.addMemberLocked(state, userID)
+			s.dialogs[userID] = state.chat.ChatId
+		} else {
+			state = s.chats[dialogID]
+		}
+		recipient = schemes.Recipient{ChatId: state.chat.ChatId, ChatType: schemes.DIALOG, UserId: userID}
+	case chatID != 0:
+		var ok bool
+		state, ok = s.chats[chatID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "chat.not.found", fmt.Sprintf("Chat %d not found", chatID))
+			return
+		}
+		recipient = schemes.Recipient{ChatId: chatID, ChatType: state.chat.Type}
+	default:
+		writeError(w, http.StatusBadRequest, "proto.payload", "chat_id or user_id is required")
+		return
+	}
+
+	message := s.appendMessageLocked(state, s.botUser(), recipient, req.Text, req.Attachments)
+	writeJSON(w, http.StatusOK, schemes.SendMessageResult{Message: message})
+}
+
+func (s *Server) handleGetMessages(w http.ResponseWriter, query url.Values) {
+	chatID, _ := strconv.ParseInt(query.Get("chat_id"), 10, 64)
+	messageIDs := query["message_ids"]
+	if chatID == 0 && len(messageIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "proto.payload", "chat_id or message_ids is required")
+		return
+	}
+
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	count, _ := strconv.Atoi(query.Get("count"))
+	if count <= 0 {
+		count = defaultMessagesCount
+	}
+	if count > maxMessagesCount {
+		count = maxMessagesCount
+	}
+
+	wanted := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		wanted[id] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var source []schemes.Message
+	if chatID != 0 {
+		state, ok := s.chats[chatID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "chat.not.found", fmt.Sprintf("Chat %d not found", chatID))
+			return
+		}
+		source = state.messages
+	} else {
+		for _, state := range s.chats {
+			source = append(source, state.messages...)
+		}
+		sort.Slice(source, func(i, j int) bool { return source[i].Timestamp < source[j].Timestamp })
+	}
+
+	// Как и настоящий API, отдаём сообщения от новых к старым
+	result := make([]schemes.Message, 0, count)
+	for i := len(source) - 1; i >= 0 && len(result) < count; i-- {
+		message := source[i]
+		if len(wanted) > 0 && !wanted[message.Body.Mid] {
+			continue
+		}
+		if from != 0 && message.Timestamp > from {
+			continue
+		}
+		if to != 0 && message.Timestamp < to {
+			continue
+		}
+		result = append(result, message)
+	}
+
+	writeJSON(w, http.StatusOK, schemes.MessageList{Messages: result})
+}
+
+// handleGetUpdates отдает обновления начиная с marker; если их нет,
+// держит запрос до timeout секунд или до PushUpdate
+func (s *Server) handleGetUpdates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = defaultUpdatesLimit
+	}
+	timeout, err := strconv.Atoi(query.Get("timeout"))
+	if err != nil || timeout < 0 {
+		timeout = defaultUpdatesWait
+	}
+
+	s.mu.Lock()
+	// Переданный маркер подтверждает получение предыдущих обновлений;
+	// без маркера, как и в MAX, продолжаем с последнего подтвержденного
+	marker := s.updatesAcked
+	if raw := query.Get("marker"); raw != "" {
+		marker, _ = strconv.ParseInt(raw, 10, 64)
+	}
+	if marker < 0 || marker > int64(len(s.updates)) {
+		marker = int64(len(s.updates))
+	}
+	s.updatesAcked = marker
+	s.mu.Unlock()
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		available := s.updates[marker:]
+		signal := s.updatesSignal
+		if len(available) > 0 || timeout == 0 {
+			if len(available) > limit {
+				available = available[:limit]
+			}
+			next := marker + int64(len(available))
+			result := maxapi.UpdatesResponse{
+				Updates: append([]json.RawMessage{}, available...),
+				Marker:  &next,
+			}
+			s.mu.Unlock()
+			writeJSON(w, http.StatusOK, result)
+			return
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-signal:
+		case <-deadline.C:
+			timeout = 0
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, method, rawID string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.findChatLocked(rawID)
+	if state == nil {
+		writeError(w, http.StatusNotFound, "chat.not.found", fmt.Sprintf("Chat %s not found", rawID))
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, state.chat)
+
+	case http.MethodPatch:
+		var patch schemes.ChatPatch
+		if err := json.Unmarshal(body, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "proto.payload", "Invalid chat patch")
+			return
+		}
+		if patch.Title != "" {
+			state.chat.Title = patch.Title
+		}
+		writeJSON(w, http.StatusOK, state.chat)
+
+	case http.MethodDelete:
+		delete(s.chats, state.chat.ChatId)
+		writeJSON(w, http.StatusOK, schemes.SimpleQueryResult{Success: true})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method.not.allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleMembers(w http.ResponseWriter, method, rawID string, query url.Values, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.findChatLocked(rawID)
+	if state == nil {
+		writeError(w, http.StatusNotFound, "chat.not.found", fmt.Sprintf("Chat %s not found", rawID))
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		count, _ := strconv.Atoi(query.Get("count"))
+		if count <= 0 {
+			count = defaultMembersCount
+		}
+		if count > maxMembersCount {
+			count = maxMembersCount
+		}
+		marker, _ := strconv.ParseInt(query.Get("marker"), 10, 64)
+
+		ids := make([]int64, 0, len(state.members))
+		for id := range state.members {
+			if id > marker {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		result := schemes.ChatMembersList{Members: []schemes.ChatMember{}}
+		for _, id := range ids {
+			if len(result.Members) == count {
+				// Маркер — ID последнего отданного участника
+				next := result.Members[len(result.Members)-1].UserId
+				result.Marker = &next
+				break
+			}
+			result.Members = append(result.Members, *state.members[id])
+		}
+		writeJSON(w, http.StatusOK, result)
+
+	case http.MethodPost:
+		var list schemes.UserIdsList
+		if err := json.Unmarshal(body, &list); err != nil || len(list.UserIds) == 0 {
+			writeError(w, http.StatusBadRequest, "proto.payload", "user_ids is required")
+			return
+		}
+		for _, id := range list.UserIds {
+			s.addMemberLocked(state, int64(id))
+		}
+		writeJSON(w, http.StatusOK, schemes.SimpleQueryResult{Success: true})
+
+	case http.MethodDelete:
+		userID, _ := strconv.ParseInt(query.Get("user_id"), 10, 64)
+		if _, ok := state.members[userID]; !ok {
+			writeError(w, http.StatusNotFound, "user.not.found", fmt.Sprintf("User %d is not a member", userID))
+			return
+		}
+		delete(state.members, userID)
+		state.chat.ParticipantsCount = len(state.members)
+		writeJSON(w, http.StatusOK, schemes.SimpleQueryResult{Success: true})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method.not.allowed", "Method not allowed")
+	}
+}
+
+// findChatLocked ищет чат по ID или по ссылке (GetChatByLink)
+func (s *Server) findChatLocked(rawID string) *chatState {
+	if chatID, err := strconv.ParseInt(rawID, 10, 64); err == nil {
+		return s.chats[chatID]
+	}
+
+	link, err := url.PathUnescape(rawID)
+	if err != nil {
+		return nil
+	}
+	for _, state := range s.chats {
+		if state.chat.Link == link {
+			return state
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError отвечает в формате ошибок MAX: {"code": "...", "message": "..."}
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{
+		"code":    code,
+		"message": message,
+	})
+}
@@ -0,0 +1,74 @@
+package fake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InitDataUser пользователь Mini App в поле user initData
+type InitDataUser struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
+	PhotoURL  string `json:"photo_url,omitempty"`
+}
+
+// SignInitData подписывает initData так же, как клиент MAX:
+// secret_key = HMAC_SHA256("WebAppData", botToken),
+// hash = HMAC_SHA256(secret_key, data_check_string), где data_check_string —
+// отсортированные пары key=value через "\n". Возвращает строку для /auth/login
+func SignInitData(botToken string, values url.Values) string {
+	signed := url.Values{}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key == "hash" {
+			continue
+		}
+		keys = append(keys, key)
+		signed.Set(key, values.Get(key))
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, key := range keys {
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(values.Get(key))
+		if i < len(keys)-1 {
+			sb.WriteByte('\n')
+		}
+	}
+
+	secretKeyMac := hmac.New(sha256.New, []byte("WebAppData"))
+	secretKeyMac.Write([]byte(botToken))
+
+	mac := hmac.New(sha256.New, secretKeyMac.Sum(nil))
+	mac.Write([]byte(sb.String()))
+
+	signed.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return signed.Encode()
+}
+
+// InitDataForUser собирает и подписывает initData для пользователя.
+// startParam попадает в start_param, если не пустой
+func InitDataForUser(botToken string, user InitDataUser, startParam string) string {
+	userJSON, _ := json.Marshal(user)
+
+	values := url.Values{}
+	values.Set("user", string(userJSON))
+	values.Set("auth_date", strconv.FormatInt(time.Now().Unix(), 10))
+	values.Set("query_id", "fake-query-"+strconv.FormatInt(user.ID, 10))
+	if startParam != "" {
+		values.Set("start_param", startParam)
+	}
+
+	return SignInitData(botToken, values)
+}
@@ -0,0 +1,296 @@
+// Package fake поднимает in-process сервер, повторяющий эндпоинты MAX Bot API,
+// которые использует maxapi.Client. Состояние (пользователи, чаты, участники,
+// сообщения) хранится в памяти, поэтому бэкенд можно запускать и тестировать
+// без platform-api.max.ru и настоящего токена бота.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/max-messenger/max-bot-api-client-go/schemes"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+// DefaultAccessToken токен бота, который принимает сервер по умолчанию
+const DefaultAccessToken = "fake-access-token"
+
+// Request запрос, полученный сервером; используется для проверок в тестах
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+type fault struct {
+	method     string
+	path       string
+	status     int
+	code       string
+	retryAfter time.Duration
+}
+
+type chatState struct {
+	chat     schemes.Chat
+	members  map[int64]*schemes.ChatMember
+	messages []schemes.Message // в порядке отправки
+}
+
+// Server фейковый MAX API
+type Server struct {
+	URL         string
+	AccessToken string
+
+	httpServer *httptest.Server
+
+	mu       sync.Mutex
+	bot      schemes.BotInfo
+	users    map[int64]schemes.User
+	chats    map[int64]*chatState
+	dialogs  map[int64]int64 // userID -> chatID личного диалога с ботом
+	nextID   int64
+	requests []Request
+	faults   []fault
+
+	updates       []json.RawMessage // очередь для GET /updates, маркер — индекс в ней
+	updatesSignal chan struct{}     // закрывается при появлении новых обновлений
+	updatesAcked  int64             // последний маркер, переданный клиентом
+}
+
+// NewServer запускает сервер с указанным токеном (пустой — DefaultAccessToken).
+// Сервер нужно остановить через Close
+func NewServer(accessToken string) *Server {
+	if accessToken == "" {
+		accessToken = DefaultAccessToken
+	}
+
+	s := &Server{
+		AccessToken: accessToken,
+		bot: schemes.BotInfo{
+			UserId:   1,
+			Name:     "Synchronous Bot",
+			Username: "synchronous_bot",
+		},
+		users:   make(map[int64]schemes.User),
+		chats:   make(map[int64]*chatState),
+		dialogs: make(map[int64]int64),
+		nextID:  1000,
+
+		updatesSignal: make(chan struct{}),
+	}
+
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.httpServer.URL
+
+	return s
+}
+
+// Close останавливает сервер
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// Client возвращает maxapi.Client, настроенный на этот сервер
+func (s *Server) Client(opts ...maxapi.Option) (*maxapi.Client, error) {
+	return maxapi.NewClient(s.URL, s.AccessToken, opts...)
+}
+
+// AddUser регистрирует пользователя MAX
+func (s *Server) AddUser(userID int64, firstName, lastName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = schemes.User{
+		UserId:    userID,
+		Name:      strings.TrimSpace(firstName + " " + lastName),
+		FirstName: firstName,
+		LastName:  lastName,
+	}
+}
+
+// CreateChat создает групповой чат с ботом и указанными участниками, возвращает его ID
+func (s *Server) CreateChat(title string, ownerID int64, memberIDs ...int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.newChatLocked(schemes.CHAT, title, ownerID)
+	for _, userID := range append([]int64{ownerID}, memberIDs...) {
+		s.addMemberLocked(state, userID)
+	}
+
+	return state.chat.ChatId
+}
+
+// Chat возвращает копию состояния чата
+func (s *Server) Chat(chatID int64) (schemes.Chat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.chats[chatID]
+	if !ok {
+		return schemes.Chat{}, false
+	}
+	return state.chat, true
+}
+
+// Members возвращает ID участников чата, отсортированные по возрастанию
+func (s *Server) Members(chatID int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.chats[chatID]
+	if !ok {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(state.members))
+	for id := range state.members {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Messages возвращает сообщения чата в порядке отправки
+func (s *Server) Messages(chatID int64) []schemes.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.chats[chatID]
+	if !ok {
+		return nil
+	}
+	return append([]schemes.Message(nil), state.messages...)
+}
+
+// MessagesToUser возвращает сообщения, отправленные ботом пользователю в личку
+func (s *Server) MessagesToUser(userID int64) []schemes.Message {
+	s.mu.Lock()
+	chatID, ok := s.dialogs[userID]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return s.Messages(chatID)
+}
+
+// Requests возвращает все полученные запросы
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// PushUpdate кладет обновление в очередь GET /updates и будит ожидающие long poll запросы
+func (s *Server) PushUpdate(update interface{}) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updates = append(s.updates, data)
+	close(s.updatesSignal)
+	s.updatesSignal = make(chan struct{})
+
+	return nil
+}
+
+// FailNext заставляет следующий запрос method+path (например "POST", "/messages")
+// вернуть status. retryAfter > 0 добавляет заголовок Retry-After
+func (s *Server) FailNext(method, path string, status int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, fault{
+		method:     method,
+		path:       path,
+		status:     status,
+		code:       fmt.Sprintf("fake.%d", status),
+		retryAfter: retryAfter,
+	})
+}
+
+func (s *Server) newChatLocked(chatType schemes.ChatType, title string, ownerID int64) *chatState {
+	s.nextID++
+	chatID := s.nextID
+
+	state := &chatState{
+		chat: schemes.Chat{
+			ChatId:        chatID,
+			Type:          chatType,
+			Status:        schemes.ACTIVE,
+			Title:         title,
+			OwnerId:       ownerID,
+			LastEventTime: int(time.Now().UnixMilli()),
+			Link:          fmt.Sprintf("https://max.ru/join/fake-%d", chatID),
+		},
+		members: make(map[int64]*schemes.ChatMember),
+	}
+	s.chats[chatID] = state
+	s.addMemberLocked(state, s.bot.UserId)
+
+	return state
+}
+
+func (s *Server) addMemberLocked(state *chatState, userID int64) {
+	if _, exists := state.members[userID]; exists {
+		return
+	}
+
+	user := s.users[userID]
+	name := user.Name
+	if name == "" {
+		name = fmt.Sprintf("user-%d", userID)
+	}
+
+	state.members[userID] = &schemes.ChatMember{
+		UserId:   userID,
+		Name:     name,
+		IsOwner:  userID == state.chat.OwnerId,
+		IsAdmin:  userID == state.chat.OwnerId || userID == s.bot.UserId,
+		JoinTime: int(time.Now().UnixMilli()),
+	}
+	state.chat.ParticipantsCount = len(state.members)
+}
+
+func (s *Server) appendMessageLocked(state *chatState, sender schemes.User, recipient schemes.Recipient, text string, attachments []json.RawMessage) schemes.Message {
+	s.nextID++
+
+	message := schemes.Message{
+		Sender:    sender,
+		Recipient: recipient,
+		Timestamp: time.Now().UnixMilli(),
+		Body: schemes.MessageBody{
+			Mid:            fmt.Sprintf("mid.fake.%d", s.nextID),
+			Seq:            int64(len(state.messages) + 1),
+			Text:           text,
+			RawAttachments: attachments,
+		},
+	}
+	// Attachments сериализуется под ключом "Attachments"; при регистронезависимом
+	// разборе он перекрывает "attachments", поэтому держим поля одинаковыми
+	for _, attachment := range attachments {
+		message.Body.Attachments = append(message.Body.Attachments, attachment)
+	}
+	state.messages = append(state.messages, message)
+	state.chat.LastEventTime = int(message.Timestamp)
+
+	return message
+}
+
+func (s *Server) botUser() schemes.User {
+	return schemes.User{UserId: s.bot.UserId, Name: s.bot.Name, Username: s.bot.Username, IsBot: true}
+}
@@ -0,0 +1,109 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/max-messenger/max-bot-api-client-go/schemes"
+	"github.com/rnegic/synchronous/pkg/maxapi"
+)
+
+// UserMessage пишет сообщение пользователя в чат и возвращает соответствующее
+// обновление message_created, которое MAX прислал бы на webhook
+func (s *Server) UserMessage(chatID, userID int64, text string) (*maxapi.MessageCreatedUpdate, error) {
+	s.mu.Lock()
+	state, ok := s.chats[chatID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("chat %d not found", chatID)
+	}
+
+	sender, ok := s.users[userID]
+	if !ok {
+		sender = schemes.User{UserId: userID, Name: fmt.Sprintf("user-%d", userID)}
+	}
+
+	recipient := schemes.Recipient{ChatId: chatID, ChatType: state.chat.Type}
+	message := s.appendMessageLocked(state, sender, recipient, text, nil)
+	s.mu.Unlock()
+
+	return messageCreated(message)
+}
+
+// DialogMessage пишет сообщение пользователя боту в личку, создавая диалог при
+// необходимости, и возвращает соответствующее обновление message_created
+func (s *Server) DialogMessage(userID int64, text string) (*maxapi.MessageCreatedUpdate, error) {
+	s.mu.Lock()
+	state, ok := s.chats[s.dialogs[userID]]
+	if !ok {
+		state = s.newChatLocked(schemes.DIALOG, "", userID)
+		s.addMemberLocked(state, userID)
+		s.dialogs[userID] = state.chat.ChatId
+	}
+
+	sender, ok := s.users[userID]
+	if !ok {
+		sender = schemes.User{UserId: userID, Name: fmt.Sprintf("user-%d", userID)}
+	}
+
+	recipient := schemes.Recipient{ChatId: state.chat.ChatId, ChatType: schemes.DIALOG, UserId: s.botUser().UserId}
+	message := s.appendMessageLocked(state, sender, recipient, text, nil)
+	s.mu.Unlock()
+
+	return messageCreated(message)
+}
+
+func messageCreated(message schemes.Message) (*maxapi.MessageCreatedUpdate, error) {
+	update := &maxapi.MessageCreatedUpdate{
+		UpdateType: "message_created",
+		Timestamp:  message.Timestamp,
+	}
+	// Конвертируем через JSON, как это делает обработчик webhook
+	if err := convert(message, &update.Message); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// ChatCreatedFromButton создает чат, как будто пользователь нажал кнопку типа "chat",
+// и возвращает обновление message_chat_created с start_payload кнопки
+func (s *Server) ChatCreatedFromButton(ownerID int64, title, startPayload string) *maxapi.MessageChatCreatedUpdate {
+	chatID := s.CreateChat(title, ownerID)
+	chat, _ := s.Chat(chatID)
+
+	return &maxapi.MessageChatCreatedUpdate{
+		UpdateType: "message_chat_created",
+		Timestamp:  time.Now().UnixMilli(),
+		Chat: maxapi.Chat{
+			ChatID:            chat.ChatId,
+			Type:              string(chat.Type),
+			Status:            string(chat.Status),
+			Title:             chat.Title,
+			LastEventTime:     int64(chat.LastEventTime),
+			ParticipantsCount: chat.ParticipantsCount,
+		},
+		StartPayload: startPayload,
+	}
+}
+
+// DeliverWebhook отправляет обновление на webhook так же, как это делает MAX
+func DeliverWebhook(webhookURL string, update interface{}) (*http.Response, error) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal update: %w", err)
+	}
+
+	return http.Post(webhookURL, "application/json", bytes.NewReader(data))
+}
+
+func convert(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
@@ -0,0 +1,68 @@
+package maxapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(10, 2)
+
+	// Burst отдается сразу, дальше — по токену в 100ms
+	for i := 0; i < 2; i++ {
+		if wait := bucket.reserve(); wait != 0 {
+			t.Fatalf("reserve %d: wait %v, want 0", i+1, wait)
+		}
+	}
+	if wait := bucket.reserve(); wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("reserve beyond burst: wait %v, want (0, 100ms]", wait)
+	}
+
+	start := time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("Wait returned after %v, want about 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait with expiring context: %v", err)
+	}
+}
+
+func TestTokenBucketDisabledAndPaused(t *testing.T) {
+	bucket := newTokenBucket(0, 0)
+	for i := 0; i < 100; i++ {
+		if wait := bucket.reserve(); wait != 0 {
+			t.Fatalf("disabled limiter: wait %v", wait)
+		}
+	}
+
+	// Retry-After останавливает даже выключенный лимит; более короткая пауза не сокращает его
+	bucket.PauseFor(time.Second)
+	bucket.PauseFor(time.Millisecond)
+	if wait := bucket.reserve(); wait < 900*time.Millisecond {
+		t.Fatalf("paused limiter: wait %v, want about 1s", wait)
+	}
+}
+
+func TestTokenBucketSetRate(t *testing.T) {
+	bucket := newTokenBucket(100, 10)
+	bucket.SetRate(1, 1)
+	if wait := bucket.reserve(); wait != 0 {
+		t.Fatalf("first reserve after SetRate: wait %v", wait)
+	}
+	if wait := bucket.reserve(); wait < 900*time.Millisecond {
+		t.Fatalf("reserve at 1 rps: wait %v, want about 1s", wait)
+	}
+
+	bucket.SetRate(0, 0)
+	if wait := bucket.reserve(); wait != 0 {
+		t.Fatalf("reserve with limit off: wait %v", wait)
+	}
+}
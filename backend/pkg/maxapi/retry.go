@@ -0,0 +1,95 @@
+package maxapi
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy описывает повторные попытки для временных ошибок
+type RetryPolicy struct {
+	MaxAttempts int           // общее число попыток, включая первую
+	BaseDelay   time.Duration // задержка перед второй попыткой
+	MaxDelay    time.Duration // верхняя граница задержки
+}
+
+// DefaultRetryPolicy используется, если политика не задана явно
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// delay возвращает паузу перед попыткой attempt+1: экспонента с full jitter,
+// но не меньше Retry-After, если сервер его прислал
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	backoff := p.BaseDelay << uint(attempt)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+
+	d := time.Duration(rand.Int63n(int64(backoff) + 1))
+	if retryAfter > d {
+		d = retryAfter
+	}
+	return d
+}
+
+// call выполняет запрос с ограничением частоты и повторами.
+// idempotent=false означает, что повторять можно только заведомо не выполненные
+// запросы (429), чтобы не отправить сообщение дважды
+func (c *Client) call(ctx context.Context, op string, idempotent bool, fn func(ctx context.Context) error) (err error) {
+	if c.observer != nil {
+		start := time.Now()
+		defer func() { c.observer(op, time.Since(start), err) }()
+	}
+
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		info := &callInfo{}
+		err := classifyError(op, fn(withCallInfo(ctx, info)), info)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+		if isAPIErr && errors.Is(err, ErrRateLimited) && apiErr.RetryAfter > 0 {
+			// Лимит общий для бота, поэтому притормаживаем все запросы клиента
+			c.limiter.PauseFor(apiErr.RetryAfter)
+		}
+
+		if !IsRetryable(err) || attempt == attempts-1 {
+			break
+		}
+		if !idempotent && !errors.Is(err, ErrRateLimited) {
+			break
+		}
+
+		var retryAfter time.Duration
+		if isAPIErr {
+			retryAfter = apiErr.RetryAfter
+		}
+
+		timer := time.NewTimer(c.retry.delay(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return lastErr
+}
@@ -0,0 +1,243 @@
+package maxapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt, limit := range []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond,
+		time.Second, time.Second, time.Second,
+	} {
+		for i := 0; i < 50; i++ {
+			if d := policy.delay(attempt, 0); d < 0 || d > limit {
+				t.Fatalf("delay(%d) = %v, want within [0, %v]", attempt, d, limit)
+			}
+		}
+	}
+
+	// Большой сдвиг переполняет Duration — берем MaxDelay
+	if d := policy.delay(80, 0); d < 0 || d > time.Second {
+		t.Fatalf("delay(80) = %v, want within [0, 1s]", d)
+	}
+	// Retry-After — нижняя граница паузы, даже больше MaxDelay
+	if d := policy.delay(0, 3*time.Second); d != 3*time.Second {
+		t.Fatalf("delay with Retry-After = %v, want 3s", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":      0,
+		"2":     2 * time.Second,
+		" 10 ":  10 * time.Second,
+		"0":     0,
+		"-1":    0,
+		"later": 0,
+		time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat): 0,
+	} {
+		header := http.Header{}
+		if value != "" {
+			header.Set("Retry-After", value)
+		}
+		if got := parseRetryAfter(header); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	header := http.Header{"Retry-After": []string{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+	if got := parseRetryAfter(header); got < 58*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(HTTP date) = %v, want about 1m", got)
+	}
+	if got := parseRetryAfter(nil); got != 0 {
+		t.Errorf("parseRetryAfter(nil) = %v, want 0", got)
+	}
+}
+
+// newTestClient поднимает сервер с handler и клиент с быстрыми повторами без лимита
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "token",
+		WithRateLimit(0, 0),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func writeBody(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+}
+
+func TestCallRetries(t *testing.T) {
+	t.Run("IdempotentRetriedOnUnavailable", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				writeBody(w, http.StatusServiceUnavailable, `{"code":"unavailable","message":"try later"}`)
+				return
+			}
+			writeBody(w, http.StatusOK, `{"user_id":1,"name":"bot","is_bot":true}`)
+		})
+
+		info, err := client.GetMyInfo(context.Background())
+		if err != nil || info == nil || calls.Load() != 3 {
+			t.Fatalf("GetMyInfo: %v, %v after %d calls; want success on the 3rd", info, err, calls.Load())
+		}
+	})
+
+	t.Run("AttemptsAreBounded", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeBody(w, http.StatusBadGateway, `{"code":"bad.gateway","message":"upstream"}`)
+		})
+
+		_, err := client.GetMyInfo(context.Background())
+		if !errors.Is(err, ErrUnavailable) || calls.Load() != 3 {
+			t.Fatalf("GetMyInfo: %v after %d calls; want ErrUnavailable after 3", err, calls.Load())
+		}
+	})
+
+	t.Run("NonIdempotentNotRetriedOnUnavailable", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeBody(w, http.StatusServiceUnavailable, `{"code":"unavailable","message":"try later"}`)
+		})
+
+		_, err := client.SendMessage(context.Background(), 42, &SendMessageRequest{Text: "hi"})
+		if !errors.Is(err, ErrUnavailable) || calls.Load() != 1 {
+			t.Fatalf("SendMessage: %v after %d calls; want one attempt", err, calls.Load())
+		}
+	})
+
+	t.Run("NonIdempotentRetriedAfterRateLimit", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				writeBody(w, http.StatusTooManyRequests, `{"code":"too.many.requests","message":"slow down"}`)
+				return
+			}
+			writeBody(w, http.StatusOK, `{"message":{"body":{"mid":"m1","text":"hi"}}}`)
+		})
+
+		start := time.Now()
+		_, err := client.SendMessage(context.Background(), 42, &SendMessageRequest{Text: "hi"})
+		if err != nil || calls.Load() != 2 {
+			t.Fatalf("SendMessage: %v after %d calls; want success on the 2nd", err, calls.Load())
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Fatalf("retried after %v, want Retry-After 1s", elapsed)
+		}
+	})
+
+	t.Run("NotFoundNotRetried", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeBody(w, http.StatusNotFound, `{"code":"chat.not.found","message":"Chat 7 not found"}`)
+		})
+
+		_, err := client.GetChat(context.Background(), 7)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !IsNotFound(err) || calls.Load() != 1 {
+			t.Fatalf("GetChat: %v after %d calls; want one not found", err, calls.Load())
+		}
+		// Код и текст берутся из тела, перехваченного своим транспортом
+		if apiErr.Code != "chat.not.found" || apiErr.Message != "Chat 7 not found" || apiErr.Op != "GetChat" {
+			t.Fatalf("APIError: %+v", apiErr)
+		}
+	})
+
+	t.Run("CanceledContextStopsRetries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			w.Header().Set("Retry-After", "30")
+			writeBody(w, http.StatusTooManyRequests, `{}`)
+		})
+
+		if _, err := client.GetMyInfo(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("GetMyInfo: %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	cause := errors.New("library error")
+
+	if err := classifyError("Op", nil, &callInfo{statusCode: 500}); err != nil {
+		t.Fatalf("classifyError(nil) = %v", err)
+	}
+
+	err := classifyError("SendMessage", cause, &callInfo{
+		statusCode: http.StatusTooManyRequests,
+		header:     http.Header{"Retry-After": []string{"5"}},
+		body:       []byte(`{"code":"too.many.requests","message":"slow down"}`),
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 5*time.Second || apiErr.Code != "too.many.requests" ||
+		apiErr.Message != "slow down" || !errors.Is(err, cause) {
+		t.Fatalf("classifyError(429) = %#v", err)
+	}
+	if !errors.Is(err, ErrRateLimited) || !IsRetryable(err) || ErrorKind(err) != "rate_limited" {
+		t.Fatalf("429: Is/IsRetryable/ErrorKind mismatch for %v", err)
+	}
+
+	// Тело не в формате MAX сохраняется текстом
+	err = classifyError("GetChat", cause, &callInfo{statusCode: http.StatusBadGateway, body: []byte(" <html>bad gateway</html> ")})
+	if !errors.As(err, &apiErr) || apiErr.Message != "<html>bad gateway</html>" || ErrorKind(err) != "unavailable" {
+		t.Fatalf("classifyError(502) = %#v", err)
+	}
+
+	for status, kind := range map[int]string{
+		http.StatusBadRequest:   "bad_request",
+		http.StatusUnauthorized: "unauthorized",
+		http.StatusForbidden:    "forbidden",
+		http.StatusNotFound:     "not_found",
+	} {
+		err := classifyError("Op", cause, &callInfo{statusCode: status})
+		if ErrorKind(err) != kind || IsRetryable(err) {
+			t.Errorf("status %d: kind %q, retryable %v; want %q, not retryable", status, ErrorKind(err), IsRetryable(err), kind)
+		}
+	}
+
+	// Без перехваченного ответа сетевые ошибки повторяемы, прочие — нет
+	netErr := classifyError("Op", &timeoutError{}, &callInfo{})
+	if ErrorKind(netErr) != "network" || !IsRetryable(netErr) {
+		t.Errorf("network error: kind %q, retryable %v", ErrorKind(netErr), IsRetryable(netErr))
+	}
+	other := classifyError("Op", cause, &callInfo{})
+	if ErrorKind(other) != "other" || IsRetryable(other) || !strings.Contains(other.Error(), "max api Op") {
+		t.Errorf("other error: %v, kind %q", other, ErrorKind(other))
+	}
+	if IsRetryable(context.DeadlineExceeded) || ErrorKind(context.Canceled) != "canceled" {
+		t.Error("context errors must not be retryable")
+	}
+}
+
+// timeoutError реализует net.Error
+type timeoutError struct{}
+
+func (*timeoutError) Error() string   { return "i/o timeout" }
+func (*timeoutError) Timeout() bool   { return true }
+func (*timeoutError) Temporary() bool { return true }
@@ -0,0 +1,101 @@
+package maxapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"unsafe"
+
+	maxbot "github.com/max-messenger/max-bot-api-client-go"
+)
+
+// errTransportNotInstalled — внутреннее устройство клиента библиотеки MAX изменилось
+var errTransportNotInstalled = errors.New("max api: cannot install transport into max-bot-api-client-go client")
+
+// maxErrorBodySize ограничивает объём тела ошибки, который сохраняется для разбора
+const maxErrorBodySize = 64 << 10
+
+type callInfoKey struct{}
+
+// callInfo хранит ответ с ошибкой, перехваченный транспортом в рамках одного вызова.
+// Библиотека MAX отбрасывает заголовки и код ошибки, поэтому снимаем их сами
+type callInfo struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func withCallInfo(ctx context.Context, info *callInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// capturingTransport запоминает статус, заголовки и тело неуспешных ответов
+type capturingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *capturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 300 {
+		return resp, err
+	}
+
+	info, ok := req.Context().Value(callInfoKey{}).(*callInfo)
+	if !ok {
+		return resp, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	resp.Body.Close()
+	// Возвращаем тело обратно, чтобы библиотека смогла его разобрать
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	info.statusCode = resp.StatusCode
+	info.header = resp.Header.Clone()
+	info.body = body
+
+	return resp, nil
+}
+
+// CloseIdleConnections закрывает простаивающие соединения базового транспорта
+func (t *capturingTransport) CloseIdleConnections() {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if closer, ok := base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// installTransport подменяет транспорт http.Client внутри клиента библиотеки.
+// Библиотека не позволяет передать свой http.Client, поэтому поле достаём через reflect
+func installTransport(api *maxbot.Api, transport http.RoundTripper) bool {
+	clientField := reflect.ValueOf(api).Elem().FieldByName("client")
+	if !clientField.IsValid() || clientField.IsNil() {
+		return false
+	}
+
+	httpClientField := clientField.Elem().FieldByName("httpClient")
+	if !httpClientField.IsValid() {
+		return false
+	}
+
+	ptr := reflect.NewAt(httpClientField.Type(), unsafe.Pointer(httpClientField.UnsafeAddr())).Elem()
+	httpClient, ok := ptr.Interface().(*http.Client)
+	if !ok || httpClient == nil {
+		return false
+	}
+
+	httpClient.Transport = transport
+	return true
+}
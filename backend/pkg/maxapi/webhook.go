@@ -13,6 +13,13 @@ type Update struct {
 	RawData    json.RawMessage `json:"-"` // Для хранения полных данных
 }
 
+// UpdatesResponse ответ GET /updates: сырые обновления для ParseUpdate
+// и маркер, с которого нужно продолжить чтение
+type UpdatesResponse struct {
+	Updates []json.RawMessage `json:"updates"`
+	Marker  *int64            `json:"marker,omitempty"`
+}
+
 // MessageCreatedUpdate обновление о создании сообщения
 type MessageCreatedUpdate struct {
 	UpdateType string  `json:"update_type"`
@@ -75,7 +75,7 @@ export function HomePage() {
         // Fetch user's active session and public sessions in parallel
         const [activeSessionResponse, publicSessionsResponse] = await Promise.all([
           sessionsApi.getActiveSession().catch(() => null),
-          sessionsApi.getPublicSessions(1, 10)
+          sessionsApi.getPublicSessions(10)
         ]);
 
         // Transform active session if exists
@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react';
-import { List, Typography, Tag, Empty, Spin, Pagination, Flex, message } from 'antd';
+import { List, Typography, Tag, Empty, Spin, Button, Flex, message } from 'antd';
 import { HistoryOutlined, ClockCircleOutlined, TeamOutlined } from '@ant-design/icons';
 import { sessionsApi } from '@/shared/api';
 import type { Session as ApiSession, SessionsHistoryResponse } from '@/shared/api';
@@ -24,6 +24,7 @@ const statusToTag: Record<ApiSession['status'], { color: string; label: string }
   active: { color: 'green', label: 'Активна' },
   paused: { color: 'orange', label: 'Пауза' },
   completed: { color: 'default', label: 'Завершена' },
+  cancelled: { color: 'red', label: 'Отменена' },
 };
 
 const formatDate = (value?: string | null) => {
@@ -58,11 +59,11 @@ export function SessionHistoryPage() {
   const { isReady, isMaxEnvironment } = useMaxWebApp();
   const [items, setItems] = useState<HistoryItem[]>([]);
   const [isLoading, setIsLoading] = useState(true);
-  const [page, setPage] = useState(1);
-  const [total, setTotal] = useState(0);
+  const [isLoadingMore, setIsLoadingMore] = useState(false);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
   const pageSize = 10;
 
-  const loadHistory = async (pageToLoad: number) => {
+  const loadHistory = async () => {
     if (!isReady) {
       return;
     }
@@ -99,17 +100,18 @@ export function SessionHistoryPage() {
         });
 
         setItems(mockItems);
-        setTotal(mockItems.length);
+        setNextCursor(null);
         return;
       }
 
-      const response: SessionsHistoryResponse = await sessionsApi.getSessionHistory(pageToLoad, pageSize);
+      const response: SessionsHistoryResponse = await sessionsApi.getSessionHistory(pageSize);
       setItems(response.sessions.map(mapToHistoryItem));
-      setTotal(response.pagination.total);
+      setNextCursor(response.pagination.nextCursor);
     } catch (error) {
       console.error('[SessionHistoryPage] Failed to load history', error);
       message.error('Не удалось загрузить историю сессий');
       setItems([]);
+      setNextCursor(null);
     } finally {
       setIsLoading(false);
     }
@@ -119,12 +121,27 @@ export function SessionHistoryPage() {
     if (!isReady) {
       return;
     }
-    loadHistory(page);
+    loadHistory();
     // eslint-disable-next-line react-hooks/exhaustive-deps
-  }, [isReady, isMaxEnvironment, page]);
+  }, [isReady, isMaxEnvironment]);
 
-  const handlePageChange = (nextPage: number) => {
-    setPage(nextPage);
+  // Следующая страница по курсору дописывается к уже загруженным
+  const handleLoadMore = async () => {
+    if (!nextCursor) {
+      return;
+    }
+
+    setIsLoadingMore(true);
+    try {
+      const response: SessionsHistoryResponse = await sessionsApi.getSessionHistory(pageSize, nextCursor);
+      setItems((current) => [...current, ...response.sessions.map(mapToHistoryItem)]);
+      setNextCursor(response.pagination.nextCursor);
+    } catch (error) {
+      console.error('[SessionHistoryPage] Failed to load more history', error);
+      message.error('Не удалось загрузить историю сессий');
+    } finally {
+      setIsLoadingMore(false);
+    }
   };
 
   if (isLoading) {
@@ -185,15 +202,11 @@ export function SessionHistoryPage() {
             }}
           />
 
-          {total > pageSize && (
+          {nextCursor && (
             <div className="session-history__pagination">
-              <Pagination
-                current={page}
-                total={total}
-                pageSize={pageSize}
-                onChange={handlePageChange}
-                showSizeChanger={false}
-              />
+              <Button onClick={handleLoadMore} loading={isLoadingMore}>
+                Показать ещё
+              </Button>
             </div>
           )}
         </>
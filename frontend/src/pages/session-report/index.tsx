@@ -359,7 +359,7 @@ export function SessionReportPage() {
     const loadRemoteHistory = async () => {
       setIsHistoryLoading(true);
       try {
-        const response = await sessionsApi.getSessionHistory(1, HISTORY_LIMIT);
+        const response = await sessionsApi.getSessionHistory(HISTORY_LIMIT);
         const mapped = response.sessions
           .filter((session) => session.status === 'completed')
           .map((session: ApiSession) => {
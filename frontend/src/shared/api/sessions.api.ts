@@ -34,33 +34,33 @@ export const createSession = async (
 };
 
 /**
- * Get session history with pagination
- * @param page - Page number (default: 1)
+ * Get session history with cursor pagination
  * @param limit - Items per page (default: 10)
- * @returns Paginated list of completed sessions
+ * @param cursor - pagination.nextCursor of the previous page; omit for the first page
+ * @returns Page of sessions, newest first
  */
 export const getSessionHistory = async (
-  page: number = 1,
-  limit: number = 10
+  limit: number = 10,
+  cursor?: string
 ): Promise<SessionsHistoryResponse> => {
   const response = await apiClient.get<SessionsHistoryResponse>('/sessions', {
-    params: { page, limit },
+    params: { limit, cursor },
   });
   return response.data;
 };
 
 /**
- * Get public active sessions
- * @param page - Page number (default: 1)
+ * Get public active sessions with cursor pagination
  * @param limit - Items per page (default: 10)
- * @returns Paginated list of public active sessions
+ * @param cursor - pagination.nextCursor of the previous page; omit for the first page
+ * @returns Page of public active sessions
  */
 export const getPublicSessions = async (
-  page: number = 1,
-  limit: number = 10
+  limit: number = 10,
+  cursor?: string
 ): Promise<SessionsHistoryResponse> => {
   const response = await apiClient.get<SessionsHistoryResponse>('/sessions/public', {
-    params: { page, limit },
+    params: { limit, cursor },
   });
   return response.data;
 };
@@ -14,10 +14,9 @@ export interface ApiError {
 }
 
 export interface PaginationMeta {
-  page: number;
   limit: number;
-  total: number;
   hasNext: boolean;
+  nextCursor: string | null;
 }
 
 // ============================================================================
@@ -53,7 +52,7 @@ export interface Contact {
 // ============================================================================
 
 export type SessionMode = 'solo' | 'group';
-export type SessionStatus = 'pending' | 'active' | 'paused' | 'completed';
+export type SessionStatus = 'pending' | 'active' | 'paused' | 'completed' | 'cancelled';
 
 export interface Task {
   id: string;